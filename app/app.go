@@ -1,17 +1,36 @@
 package app
 
 import (
+	"strings"
+
 	"github.com/goccy/go-json"
 	"github.com/gofiber/fiber/v2"
 
 	"github.com/liobrdev/simplepasswords_vaults/config"
 )
 
+// CreateApp configures the Fiber app. Behind a proxy, such as the API gateway, every
+// request comes from the proxy's address, so client IPs are taken from PROXY_HEADER
+// instead, but only on requests that come from one of TRUSTED_PROXIES, since anyone else
+// could set that header to whatever they like.
 func CreateApp(conf *config.AppConfig) (app *fiber.App) {
-	return fiber.New(fiber.Config{
+	fiberConfig := fiber.Config{
 		CaseSensitive:           true,
 		JSONEncoder:             json.Marshal,
 		JSONDecoder:             json.Unmarshal,
 		Prefork:                 true,
-	})
+	}
+
+	if conf.PROXY_HEADER != "" {
+		fiberConfig.ProxyHeader = conf.PROXY_HEADER
+		fiberConfig.EnableTrustedProxyCheck = true
+
+		for _, proxy := range strings.Split(conf.TRUSTED_PROXIES, ",") {
+			if proxy = strings.TrimSpace(proxy); proxy != "" {
+				fiberConfig.TrustedProxies = append(fiberConfig.TrustedProxies, proxy)
+			}
+		}
+	}
+
+	return fiber.New(fiberConfig)
 }
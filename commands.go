@@ -0,0 +1,355 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/database"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+type command struct {
+	usage string
+	run   func(db *gorm.DB, conf *config.AppConfig, args []string) error
+	// currentSchema commands refuse to run unless every migration has been applied.
+	currentSchema bool
+}
+
+// Subcommands run instead of the server when main is given arguments, against the same
+// config and database.
+var commands = map[string]command{
+	"backup":  {"backup <file|->", backupCommand, true},
+	"restore": {"restore <file|->", restoreCommand, true},
+	"migrate": {"migrate [status | up [version] | down <version>]", migrateCommand, false},
+	"integrity": {
+		"integrity verify|rebuild <user_slug|--all>", integrityCommand, true,
+	},
+	"clients": {
+		"clients list | create|import <name> <scope>... | revoke <name>", clientsCommand, true,
+	},
+	"kek": {"kek rotate", kekCommand, true},
+}
+
+func runCommand(db *gorm.DB, conf *config.AppConfig, args []string) {
+	if cmd, ok := commands[args[0]]; !ok {
+		usages := []string{}
+
+		for _, cmd := range commands {
+			usages = append(usages, "  " + cmd.usage)
+		}
+
+		log.Fatalf("Unknown command %q. Commands:\n%s", args[0], strings.Join(usages, "\n"))
+	} else if err := database.CheckSchemaVersion(db); cmd.currentSchema && err != nil {
+		log.Fatalf("Failed %s: %s", args[0], err)
+	} else if err := cmd.run(db, conf, args[1:]); err != nil {
+		log.Fatalf("Failed %s: %s", args[0], err)
+	}
+}
+
+// backupCommand writes a backup archive to a new file, or to stdout given "-".
+func backupCommand(db *gorm.DB, conf *config.AppConfig, args []string) error {
+	if len(args) != 1 {
+		return errors.New("Expected one file argument.")
+	}
+
+	var w io.Writer = os.Stdout
+
+	if args[0] != "-" {
+		// An existing file is never overwritten, since it may be the only other backup.
+		file, err := os.OpenFile(args[0], os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+		w = file
+	}
+
+	manifest, err := database.Backup(db, w)
+
+	if err != nil {
+		if file, ok := w.(*os.File); ok && file != os.Stdout {
+			os.Remove(file.Name())
+		}
+
+		return err
+	}
+
+	for _, table := range manifest.Tables {
+		log.Printf("Backed up %d row(s) from %s, sha256 %s", table.Rows, table.Name, table.SHA256)
+	}
+
+	if file, ok := w.(*os.File); ok && file != os.Stdout {
+		return file.Sync()
+	}
+
+	return nil
+}
+
+// restoreCommand loads a backup archive from a file, or from stdin given "-".
+func restoreCommand(db *gorm.DB, conf *config.AppConfig, args []string) error {
+	if len(args) != 1 {
+		return errors.New("Expected one file argument.")
+	}
+
+	var r io.Reader = os.Stdin
+
+	if args[0] != "-" {
+		file, err := os.Open(args[0])
+
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+		r = file
+	}
+
+	manifest, err := database.Restore(db, r)
+
+	if err != nil {
+		return err
+	}
+
+	for _, table := range manifest.Tables {
+		log.Printf("Restored %d row(s) to %s", table.Rows, table.Name)
+	}
+
+	log.Println("Restored backup taken at", manifest.CreatedAt.Format(time.RFC3339))
+
+	return nil
+}
+
+// migrateCommand reports the schema version, or migrates up to the latest or a given
+// version, or down to a given version.
+func migrateCommand(db *gorm.DB, conf *config.AppConfig, args []string) error {
+	if len(args) == 0 || (len(args) == 1 && args[0] == "status") {
+		version, err := database.SchemaVersion(db)
+
+		if err != nil {
+			return err
+		}
+
+		log.Printf(
+			"Schema version %d, latest version %d", version, database.LatestSchemaVersion(),
+		)
+
+		return nil
+	}
+
+	switch {
+	case args[0] == "up" && len(args) <= 2:
+		target := database.LatestSchemaVersion()
+
+		if len(args) == 2 {
+			if version, err := strconv.Atoi(args[1]); err != nil {
+				return fmt.Errorf("Invalid version %q.", args[1])
+			} else {
+				target = version
+			}
+		}
+
+		applied, err := database.MigrateUp(db, target)
+
+		for _, migration := range applied {
+			log.Printf("Applied migration %04d_%s", migration.Version, migration.Name)
+		}
+
+		return err
+	case args[0] == "down" && len(args) == 2:
+		target, err := strconv.Atoi(args[1])
+
+		if err != nil {
+			return fmt.Errorf("Invalid version %q.", args[1])
+		}
+
+		reverted, err := database.MigrateDown(db, target)
+
+		for _, migration := range reverted {
+			log.Printf("Reverted migration %04d_%s", migration.Version, migration.Name)
+		}
+
+		return err
+	}
+
+	return errors.New("Expected `status`, `up [version]` or `down <version>`.")
+}
+
+// integrityCommand verifies one user's records, or every user's, against their integrity
+// manifests, logging each discrepancy, and fails unless all of them are intact. Rebuilding
+// instead records the rows as they are now, which accepts any changes made out-of-band, so
+// it's for manifests that predate the records, or after such changes have been reviewed.
+func integrityCommand(db *gorm.DB, conf *config.AppConfig, args []string) error {
+	if len(args) != 2 || (args[0] != "verify" && args[0] != "rebuild") {
+		return errors.New("Expected `verify` or `rebuild`, and a user slug or `--all`.")
+	}
+
+	userSlugs := []string{args[1]}
+
+	if args[1] == "--all" {
+		if result := db.Model(&models.User{}).Order("slug").Pluck("slug", &userSlugs);
+		result.Error != nil {
+			return result.Error
+		}
+	} else if !utils.SlugRegexp.MatchString(args[1]) {
+		return fmt.Errorf("Invalid user slug %q.", args[1])
+	} else if result := db.First(&models.User{}, "slug = ?", args[1]); result.Error != nil {
+		return result.Error
+	}
+
+	failed := 0
+
+	for _, userSlug := range userSlugs {
+		if args[0] == "rebuild" {
+			if err := db.Transaction(func(tx *gorm.DB) error {
+				return database.RefreshIntegrity(tx, userSlug, database.IntegrityAll())
+			}); err != nil {
+				return err
+			}
+
+			log.Printf("Rebuilt integrity manifest of user %s", userSlug)
+			continue
+		}
+
+		report, err := database.VerifyIntegrity(db, userSlug)
+
+		if err != nil {
+			return err
+		}
+
+		if report.ManifestAltered {
+			log.Printf("User %s: integrity manifest altered", userSlug)
+		}
+
+		for _, d := range report.Discrepancies {
+			log.Printf("User %s: %s %s %s", userSlug, d.Kind, d.Slug, d.Change)
+		}
+
+		// These are bound the next time the user unlocks, unless they're among the above.
+		if report.UnboundSecrets > 0 {
+			log.Printf("User %s: %d secret(s) not bound to their record", userSlug, report.UnboundSecrets)
+		}
+
+		if !report.Intact {
+			failed++
+		} else {
+			log.Printf("User %s: %d record(s) intact", userSlug, report.Records)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d user(s) not intact.", failed, len(userSlugs))
+	}
+
+	return nil
+}
+
+// clientsCommand lists, registers or revokes the API clients. Creating a client prints its
+// newly generated token, which isn't stored and can't be shown again. Importing instead
+// reads an existing token from stdin, so a gateway can keep the token it already has.
+func clientsCommand(db *gorm.DB, conf *config.AppConfig, args []string) error {
+	switch {
+	case len(args) == 1 && args[0] == "list":
+		var clients []models.APIClient
+
+		if result := db.Order("name").Find(&clients); result.Error != nil {
+			return result.Error
+		}
+
+		for _, client := range clients {
+			lastUsed, revoked := "never", ""
+
+			if client.LastUsedAt != nil {
+				lastUsed = client.LastUsedAt.Format(time.RFC3339)
+			}
+
+			if client.RevokedAt != nil {
+				revoked = ", revoked " + client.RevokedAt.Format(time.RFC3339)
+			}
+
+			fmt.Printf(
+				"%s\t%s\tlast used %s%s\n", client.Name, client.Scopes, lastUsed, revoked,
+			)
+		}
+
+		return nil
+	case len(args) >= 3 && (args[0] == "create" || args[0] == "import"):
+		token := ""
+
+		if args[0] == "import" {
+			scanner := bufio.NewScanner(os.Stdin)
+
+			if scanner.Scan(); scanner.Err() != nil {
+				return scanner.Err()
+			}
+
+			token = strings.TrimSpace(scanner.Text())
+
+			if token == "" {
+				return errors.New("Expected the token on stdin.")
+			}
+		}
+
+		client, token, err := database.CreateAPIClient(db, args[1], token, args[2:])
+
+		if err != nil {
+			return err
+		}
+
+		log.Printf("Registered client %s with scopes %s", client.Name, client.Scopes)
+
+		if args[0] == "create" {
+			fmt.Println(token)
+		}
+
+		return nil
+	case len(args) == 2 && args[0] == "revoke":
+		if err := database.RevokeAPIClient(db, args[1]); err != nil {
+			return err
+		}
+
+		log.Printf("Revoked client %s", args[1])
+
+		return nil
+	}
+
+	return errors.New("Expected `list`, `create <name> <scope>...`, " +
+	"`import <name> <scope>...` or `revoke <name>`.")
+}
+
+// kekCommand rewraps every data key under the current KEK, the first of VAULTS_KEK. To
+// rotate the KEK, prepend the new one to VAULTS_KEK and restart, so that data keys can be
+// unwrapped under either, then run `kek rotate`, then drop the previous one and restart.
+func kekCommand(db *gorm.DB, conf *config.AppConfig, args []string) error {
+	if len(args) != 1 || args[0] != "rotate" {
+		return errors.New("Expected `rotate`.")
+	}
+
+	keys, err := utils.NewLocalKeyProvider(conf.VAULTS_KEK)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := database.RewrapDataKeys(db, keys)
+
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Rewrapped %d data key(s) under the current KEK", n)
+
+	return nil
+}
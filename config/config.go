@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"reflect"
+	"strconv"
 	"testing"
 
 	"github.com/spf13/viper"
@@ -13,8 +14,18 @@ import (
 type AppConfig struct {
 	API_GATEWAY_HOST		string
 	API_GATEWAY_PORT		string
+	AUTH_FAILURE_LIMIT	int
+	AUTH_FAILURE_WINDOW_SECONDS	int
 	ENVIRONMENT					string
+	EXPORT_PASSPHRASE_HEADER_KEY	string
+	KDF_MEMORY_KIB			int
+	KDF_THREADS					int
+	KDF_TIME						int
 	PASSWORD_HEADER_KEY	string
+	PROXY_HEADER				string
+	SECRET_VERSION_RETENTION	int
+	TRASH_RETENTION_DAYS	int
+	TRUSTED_PROXIES			string
 	VAULTS_ACCESS_TOKEN	string
 	VAULTS_DB_HOST			string
 	VAULTS_DB_NAME			string
@@ -22,15 +33,29 @@ type AppConfig struct {
 	VAULTS_DB_PORT			string
 	VAULTS_DB_USER			string
 	VAULTS_HOST					string
+	VAULTS_KEK					string
 	VAULTS_PORT					string
 	GO_TESTING_CONTEXT	*testing.T
 }
 
+// envAbsPaths holds the path of the file each setting is read from. A setting with a
+// `default` tag is optional: its default is used when its variable isn't set, or its
+// file is empty.
 type envAbsPaths struct {
 	API_GATEWAY_HOST		string
 	API_GATEWAY_PORT		string
+	AUTH_FAILURE_LIMIT	string	`default:"10"`
+	AUTH_FAILURE_WINDOW_SECONDS	string	`default:"60"`
 	ENVIRONMENT					string
+	EXPORT_PASSPHRASE_HEADER_KEY	string	`default:"X-Export-Passphrase"`
+	KDF_MEMORY_KIB			string	`default:"19456"`
+	KDF_THREADS					string	`default:"1"`
+	KDF_TIME						string	`default:"2"`
 	PASSWORD_HEADER_KEY	string
+	PROXY_HEADER				string	`default:""`
+	SECRET_VERSION_RETENTION	string	`default:"10"`
+	TRASH_RETENTION_DAYS	string	`default:"30"`
+	TRUSTED_PROXIES			string	`default:""`
 	VAULTS_ACCESS_TOKEN	string
 	VAULTS_DB_HOST			string
 	VAULTS_DB_NAME			string
@@ -38,10 +63,28 @@ type envAbsPaths struct {
 	VAULTS_DB_PORT			string
 	VAULTS_DB_USER			string
 	VAULTS_HOST					string
+	VAULTS_KEK					string
 	VAULTS_PORT					string
 }
 
-func scanFileFirstLineToConf(file *os.File, confElem *reflect.Value, path, fieldName string) {
+func setConfField(confElem *reflect.Value, path, fieldName, contents string) {
+	if field := confElem.FieldByName(fieldName); field.Kind() == reflect.Int {
+		if n, err := strconv.Atoi(contents); err != nil {
+			log.Fatalf(
+				"Error parsing contents of '%s' from environment variable %s as int:\n%s",
+				path, fieldName, err,
+			)
+		} else {
+			field.SetInt(int64(n))
+		}
+	} else {
+		field.SetString(contents)
+	}
+}
+
+func scanFileFirstLineToConf(
+	file *os.File, confElem *reflect.Value, path, fieldName, defaultValue string, optional bool,
+) {
 	scanner := bufio.NewScanner(file)
 	scanner.Scan()
 
@@ -50,10 +93,12 @@ func scanFileFirstLineToConf(file *os.File, confElem *reflect.Value, path, field
 			"Error reading contents of '%s' from environment variable %s:\n%s",
 			path, fieldName, scanner.Err(),
 		)
-	} else if contents == "" {
-		log.Fatalf("Empty contents of '%s' from environment variable %s", path, fieldName)
+	} else if contents != "" {
+		setConfField(confElem, path, fieldName, contents)
+	} else if optional {
+		setConfField(confElem, "default", fieldName, defaultValue)
 	} else {
-		confElem.FieldByName(fieldName).SetString(contents)
+		log.Fatalf("Empty contents of '%s' from environment variable %s", path, fieldName)
 	}
 }
 
@@ -64,9 +109,15 @@ func loadFileContentsFromPathsToConf(
 
 	for i := 0; i < fieldCount; i++ {
 		fieldName := (*pathsType).Field(i).Name
+		defaultValue, optional := (*pathsType).Field(i).Tag.Lookup("default")
 		path := pathsValue.Field(i).Interface().(string)
 
 		if path == "" {
+			if optional {
+				setConfField(&confElem, "default", fieldName, defaultValue)
+				continue
+			}
+
 			log.Fatal("Missing or empty environment variable: ", fieldName)
 		}
 
@@ -80,7 +131,7 @@ func loadFileContentsFromPathsToConf(
 
 		defer file.Close()
 
-		scanFileFirstLineToConf(file, &confElem, path, fieldName)
+		scanFileFirstLineToConf(file, &confElem, path, fieldName, defaultValue, optional)
 	}
 }
 
@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/database"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+type AddVaultMemberRequestBody struct {
+	MemberSlug string `json:"member_slug"`
+	Role       string `json:"member_role"`
+}
+
+// AddVaultMember gives another user a role in a vault the user owns. Adding the first
+// member makes the vault a team vault: its secrets are re-encrypted under a new vault key,
+// and its creator becomes its first owner. A vault with shared entries can't become one,
+// since shares are kept under their owner's key. Removing a member later doesn't change
+// the vault key, so a former member who kept a copy of it could still decrypt a copy of
+// the secrets taken before or after.
+func (H Handler) AddVaultMember(c *fiber.Ctx) error {
+	slug := c.Params("slug")
+
+	if !utils.SlugRegexp.MatchString(slug) {
+		return utils.RespondWithError(c, 400, utils.AddVaultMember, utils.ErrorVaultSlug, slug)
+	}
+
+	body := AddVaultMemberRequestBody{}
+
+	if err := c.BodyParser(&body); err != nil {
+		return utils.RespondWithError(c, 400, utils.AddVaultMember, utils.ErrorParse, err.Error())
+	}
+
+	if !utils.SlugRegexp.MatchString(body.MemberSlug) {
+		return utils.RespondWithError(
+			c, 400, utils.AddVaultMember, utils.ErrorMemberSlug, body.MemberSlug,
+		)
+	}
+
+	userSlug := requestUserSlug(c)
+
+	if body.MemberSlug == userSlug {
+		return utils.RespondWithError(
+			c, 400, utils.AddVaultMember, utils.ErrorMemberSlug, "Same as `User-Slug` header.",
+		)
+	}
+
+	if _, ok := roleRanks[body.Role]; !ok {
+		return utils.RespondWithError(c, 400, utils.AddVaultMember, utils.ErrorMemberRole, body.Role)
+	}
+
+	access, err := H.accessVault(slug, userSlug, models.RoleOwner)
+
+	if err != nil {
+		return respondWithAccessError(c, utils.AddVaultMember, slug, &access, err)
+	}
+
+	var memberUser models.User
+
+	if result := H.DB.First(&memberUser, "slug = ?", body.MemberSlug); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return utils.RespondWithError(
+				c, 404, utils.AddVaultMember, utils.ErrorNotFound, body.MemberSlug,
+			)
+		}
+
+		return utils.RespondWithError(
+			c, 500, utils.AddVaultMember, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	// Users created before sharing existed get their key pair the next time they unlock.
+	if memberUser.PublicKey == "" {
+		return utils.RespondWithError(
+			c, 409, utils.AddVaultMember, utils.ErrorMemberShareKey, body.MemberSlug,
+		)
+	}
+
+	key, user, err := H.userKey(c, userSlug)
+
+	if err != nil {
+		return respondWithKeyError(c, utils.AddVaultMember, userSlug, err)
+	}
+
+	if access.member != nil {
+		vaultKey, err := access.secretsKey(&user, key)
+
+		if err != nil {
+			return respondWithKeyError(c, utils.AddVaultMember, userSlug, err)
+		}
+
+		member, err := newVaultMember(slug, &memberUser, body.Role, vaultKey)
+
+		if err != nil {
+			return utils.RespondWithError(
+				c, 500, utils.AddVaultMember, utils.ErrorEncrypt, err.Error(),
+			)
+		}
+
+		if result := H.DB.Create(&member); result.Error != nil {
+			return respondWithDBError(c, utils.AddVaultMember, result.Error)
+		}
+
+		return c.SendStatus(204)
+	}
+
+	var shareCount int64
+
+	if result := H.DB.Model(&models.Share{}).
+	Joins("JOIN entries ON entries.slug = shares.entry_slug").
+	Where("entries.vault_slug = ?", slug).Count(&shareCount); result.Error != nil {
+		return utils.RespondWithError(
+			c, 500, utils.AddVaultMember, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	} else if shareCount > 0 {
+		return utils.RespondWithError(c, 409, utils.AddVaultMember, utils.ErrorSharedEntries, slug)
+	}
+
+	vaultKey, err := utils.NewDataKey()
+
+	if err != nil {
+		return utils.RespondWithError(c, 500, utils.AddVaultMember, utils.ErrorEncrypt, err.Error())
+	}
+
+	members := make([]models.VaultMember, 2)
+
+	if members[0], err = newVaultMember(slug, &user, models.RoleOwner, vaultKey); err != nil {
+		return utils.RespondWithError(c, 500, utils.AddVaultMember, utils.ErrorEncrypt, err.Error())
+	}
+
+	if members[1], err = newVaultMember(slug, &memberUser, body.Role, vaultKey); err != nil {
+		return utils.RespondWithError(c, 500, utils.AddVaultMember, utils.ErrorEncrypt, err.Error())
+	}
+
+	var failedSlug string
+
+	if err := H.DB.Transaction(func(tx *gorm.DB) (err error) {
+		// A concurrent conversion fails on the creator's membership, and rolls back.
+		if result := tx.Create(&members); result.Error != nil {
+			return result.Error
+		}
+
+		if failedSlug, err = rekeySecretStrings(tx, func(db *gorm.DB) *gorm.DB {
+			return db.Where("vault_slug = ?", slug)
+		}, key, vaultKey); err != nil {
+			return err
+		}
+
+		return database.RefreshIntegrity(tx, access.vault.UserSlug, database.IntegrityAllSecrets())
+	}); err != nil {
+		if errText := err.Error(); errText == utils.ErrorDecrypt || errText == utils.ErrorEncrypt {
+			return utils.RespondWithError(c, 500, utils.AddVaultMember, errText, failedSlug)
+		}
+
+		return respondWithDBError(c, utils.AddVaultMember, err)
+	}
+
+	return c.SendStatus(204)
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+const (
+	AuditOutcomeSuccess string = "success"
+	AuditOutcomeFailure string = "failure"
+
+	// RequestIDLocal is where the request ID middleware keeps each request's ID.
+	RequestIDLocal      string = "request_id"
+	auditOperationLocal string = "audit_operation"
+	auditTargetLocal    string = "audit_target_slug"
+	maxRequestIDLength  int    = 255
+)
+
+// Audit records an audit event for `operation` once the handlers after it have run. The
+// user is the one from the User-Slug header, or else the target, which is the route's
+// `:slug` unless a handler names another one with setAuditTarget. Requests that don't
+// identify a valid user aren't recorded. A failure to record is logged without changing
+// the response, which has already been written.
+func (H Handler) Audit(operation string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+
+		event := models.AuditEvent{
+			Operation:  operation,
+			TargetSlug: c.Params("slug"),
+			Outcome:    AuditOutcomeSuccess,
+			Status:     c.Response().StatusCode(),
+			RequestID:  requestID(c),
+		}
+
+		if op, ok := c.Locals(auditOperationLocal).(string); ok {
+			event.Operation = op
+		}
+
+		if target, ok := c.Locals(auditTargetLocal).(string); ok {
+			event.TargetSlug = target
+		}
+
+		if userSlug, ok := c.Locals(userSlugLocal).(string); ok {
+			event.UserSlug = userSlug
+		} else {
+			event.UserSlug = event.TargetSlug
+		}
+
+		if !utils.SlugRegexp.MatchString(event.UserSlug) {
+			return err
+		} else if !utils.SlugRegexp.MatchString(event.TargetSlug) {
+			event.TargetSlug = ""
+		}
+
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			event.Status = fiberErr.Code
+		} else if err != nil {
+			event.Status = 500
+		}
+
+		if event.Status >= 400 {
+			event.Outcome = AuditOutcomeFailure
+		}
+
+		if slug, slugErr := utils.GenerateSlug(16); slugErr != nil {
+			log.Println("Failed audit event:", slugErr)
+			return err
+		} else {
+			event.Slug = slug
+		}
+
+		if result := H.DB.Create(&event); result.Error != nil {
+			log.Println("Failed audit event:", result.Error)
+		}
+
+		return err
+	}
+}
+
+// setAuditTarget names the record a request acted on, for handlers whose route has no
+// `:slug`, such as one that creates the record.
+func setAuditTarget(c *fiber.Ctx, slug string) {
+	c.Locals(auditTargetLocal, slug)
+}
+
+// setAuditOperation overrides the operation given to Audit, for routes shared by more
+// than one handler.
+func setAuditOperation(c *fiber.Ctx, operation string) {
+	c.Locals(auditOperationLocal, operation)
+}
+
+func requestID(c *fiber.Ctx) string {
+	id, _ := c.Locals(RequestIDLocal).(string)
+
+	if len(id) > maxRequestIDLength {
+		return id[:maxRequestIDLength]
+	}
+
+	return id
+}
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+)
+
+// AuthFailureTracker counts failed authorizations per client IP. Once an IP has failed
+// AUTH_FAILURE_LIMIT times within a window of AUTH_FAILURE_WINDOW_SECONDS, it's refused
+// until the window ends. Counts are kept in the database, so every prefork child, and
+// every instance on the same database, shares them, and they outlast restarts. Behind a
+// proxy, every client shares the proxy's IP unless PROXY_HEADER and TRUSTED_PROXIES are
+// set, and then one client's failures would lock out all of them.
+type AuthFailureTracker struct {
+	db     *gorm.DB
+	limit  int
+	window time.Duration
+	mu     sync.Mutex
+	swept  time.Time
+}
+
+// NewAuthFailureTracker returns a tracker with the configured thresholds, or nil, which
+// never refuses anyone, if AUTH_FAILURE_LIMIT isn't positive.
+func NewAuthFailureTracker(db *gorm.DB, conf *config.AppConfig) *AuthFailureTracker {
+	if conf.AUTH_FAILURE_LIMIT <= 0 || conf.AUTH_FAILURE_WINDOW_SECONDS <= 0 {
+		return nil
+	}
+
+	return &AuthFailureTracker{
+		db:     db,
+		limit:  conf.AUTH_FAILURE_LIMIT,
+		window: time.Duration(conf.AUTH_FAILURE_WINDOW_SECONDS) * time.Second,
+		swept:  time.Now(),
+	}
+}
+
+// retryAfter is how long `ip` is refused for, or 0 if it isn't.
+func (tracker *AuthFailureTracker) retryAfter(ip string, now time.Time) (time.Duration, error) {
+	if tracker == nil {
+		return 0, nil
+	}
+
+	var f models.AuthFailure
+
+	if result := tracker.db.Where("ip = ?", ip).Limit(1).Find(&f); result.Error != nil {
+		return 0, result.Error
+	} else if result.RowsAffected == 0 || f.Failures < tracker.limit {
+		return 0, nil
+	}
+
+	if remaining := time.UnixMilli(f.WindowStart).Add(tracker.window).Sub(now); remaining > 0 {
+		return remaining, nil
+	}
+
+	return 0, nil
+}
+
+// fail records a failed authorization by `ip`. The count is incremented in the same
+// statement that reads it, so that concurrent failures are all counted. A failure to
+// record it is only logged, since the request is refused anyway.
+func (tracker *AuthFailureTracker) fail(ip string, now time.Time) {
+	if tracker == nil {
+		return
+	}
+
+	start, ended := now.UnixMilli(), now.Add(-tracker.window).UnixMilli()
+
+	if result := tracker.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "ip"}},
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "failures"}, Value: gorm.Expr(
+				"CASE WHEN auth_failures.window_start <= ? THEN 1 " +
+				"ELSE auth_failures.failures + 1 END", ended,
+			)},
+			{Column: clause.Column{Name: "window_start"}, Value: gorm.Expr(
+				"CASE WHEN auth_failures.window_start <= ? THEN ? " +
+				"ELSE auth_failures.window_start END", ended, start,
+			)},
+		},
+	}).Create(&models.AuthFailure{IP: ip, Failures: 1, WindowStart: start}); result.Error != nil {
+		log.Printf("Failed to record authorization failure of %s: %s", ip, result.Error)
+	}
+
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	// Forget the IPs whose windows have ended, at most once per window per process.
+	if now.Sub(tracker.swept) >= tracker.window {
+		if result := tracker.db.Where("window_start <= ?", ended).Delete(&models.AuthFailure{});
+		result.Error != nil {
+			log.Println("Failed to forget ended authorization failures:", result.Error)
+		}
+
+		tracker.swept = now
+	}
+}
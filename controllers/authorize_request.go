@@ -1,24 +1,108 @@
 package controllers
 
 import (
+	"crypto/subtle"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 
+	"github.com/liobrdev/simplepasswords_vaults/database"
+	"github.com/liobrdev/simplepasswords_vaults/models"
 	"github.com/liobrdev/simplepasswords_vaults/utils"
 )
 
+const apiClientLocal string = "api_client"
+
+// AuthorizeRequest identifies the API client by its token. Until the first client is
+// registered, VAULTS_ACCESS_TOKEN is accepted in its place with every scope, so a
+// deployment can register its gateway's token as a client without any downtime. Tokens
+// are only ever compared by their digests, in constant time. An IP with too many recent
+// failures is refused before its token is looked at.
 func (H Handler) AuthorizeRequest(c *fiber.Ctx) error {
+	if retryAfter, err := H.AuthFailures.retryAfter(c.IP(), time.Now()); err != nil {
+		return utils.RespondWithError(
+			c, 500, c.Get("Client-Operation"), utils.ErrorFailedDB, err.Error(),
+		)
+	} else if retryAfter > 0 {
+		seconds := int(math.Ceil(retryAfter.Seconds()))
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+
+		return utils.RespondWithError(
+			c, 429, c.Get("Client-Operation"), utils.ErrorAuthFailures,
+			fmt.Sprintf("Retry after %d second(s).", seconds),
+		)
+	}
+
 	authHeader := c.Get("Authorization")
 
 	// Check Authorization header format
 	if !utils.AuthHeaderRegexp.Match([]byte(authHeader)) {
-		return utils.RespondWithError(c, 401, c.Get("Client-Operation"), utils.ErrorToken, authHeader)
+		H.AuthFailures.fail(c.IP(), time.Now())
+
+		return utils.RespondWithError(
+			c, 401, c.Get("Client-Operation"), utils.ErrorToken, "Malformed `Authorization` header.",
+		)
 	}
 
 	authToken := authHeader[6:]
+	client, found, err := database.FindAPIClient(H.DB, authToken)
+
+	if err != nil {
+		return utils.RespondWithError(
+			c, 500, c.Get("Client-Operation"), utils.ErrorFailedDB, err.Error(),
+		)
+	}
+
+	if !found && subtle.ConstantTimeCompare(
+		utils.HashToken(authToken), utils.HashToken(H.Conf.VAULTS_ACCESS_TOKEN),
+	) == 1 {
+		if hasClients, err := database.HasAPIClients(H.DB); err != nil {
+			return utils.RespondWithError(
+				c, 500, c.Get("Client-Operation"), utils.ErrorFailedDB, err.Error(),
+			)
+		} else if !hasClients {
+			found = true
+			client = models.APIClient{
+				Scopes: models.ScopeRead + " " + models.ScopeWrite + " " + models.ScopeAdmin,
+			}
+		}
+	}
 
-	if authToken != H.Conf.VAULTS_ACCESS_TOKEN {
-		return utils.RespondWithError(c, 401, c.Get("Client-Operation"), utils.ErrorToken, authToken)
+	if !found {
+		H.AuthFailures.fail(c.IP(), time.Now())
+
+		return utils.RespondWithError(
+			c, 401, c.Get("Client-Operation"), utils.ErrorToken, "Unrecognized token.",
+		)
 	}
 
+	c.Locals(apiClientLocal, client)
+
 	return c.Next()
 }
+
+// RequireScope refuses clients that weren't granted `scope`.
+func (H Handler) RequireScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if client, ok := c.Locals(apiClientLocal).(models.APIClient); !ok || !client.HasScope(scope) {
+			return utils.RespondWithError(
+				c, 403, c.Get("Client-Operation"), utils.ErrorTokenScope, scope,
+			)
+		}
+
+		return c.Next()
+	}
+}
+
+// RequireMethodScope requires the read scope of GET requests, and the write scope of any
+// other, for route groups that both read and write.
+func (H Handler) RequireMethodScope(c *fiber.Ctx) error {
+	if c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead {
+		return H.RequireScope(models.ScopeRead)(c)
+	}
+
+	return H.RequireScope(models.ScopeWrite)(c)
+}
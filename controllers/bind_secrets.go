@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/database"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+)
+
+// bindUserSecrets binds whatever of the user's secrets still aren't, once `key` is
+// confirmed to be the user's, since that's the only time the key is at hand. Failures are
+// only logged, since the request itself has nothing to do with them, and the binding is
+// tried again on the next unlock.
+func (H Handler) bindUserSecrets(user *models.User, key string) {
+	var unbound int64
+
+	for _, model := range []interface{}{&models.Secret{}, &models.SecretVersion{}} {
+		var n int64
+
+		if result := H.DB.Unscoped().Model(model).Where("user_slug = ?", user.Slug).
+		Where(teamVaultsExcluded).Scopes(database.UnboundCiphertexts).Count(&n);
+		result.Error != nil {
+			log.Printf("Failed ciphertext binding of user %s: %s", user.Slug, result.Error)
+			return
+		}
+
+		unbound += n
+	}
+
+	if unbound == 0 {
+		return
+	}
+
+	if err := H.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockUserKey(tx, user); err != nil {
+			return err
+		}
+
+		skipped, err := bindSecrets(tx, user.Slug, key)
+
+		for _, slug := range skipped {
+			log.Printf(
+				"Skipped ciphertext binding of secret %s of user %s: altered since the " +
+				"integrity manifest recorded it", slug, user.Slug,
+			)
+		}
+
+		return err
+	}); err != nil && !errors.Is(err, errWrongVaultKey) {
+		log.Printf("Failed ciphertext binding of user %s: %s", user.Slug, err)
+	}
+}
+
+// bindSecrets re-encrypts the user's secrets, and secret versions, that were sealed before
+// ciphertexts were bound to their record, under the same key but bound to it. A secret
+// whose row isn't as the integrity manifest recorded it may have had another secret's
+// ciphertext copied onto it, which binding would make look genuine, so it's left unbound,
+// and so unreadable, and returned in `skipped`. Once it's been reviewed and the manifest
+// rebuilt, the next unlock binds it. Versions aren't in the manifest, so they're bound as
+// they are. The columns are updated directly, so that binding doesn't show up as a change
+// to the secrets.
+func bindSecrets(tx *gorm.DB, userSlug, key string) (skipped []string, err error) {
+	var secrets []models.Secret
+
+	// Team vaults' secrets were re-encrypted, and so bound, when they became team vaults.
+	if result := tx.Unscoped().Where("user_slug = ?", userSlug).Where(teamVaultsExcluded).
+	Scopes(database.UnboundCiphertexts).Find(&secrets); result.Error != nil {
+		return nil, result.Error
+	} else if len(secrets) > 0 {
+		unaltered, err := database.UnalteredSecrets(tx, userSlug)
+
+		if err != nil {
+			return nil, err
+		}
+
+		scopes := []database.IntegrityScope{}
+
+		for _, secret := range secrets {
+			if !unaltered[secret.Slug] {
+				skipped = append(skipped, secret.Slug)
+				continue
+			}
+
+			if err := bindSecretString(&secret, key); err != nil {
+				return skipped, err
+			}
+
+			if result := tx.Unscoped().Model(&models.Secret{}).Where("slug = ?", secret.Slug).
+			UpdateColumn("string", secret.String); result.Error != nil {
+				return skipped, result.Error
+			}
+
+			scopes = append(scopes, database.IntegritySecretRow(secret.Slug))
+		}
+
+		if len(scopes) > 0 {
+			if err := database.RefreshIntegrity(tx, userSlug, scopes...); err != nil {
+				return skipped, err
+			}
+		}
+	}
+
+	var versions []models.SecretVersion
+
+	if result := tx.Where("user_slug = ?", userSlug).Where(teamVaultsExcluded).
+	Scopes(database.UnboundCiphertexts).Find(&versions); result.Error != nil {
+		return skipped, result.Error
+	}
+
+	for _, version := range versions {
+		secret := versionSecret(&version)
+
+		if err := bindSecretString(secret, key); err != nil {
+			return skipped, err
+		}
+
+		if result := tx.Model(&models.SecretVersion{}).Where("slug = ?", version.Slug).
+		UpdateColumn("string", secret.String); result.Error != nil {
+			return skipped, result.Error
+		}
+	}
+
+	return skipped, nil
+}
+
+func bindSecretString(secret *models.Secret, key string) error {
+	plaintext, err := decryptUnboundSecretString(secret, key)
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", secret.Slug, err)
+	}
+
+	return encryptSecretString(secret, plaintext, key)
+}
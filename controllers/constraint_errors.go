@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/liobrdev/simplepasswords_vaults/database"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+// duplicateMessages are the messages for unique violations, by the table violated.
+var duplicateMessages = map[string]string{
+	"users":   utils.ErrorDuplicateUser,
+	"vaults":  utils.ErrorDuplicateVault,
+	"entries": utils.ErrorDuplicateEntry,
+	"secrets": utils.ErrorDuplicateSecret,
+	"shares":  utils.ErrorDuplicateShare,
+	"vault_members": utils.ErrorDuplicateMember,
+}
+
+// respondWithDBError responds to a failed write: 409 if it would have duplicated a record,
+// 400 if it referred to a missing record or left out a required field, or else 500.
+func respondWithDBError(c *fiber.Ctx, operation string, err error) error {
+	constraintErr := database.ClassifyConstraintError(err)
+
+	if constraintErr == nil {
+		return utils.RespondWithError(c, 500, operation, utils.ErrorFailedDB, err.Error())
+	}
+
+	switch constraintErr.Kind {
+	case database.ConstraintUnique:
+		message, ok := duplicateMessages[constraintErr.Table]
+
+		if !ok {
+			break
+		}
+
+		return utils.RespondWithError(c, 409, operation, message, constraintErr.Error())
+	case database.ConstraintForeignKey:
+		return utils.RespondWithError(
+			c, 400, operation, utils.ErrorMissingReference, constraintErr.Error(),
+		)
+	case database.ConstraintNotNull:
+		return utils.RespondWithError(
+			c, 400, operation, utils.ErrorMissingField, constraintErr.Error(),
+		)
+	}
+
+	return utils.RespondWithError(c, 500, operation, utils.ErrorFailedDB, err.Error())
+}
@@ -1,11 +1,13 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 
+	"github.com/liobrdev/simplepasswords_vaults/database"
 	"github.com/liobrdev/simplepasswords_vaults/models"
 	"github.com/liobrdev/simplepasswords_vaults/utils"
 )
@@ -34,6 +36,12 @@ func (H Handler) CreateEntry(c *fiber.Ctx) error {
 		return utils.RespondWithError(c, 400, utils.CreateEntry, utils.ErrorUserSlug, body.UserSlug)
 	}
 
+	if body.UserSlug != requestUserSlug(c) {
+		return utils.RespondWithError(
+			c, 400, utils.CreateEntry, utils.ErrorUserSlug, utils.ErrorUserSlugHeader,
+		)
+	}
+
 	if !utils.SlugRegexp.MatchString(body.VaultSlug) {
 		return utils.RespondWithError(c, 400, utils.CreateEntry, utils.ErrorVaultSlug, body.VaultSlug)
 	}
@@ -85,6 +93,12 @@ func (H Handler) CreateEntry(c *fiber.Ctx) error {
 		priorities[secret.Priority] = true
 	}
 
+	access, err := H.accessVault(body.VaultSlug, body.UserSlug, models.RoleEditor)
+
+	if err != nil {
+		return respondWithAccessError(c, utils.CreateEntry, body.VaultSlug, &access, err)
+	}
+
 	var entry models.Entry
 
 	if entrySlug, err := utils.GenerateSlug(16); err != nil {
@@ -93,44 +107,76 @@ func (H Handler) CreateEntry(c *fiber.Ctx) error {
 		)
 	} else {
 		entry.Slug = entrySlug
+		setAuditTarget(c, entrySlug)
 	}
 
-	entry.UserSlug = body.UserSlug
+	// Entries in a team vault belong to its creator, whichever member creates them.
+	entry.UserSlug = access.vault.UserSlug
 	entry.VaultSlug = body.VaultSlug
 	entry.Title = body.EntryTitle
 
+	var key string
+	var user models.User
+
+	if len(body.Secrets) > 0 {
+		var userKey string
+
+		if userKey, user, err = H.userKey(c, body.UserSlug); err != nil {
+			return respondWithKeyError(c, utils.CreateEntry, body.UserSlug, err)
+		}
+
+		if key, err = access.secretsKey(&user, userKey); err != nil {
+			return respondWithKeyError(c, utils.CreateEntry, body.UserSlug, err)
+		}
+	}
+
 	if err := H.DB.Transaction(func(tx *gorm.DB) error {
+		if len(body.Secrets) > 0 {
+			if err := lockUserKey(tx, &user); err != nil {
+				return err
+			}
+		}
+
 		if result := tx.Create(&entry); result.Error != nil {
 			return result.Error
 		}
 
-		password := c.Get(H.Conf.PASSWORD_HEADER_KEY)
-
 		for _, secret := range(body.Secrets) {
-			if slug, err := utils.GenerateSlug(16); err != nil {
-				return fmt.Errorf("`secret.Slug` generation failed: %s", err.Error())
-			} else if encryptedString, err := utils.Encrypt(secret.String, password); err != nil {
-				return fmt.Errorf("`secret.String` encryption failed: %s", err.Error())
-			} else if result := tx.Create(&models.Secret{
-				Slug:      slug,
+			newSecret := models.Secret{
 				Label:     secret.Label,
-				String:    encryptedString,
 				Priority:	 secret.Priority,
 				EntrySlug: entry.Slug,
 				VaultSlug: entry.VaultSlug,
 				UserSlug:  entry.UserSlug,
-			}); result.Error != nil {
+			}
+
+			if slug, err := utils.GenerateSlug(16); err != nil {
+				return fmt.Errorf("`secret.Slug` generation failed: %s", err.Error())
+			} else {
+				newSecret.Slug = slug
+			}
+
+			if err := encryptSecretString(&newSecret, secret.String, key); err != nil {
+				return fmt.Errorf("`secret.String` encryption failed: %s", err.Error())
+			} else if result := tx.Create(&newSecret); result.Error != nil {
 				return result.Error
 			}
 		}
 
-		return nil
-	}); err != nil {
+		return database.RefreshIntegrity(tx, entry.UserSlug, database.IntegrityEntryTree(entry.Slug))
+	}); errors.Is(err, errWrongVaultKey) {
+		// The user's key derivation was upgraded since the key was derived.
+		if H.rederiveUpgradedKey(c, &user) {
+			return H.CreateEntry(c)
+		}
+
+		return respondWithKeyError(c, utils.CreateEntry, body.UserSlug, err)
+	} else if err != nil {
 		if errText := err.Error(); utils.FailedSecretSlugRegexp.MatchString(errText) {
 			return utils.RespondWithError(c, 500, utils.CreateEntry, errText, "")
 		}
 
-		return utils.RespondWithError(c, 500, utils.CreateEntry, utils.ErrorFailedDB, err.Error())
+		return respondWithDBError(c, utils.CreateEntry, err)
 	}
 
 	return c.SendStatus(204)
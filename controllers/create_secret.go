@@ -1,8 +1,12 @@
 package controllers
 
 import (
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 
+	"github.com/liobrdev/simplepasswords_vaults/database"
 	"github.com/liobrdev/simplepasswords_vaults/models"
 	"github.com/liobrdev/simplepasswords_vaults/utils"
 )
@@ -26,6 +30,12 @@ func (H Handler) CreateSecret(c *fiber.Ctx) error {
 		return utils.RespondWithError(c, 400, utils.CreateSecret, utils.ErrorUserSlug, body.UserSlug)
 	}
 
+	if body.UserSlug != requestUserSlug(c) {
+		return utils.RespondWithError(
+			c, 400, utils.CreateSecret, utils.ErrorUserSlug, utils.ErrorUserSlugHeader,
+		)
+	}
+
 	if !utils.SlugRegexp.MatchString(body.VaultSlug) {
 		return utils.RespondWithError(c, 400, utils.CreateSecret, utils.ErrorVaultSlug, body.VaultSlug)
 	}
@@ -50,6 +60,18 @@ func (H Handler) CreateSecret(c *fiber.Ctx) error {
 		return utils.RespondWithError(c, 400, utils.CreateSecret, utils.ErrorSecretString, "Too long")
 	}
 
+	entry, access, err := H.entryAccess(body.EntrySlug, body.UserSlug, models.RoleEditor)
+
+	if err != nil {
+		return respondWithAccessError(c, utils.CreateSecret, body.EntrySlug, &access, err)
+	}
+
+	if entry.VaultSlug != body.VaultSlug {
+		return utils.RespondWithError(
+			c, 400, utils.CreateSecret, utils.ErrorVaultSlug, utils.ErrorEntryVaultSlug,
+		)
+	}
+
 	var secret models.Secret
 
 	if secretSlug, err := utils.GenerateSlug(16); err != nil {
@@ -58,11 +80,13 @@ func (H Handler) CreateSecret(c *fiber.Ctx) error {
 		)
 	} else {
 		secret.Slug = secretSlug
+		setAuditTarget(c, secretSlug)
 	}
 
 	var count int64
 
-	if result := H.DB.Model(&models.Secret{}).Where("entry_slug = ?", body.EntrySlug).Count(&count);
+	if result := H.DB.Model(&models.Secret{}).
+	Where("entry_slug = ? AND user_slug = ?", body.EntrySlug, entry.UserSlug).Count(&count);
 	result.Error != nil {
 		return utils.RespondWithError(
 			c, 500, utils.CreateSecret, utils.ErrorFailedDB, result.Error.Error(),
@@ -71,23 +95,51 @@ func (H Handler) CreateSecret(c *fiber.Ctx) error {
 		secret.Priority = uint8(count)
 	}
 
-	password := c.Get(H.Conf.PASSWORD_HEADER_KEY)
-
-	if encryptedString, err := utils.Encrypt(body.SecretString, password); err != nil {
-		return utils.RespondWithError(c, 500, utils.CreateSecret, utils.ErrorEncrypt, err.Error())
-	} else {
-		secret.String = encryptedString
-	}
-
 	secret.Label = body.SecretLabel
-	secret.UserSlug = body.UserSlug
+	// Like its entry, a secret in a team vault belongs to the vault's creator.
+	secret.UserSlug = entry.UserSlug
 	secret.VaultSlug = body.VaultSlug
 	secret.EntrySlug = body.EntrySlug
 
-	if result := H.DB.Create(&secret); result.Error != nil {
-		return utils.RespondWithError(
-			c, 500, utils.CreateSecret, utils.ErrorFailedDB, result.Error.Error(),
-		)
+	userKey, user, err := H.userKey(c, body.UserSlug)
+
+	if err != nil {
+		return respondWithKeyError(c, utils.CreateSecret, body.UserSlug, err)
+	}
+
+	key, err := access.secretsKey(&user, userKey)
+
+	if err != nil {
+		return respondWithKeyError(c, utils.CreateSecret, body.UserSlug, err)
+	}
+
+	if err := encryptSecretString(&secret, body.SecretString, key); err != nil {
+		return utils.RespondWithError(c, 500, utils.CreateSecret, utils.ErrorEncrypt, err.Error())
+	}
+
+	if err := H.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockUserKey(tx, &user); err != nil {
+			return err
+		}
+
+		if result := tx.Create(&secret); result.Error != nil {
+			return result.Error
+		}
+
+		if err := propagateSharedSecret(tx, &secret, body.SecretString, key, ""); err != nil {
+			return err
+		}
+
+		return database.RefreshIntegrity(tx, secret.UserSlug, database.IntegritySecretRow(secret.Slug))
+	}); errors.Is(err, errWrongVaultKey) {
+		// The user's key derivation was upgraded since the key was derived.
+		if H.rederiveUpgradedKey(c, &user) {
+			return H.CreateSecret(c)
+		}
+
+		return respondWithKeyError(c, utils.CreateSecret, body.UserSlug, err)
+	} else if err != nil {
+		return respondWithDBError(c, utils.CreateSecret, err)
 	}
 
 	return c.SendStatus(204)
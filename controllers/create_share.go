@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+type CreateShareRequestBody struct {
+	EntrySlug   string `json:"entry_slug"`
+	GranteeSlug string `json:"grantee_slug"`
+	Permission  string `json:"permission"`
+}
+
+// CreateShare shares one of the user's entries with another user. The grantee gets a copy
+// of each of the entry's secrets, encrypted to their sharing public key, so that the
+// grantee's own password is all they need to read them.
+func (H Handler) CreateShare(c *fiber.Ctx) error {
+	body := CreateShareRequestBody{}
+
+	if err := c.BodyParser(&body); err != nil {
+		return utils.RespondWithError(c, 400, utils.CreateShare, utils.ErrorParse, err.Error())
+	}
+
+	if !utils.SlugRegexp.MatchString(body.EntrySlug) {
+		return utils.RespondWithError(c, 400, utils.CreateShare, utils.ErrorEntrySlug, body.EntrySlug)
+	}
+
+	if !utils.SlugRegexp.MatchString(body.GranteeSlug) {
+		return utils.RespondWithError(
+			c, 400, utils.CreateShare, utils.ErrorGranteeSlug, body.GranteeSlug,
+		)
+	}
+
+	userSlug := requestUserSlug(c)
+
+	if body.GranteeSlug == userSlug {
+		return utils.RespondWithError(
+			c, 400, utils.CreateShare, utils.ErrorGranteeSlug, "Same as `User-Slug` header.",
+		)
+	}
+
+	if body.Permission != models.PermissionRead && body.Permission != models.PermissionWrite {
+		return utils.RespondWithError(
+			c, 400, utils.CreateShare, utils.ErrorSharePermission, body.Permission,
+		)
+	}
+
+	var entry models.Entry
+
+	if result := H.DB.Preload("Secrets").
+	First(&entry, "slug = ? AND user_slug = ?", body.EntrySlug, userSlug); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return utils.RespondWithError(c, 404, utils.CreateShare, utils.ErrorNotFound, body.EntrySlug)
+		}
+
+		return utils.RespondWithError(
+			c, 500, utils.CreateShare, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	var memberCount int64
+
+	// Team vaults' secrets are under their vault's key, which shares don't hold.
+	if result := H.DB.Model(&models.VaultMember{}).Where("vault_slug = ?", entry.VaultSlug).
+	Count(&memberCount); result.Error != nil {
+		return utils.RespondWithError(
+			c, 500, utils.CreateShare, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	} else if memberCount > 0 {
+		return utils.RespondWithError(
+			c, 409, utils.CreateShare, utils.ErrorTeamVaultShare, entry.VaultSlug,
+		)
+	}
+
+	var grantee models.User
+
+	if result := H.DB.First(&grantee, "slug = ?", body.GranteeSlug); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return utils.RespondWithError(
+				c, 404, utils.CreateShare, utils.ErrorNotFound, body.GranteeSlug,
+			)
+		}
+
+		return utils.RespondWithError(
+			c, 500, utils.CreateShare, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	// Users created before sharing existed get their key pair the next time they unlock.
+	if grantee.PublicKey == "" {
+		return utils.RespondWithError(
+			c, 409, utils.CreateShare, utils.ErrorGranteeShareKey, body.GranteeSlug,
+		)
+	}
+
+	share := models.Share{
+		EntrySlug:   entry.Slug,
+		OwnerSlug:   userSlug,
+		GranteeSlug: grantee.Slug,
+		Permission:  body.Permission,
+	}
+
+	if shareSlug, err := utils.GenerateSlug(16); err != nil {
+		return utils.RespondWithError(
+			c, 500, utils.CreateShare, "Failed to generate `share.Slug`.", err.Error(),
+		)
+	} else {
+		share.Slug = shareSlug
+		setAuditTarget(c, shareSlug)
+	}
+
+	key, _, err := H.userKey(c, userSlug)
+
+	if err != nil {
+		return respondWithKeyError(c, utils.CreateShare, userSlug, err)
+	}
+
+	// The share key is agreed between a key pair made for this share alone and the grantee's.
+	publicKey, privateKey, err := utils.NewShareKeyPair()
+
+	if err != nil {
+		return utils.RespondWithError(c, 500, utils.CreateShare, utils.ErrorEncrypt, err.Error())
+	}
+
+	shareKey, err := utils.ShareKey(privateKey, grantee.PublicKey)
+
+	if err != nil {
+		return utils.RespondWithError(c, 500, utils.CreateShare, utils.ErrorEncrypt, err.Error())
+	}
+
+	share.PublicKey = publicKey
+
+	if share.OwnerKey, err = utils.Encrypt(
+		shareKey, key, utils.ShareKeyAdditionalData(share.Slug),
+	); err != nil {
+		return utils.RespondWithError(c, 500, utils.CreateShare, utils.ErrorEncrypt, err.Error())
+	}
+
+	for _, secret := range entry.Secrets {
+		plaintext, err := decryptSecretString(&secret, key)
+
+		if err != nil {
+			return utils.RespondWithError(c, 500, utils.CreateShare, utils.ErrorDecrypt, err.Error())
+		}
+
+		shareSecret := models.ShareSecret{ShareSlug: share.Slug, SecretSlug: secret.Slug}
+
+		if shareSecret.String, err = encryptShareSecretString(
+			share.Slug, secret.Slug, plaintext, shareKey,
+		); err != nil {
+			return utils.RespondWithError(c, 500, utils.CreateShare, utils.ErrorEncrypt, err.Error())
+		}
+
+		share.Secrets = append(share.Secrets, shareSecret)
+	}
+
+	if result := H.DB.Create(&share); result.Error != nil {
+		return respondWithDBError(c, utils.CreateShare, result.Error)
+	}
+
+	return c.SendStatus(204)
+}
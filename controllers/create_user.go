@@ -24,16 +24,47 @@ func (H Handler) CreateUser(c *fiber.Ctx) error {
 		return utils.RespondWithError(c, 400, utils.CreateUser, utils.ErrorUserSlug, body.Slug)
 	}
 
-	if result := H.DB.Create(&models.User{ Slug: body.Slug }); result.Error != nil {
-		if result.Error.Error() == "UNIQUE constraint failed: users.slug" {
-			return utils.RespondWithError(
-				c, 409, utils.CreateUser, utils.ErrorDuplicateUser, result.Error.Error(),
-			)
+	setAuditTarget(c, body.Slug)
+
+	user := models.User{Slug: body.Slug}
+
+	// Users who send a master password have their key derived from it. Those who don't are
+	// created as users always were, on the raw key they send when they first unlock.
+	if password := c.Get(H.Conf.PASSWORD_HEADER_KEY); password != "" {
+		if len(password) < utils.MinPasswordSize {
+			return utils.RespondWithError(c, 400, utils.CreateUser, utils.ErrorPassword, "Too short")
+		} else if len(password) > utils.MaxPasswordSize {
+			return utils.RespondWithError(c, 400, utils.CreateUser, utils.ErrorPassword, "Too long")
 		}
 
-		return utils.RespondWithError(
-			c, 500, utils.CreateUser, utils.ErrorFailedDB, result.Error.Error(),
-		)
+		params, err := H.newUserKDFParams()
+
+		if err != nil {
+			return utils.RespondWithError(c, 500, utils.CreateUser, utils.ErrorEncrypt, err.Error())
+		}
+
+		clientKey, err := params.DeriveKey(password)
+
+		if err != nil {
+			return utils.RespondWithError(c, 500, utils.CreateUser, utils.ErrorEncrypt, err.Error())
+		}
+
+		// The user gets a new data key, and the check that later requests' keys must pass.
+		key, keyed, err := H.keyedUser(user, params, clientKey)
+
+		if err != nil {
+			return utils.RespondWithError(c, 500, utils.CreateUser, utils.ErrorEncrypt, err.Error())
+		}
+
+		if keyed.PublicKey, keyed.PrivateKey, err = newShareKeys(keyed.Slug, key); err != nil {
+			return utils.RespondWithError(c, 500, utils.CreateUser, utils.ErrorEncrypt, err.Error())
+		}
+
+		user = keyed
+	}
+
+	if result := H.DB.Create(&user); result.Error != nil {
+		return respondWithDBError(c, utils.CreateUser, result.Error)
 	} else if n := result.RowsAffected; n != 1 {
 		return utils.RespondWithError(
 			c, 500, utils.CreateUser, "result.RowsAffected != 1", strconv.FormatInt(n, 10),
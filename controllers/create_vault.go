@@ -1,10 +1,13 @@
 package controllers
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 
+	"github.com/liobrdev/simplepasswords_vaults/database"
 	"github.com/liobrdev/simplepasswords_vaults/models"
 	"github.com/liobrdev/simplepasswords_vaults/utils"
 )
@@ -25,6 +28,12 @@ func (H Handler) CreateVault(c *fiber.Ctx) error {
 		return utils.RespondWithError(c, 400, utils.CreateVault, utils.ErrorUserSlug, body.UserSlug)
 	}
 
+	if body.UserSlug != requestUserSlug(c) {
+		return utils.RespondWithError(
+			c, 400, utils.CreateVault, utils.ErrorUserSlug, utils.ErrorUserSlugHeader,
+		)
+	}
+
 	if body.VaultTitle == "" {
 		return utils.RespondWithError(c, 400, utils.CreateVault, utils.ErrorVaultTitle, "")
 	}
@@ -41,25 +50,30 @@ func (H Handler) CreateVault(c *fiber.Ctx) error {
 		)
 	} else {
 		vault.Slug = vaultSlug
+		setAuditTarget(c, vaultSlug)
 	}
 
 	vault.UserSlug = body.UserSlug
 	vault.Title = body.VaultTitle
 
-	if result := H.DB.Create(&vault); result.Error != nil {
-		if result.Error.Error() == "UNIQUE constraint failed: vaults.title, vaults.user_slug" {
+	var n int64
+
+	if err := H.DB.Transaction(func(tx *gorm.DB) error {
+		if result := tx.Create(&vault); result.Error != nil {
+			return result.Error
+		} else if n = result.RowsAffected; n != 1 {
+			return errors.New("result.RowsAffected != 1")
+		}
+
+		return database.RefreshIntegrity(tx, vault.UserSlug, database.IntegrityVaultRow(vault.Slug))
+	}); err != nil {
+		if err.Error() == "result.RowsAffected != 1" {
 			return utils.RespondWithError(
-				c, 409, utils.CreateVault, utils.ErrorDuplicateVault, result.Error.Error(),
+				c, 500, utils.CreateVault, err.Error(), strconv.FormatInt(n, 10),
 			)
 		}
 
-		return utils.RespondWithError(
-			c, 500, utils.CreateVault, utils.ErrorFailedDB, result.Error.Error(),
-		)
-	} else if n := result.RowsAffected; n != 1 {
-		return utils.RespondWithError(
-			c, 500, utils.CreateVault, "result.RowsAffected != 1", strconv.FormatInt(n, 10),
-		)
+		return respondWithDBError(c, utils.CreateVault, err)
 	}
 
 	return c.SendStatus(204)
@@ -3,10 +3,12 @@ package controllers
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 
+	"github.com/liobrdev/simplepasswords_vaults/database"
 	"github.com/liobrdev/simplepasswords_vaults/models"
 	"github.com/liobrdev/simplepasswords_vaults/utils"
 )
@@ -18,11 +20,25 @@ func (H Handler) DeleteEntry(c *fiber.Ctx) error {
 		return utils.RespondWithError(c, 400, utils.DeleteEntry, utils.ErrorEntrySlug, slug)
 	}
 
-	var entry models.Entry
+	userSlug := requestUserSlug(c)
+
+	// Editors of a team vault trash its entries on behalf of its creator.
+	if entry, access, err := H.entryAccess(slug, userSlug, models.RoleEditor); err == nil {
+		userSlug = entry.UserSlug
+	} else if errors.Is(err, errVaultRole) {
+		return utils.RespondWithError(c, 403, utils.DeleteEntry, utils.ErrorVaultRole, access.role)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.RespondWithError(c, 500, utils.DeleteEntry, utils.ErrorFailedDB, err.Error())
+	}
+
 	var result *gorm.DB
 
 	if err := H.DB.Transaction(func(tx *gorm.DB) error {
-		if result = tx.Delete(&entry, "slug = ?", slug); result.Error != nil {
+		// See DeleteVault; the entry's secrets are trashed at the same `deleted_at`.
+		now := time.Now().UTC()
+
+		if result = tx.Model(&models.Entry{}).Where("slug = ? AND user_slug = ?", slug, userSlug).
+		UpdateColumn("deleted_at", now); result.Error != nil {
 			return result.Error
 		} else if n := result.RowsAffected; n == 0 {
 			return errors.New(utils.ErrorNoRowsAffected)
@@ -30,11 +46,13 @@ func (H Handler) DeleteEntry(c *fiber.Ctx) error {
 			return fmt.Errorf("result.RowsAffected (%d) > 1", n)
 		}
 
-		if result = tx.Delete(&models.Secret{}, "entry_slug = ?", slug); result.Error != nil {
+		if result = tx.Model(&models.Secret{}).
+		Where("entry_slug = ? AND user_slug = ?", slug, userSlug).
+		UpdateColumn("deleted_at", now); result.Error != nil {
 			return result.Error
 		}
 
-		return nil
+		return database.RefreshIntegrity(tx, userSlug, database.IntegrityEntryTree(slug))
 	}); err != nil {
 		if errText := err.Error(); errText == utils.ErrorNoRowsAffected {
 			return utils.RespondWithError(
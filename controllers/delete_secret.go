@@ -1,12 +1,12 @@
 package controllers
 
 import (
-	"errors"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 
+	"github.com/liobrdev/simplepasswords_vaults/database"
 	"github.com/liobrdev/simplepasswords_vaults/models"
 	"github.com/liobrdev/simplepasswords_vaults/utils"
 )
@@ -18,23 +18,21 @@ func (H Handler) DeleteSecret(c *fiber.Ctx) error {
 		return utils.RespondWithError(c, 400, utils.DeleteSecret, utils.ErrorSecretSlug, slug)
 	}
 
-	var secret models.Secret
+	secret, access, err := H.secretAccess(slug, requestUserSlug(c), models.RoleEditor)
 
-	if result := H.DB.First(&secret, "slug = ?", slug); result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return utils.RespondWithError(c, 404, utils.DeleteSecret, utils.ErrorNotFound, slug)
-		}
-
-		return utils.RespondWithError(
-			c, 500, utils.DeleteSecret, utils.ErrorFailedDB, result.Error.Error(),
-		)
+	if err != nil {
+		return respondWithAccessError(c, utils.DeleteSecret, slug, &access, err)
 	}
 
+	// Editors of a team vault trash its secrets on behalf of its creator.
+	userSlug := secret.UserSlug
+
 	var secrets []string
 
 	if result := H.DB.Raw(
-		"SELECT slug FROM secrets WHERE entry_slug = ? AND priority > ?",
-		secret.EntrySlug, secret.Priority,
+		"SELECT slug FROM secrets " +
+		"WHERE entry_slug = ? AND user_slug = ? AND priority > ? AND deleted_at IS NULL",
+		secret.EntrySlug, userSlug, secret.Priority,
 	).Scan(&secrets); result.Error != nil {
 		return utils.RespondWithError(
 			c, 500, utils.DeleteSecret, utils.ErrorFailedDB, result.Error.Error(),
@@ -42,18 +40,21 @@ func (H Handler) DeleteSecret(c *fiber.Ctx) error {
 	}
 
 	if err := H.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+
 		if result := tx.Exec(
 			"UPDATE secrets SET priority = ?, updated_at = ? WHERE slug IN ?",
-			gorm.Expr("priority - 1"), time.Now().UTC(), secrets,
+			gorm.Expr("priority - 1"), now, secrets,
 		); result.Error != nil {
 			return result.Error
 		}
 
-		if result := tx.Delete(&secret); result.Error != nil {
+		// The trashed secret keeps its priority so RestoreSecret can put it back in place.
+		if result := tx.Model(&secret).UpdateColumn("deleted_at", now); result.Error != nil {
 			return result.Error
 		}
 
-		return nil
+		return database.RefreshIntegrity(tx, userSlug, database.IntegrityEntrySecrets(secret.EntrySlug))
 	}); err != nil {
 		return utils.RespondWithError(c, 500, utils.MoveSecret, utils.ErrorFailedDB, err.Error())
 	}
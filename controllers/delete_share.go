@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+// DeleteShare revokes a share of one of the user's entries, or lets the user leave a share
+// of someone else's. Either way, the grantee's copies of the secrets are deleted with it.
+func (H Handler) DeleteShare(c *fiber.Ctx) error {
+	slug := c.Params("slug")
+
+	if !utils.SlugRegexp.MatchString(slug) {
+		return utils.RespondWithError(c, 400, utils.DeleteShare, utils.ErrorShareSlug, slug)
+	}
+
+	userSlug := requestUserSlug(c)
+	var share models.Share
+
+	if result := H.DB.First(
+		&share, "slug = ? AND (owner_slug = ? OR grantee_slug = ?)", slug, userSlug, userSlug,
+	); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return utils.RespondWithError(c, 404, utils.DeleteShare, utils.ErrorNotFound, slug)
+		}
+
+		return utils.RespondWithError(
+			c, 500, utils.DeleteShare, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	if err := H.DB.Transaction(func(tx *gorm.DB) error {
+		if result := tx.Delete(&models.ShareSecret{}, "share_slug = ?", slug); result.Error != nil {
+			return result.Error
+		}
+
+		return tx.Delete(&share).Error
+	}); err != nil {
+		return utils.RespondWithError(c, 500, utils.DeleteShare, utils.ErrorFailedDB, err.Error())
+	}
+
+	return c.SendStatus(204)
+}
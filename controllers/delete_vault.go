@@ -3,10 +3,12 @@ package controllers
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 
+	"github.com/liobrdev/simplepasswords_vaults/database"
 	"github.com/liobrdev/simplepasswords_vaults/models"
 	"github.com/liobrdev/simplepasswords_vaults/utils"
 )
@@ -18,11 +20,26 @@ func (H Handler) DeleteVault(c *fiber.Ctx) error {
 		return utils.RespondWithError(c, 400, utils.DeleteVault, utils.ErrorVaultSlug, slug)
 	}
 
-	var vault models.Vault
+	userSlug := requestUserSlug(c)
+
+	// Owners of a team vault trash it on behalf of its creator.
+	if access, err := H.accessVault(slug, userSlug, models.RoleOwner); err == nil {
+		userSlug = access.vault.UserSlug
+	} else if errors.Is(err, errVaultRole) {
+		return utils.RespondWithError(c, 403, utils.DeleteVault, utils.ErrorVaultRole, access.role)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.RespondWithError(c, 500, utils.DeleteVault, utils.ErrorFailedDB, err.Error())
+	}
+
 	var result *gorm.DB
 
 	if err := H.DB.Transaction(func(tx *gorm.DB) error {
-		if result = tx.Delete(&vault, "slug = ?", slug); result.Error != nil {
+		// The vault's entries and secrets are trashed at the same `deleted_at`, which is how
+		// RestoreVault tells them apart from children that were trashed on their own.
+		now := time.Now().UTC()
+
+		if result = tx.Model(&models.Vault{}).Where("slug = ? AND user_slug = ?", slug, userSlug).
+		UpdateColumn("deleted_at", now); result.Error != nil {
 			return result.Error
 		} else if n := result.RowsAffected; n == 0 {
 			return errors.New(utils.ErrorNoRowsAffected)
@@ -30,15 +47,19 @@ func (H Handler) DeleteVault(c *fiber.Ctx) error {
 			return fmt.Errorf("result.RowsAffected (%d) > 1", n)
 		}
 
-		if result = tx.Delete(&models.Entry{}, "vault_slug = ?", slug); result.Error != nil {
+		if result = tx.Model(&models.Entry{}).
+		Where("vault_slug = ? AND user_slug = ?", slug, userSlug).
+		UpdateColumn("deleted_at", now); result.Error != nil {
 			return result.Error
 		}
 
-		if result = tx.Delete(&models.Secret{}, "vault_slug = ?", slug); result.Error != nil {
+		if result = tx.Model(&models.Secret{}).
+		Where("vault_slug = ? AND user_slug = ?", slug, userSlug).
+		UpdateColumn("deleted_at", now); result.Error != nil {
 			return result.Error
 		}
 
-		return nil
+		return database.RefreshIntegrity(tx, userSlug, database.IntegrityVaultTree(slug))
 	}); err != nil {
 		if errText := err.Error(); errText == utils.ErrorNoRowsAffected {
 			return utils.RespondWithError(
@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+const exportBatchSize int = 100
+
+// ExportUser streams every live vault, entry and decrypted secret of the user's vaults,
+// team vaults they're a member of included, as one JSON document of the form
+//
+//	{"user_slug":"...","exported_at":"...","vaults":[{...,"entries":[{...,"secrets":[...]}]}]}
+//
+// encrypted into a utils.ExportWriter file under the passphrase in the export passphrase
+// header. Records are read in batches while the response is written, so the export is
+// never held in memory whole. The status is sent before the first batch is read, so a
+// failure after that point can only cut the response short, which leaves the file
+// without its final chunk and makes it fail to decrypt.
+func (H Handler) ExportUser(c *fiber.Ctx) error {
+	slug := c.Params("slug")
+
+	if !utils.SlugRegexp.MatchString(slug) {
+		return utils.RespondWithError(c, 400, utils.ExportUser, utils.ErrorUserSlug, slug)
+	}
+
+	if slug != requestUserSlug(c) {
+		return utils.RespondWithError(
+			c, 400, utils.ExportUser, utils.ErrorUserSlug, utils.ErrorUserSlugHeader,
+		)
+	}
+
+	passphrase := c.Get(H.Conf.EXPORT_PASSPHRASE_HEADER_KEY)
+
+	if len(passphrase) < 12 {
+		return utils.RespondWithError(
+			c, 400, utils.ExportUser, utils.ErrorExportPassphrase, "Too short",
+		)
+	} else if len(passphrase) > 1024 {
+		return utils.RespondWithError(
+			c, 400, utils.ExportUser, utils.ErrorExportPassphrase, "Too long",
+		)
+	}
+
+	key, user, err := H.userKey(c, slug)
+
+	if err != nil {
+		return respondWithKeyError(c, utils.ExportUser, slug, err)
+	}
+
+	// Team vaults' secrets are under their vault's key instead.
+	keys := newVaultKeys(user, strings.Clone(key))
+	var secret models.Secret
+
+	// A wrong key is the likeliest failure, so it's caught while an error can still be sent.
+	if result := H.DB.Scopes(inUserVaults(slug, "vault_slug", models.RoleViewer)).Limit(1).
+	Find(&secret); result.Error != nil {
+		return utils.RespondWithError(
+			c, 500, utils.ExportUser, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	} else if result.RowsAffected == 1 {
+		if secretsKey, err := keys.forVault(H.DB, secret.VaultSlug); err != nil {
+			return respondWithKeyError(c, utils.ExportUser, slug, err)
+		} else if _, err := decryptSecretString(&secret, secretsKey); err != nil {
+			return utils.RespondWithError(c, 500, utils.ExportUser, utils.ErrorDecrypt, err.Error())
+		}
+	}
+
+	// The stream is written after the handler returns, when fasthttp may have reused the
+	// request's memory, so the strings it needs are copied out first.
+	export := userExport{
+		db:         H.DB,
+		userSlug:   strings.Clone(slug),
+		keys:       keys,
+		passphrase: strings.Clone(passphrase),
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEOctetStream)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(
+		`attachment; filename="simplepasswords-export-%s.json.enc"`, slug,
+	))
+	c.Status(200).Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := export.write(w); err != nil {
+			log.Println("Failed user export:", err)
+		}
+	})
+
+	return nil
+}
+
+type userExport struct {
+	db         *gorm.DB
+	userSlug   string
+	keys       *vaultKeys
+	passphrase string
+}
+
+func (export userExport) write(w *bufio.Writer) error {
+	ew, err := utils.NewExportWriter(w, export.passphrase)
+
+	if err != nil {
+		return err
+	}
+
+	userSlug, _ := json.Marshal(export.userSlug)
+	exportedAt, _ := json.Marshal(time.Now().UTC())
+
+	if _, err := fmt.Fprintf(
+		ew, `{"user_slug":%s,"exported_at":%s,"vaults":[`, userSlug, exportedAt,
+	); err != nil {
+		return err
+	}
+
+	var vaults []models.Vault
+	vaultCount := 0
+
+	if result := export.db.Scopes(inUserVaults(export.userSlug, "slug", models.RoleViewer)).
+	FindInBatches(&vaults, exportBatchSize, func(tx *gorm.DB, batch int) error {
+		for _, vault := range vaults {
+			if vaultCount > 0 {
+				if _, err := io.WriteString(ew, ","); err != nil {
+					return err
+				}
+			}
+
+			if err := export.writeVault(ew, &vault); err != nil {
+				return err
+			}
+
+			vaultCount++
+		}
+
+		return w.Flush()
+	}); result.Error != nil {
+		return result.Error
+	}
+
+	if _, err := io.WriteString(ew, "]}"); err != nil {
+		return err
+	} else if err := ew.Close(); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
+
+// writeVault writes the vault as models.Vault would marshal it, with its entries read a
+// batch at a time instead of all at once.
+func (export userExport) writeVault(w io.Writer, vault *models.Vault) error {
+	// Marshaling without entries leaves `"entries":null}` at the end, to be replaced.
+	vaultJSON, err := json.Marshal(vault)
+
+	if err != nil {
+		return err
+	} else if opening, ok := strings.CutSuffix(string(vaultJSON), "null}"); !ok {
+		return fmt.Errorf("Unexpected vault JSON: %s", vaultJSON)
+	} else if _, err := io.WriteString(w, opening + "["); err != nil {
+		return err
+	}
+
+	key, err := export.keys.forVault(export.db, vault.Slug)
+
+	if err != nil {
+		return err
+	}
+
+	var entries []models.Entry
+	entryCount := 0
+
+	if result := export.db.
+	Where("vault_slug = ? AND user_slug = ?", vault.Slug, vault.UserSlug).
+	Preload("Secrets", func(db *gorm.DB) *gorm.DB {
+		return db.Order("secrets.priority, secrets.created_at")
+	}).
+	FindInBatches(&entries, exportBatchSize, func(tx *gorm.DB, batch int) error {
+		for _, entry := range entries {
+			for i := range entry.Secrets {
+				if plaintext, err := decryptSecretString(&entry.Secrets[i], key);
+				err != nil {
+					return fmt.Errorf("%s: %s", entry.Secrets[i].Slug, err.Error())
+				} else {
+					entry.Secrets[i].String = plaintext
+				}
+			}
+
+			entryJSON, err := json.Marshal(&entry)
+
+			if err != nil {
+				return err
+			} else if entryCount > 0 {
+				entryJSON = append([]byte(","), entryJSON...)
+			}
+
+			if _, err := w.Write(entryJSON); err != nil {
+				return err
+			}
+
+			entryCount++
+		}
+
+		return nil
+	}); result.Error != nil {
+		return result.Error
+	}
+
+	_, err = io.WriteString(w, "]}")
+
+	return err
+}
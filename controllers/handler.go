@@ -4,9 +4,13 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
 )
 
 type Handler struct {
-	DB   *gorm.DB
-	Conf *config.AppConfig
+	DB           *gorm.DB
+	Conf         *config.AppConfig
+	AuthFailures *AuthFailureTracker
+	KDFUpgrades  *KDFUpgrades
+	Keys         utils.KeyProvider
 }
@@ -0,0 +1,312 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/database"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+const (
+	ImportKindVault  string = "vault"
+	ImportKindEntry  string = "entry"
+	ImportKindSecret string = "secret"
+)
+
+const defaultImportVaultTitle string = "Imported"
+
+type ImportRequestBody struct {
+	UserSlug   string `json:"user_slug"`
+	Format     string `json:"format"`
+	Data       string `json:"data"`
+	VaultTitle string `json:"vault_title"`
+}
+
+// ImportReportItem describes one imported record. `slug` is only set for created ones and
+// `reason` only for skipped or conflicting ones.
+type ImportReportItem struct {
+	Kind        string `json:"kind"`
+	Slug        string `json:"slug,omitempty"`
+	VaultTitle  string `json:"vault_title"`
+	EntryTitle  string `json:"entry_title,omitempty"`
+	SecretLabel string `json:"secret_label,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+type ImportResponseBody struct {
+	Created   []ImportReportItem `json:"created"`
+	Skipped   []ImportReportItem `json:"skipped"`
+	Conflicts []ImportReportItem `json:"conflicts"`
+}
+
+// Import creates vaults, entries and secrets from a Bitwarden JSON, KeePass XML or CSV
+// export, all in one transaction. Records that would break a length limit are skipped,
+// and ones that would break a unique index on titles or labels are reported as conflicts
+// instead; a skipped or conflicting vault or entry takes its contents with it. Entries
+// whose vault title matches an existing vault are added to it.
+func (H Handler) Import(c *fiber.Ctx) error {
+	body := ImportRequestBody{}
+
+	if err := c.BodyParser(&body); err != nil {
+		return utils.RespondWithError(c, 400, utils.Import, utils.ErrorParse, err.Error())
+	}
+
+	if !utils.SlugRegexp.MatchString(body.UserSlug) {
+		return utils.RespondWithError(c, 400, utils.Import, utils.ErrorUserSlug, body.UserSlug)
+	}
+
+	if body.UserSlug != requestUserSlug(c) {
+		return utils.RespondWithError(
+			c, 400, utils.Import, utils.ErrorUserSlug, utils.ErrorUserSlugHeader,
+		)
+	}
+
+	parse, ok := importParsers[body.Format]
+
+	if !ok {
+		return utils.RespondWithError(c, 400, utils.Import, utils.ErrorImportFormat, body.Format)
+	}
+
+	if body.VaultTitle == "" {
+		body.VaultTitle = defaultImportVaultTitle
+	} else if len(body.VaultTitle) > 255 {
+		return utils.RespondWithError(c, 400, utils.Import, utils.ErrorVaultTitle, "Too long")
+	}
+
+	if body.Data == "" {
+		return utils.RespondWithError(c, 400, utils.Import, utils.ErrorImportData, "")
+	}
+
+	vaults, err := parse([]byte(body.Data), body.VaultTitle)
+
+	if err != nil {
+		return utils.RespondWithError(c, 400, utils.Import, utils.ErrorImportData, err.Error())
+	}
+
+	key, user, err := H.userKey(c, body.UserSlug)
+
+	if err != nil {
+		return respondWithKeyError(c, utils.Import, body.UserSlug, err)
+	}
+
+	report := ImportResponseBody{
+		Created:   []ImportReportItem{},
+		Skipped:   []ImportReportItem{},
+		Conflicts: []ImportReportItem{},
+	}
+
+	if err := H.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockUserKey(tx, &user); err != nil {
+			return err
+		}
+
+		importer := importer{
+			tx:       tx,
+			userSlug: body.UserSlug,
+			keys:     newVaultKeys(user, key),
+			report:   &report,
+			scopes:   &[]database.IntegrityScope{},
+		}
+
+		for _, vault := range vaults {
+			if err := importer.importVault(vault); err != nil {
+				return err
+			}
+		}
+
+		return database.RefreshIntegrity(tx, body.UserSlug, *importer.scopes...)
+	}); errors.Is(err, errWrongVaultKey) {
+		// The user's key derivation was upgraded since the key was derived.
+		if H.rederiveUpgradedKey(c, &user) {
+			return H.Import(c)
+		}
+
+		return respondWithKeyError(c, utils.Import, body.UserSlug, err)
+	} else if err != nil {
+		if errText := err.Error(); utils.FailedSecretSlugRegexp.MatchString(errText) {
+			return utils.RespondWithError(c, 500, utils.Import, errText, "")
+		} else if errors.Is(err, errImportEncrypt) {
+			return utils.RespondWithError(c, 500, utils.Import, utils.ErrorEncrypt, errText)
+		} else if errors.Is(err, errUnwrapVaultKey) {
+			return utils.RespondWithError(c, 500, utils.Import, utils.ErrorDecrypt, errText)
+		}
+
+		return respondWithDBError(c, utils.Import, err)
+	}
+
+	return c.Status(200).JSON(&report)
+}
+
+var errImportEncrypt = errors.New("`secret.String` encryption failed")
+
+type importer struct {
+	tx       *gorm.DB
+	userSlug string
+	// keys are those of the secrets of each vault, since team vaults' aren't the user's own.
+	keys     *vaultKeys
+	report   *ImportResponseBody
+	// scopes are the records created so far, for the integrity manifest.
+	scopes   *[]database.IntegrityScope
+}
+
+func (imp importer) importVault(imported *importedVault) error {
+	item := ImportReportItem{Kind: ImportKindVault, VaultTitle: imported.title}
+
+	if imported.title == "" || len(imported.title) > 255 {
+		item.Reason = utils.ErrorVaultTitle
+		imp.report.Skipped = append(imp.report.Skipped, item)
+		return nil
+	}
+
+	// Trashed vaults keep their titles until they're purged, so they're looked up too.
+	var vault models.Vault
+
+	if result := imp.tx.Unscoped().Limit(1).
+	Find(&vault, "user_slug = ? AND title = ?", imp.userSlug, imported.title);
+	result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		if slug, err := utils.GenerateSlug(16); err != nil {
+			return fmt.Errorf("Failed to generate `vault.Slug`: %s", err.Error())
+		} else {
+			vault = models.Vault{Slug: slug, Title: imported.title, UserSlug: imp.userSlug}
+		}
+
+		if result := imp.tx.Create(&vault); result.Error != nil {
+			return result.Error
+		}
+
+		item.Slug = vault.Slug
+		imp.report.Created = append(imp.report.Created, item)
+		*imp.scopes = append(*imp.scopes, database.IntegrityVaultRow(vault.Slug))
+	} else if vault.DeletedAt.Valid {
+		item.Reason = utils.ErrorImportTrashed
+		imp.report.Conflicts = append(imp.report.Conflicts, item)
+		return nil
+	}
+
+	var titles []string
+
+	if result := imp.tx.Unscoped().Model(&models.Entry{}).
+	Where("vault_slug = ?", vault.Slug).Pluck("title", &titles); result.Error != nil {
+		return result.Error
+	}
+
+	entryTitles := map[string]bool{}
+
+	for _, title := range titles {
+		entryTitles[title] = true
+	}
+
+	for _, entry := range imported.entries {
+		if err := imp.importEntry(&vault, entry, entryTitles); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (imp importer) importEntry(
+	vault *models.Vault, imported importedEntry, entryTitles map[string]bool,
+) error {
+	item := ImportReportItem{
+		Kind: ImportKindEntry, VaultTitle: vault.Title, EntryTitle: imported.title,
+	}
+
+	if imported.title == "" || len(imported.title) > 255 {
+		item.Reason = utils.ErrorEntryTitle
+		imp.report.Skipped = append(imp.report.Skipped, item)
+		return nil
+	} else if entryTitles[imported.title] {
+		item.Reason = utils.ErrorDuplicateEntry
+		imp.report.Conflicts = append(imp.report.Conflicts, item)
+		return nil
+	}
+
+	entry := models.Entry{Title: imported.title, VaultSlug: vault.Slug, UserSlug: imp.userSlug}
+
+	if slug, err := utils.GenerateSlug(16); err != nil {
+		return fmt.Errorf("Failed to generate `entry.Slug`: %s", err.Error())
+	} else {
+		entry.Slug = slug
+	}
+
+	*imp.scopes = append(*imp.scopes, database.IntegrityEntryTree(entry.Slug))
+
+	if result := imp.tx.Create(&entry); result.Error != nil {
+		return result.Error
+	}
+
+	entryTitles[entry.Title] = true
+	item.Slug = entry.Slug
+	imp.report.Created = append(imp.report.Created, item)
+
+	labels := map[string]bool{}
+	priority := 0
+
+	for _, secret := range imported.secrets {
+		item := ImportReportItem{
+			Kind:        ImportKindSecret,
+			VaultTitle:  vault.Title,
+			EntryTitle:  entry.Title,
+			SecretLabel: secret.label,
+		}
+
+		if secret.label == "" || len(secret.label) > 255 {
+			item.Reason = utils.ErrorSecretLabel
+			imp.report.Skipped = append(imp.report.Skipped, item)
+			continue
+		} else if len(secret.value) > 1000 {
+			item.Reason = utils.ErrorSecretString
+			imp.report.Skipped = append(imp.report.Skipped, item)
+			continue
+		} else if priority > 255 {
+			item.Reason = utils.ErrorSecretPriority
+			imp.report.Skipped = append(imp.report.Skipped, item)
+			continue
+		} else if labels[secret.label] {
+			item.Reason = utils.ErrorDuplicateSecretsLabel
+			imp.report.Conflicts = append(imp.report.Conflicts, item)
+			continue
+		}
+
+		newSecret := models.Secret{
+			Label:     secret.label,
+			Priority:  uint8(priority),
+			EntrySlug: entry.Slug,
+			VaultSlug: entry.VaultSlug,
+			UserSlug:  entry.UserSlug,
+		}
+
+		if slug, err := utils.GenerateSlug(16); err != nil {
+			return fmt.Errorf("Failed to generate `secret.Slug`: %s", err.Error())
+		} else {
+			newSecret.Slug = slug
+		}
+
+		key, err := imp.keys.forVault(imp.tx, newSecret.VaultSlug)
+
+		if err != nil {
+			return err
+		}
+
+		if err := encryptSecretString(&newSecret, secret.value, key); err != nil {
+			return fmt.Errorf("%w: %s", errImportEncrypt, err.Error())
+		} else if result := imp.tx.Create(&newSecret); result.Error != nil {
+			return result.Error
+		}
+
+		labels[secret.label] = true
+		priority++
+		item.Slug = newSecret.Slug
+		imp.report.Created = append(imp.report.Created, item)
+	}
+
+	return nil
+}
@@ -0,0 +1,334 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+type importedSecret struct {
+	label string
+	value string
+}
+
+type importedEntry struct {
+	title   string
+	secrets []importedSecret
+}
+
+type importedVault struct {
+	title   string
+	entries []importedEntry
+}
+
+// importedVaults keeps vaults in the order they first appear in an export, merging
+// every folder or group that maps to the same title into one vault.
+type importedVaults struct {
+	vaults  []*importedVault
+	byTitle map[string]*importedVault
+}
+
+func (v *importedVaults) add(vaultTitle string, entry importedEntry) {
+	if v.byTitle == nil {
+		v.byTitle = map[string]*importedVault{}
+	}
+
+	vault, ok := v.byTitle[vaultTitle]
+
+	if !ok {
+		vault = &importedVault{title: vaultTitle}
+		v.byTitle[vaultTitle] = vault
+		v.vaults = append(v.vaults, vault)
+	}
+
+	vault.entries = append(vault.entries, entry)
+}
+
+// Each parser turns an export into vaults, entries and one secret per non-empty field,
+// in the order the fields should be prioritized. Records without a folder or group go
+// into `defaultVaultTitle`.
+var importParsers = map[string]func(data []byte, defaultVaultTitle string) (
+	[]*importedVault, error,
+){
+	"bitwarden": parseBitwardenExport,
+	"keepass":   parseKeePassExport,
+	"csv":       parseCSVExport,
+}
+
+type bitwardenExport struct {
+	Encrypted bool `json:"encrypted"`
+	Folders   []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"folders"`
+	Items []struct {
+		FolderID string `json:"folderId"`
+		Name     string `json:"name"`
+		Notes    string `json:"notes"`
+		Login    *struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			TOTP     string `json:"totp"`
+			URIs     []struct {
+				URI string `json:"uri"`
+			} `json:"uris"`
+		} `json:"login"`
+		Card     map[string]string `json:"card"`
+		Identity map[string]string `json:"identity"`
+		Fields   []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"fields"`
+	} `json:"items"`
+}
+
+// Card and identity fields, in the order the Bitwarden clients show them.
+var (
+	bitwardenCardFields = []struct{ key, label string }{
+		{"cardholderName", "Cardholder name"}, {"brand", "Brand"}, {"number", "Number"},
+		{"expMonth", "Expiration month"}, {"expYear", "Expiration year"}, {"code", "Security code"},
+	}
+	bitwardenIdentityFields = []struct{ key, label string }{
+		{"title", "Title"}, {"firstName", "First name"}, {"middleName", "Middle name"},
+		{"lastName", "Last name"}, {"username", "Username"}, {"company", "Company"},
+		{"ssn", "Social Security number"}, {"passportNumber", "Passport number"},
+		{"licenseNumber", "License number"}, {"email", "Email"}, {"phone", "Phone"},
+		{"address1", "Address 1"}, {"address2", "Address 2"}, {"address3", "Address 3"},
+		{"city", "City / Town"}, {"state", "State / Province"}, {"postalCode", "Zip / Postal code"},
+		{"country", "Country"},
+	}
+)
+
+func parseBitwardenExport(data []byte, defaultVaultTitle string) ([]*importedVault, error) {
+	var export bitwardenExport
+
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, err
+	} else if export.Encrypted {
+		return nil, errors.New("Encrypted exports aren't supported.")
+	}
+
+	folders := map[string]string{}
+
+	for _, folder := range export.Folders {
+		folders[folder.ID] = folder.Name
+	}
+
+	var vaults importedVaults
+
+	for _, item := range export.Items {
+		entry := importedEntry{title: item.Name}
+
+		if item.Login != nil {
+			entry.addSecret("Username", item.Login.Username)
+			entry.addSecret("Password", item.Login.Password)
+
+			for i, uri := range item.Login.URIs {
+				if i == 0 {
+					entry.addSecret("URI", uri.URI)
+				} else {
+					entry.addSecret(fmt.Sprintf("URI %d", i + 1), uri.URI)
+				}
+			}
+
+			entry.addSecret("TOTP", item.Login.TOTP)
+		}
+
+		for _, field := range bitwardenCardFields {
+			entry.addSecret(field.label, item.Card[field.key])
+		}
+
+		for _, field := range bitwardenIdentityFields {
+			entry.addSecret(field.label, item.Identity[field.key])
+		}
+
+		entry.addSecret("Notes", item.Notes)
+
+		for i, field := range item.Fields {
+			if field.Name == "" {
+				field.Name = fmt.Sprintf("Field %d", i + 1)
+			}
+
+			entry.addSecret(field.Name, field.Value)
+		}
+
+		if folder, ok := folders[item.FolderID]; ok && folder != "" {
+			vaults.add(folder, entry)
+		} else {
+			vaults.add(defaultVaultTitle, entry)
+		}
+	}
+
+	return vaults.vaults, nil
+}
+
+type keePassExport struct {
+	Meta struct {
+		RecycleBinUUID string `xml:"RecycleBinUUID"`
+	} `xml:"Meta"`
+	Root struct {
+		Groups []keePassGroup `xml:"Group"`
+	} `xml:"Root"`
+}
+
+type keePassGroup struct {
+	UUID    string         `xml:"UUID"`
+	Name    string         `xml:"Name"`
+	Entries []keePassEntry `xml:"Entry"`
+	Groups  []keePassGroup `xml:"Group"`
+}
+
+type keePassEntry struct {
+	Strings []struct {
+		Key   string `xml:"Key"`
+		Value string `xml:"Value"`
+	} `xml:"String"`
+}
+
+// KeePass writes an entry's strings alphabetically, so the standard ones are put back
+// in the order its UI shows them, ahead of any custom strings.
+var keePassStandardKeys = []string{"UserName", "Password", "URL", "Notes"}
+
+// parseKeePassExport reads a KeePass 2.x XML export. Each group becomes a vault titled
+// by its path below the root group, whose own entries go into the default vault. The
+// recycle bin and entry histories aren't imported.
+func parseKeePassExport(data []byte, defaultVaultTitle string) ([]*importedVault, error) {
+	var export keePassExport
+
+	if err := xml.Unmarshal(data, &export); err != nil {
+		return nil, err
+	} else if len(export.Root.Groups) == 0 {
+		return nil, errors.New("No root group.")
+	}
+
+	var vaults importedVaults
+	var walk func(group keePassGroup, path string)
+
+	walk = func(group keePassGroup, path string) {
+		if group.UUID != "" && group.UUID == export.Meta.RecycleBinUUID {
+			return
+		}
+
+		vaultTitle := path
+
+		if path == "" {
+			vaultTitle = defaultVaultTitle
+		}
+
+		for _, keePassEntry := range group.Entries {
+			values := map[string]string{}
+			entry := importedEntry{}
+
+			for _, s := range keePassEntry.Strings {
+				values[s.Key] = s.Value
+			}
+
+			entry.title = values["Title"]
+
+			for _, key := range keePassStandardKeys {
+				entry.addSecret(key, values[key])
+			}
+
+			for _, s := range keePassEntry.Strings {
+				if s.Key != "Title" && !containsString(keePassStandardKeys, s.Key) {
+					entry.addSecret(s.Key, s.Value)
+				}
+			}
+
+			vaults.add(vaultTitle, entry)
+		}
+
+		for _, child := range group.Groups {
+			if path == "" {
+				walk(child, child.Name)
+			} else {
+				walk(child, path + "/" + child.Name)
+			}
+		}
+	}
+
+	for _, root := range export.Root.Groups {
+		walk(root, "")
+	}
+
+	return vaults.vaults, nil
+}
+
+var (
+	csvVaultColumns = []string{"vault", "folder", "group"}
+	csvTitleColumns = []string{"title", "name"}
+)
+
+// parseCSVExport reads a CSV file with a header row. It needs a title (or name) column,
+// may have a vault (or folder or group) column, and every other column becomes a secret
+// labeled by its header, prioritized left to right.
+func parseCSVExport(data []byte, defaultVaultTitle string) ([]*importedVault, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	records, err := reader.ReadAll()
+
+	if err != nil {
+		return nil, err
+	} else if len(records) == 0 {
+		return nil, errors.New("No header row.")
+	}
+
+	header := records[0]
+	vaultColumn, titleColumn := -1, -1
+
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+
+		if vaultColumn == -1 && containsString(csvVaultColumns, column) {
+			vaultColumn = i
+		} else if titleColumn == -1 && containsString(csvTitleColumns, column) {
+			titleColumn = i
+		}
+	}
+
+	if titleColumn == -1 {
+		return nil, errors.New("No title column.")
+	}
+
+	var vaults importedVaults
+
+	for _, record := range records[1:] {
+		entry := importedEntry{title: record[titleColumn]}
+		vaultTitle := defaultVaultTitle
+
+		if vaultColumn != -1 && record[vaultColumn] != "" {
+			vaultTitle = record[vaultColumn]
+		}
+
+		for i, value := range record {
+			if i != vaultColumn && i != titleColumn {
+				entry.addSecret(strings.TrimSpace(header[i]), value)
+			}
+		}
+
+		vaults.add(vaultTitle, entry)
+	}
+
+	return vaults.vaults, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addSecret skips empty fields, which exports write out for every unused one.
+func (entry *importedEntry) addSecret(label, value string) {
+	if value != "" {
+		entry.secrets = append(entry.secrets, importedSecret{label, value})
+	}
+}
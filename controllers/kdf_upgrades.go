@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"sync"
+)
+
+// KDFUpgrades runs key derivation upgrades off the request path, at most one at a time
+// per user, so that neither the unlock that starts one waits for it, nor do concurrent
+// unlocks by the same user each derive the key again. Each prefork child runs its own,
+// and across them, only the first upgrade to replace the user's salt is kept.
+type KDFUpgrades struct {
+	mu      sync.Mutex
+	running map[string]bool
+}
+
+func NewKDFUpgrades() *KDFUpgrades {
+	return &KDFUpgrades{running: map[string]bool{}}
+}
+
+// start runs `upgrade` in the background, unless one is already running for `userSlug`.
+func (upgrades *KDFUpgrades) start(userSlug string, upgrade func()) {
+	upgrades.mu.Lock()
+	defer upgrades.mu.Unlock()
+
+	if upgrades.running[userSlug] {
+		return
+	}
+
+	upgrades.running[userSlug] = true
+
+	go func() {
+		defer func() {
+			upgrades.mu.Lock()
+			delete(upgrades.running, userSlug)
+			upgrades.mu.Unlock()
+		}()
+
+		upgrade()
+	}()
+}
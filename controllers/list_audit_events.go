@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+type ListAuditEventsResponseBody struct {
+	Events     []models.AuditEvent `json:"events"`
+	NextCursor string              `json:"next_cursor"`
+}
+
+// ListAuditEvents returns a page of at most `limit` of the user's audit events, newest
+// first. They can be filtered by `operation`, `target_slug` and `outcome` (`success` or
+// `failure`), and to those recorded from `since` and before `until`, both RFC 3339
+// timestamps. Passing a page's `next_cursor` back as `cursor` fetches the next one; it's
+// empty on the last page.
+func (H Handler) ListAuditEvents(c *fiber.Ctx) error {
+	slug := c.Params("slug")
+
+	if !utils.SlugRegexp.MatchString(slug) {
+		return utils.RespondWithError(c, 400, utils.ListAuditEvents, utils.ErrorUserSlug, slug)
+	}
+
+	if slug != requestUserSlug(c) {
+		return utils.RespondWithError(
+			c, 400, utils.ListAuditEvents, utils.ErrorUserSlug, utils.ErrorUserSlugHeader,
+		)
+	}
+
+	params := pageParams{sort: "created"}
+	var paramsErr *pageParamsError
+
+	if params.limit, paramsErr = parsePageLimit(c); paramsErr == nil {
+		paramsErr = params.parseCursor(c)
+	}
+
+	if paramsErr != nil {
+		return utils.RespondWithError(
+			c, 400, utils.ListAuditEvents, paramsErr.message, paramsErr.detail,
+		)
+	}
+
+	query := H.DB.Where("user_slug = ?", slug)
+
+	if operation := c.Query("operation"); operation != "" {
+		if !utils.OperationRegexp.MatchString(operation) {
+			return utils.RespondWithError(
+				c, 400, utils.ListAuditEvents, utils.ErrorAuditOperation, operation,
+			)
+		}
+
+		query = query.Where("operation = ?", operation)
+	}
+
+	if targetSlug := c.Query("target_slug"); targetSlug != "" {
+		if !utils.SlugRegexp.MatchString(targetSlug) {
+			return utils.RespondWithError(
+				c, 400, utils.ListAuditEvents, utils.ErrorAuditTargetSlug, targetSlug,
+			)
+		}
+
+		query = query.Where("target_slug = ?", targetSlug)
+	}
+
+	if outcome := c.Query("outcome"); outcome != "" {
+		if outcome != AuditOutcomeSuccess && outcome != AuditOutcomeFailure {
+			return utils.RespondWithError(
+				c, 400, utils.ListAuditEvents, utils.ErrorAuditOutcome, outcome,
+			)
+		}
+
+		query = query.Where("outcome = ?", outcome)
+	}
+
+	if since := c.Query("since"); since != "" {
+		if t, err := time.Parse(time.RFC3339Nano, since); err != nil {
+			return utils.RespondWithError(c, 400, utils.ListAuditEvents, utils.ErrorAuditSince, since)
+		} else {
+			query = query.Where("created_at >= ?", t)
+		}
+	}
+
+	if until := c.Query("until"); until != "" {
+		if t, err := time.Parse(time.RFC3339Nano, until); err != nil {
+			return utils.RespondWithError(c, 400, utils.ListAuditEvents, utils.ErrorAuditUntil, until)
+		} else {
+			query = query.Where("created_at < ?", t)
+		}
+	}
+
+	if result := H.DB.First(&models.User{}, "slug = ?", slug); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return utils.RespondWithError(c, 404, utils.ListAuditEvents, utils.ErrorNotFound, slug)
+		}
+
+		return utils.RespondWithError(
+			c, 500, utils.ListAuditEvents, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	var events []models.AuditEvent
+
+	if result := query.Scopes(params.scope("audit_events")).Find(&events); result.Error != nil {
+		return utils.RespondWithError(
+			c, 500, utils.ListAuditEvents, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	body := ListAuditEventsResponseBody{Events: events}
+
+	if len(events) > params.limit {
+		body.Events = events[:params.limit]
+		last := body.Events[params.limit - 1]
+		body.NextCursor = params.nextCursor(last.Slug, "", last.CreatedAt, last.CreatedAt)
+	}
+
+	return c.Status(200).JSON(&body)
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+type ListSecretVersionsResponseBody struct {
+	Versions []models.SecretVersion `json:"secret_versions"`
+}
+
+func (H Handler) ListSecretVersions(c *fiber.Ctx) error {
+	slug := c.Params("slug")
+
+	if !utils.SlugRegexp.MatchString(slug) {
+		return utils.RespondWithError(c, 400, utils.ListSecretVersions, utils.ErrorSecretSlug, slug)
+	}
+
+	userSlug := requestUserSlug(c)
+	secret, access, err := H.secretAccess(slug, userSlug, models.RoleViewer)
+
+	if err != nil {
+		return respondWithAccessError(c, utils.ListSecretVersions, slug, &access, err)
+	}
+
+	var versions []models.SecretVersion
+
+	if result := H.DB.Order("created_at DESC").
+	Find(&versions, "secret_slug = ? AND user_slug = ?", slug, secret.UserSlug);
+	result.Error != nil {
+		return utils.RespondWithError(
+			c, 500, utils.ListSecretVersions, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	if len(versions) == 0 {
+		return c.Status(200).JSON(&ListSecretVersionsResponseBody{ Versions: versions })
+	}
+
+	userKey, user, err := H.userKey(c, userSlug)
+
+	if err != nil {
+		return respondWithKeyError(c, utils.ListSecretVersions, userSlug, err)
+	}
+
+	key, err := access.secretsKey(&user, userKey)
+
+	if err != nil {
+		return respondWithKeyError(c, utils.ListSecretVersions, userSlug, err)
+	}
+
+	for i := range versions {
+		if decryptedString, err := decryptSecretString(versionSecret(&versions[i]), key);
+		err != nil {
+			// The versions were rekeyed by an upgrade since the key was derived.
+			if H.rederiveUpgradedKey(c, &user) {
+				return H.ListSecretVersions(c)
+			}
+
+			return utils.RespondWithError(
+				c, 500, utils.ListSecretVersions, utils.ErrorDecrypt, err.Error(),
+			)
+		} else {
+			versions[i].String = decryptedString
+		}
+	}
+
+	H.upgradeUserKDF(&user, c.Get(H.Conf.PASSWORD_HEADER_KEY), userKey)
+
+	return c.Status(200).JSON(&ListSecretVersionsResponseBody{ Versions: versions })
+}
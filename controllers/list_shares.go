@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+type ListSharesResponseBody struct {
+	Owned    []models.Share `json:"owned"`
+	Received []models.Share `json:"received"`
+}
+
+// ListShares lists the shares of the user's entries, and the shares of others' entries
+// with the user. Shares of entries in the trash are left out of those received.
+func (H Handler) ListShares(c *fiber.Ctx) error {
+	userSlug := requestUserSlug(c)
+	body := ListSharesResponseBody{Owned: []models.Share{}, Received: []models.Share{}}
+
+	if result := H.DB.Where("owner_slug = ?", userSlug).Order("created_at").Find(&body.Owned);
+	result.Error != nil {
+		return utils.RespondWithError(
+			c, 500, utils.ListShares, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	if result := H.DB.Joins("JOIN entries ON entries.slug = shares.entry_slug").
+	Where("shares.grantee_slug = ? AND entries.deleted_at IS NULL", userSlug).
+	Order("shares.created_at").Find(&body.Received); result.Error != nil {
+		return utils.RespondWithError(
+			c, 500, utils.ListShares, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	return c.Status(200).JSON(&body)
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+type TrashedVault struct {
+	Slug      string    `json:"vault_slug"`
+	Title     string    `json:"vault_title"`
+	DeletedAt time.Time `json:"vault_deleted_at"`
+}
+
+type TrashedEntry struct {
+	Slug      string    `json:"entry_slug"`
+	Title     string    `json:"entry_title"`
+	VaultSlug string    `json:"vault_slug"`
+	DeletedAt time.Time `json:"entry_deleted_at"`
+}
+
+type TrashedSecret struct {
+	Slug      string    `json:"secret_slug"`
+	Label     string    `json:"secret_label"`
+	EntrySlug string    `json:"entry_slug"`
+	VaultSlug string    `json:"vault_slug"`
+	DeletedAt time.Time `json:"secret_deleted_at"`
+}
+
+type ListTrashResponseBody struct {
+	Vaults  []TrashedVault  `json:"vaults"`
+	Entries []TrashedEntry  `json:"entries"`
+	Secrets []TrashedSecret `json:"secrets"`
+}
+
+// ListTrash lists what the user can restore: the trashed vaults they own, and the trashed
+// entries and secrets of the vaults they can edit, team vaults included. Entries and
+// secrets that were trashed along with their vault or entry are left out, since restoring
+// the parent brings them back.
+func (H Handler) ListTrash(c *fiber.Ctx) error {
+	userSlug := requestUserSlug(c)
+	body := ListTrashResponseBody{
+		Vaults:  []TrashedVault{},
+		Entries: []TrashedEntry{},
+		Secrets: []TrashedSecret{},
+	}
+
+	if result := H.DB.Unscoped().Model(&models.Vault{}).
+	Scopes(inUserVaults(userSlug, "slug", models.RoleOwner)).Where("deleted_at IS NOT NULL").
+	Order("deleted_at DESC").Find(&body.Vaults); result.Error != nil {
+		return utils.RespondWithError(
+			c, 500, utils.ListTrash, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	if result := H.DB.Unscoped().Model(&models.Entry{}).
+	Scopes(inUserVaults(userSlug, "vault_slug", models.RoleEditor)).
+	Where("deleted_at IS NOT NULL").
+	Where(
+		"NOT EXISTS (SELECT 1 FROM vaults " +
+		"WHERE vaults.slug = entries.vault_slug AND vaults.deleted_at = entries.deleted_at)",
+	).
+	Order("deleted_at DESC").Find(&body.Entries); result.Error != nil {
+		return utils.RespondWithError(
+			c, 500, utils.ListTrash, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	if result := H.DB.Unscoped().Model(&models.Secret{}).
+	Scopes(inUserVaults(userSlug, "vault_slug", models.RoleEditor)).
+	Where("deleted_at IS NOT NULL").
+	Where(
+		"NOT EXISTS (SELECT 1 FROM entries " +
+		"WHERE entries.slug = secrets.entry_slug AND entries.deleted_at = secrets.deleted_at)",
+	).
+	Order("deleted_at DESC").Find(&body.Secrets); result.Error != nil {
+		return utils.RespondWithError(
+			c, 500, utils.ListTrash, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	return c.Status(200).JSON(&body)
+}
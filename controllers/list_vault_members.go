@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+type ListVaultMembersResponseBody struct {
+	Members []models.VaultMember `json:"vault_members"`
+}
+
+// ListVaultMembers lists the members of a vault the user has any role in, in the order
+// they were added. A personal vault has none.
+func (H Handler) ListVaultMembers(c *fiber.Ctx) error {
+	slug := c.Params("slug")
+
+	if !utils.SlugRegexp.MatchString(slug) {
+		return utils.RespondWithError(c, 400, utils.ListVaultMembers, utils.ErrorVaultSlug, slug)
+	}
+
+	if access, err := H.accessVault(slug, requestUserSlug(c), models.RoleViewer); err != nil {
+		return respondWithAccessError(c, utils.ListVaultMembers, slug, &access, err)
+	}
+
+	body := ListVaultMembersResponseBody{Members: []models.VaultMember{}}
+
+	if result := H.DB.Where("vault_slug = ?", slug).Order("created_at").Find(&body.Members);
+	result.Error != nil {
+		return utils.RespondWithError(
+			c, 500, utils.ListVaultMembers, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	return c.Status(200).JSON(&body)
+}
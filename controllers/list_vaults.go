@@ -8,24 +8,64 @@ import (
 )
 
 type ListVaultsResponseBody struct {
-	Vaults []models.Vault `json:"vaults"`
+	Vaults     []models.Vault `json:"vaults"`
+	NextCursor string         `json:"next_cursor"`
 }
 
+// ListVaults returns a page of at most `limit` vaults, ordered by `sort` (`title`,
+// `created` or `updated`) and optionally filtered by a case-insensitive `title_prefix`.
+// Passing a page's `next_cursor` back as `cursor` fetches the next one; it's empty on the
+// last page. Vaults the user is a member of are listed along with their own, and each has
+// the user's `vault_role` in it.
 func (H Handler) ListVaults(c *fiber.Ctx) error {
-	slug := c.Get("User-Slug")
+	slug := requestUserSlug(c)
+	params, paramsErr := parsePageParams(c)
 
-	if !utils.SlugRegexp.MatchString(slug) {
-		return utils.RespondWithError(c, 400, utils.ListVaults, utils.ErrorUserSlug, slug)
+	if paramsErr != nil {
+		return utils.RespondWithError(c, 400, utils.ListVaults, paramsErr.message, paramsErr.detail)
 	}
 
 	var vaults []models.Vault
 
-	if result := H.DB.Order("created_at DESC").Find(&vaults, "user_slug = ?", slug);
-	result.Error != nil {
+	if result := H.DB.Scopes(params.scope("vaults")).Find(
+		&vaults,
+		"(user_slug = ? OR slug IN (SELECT vault_slug FROM vault_members WHERE user_slug = ?))",
+		slug, slug,
+	); result.Error != nil {
 		return utils.RespondWithError(
 			c, 500, utils.ListVaults, utils.ErrorFailedDB, result.Error.Error(),
 		)
 	}
 
-	return c.Status(200).JSON(&ListVaultsResponseBody{ Vaults: vaults })
+	var members []models.VaultMember
+
+	if result := H.DB.Find(&members, "user_slug = ?", slug); result.Error != nil {
+		return utils.RespondWithError(
+			c, 500, utils.ListVaults, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	roles := make(map[string]string, len(members))
+
+	for _, member := range members {
+		roles[member.VaultSlug] = member.Role
+	}
+
+	for i := range vaults {
+		if role, ok := roles[vaults[i].Slug]; ok {
+			vaults[i].Role = role
+		} else {
+			vaults[i].Role = models.RoleOwner
+		}
+	}
+
+	body := ListVaultsResponseBody{Vaults: vaults}
+
+	if len(vaults) > params.limit {
+		body.Vaults = vaults[:params.limit]
+		last := body.Vaults[params.limit - 1]
+		body.NextCursor = params.nextCursor(last.Slug, last.Title, last.CreatedAt, last.UpdatedAt)
+	}
+
+	return c.Status(200).JSON(&body)
 }
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"strconv"
 	"time"
 
@@ -8,6 +9,7 @@ import (
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 
+	"github.com/liobrdev/simplepasswords_vaults/database"
 	"github.com/liobrdev/simplepasswords_vaults/models"
 	"github.com/liobrdev/simplepasswords_vaults/utils"
 )
@@ -18,6 +20,7 @@ type MoveSecretRequestBody struct {
 }
 
 func (H Handler) MoveSecret(c *fiber.Ctx) error {
+	setAuditOperation(c, utils.MoveSecret)
 	body := MoveSecretRequestBody{}
 
 	if err := c.BodyParser(&body); err != nil {
@@ -41,19 +44,42 @@ func (H Handler) MoveSecret(c *fiber.Ctx) error {
 
 	var secrets []models.Secret
 	slug := c.Params("slug")
+	userSlug := requestUserSlug(c)
+
+	// Editors of a team vault move its secrets on behalf of its creator.
+	if entry, access, err := H.entryAccess(body.EntrySlug, userSlug, models.RoleEditor);
+	err == nil {
+		userSlug = entry.UserSlug
+	} else if errors.Is(err, errVaultRole) {
+		return utils.RespondWithError(c, 403, utils.MoveSecret, utils.ErrorVaultRole, access.role)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.RespondWithError(c, 500, utils.MoveSecret, utils.ErrorFailedDB, err.Error())
+	}
 
-	if result := H.DB.Where("entry_slug = ?", body.EntrySlug).Find(&secrets); result.Error != nil {
+	if result := H.DB.Where("entry_slug = ? AND user_slug = ?", body.EntrySlug, userSlug).
+	Find(&secrets); result.Error != nil {
 		return utils.RespondWithError(
 			c, 500, utils.MoveSecret, utils.ErrorFailedDB, result.Error.Error(),
 		)
 	} else if result.RowsAffected == 0 {
 		return utils.RespondWithError(c, 404, utils.MoveSecret, utils.ErrorNotFound, "No secrets found")
 	} else if result.RowsAffected == 1 {
-		if result := H.DB.Model(models.Secret{}).Where("slug = ?", slug).Update("priority", 0);
-		result.Error != nil {
-			return utils.RespondWithError(
-				c, 500, utils.MoveSecret, utils.ErrorFailedDB, result.Error.Error(),
+		if err := H.DB.Transaction(func(tx *gorm.DB) error {
+			if err := lockUser(tx, userSlug); err != nil {
+				return err
+			}
+
+			if result := tx.Model(models.Secret{}).
+			Where("slug = ? AND entry_slug = ? AND user_slug = ?", slug, body.EntrySlug, userSlug).
+			Update("priority", 0); result.Error != nil {
+				return result.Error
+			}
+
+			return database.RefreshIntegrity(
+				tx, userSlug, database.IntegrityEntrySecrets(body.EntrySlug),
 			)
+		}); err != nil {
+			return utils.RespondWithError(c, 500, utils.MoveSecret, utils.ErrorFailedDB, err.Error())
 		}
 
 		return c.SendStatus(204)
@@ -102,6 +128,10 @@ func (H Handler) MoveSecret(c *fiber.Ctx) error {
 	}
 
 	if err := H.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockUser(tx, userSlug); err != nil {
+			return err
+		}
+
 		now := time.Now().UTC()
 
 		if result := tx.Exec(
@@ -118,7 +148,7 @@ func (H Handler) MoveSecret(c *fiber.Ctx) error {
 			return result.Error
 		}
 
-		return nil
+		return database.RefreshIntegrity(tx, userSlug, database.IntegrityEntrySecrets(body.EntrySlug))
 	}); err != nil {
 		return utils.RespondWithError(c, 500, utils.MoveSecret, utils.ErrorFailedDB, err.Error())
 	}
@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+const (
+	defaultPageLimit int = 100
+	maxPageLimit     int = 500
+)
+
+// Titles sort ascending, timestamps newest first. `slug` breaks ties so that every row
+// has a unique position, which is what keeps a cursor stable between requests.
+var pageSorts = map[string]struct{ column, direction string }{
+	"title":   {"title", "ASC"},
+	"created": {"created_at", "DESC"},
+	"updated": {"updated_at", "DESC"},
+}
+
+type pageCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	Slug  string `json:"k"`
+}
+
+type pageParams struct {
+	limit       int
+	sort        string
+	titlePrefix string
+	cursor      *pageCursor
+	cursorValue interface{}
+}
+
+type pageParamsError struct {
+	message string
+	detail  string
+}
+
+// parsePageParams reads `limit`, `sort`, `cursor` and `title_prefix` from the query string.
+func parsePageParams(c *fiber.Ctx) (params pageParams, paramsErr *pageParamsError) {
+	params.sort = "created"
+
+	if params.limit, paramsErr = parsePageLimit(c); paramsErr != nil {
+		return
+	}
+
+	if sort := c.Query("sort"); sort != "" {
+		if _, ok := pageSorts[sort]; !ok {
+			return params, &pageParamsError{utils.ErrorSort, sort}
+		}
+
+		params.sort = sort
+	}
+
+	if params.titlePrefix = c.Query("title_prefix"); len(params.titlePrefix) > 255 {
+		return params, &pageParamsError{utils.ErrorTitlePrefix, "Too long"}
+	}
+
+	paramsErr = params.parseCursor(c)
+
+	return
+}
+
+func parsePageLimit(c *fiber.Ctx) (int, *pageParamsError) {
+	if limit := c.Query("limit"); limit != "" {
+		if n, convErr := strconv.Atoi(limit); convErr != nil || n < 1 || n > maxPageLimit {
+			return 0, &pageParamsError{utils.ErrorLimit, limit}
+		} else {
+			return n, nil
+		}
+	}
+
+	return defaultPageLimit, nil
+}
+
+// parseCursor reads `cursor`, which must have been made for the params' sort.
+func (params *pageParams) parseCursor(c *fiber.Ctx) *pageParamsError {
+	cursor := c.Query("cursor")
+
+	if cursor == "" {
+		return nil
+	}
+
+	params.cursor = &pageCursor{}
+
+	if data, decodeErr := base64.RawURLEncoding.DecodeString(cursor); decodeErr != nil {
+		return &pageParamsError{utils.ErrorCursor, cursor}
+	} else if json.Unmarshal(data, params.cursor) != nil ||
+	!utils.SlugRegexp.MatchString(params.cursor.Slug) {
+		return &pageParamsError{utils.ErrorCursor, cursor}
+	} else if params.cursor.Sort != params.sort {
+		return &pageParamsError{utils.ErrorCursor, utils.ErrorCursorSort}
+	} else if params.sort == "title" {
+		params.cursorValue = params.cursor.Value
+	} else if t, parseErr := time.Parse(time.RFC3339Nano, params.cursor.Value);
+	parseErr != nil {
+		return &pageParamsError{utils.ErrorCursor, cursor}
+	} else {
+		params.cursorValue = t
+	}
+
+	return nil
+}
+
+// scope filters, orders and limits a query on `table`. It fetches one row more than the
+// limit, so the caller can tell whether there's a next page.
+func (params pageParams) scope(table string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		sort := pageSorts[params.sort]
+		column := table + "." + sort.column
+		slug := table + ".slug"
+
+		if params.titlePrefix != "" {
+			db = db.Where(
+				"LOWER(" + table + ".title) LIKE ? ESCAPE '\\'",
+				escapeLike(strings.ToLower(params.titlePrefix)) + "%",
+			)
+		}
+
+		if params.cursor != nil {
+			operator := ">"
+
+			if sort.direction == "DESC" {
+				operator = "<"
+			}
+
+			db = db.Where(fmt.Sprintf(
+				"(%s %s ? OR (%s = ? AND %s %s ?))", column, operator, column, slug, operator,
+			), params.cursorValue, params.cursorValue, params.cursor.Slug)
+		}
+
+		return db.Order(column + " " + sort.direction).Order(slug + " " + sort.direction).
+		Limit(params.limit + 1)
+	}
+}
+
+// nextCursor points just past the last row of a page.
+func (params pageParams) nextCursor(slug, title string, createdAt, updatedAt time.Time) string {
+	cursor := pageCursor{Sort: params.sort, Slug: slug}
+
+	switch params.sort {
+	case "title":
+		cursor.Value = title
+	case "created":
+		cursor.Value = createdAt.Format(time.RFC3339Nano)
+	case "updated":
+		cursor.Value = updatedAt.Format(time.RFC3339Nano)
+	}
+
+	data, _ := json.Marshal(&cursor)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
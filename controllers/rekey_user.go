@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/database"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+type RekeyUserRequestBody struct {
+	OldKey      string `json:"old_key"`
+	NewKey      string `json:"new_key"`
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+// RekeyUser re-encrypts every secret of the user under a new key. Users on raw keys send
+// `old_key`, and users with a master password send `old_password`. The new key is either
+// `new_key`, or derived from `new_password` with a new salt and the target parameters,
+// which is how users on raw keys move to master passwords.
+func (H Handler) RekeyUser(c *fiber.Ctx) error {
+	slug := c.Params("slug")
+
+	if !utils.SlugRegexp.MatchString(slug) {
+		return utils.RespondWithError(c, 400, utils.RekeyUser, utils.ErrorUserSlug, slug)
+	}
+
+	body := RekeyUserRequestBody{}
+
+	if err := c.BodyParser(&body); err != nil {
+		return utils.RespondWithError(c, 400, utils.RekeyUser, utils.ErrorParse, err.Error())
+	}
+
+	if (body.OldKey == "" && body.OldPassword == "") ||
+	(body.OldKey != "" && !utils.HexKeyRegexp.MatchString(body.OldKey)) {
+		return utils.RespondWithError(c, 400, utils.RekeyUser, utils.ErrorOldKey, "")
+	}
+
+	if len(body.OldPassword) > utils.MaxPasswordSize {
+		return utils.RespondWithError(c, 400, utils.RekeyUser, utils.ErrorOldPassword, "Too long")
+	}
+
+	if body.NewPassword == "" {
+		if !utils.HexKeyRegexp.MatchString(body.NewKey) {
+			return utils.RespondWithError(c, 400, utils.RekeyUser, utils.ErrorNewKey, "")
+		}
+	} else if body.NewKey != "" {
+		return utils.RespondWithError(
+			c, 400, utils.RekeyUser, utils.ErrorNewKey, "Expected either `new_key` or `new_password`.",
+		)
+	} else if len(body.NewPassword) < utils.MinPasswordSize {
+		return utils.RespondWithError(c, 400, utils.RekeyUser, utils.ErrorNewPassword, "Too short")
+	} else if len(body.NewPassword) > utils.MaxPasswordSize {
+		return utils.RespondWithError(c, 400, utils.RekeyUser, utils.ErrorNewPassword, "Too long")
+	}
+
+	if body.OldKey != "" && body.OldKey == body.NewKey {
+		return utils.RespondWithError(
+			c, 400, utils.RekeyUser, utils.ErrorNewKey, "Same as `old_key`.",
+		)
+	} else if body.OldPassword != "" && body.OldPassword == body.NewPassword {
+		return utils.RespondWithError(
+			c, 400, utils.RekeyUser, utils.ErrorNewPassword, "Same as `old_password`.",
+		)
+	}
+
+	var user models.User
+
+	if result := H.DB.First(&user, "slug = ?", slug); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return utils.RespondWithError(c, 404, utils.RekeyUser, utils.ErrorNotFound, slug)
+		}
+
+		return utils.RespondWithError(
+			c, 500, utils.RekeyUser, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	var oldKey string
+	var err error
+
+	if user.KDF == utils.KDFRawKey {
+		if body.OldKey == "" {
+			return utils.RespondWithError(
+				c, 400, utils.RekeyUser, utils.ErrorOldKey, "Expected `old_key`.",
+			)
+		}
+
+		oldKey, err = H.deriveUserKey(&user, body.OldKey, utils.ErrorOldKey)
+	} else if body.OldPassword == "" {
+		return utils.RespondWithError(
+			c, 400, utils.RekeyUser, utils.ErrorOldPassword, "Expected `old_password`.",
+		)
+	} else {
+		oldKey, err = H.deriveUserKey(&user, body.OldPassword, utils.ErrorOldPassword)
+	}
+
+	if err != nil {
+		return respondWithKeyError(c, utils.RekeyUser, slug, err)
+	}
+
+	// Users without a key check have the old key confirmed by decrypting their secrets.
+	if user.KeyCheck != "" {
+		if err := H.checkUserKey(&user, oldKey); err != nil {
+			return respondWithKeyError(c, utils.RekeyUser, slug, err)
+		}
+	}
+
+	clientKey, params := body.NewKey, utils.KDFParams{KDF: utils.KDFRawKey}
+
+	if body.NewPassword != "" {
+		if params, err = H.newUserKDFParams(); err != nil {
+			return utils.RespondWithError(c, 500, utils.RekeyUser, utils.ErrorEncrypt, err.Error())
+		} else if clientKey, err = params.DeriveKey(body.NewPassword); err != nil {
+			return utils.RespondWithError(c, 500, utils.RekeyUser, utils.ErrorEncrypt, err.Error())
+		}
+	}
+
+	// Users without a data key get one, so the new key is never the client key alone.
+	newKey, rekeyed, err := H.keyedUser(user, params, clientKey)
+
+	if err != nil {
+		return utils.RespondWithError(c, 500, utils.RekeyUser, utils.ErrorEncrypt, err.Error())
+	}
+
+	var failedSlug string
+
+	if err := H.DB.Transaction(func(tx *gorm.DB) (err error) {
+		// Concurrent rekeys, and writes under the old key, wait, or fail if this one won.
+		if err := lockUserKey(tx, &user); err != nil {
+			return err
+		}
+
+		if failedSlug, err = rekeySecrets(tx, slug, oldKey, newKey); err != nil {
+			return err
+		}
+
+		return tx.Model(&models.User{}).Where("slug = ?", slug).
+		Updates(userKeyColumns(&rekeyed)).Error
+	}); errors.Is(err, errWrongVaultKey) {
+		return respondWithKeyError(c, utils.RekeyUser, slug, err)
+	} else if err != nil {
+		if errText := err.Error(); errText == utils.ErrorDecrypt {
+			return utils.RespondWithError(c, 400, utils.RekeyUser, errText, failedSlug)
+		} else if errText == utils.ErrorEncrypt {
+			return utils.RespondWithError(c, 500, utils.RekeyUser, errText, failedSlug)
+		}
+
+		return utils.RespondWithError(c, 500, utils.RekeyUser, utils.ErrorFailedDB, err.Error())
+	}
+
+	return c.SendStatus(204)
+}
+
+// rekeySecrets re-encrypts every secret and secret version of the user, and the user's
+// sharing keys, from `oldKey` to `newKey`, and returns the slug of the one that failed,
+// if any.
+func rekeySecrets(tx *gorm.DB, userSlug, oldKey, newKey string) (failedSlug string, err error) {
+	// What can't be bound can't be read either, so it fails the rekey below.
+	if _, err := bindSecrets(tx, userSlug, oldKey); err != nil {
+		return "", err
+	}
+
+	// Team vaults' secrets aren't under the user's key, so they're left as they are.
+	if failedSlug, err := rekeySecretStrings(tx, func(db *gorm.DB) *gorm.DB {
+		return db.Where("user_slug = ?", userSlug).Where(teamVaultsExcluded)
+	}, oldKey, newKey); err != nil {
+		return failedSlug, err
+	}
+
+	if failedSlug, err := rekeyShareKeys(tx, userSlug, oldKey, newKey); err != nil {
+		return failedSlug, err
+	}
+
+	return "", database.RefreshIntegrity(tx, userSlug, database.IntegrityAllSecrets())
+}
+
+// rekeySecretStrings re-encrypts the secrets, and secret versions, that `scope` selects
+// from `oldKey` to `newKey`.
+func rekeySecretStrings(
+	tx *gorm.DB, scope func(*gorm.DB) *gorm.DB, oldKey, newKey string,
+) (failedSlug string, err error) {
+	var secrets []models.Secret
+
+	// Trashed secrets are rekeyed too, or they couldn't be read after being restored.
+	if result := tx.Unscoped().Scopes(scope).Find(&secrets); result.Error != nil {
+		return "", result.Error
+	}
+
+	for _, secret := range secrets {
+		plaintext, err := decryptSecretString(&secret, oldKey)
+
+		if err != nil {
+			return secret.Slug, errors.New(utils.ErrorDecrypt)
+		}
+
+		if err := encryptSecretString(&secret, plaintext, newKey); err != nil {
+			return secret.Slug, errors.New(utils.ErrorEncrypt)
+		}
+
+		// Only the ciphertext changed, so the secret's last update stays what it was.
+		if result := tx.Unscoped().Model(&models.Secret{}).Where("slug = ?", secret.Slug).
+		UpdateColumn("string", secret.String); result.Error != nil {
+			return "", result.Error
+		}
+	}
+
+	var versions []models.SecretVersion
+
+	if result := tx.Scopes(scope).Find(&versions); result.Error != nil {
+		return "", result.Error
+	}
+
+	for _, version := range versions {
+		secret := versionSecret(&version)
+		plaintext, err := decryptSecretString(secret, oldKey)
+
+		if err != nil {
+			return version.Slug, errors.New(utils.ErrorDecrypt)
+		}
+
+		if err := encryptSecretString(secret, plaintext, newKey); err != nil {
+			return version.Slug, errors.New(utils.ErrorEncrypt)
+		}
+
+		if result := tx.Model(&models.SecretVersion{}).Where("slug = ?", version.Slug).
+		UpdateColumn("string", secret.String); result.Error != nil {
+			return "", result.Error
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+// RemoveVaultMember removes a member from a vault the user owns, or lets the user leave a
+// vault they're a member of. The vault's creator can't be removed. The vault stays a team
+// vault, and its key isn't changed; see AddVaultMember.
+func (H Handler) RemoveVaultMember(c *fiber.Ctx) error {
+	slug := c.Params("slug")
+
+	if !utils.SlugRegexp.MatchString(slug) {
+		return utils.RespondWithError(c, 400, utils.RemoveVaultMember, utils.ErrorVaultSlug, slug)
+	}
+
+	memberSlug := c.Params("member_slug")
+
+	if !utils.SlugRegexp.MatchString(memberSlug) {
+		return utils.RespondWithError(
+			c, 400, utils.RemoveVaultMember, utils.ErrorMemberSlug, memberSlug,
+		)
+	}
+
+	userSlug := requestUserSlug(c)
+	role := models.RoleOwner
+
+	// Any member can leave.
+	if memberSlug == userSlug {
+		role = models.RoleViewer
+	}
+
+	access, err := H.accessVault(slug, userSlug, role)
+
+	if err != nil {
+		return respondWithAccessError(c, utils.RemoveVaultMember, slug, &access, err)
+	}
+
+	if memberSlug == access.vault.UserSlug {
+		return utils.RespondWithError(
+			c, 400, utils.RemoveVaultMember, utils.ErrorMemberSlug,
+			"Vault creator can't be removed.",
+		)
+	}
+
+	if result := H.DB.Delete(
+		&models.VaultMember{}, "vault_slug = ? AND user_slug = ?", slug, memberSlug,
+	); result.Error != nil {
+		return utils.RespondWithError(
+			c, 500, utils.RemoveVaultMember, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	} else if result.RowsAffected == 0 {
+		return utils.RespondWithError(c, 404, utils.RemoveVaultMember, utils.ErrorNotFound, memberSlug)
+	}
+
+	return c.SendStatus(204)
+}
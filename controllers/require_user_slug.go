@@ -0,0 +1,27 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+const userSlugLocal string = "user_slug"
+
+// RequireUserSlug identifies the user on whose behalf a vault, entry or secret
+// route is called. Handlers behind it must scope every query to that user.
+func (H Handler) RequireUserSlug(c *fiber.Ctx) error {
+	slug := c.Get("User-Slug")
+
+	if !utils.SlugRegexp.MatchString(slug) {
+		return utils.RespondWithError(c, 400, c.Get("Client-Operation"), utils.ErrorUserSlug, slug)
+	}
+
+	c.Locals(userSlugLocal, slug)
+
+	return c.Next()
+}
+
+func requestUserSlug(c *fiber.Ctx) string {
+	return c.Locals(userSlugLocal).(string)
+}
@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+const vaultKeyLocal string = "vault_key"
+
+type vaultKey struct {
+	key  string
+	user models.User
+}
+
+// RequireVaultKey refuses a password header that isn't the user's key, or doesn't derive
+// it, before the handler behind it encrypts or decrypts anything with it. Otherwise a
+// wrong key would only fail to decrypt, and worse, new secrets would be encrypted under
+// it. Requests without the header are let through, since not every request to these
+// routes needs the key, and the handlers refuse the ones that do.
+func (H Handler) RequireVaultKey(c *fiber.Ctx) error {
+	if c.Get(H.Conf.PASSWORD_HEADER_KEY) == "" {
+		return c.Next()
+	}
+
+	userSlug := requestUserSlug(c)
+	key, user, err := H.userKey(c, userSlug)
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The handler responds as it would to any other request for a user that doesn't exist.
+		return c.Next()
+	} else if err == nil {
+		if err = H.checkUserKey(&user, key); err == nil {
+			if key, err = H.ensureDataKey(&user, key); err == nil {
+				err = H.ensureShareKeys(&user, key)
+			}
+		}
+	}
+
+	if err != nil {
+		return respondWithKeyError(c, c.Get("Client-Operation"), userSlug, err)
+	}
+
+	H.bindUserSecrets(&user, key)
+	H.applySharedWrites(&user, key)
+	c.Locals(vaultKeyLocal, vaultKey{key, user})
+
+	return c.Next()
+}
+
+// checkUserKey returns errWrongVaultKey unless `key` is the user's. Users without a key
+// check, who were created before there were any, get one once their key is confirmed.
+func (H Handler) checkUserKey(user *models.User, key string) error {
+	if user.KeyCheck != "" {
+		if _, err := utils.Decrypt(
+			user.KeyCheck, key, utils.KeyCheckAdditionalData(user.Slug),
+		); err != nil {
+			return errWrongVaultKey
+		}
+
+		return nil
+	}
+
+	if matches, err := H.matchesUserSecrets(user.Slug, key); err != nil {
+		return err
+	} else if !matches {
+		return errWrongVaultKey
+	}
+
+	keyCheck, err := newKeyCheck(user.Slug, key)
+
+	if err != nil {
+		return err
+	}
+
+	// Only the first of concurrent requests sets it, and the others' keys matched it anyway.
+	if result := H.DB.Model(&models.User{}).
+	Where("slug = ? AND key_check = ?", user.Slug, "").
+	UpdateColumn("key_check", keyCheck); result.Error != nil {
+		return result.Error
+	}
+
+	user.KeyCheck = keyCheck
+
+	return nil
+}
+
+// matchesUserSecrets reports whether `key` sealed the user's secrets, by the key id their
+// ciphertexts record, or for ciphertexts too old to record one, by whether any of them
+// decrypts. A user with no secrets yet has nothing to confirm the key against, so the
+// first key they send becomes theirs.
+func (H Handler) matchesUserSecrets(userSlug, key string) (bool, error) {
+	var secrets []models.Secret
+
+	if result := H.DB.Unscoped().Where("user_slug = ?", userSlug).Where(teamVaultsExcluded).
+	Order("slug").Find(&secrets); result.Error != nil {
+		return false, result.Error
+	} else if len(secrets) == 0 {
+		return true, nil
+	}
+
+	keyID, err := utils.KeyID(key)
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, secret := range secrets {
+		if utils.IsLegacyCiphertext(secret.String) {
+			if _, err := decryptUnboundSecretString(&secret, key); err == nil {
+				return true, nil
+			}
+		} else if envelope, err := utils.ParseEnvelope(secret.String); err != nil {
+			continue
+		} else if envelope.KeyID != "" {
+			return envelope.KeyID == keyID, nil
+		} else if _, err := decryptUnboundSecretString(&secret, key); err == nil {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/database"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+// RestoreEntry takes an entry, and whatever was trashed along with it, out of the trash,
+// for anyone who could have trashed it. As with RestoreVault, an entry whose title has
+// been taken since is 409 Conflict.
+func (H Handler) RestoreEntry(c *fiber.Ctx) error {
+	slug := c.Params("slug")
+
+	if !utils.SlugRegexp.MatchString(slug) {
+		return utils.RespondWithError(c, 400, utils.RestoreEntry, utils.ErrorEntrySlug, slug)
+	}
+
+	var entry models.Entry
+
+	if result := H.DB.Unscoped().First(
+		&entry, "slug = ? AND deleted_at IS NOT NULL", slug,
+	); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return utils.RespondWithError(c, 404, utils.RestoreEntry, utils.ErrorNotFound, slug)
+		}
+
+		return utils.RespondWithError(
+			c, 500, utils.RestoreEntry, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	// Editors of a team vault restore its entries on behalf of its creator, as they trash them.
+	if access, err := H.accessTrashedVault(
+		entry.VaultSlug, requestUserSlug(c), models.RoleEditor,
+	); err != nil {
+		return respondWithAccessError(c, utils.RestoreEntry, slug, &access, err)
+	}
+
+	userSlug := entry.UserSlug
+
+	if result := H.DB.First(&models.Vault{}, "slug = ? AND user_slug = ?", entry.VaultSlug, userSlug);
+	result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return utils.RespondWithError(
+				c, 409, utils.RestoreEntry, utils.ErrorParentTrashed, entry.VaultSlug,
+			)
+		}
+
+		return utils.RespondWithError(
+			c, 500, utils.RestoreEntry, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	var result *gorm.DB
+
+	if err := H.DB.Transaction(func(tx *gorm.DB) error {
+		// See RestoreVault.
+		deletedAt := tx.Unscoped().Model(&models.Entry{}).Select("deleted_at").
+		Where("slug = ? AND user_slug = ?", slug, userSlug)
+
+		if result = tx.Unscoped().Model(&models.Secret{}).
+		Where("entry_slug = ? AND user_slug = ? AND deleted_at = (?)", slug, userSlug, deletedAt).
+		UpdateColumn("deleted_at", nil); result.Error != nil {
+			return result.Error
+		}
+
+		if result = tx.Unscoped().Model(&models.Entry{}).
+		Where("slug = ? AND user_slug = ? AND deleted_at IS NOT NULL", slug, userSlug).
+		UpdateColumn("deleted_at", nil); result.Error != nil {
+			return result.Error
+		} else if n := result.RowsAffected; n == 0 {
+			return errors.New(utils.ErrorNoRowsAffected)
+		} else if n > 1 {
+			return fmt.Errorf("result.RowsAffected (%d) > 1", n)
+		}
+
+		return database.RefreshIntegrity(tx, userSlug, database.IntegrityEntryTree(slug))
+	}); err != nil {
+		if errText := err.Error(); errText == utils.ErrorNoRowsAffected {
+			return utils.RespondWithError(
+				c, 404, utils.RestoreEntry, errText, "Likely that slug was not found in the trash.",
+			)
+		} else if utils.RowsRegexp.MatchString(errText) {
+			return utils.RespondWithError(c, 500, utils.RestoreEntry, errText, "")
+		}
+
+		return respondWithDBError(c, utils.RestoreEntry, err)
+	}
+
+	return c.SendStatus(204)
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/database"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+// RestoreSecret takes a secret out of the trash, for anyone who could have trashed it. As
+// with RestoreVault, a secret whose label has been taken since is 409 Conflict.
+func (H Handler) RestoreSecret(c *fiber.Ctx) error {
+	slug := c.Params("slug")
+
+	if !utils.SlugRegexp.MatchString(slug) {
+		return utils.RespondWithError(c, 400, utils.RestoreSecret, utils.ErrorSecretSlug, slug)
+	}
+
+	var secret models.Secret
+
+	if result := H.DB.Unscoped().First(
+		&secret, "slug = ? AND deleted_at IS NOT NULL", slug,
+	); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return utils.RespondWithError(c, 404, utils.RestoreSecret, utils.ErrorNotFound, slug)
+		}
+
+		return utils.RespondWithError(
+			c, 500, utils.RestoreSecret, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	// Editors of a team vault restore its secrets on behalf of its creator, as they trash them.
+	if access, err := H.accessTrashedVault(
+		secret.VaultSlug, requestUserSlug(c), models.RoleEditor,
+	); err != nil {
+		return respondWithAccessError(c, utils.RestoreSecret, slug, &access, err)
+	}
+
+	userSlug := secret.UserSlug
+
+	if result := H.DB.First(
+		&models.Entry{}, "slug = ? AND user_slug = ?", secret.EntrySlug, userSlug,
+	); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return utils.RespondWithError(
+				c, 409, utils.RestoreSecret, utils.ErrorParentTrashed, secret.EntrySlug,
+			)
+		}
+
+		return utils.RespondWithError(
+			c, 500, utils.RestoreSecret, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	var count int64
+
+	if result := H.DB.Model(&models.Secret{}).
+	Where("entry_slug = ? AND user_slug = ?", secret.EntrySlug, userSlug).Count(&count);
+	result.Error != nil {
+		return utils.RespondWithError(
+			c, 500, utils.RestoreSecret, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	// The secret goes back to the priority it was trashed at, unless the entry has since
+	// lost secrets of its own, in which case it goes last.
+	priority := secret.Priority
+
+	if int64(priority) > count {
+		priority = uint8(count)
+	}
+
+	if err := H.DB.Transaction(func(tx *gorm.DB) error {
+		if result := tx.Model(&models.Secret{}).Where(
+			"entry_slug = ? AND user_slug = ? AND priority >= ?", secret.EntrySlug, userSlug, priority,
+		).Updates(map[string]interface{}{
+			"priority":   gorm.Expr("priority + 1"),
+			"updated_at": time.Now().UTC(),
+		}); result.Error != nil {
+			return result.Error
+		}
+
+		if result := tx.Unscoped().Model(&secret).UpdateColumns(map[string]interface{}{
+			"deleted_at": nil,
+			"priority":   priority,
+		}); result.Error != nil {
+			return result.Error
+		}
+
+		return database.RefreshIntegrity(tx, userSlug, database.IntegrityEntrySecrets(secret.EntrySlug))
+	}); err != nil {
+		return respondWithDBError(c, utils.RestoreSecret, err)
+	}
+
+	return c.SendStatus(204)
+}
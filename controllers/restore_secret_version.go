@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/database"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+func (H Handler) RestoreSecretVersion(c *fiber.Ctx) error {
+	slug := c.Params("slug")
+
+	if !utils.SlugRegexp.MatchString(slug) {
+		return utils.RespondWithError(c, 400, utils.RestoreSecretVersion, utils.ErrorSecretSlug, slug)
+	}
+
+	versionSlug := c.Params("version_slug")
+
+	if !utils.SlugRegexp.MatchString(versionSlug) {
+		return utils.RespondWithError(
+			c, 400, utils.RestoreSecretVersion, utils.ErrorSecretVersionSlug, versionSlug,
+		)
+	}
+
+	userSlug := requestUserSlug(c)
+	secret, access, err := H.secretAccess(slug, userSlug, models.RoleEditor)
+
+	if err != nil {
+		return respondWithAccessError(c, utils.RestoreSecretVersion, slug, &access, err)
+	}
+
+	ownerSlug := secret.UserSlug
+
+	var version models.SecretVersion
+
+	if result := H.DB.First(
+		&version, "slug = ? AND secret_slug = ? AND user_slug = ?", versionSlug, slug, ownerSlug,
+	); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return utils.RespondWithError(
+				c, 404, utils.RestoreSecretVersion, utils.ErrorNotFound, versionSlug,
+			)
+		}
+
+		return utils.RespondWithError(
+			c, 500, utils.RestoreSecretVersion, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	var shareCount int64
+
+	if result := H.DB.Model(&models.Share{}).Where("entry_slug = ?", secret.EntrySlug).
+	Count(&shareCount); result.Error != nil {
+		return utils.RespondWithError(
+			c, 500, utils.RestoreSecretVersion, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	var key, plaintext string
+
+	// Grantees of the secret's entry get the restored string too, which takes the key.
+	if shareCount > 0 {
+		userKey, user, err := H.userKey(c, userSlug)
+
+		if err != nil {
+			return respondWithKeyError(c, utils.RestoreSecretVersion, userSlug, err)
+		}
+
+		if key, err = access.secretsKey(&user, userKey); err != nil {
+			return respondWithKeyError(c, utils.RestoreSecretVersion, userSlug, err)
+		}
+
+		if plaintext, err = decryptSecretString(versionSecret(&version), key); err != nil {
+			return utils.RespondWithError(
+				c, 500, utils.RestoreSecretVersion, utils.ErrorDecrypt, versionSlug,
+			)
+		}
+	}
+
+	if err := H.DB.Transaction(func(tx *gorm.DB) error {
+		// The version is read again once no rekey can replace its ciphertext before it's copied.
+		if err := lockUser(tx, ownerSlug); err != nil {
+			return err
+		} else if result := tx.First(&version, "slug = ?", version.Slug); result.Error != nil {
+			return result.Error
+		}
+
+		// The current value becomes a version of its own, so a restore can be undone.
+		if err := archiveSecretVersion(tx, &secret, H.Conf.SECRET_VERSION_RETENTION); err != nil {
+			return err
+		}
+
+		if result := tx.Model(&models.Secret{}).
+		Where("slug = ? AND user_slug = ?", slug, ownerSlug).
+		Updates(models.Secret{Label: version.Label, String: version.String}); result.Error != nil {
+			return result.Error
+		} else if n := result.RowsAffected; n == 0 {
+			return errors.New(utils.ErrorNoRowsAffected)
+		} else if n > 1 {
+			return fmt.Errorf("result.RowsAffected (%d) > 1", n)
+		}
+
+		if shareCount > 0 {
+			if err := propagateSharedSecret(tx, &secret, plaintext, key, ""); err != nil {
+				return err
+			}
+		}
+
+		return database.RefreshIntegrity(tx, ownerSlug, database.IntegritySecretRow(slug))
+	}); err != nil {
+		if errText := err.Error(); errText == utils.ErrorNoRowsAffected {
+			return utils.RespondWithError(
+				c, 404, utils.RestoreSecretVersion, errText, "Likely that slug was not found.",
+			)
+		} else if utils.RowsRegexp.MatchString(errText) {
+			return utils.RespondWithError(c, 500, utils.RestoreSecretVersion, errText, "")
+		}
+
+		return utils.RespondWithError(
+			c, 500, utils.RestoreSecretVersion, utils.ErrorFailedDB, err.Error(),
+		)
+	}
+
+	return c.SendStatus(204)
+}
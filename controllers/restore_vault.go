@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/database"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+// RestoreVault takes a vault, and whatever was trashed along with it, out of the trash,
+// for any of its owners. A vault whose title another of its creator's vaults has taken
+// since can't be restored until one of them is renamed, so that's 409 Conflict.
+func (H Handler) RestoreVault(c *fiber.Ctx) error {
+	slug := c.Params("slug")
+
+	if !utils.SlugRegexp.MatchString(slug) {
+		return utils.RespondWithError(c, 400, utils.RestoreVault, utils.ErrorVaultSlug, slug)
+	}
+
+	userSlug := requestUserSlug(c)
+
+	// Owners of a team vault restore it on behalf of its creator, as they trash it.
+	if access, err := H.accessTrashedVault(slug, userSlug, models.RoleOwner); err == nil {
+		userSlug = access.vault.UserSlug
+	} else if errors.Is(err, errVaultRole) {
+		return utils.RespondWithError(c, 403, utils.RestoreVault, utils.ErrorVaultRole, access.role)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.RespondWithError(c, 500, utils.RestoreVault, utils.ErrorFailedDB, err.Error())
+	}
+
+	var result *gorm.DB
+
+	if err := H.DB.Transaction(func(tx *gorm.DB) error {
+		// Children trashed along with the vault share its `deleted_at`, so they're restored
+		// before the vault itself. Those trashed on their own beforehand stay in the trash.
+		deletedAt := tx.Unscoped().Model(&models.Vault{}).Select("deleted_at").
+		Where("slug = ? AND user_slug = ?", slug, userSlug)
+
+		if result = tx.Unscoped().Model(&models.Entry{}).
+		Where("vault_slug = ? AND user_slug = ? AND deleted_at = (?)", slug, userSlug, deletedAt).
+		UpdateColumn("deleted_at", nil); result.Error != nil {
+			return result.Error
+		}
+
+		if result = tx.Unscoped().Model(&models.Secret{}).
+		Where("vault_slug = ? AND user_slug = ? AND deleted_at = (?)", slug, userSlug, deletedAt).
+		UpdateColumn("deleted_at", nil); result.Error != nil {
+			return result.Error
+		}
+
+		if result = tx.Unscoped().Model(&models.Vault{}).
+		Where("slug = ? AND user_slug = ? AND deleted_at IS NOT NULL", slug, userSlug).
+		UpdateColumn("deleted_at", nil); result.Error != nil {
+			return result.Error
+		} else if n := result.RowsAffected; n == 0 {
+			return errors.New(utils.ErrorNoRowsAffected)
+		} else if n > 1 {
+			return fmt.Errorf("result.RowsAffected (%d) > 1", n)
+		}
+
+		return database.RefreshIntegrity(tx, userSlug, database.IntegrityVaultTree(slug))
+	}); err != nil {
+		if errText := err.Error(); errText == utils.ErrorNoRowsAffected {
+			return utils.RespondWithError(
+				c, 404, utils.RestoreVault, errText, "Likely that slug was not found in the trash.",
+			)
+		} else if utils.RowsRegexp.MatchString(errText) {
+			return utils.RespondWithError(c, 500, utils.RestoreVault, errText, "")
+		}
+
+		return respondWithDBError(c, utils.RestoreVault, err)
+	}
+
+	return c.SendStatus(204)
+}
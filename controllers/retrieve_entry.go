@@ -18,11 +18,20 @@ func (H Handler) RetrieveEntry(c *fiber.Ctx) error {
 		return utils.RespondWithError(c, 400, utils.RetrieveEntry, utils.ErrorEntrySlug, slug)
 	}
 
-	var entry models.Entry
+	userSlug := requestUserSlug(c)
+	entry, access, err := H.entryAccess(slug, userSlug, models.RoleViewer)
+
+	if err != nil {
+		return respondWithAccessError(c, utils.RetrieveEntry, slug, &access, err)
+	}
+
+	// Any member of a team vault retrieves its entries, which belong to its creator.
+	ownerSlug := entry.UserSlug
 
 	if result := H.DB.Preload("Secrets", func(db *gorm.DB) *gorm.DB {
-		return db.Order("secrets.priority, secrets.created_at")
-	}).First(&entry, "slug = ?", slug); result.Error != nil {
+		return db.Where("secrets.user_slug = ?", ownerSlug).
+		Order("secrets.priority, secrets.created_at")
+	}).First(&entry, "slug = ? AND user_slug = ?", slug, ownerSlug); result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return utils.RespondWithError(c, 404, utils.RetrieveEntry, utils.ErrorNotFound, slug)
 		}
@@ -36,11 +45,31 @@ func (H Handler) RetrieveEntry(c *fiber.Ctx) error {
 		)
 	}
 
-	password := c.Get(H.Conf.PASSWORD_HEADER_KEY)
+	if len(entry.Secrets) == 0 {
+		return c.Status(200).JSON(&entry)
+	}
+
+	userKey, user, err := H.userKey(c, userSlug)
+
+	if err != nil {
+		return respondWithKeyError(c, utils.RetrieveEntry, userSlug, err)
+	}
+
+	key, err := access.secretsKey(&user, userKey)
+
+	if err != nil {
+		return respondWithKeyError(c, utils.RetrieveEntry, userSlug, err)
+	}
+
 	decryptedSecrets := []models.Secret{}
 
 	for _, secret := range entry.Secrets {
-		if decryptedString, err := utils.Decrypt(secret.String, password); err != nil {
+		if decryptedString, err := decryptSecretString(&secret, key); err != nil {
+			// The secrets were rekeyed by an upgrade since the key was derived.
+			if H.rederiveUpgradedKey(c, &user) {
+				return H.RetrieveEntry(c)
+			}
+
 			return utils.RespondWithError(c, 500, utils.RetrieveEntry, utils.ErrorDecrypt, err.Error())
 		} else {
 			secret.String = decryptedString
@@ -48,6 +77,7 @@ func (H Handler) RetrieveEntry(c *fiber.Ctx) error {
 		}
 	}
 
+	H.upgradeUserKDF(&user, c.Get(H.Conf.PASSWORD_HEADER_KEY), userKey)
 	entry.Secrets = decryptedSecrets
 
 	return c.Status(200).JSON(&entry)
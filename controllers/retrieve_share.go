@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+type RetrieveShareResponseBody struct {
+	models.Share
+	EntryTitle string          `json:"entry_title"`
+	Secrets    []models.Secret `json:"secrets"`
+}
+
+// RetrieveShare returns an entry shared with the user, with its secrets decrypted from the
+// user's copies of them.
+func (H Handler) RetrieveShare(c *fiber.Ctx) error {
+	slug := c.Params("slug")
+
+	if !utils.SlugRegexp.MatchString(slug) {
+		return utils.RespondWithError(c, 400, utils.RetrieveShare, utils.ErrorShareSlug, slug)
+	}
+
+	userSlug := requestUserSlug(c)
+	body := RetrieveShareResponseBody{Secrets: []models.Secret{}}
+
+	if result := H.DB.First(&body.Share, "slug = ? AND grantee_slug = ?", slug, userSlug);
+	result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return utils.RespondWithError(c, 404, utils.RetrieveShare, utils.ErrorNotFound, slug)
+		}
+
+		return utils.RespondWithError(
+			c, 500, utils.RetrieveShare, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	var entry models.Entry
+
+	// While the entry is in the trash, so is the share.
+	if result := H.DB.Preload("Secrets", func(db *gorm.DB) *gorm.DB {
+		return db.Order("secrets.priority, secrets.created_at")
+	}).First(&entry, "slug = ?", body.EntrySlug); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return utils.RespondWithError(c, 404, utils.RetrieveShare, utils.ErrorNotFound, slug)
+		}
+
+		return utils.RespondWithError(
+			c, 500, utils.RetrieveShare, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	body.EntryTitle = entry.Title
+
+	var shareSecrets []models.ShareSecret
+
+	if result := H.DB.Find(&shareSecrets, "share_slug = ?", slug); result.Error != nil {
+		return utils.RespondWithError(
+			c, 500, utils.RetrieveShare, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	} else if len(shareSecrets) == 0 {
+		return c.Status(200).JSON(&body)
+	}
+
+	key, user, err := H.userKey(c, userSlug)
+
+	if err != nil {
+		return respondWithKeyError(c, utils.RetrieveShare, userSlug, err)
+	}
+
+	shareKey, err := granteeShareKey(&body.Share, &user, key)
+
+	if err != nil {
+		return utils.RespondWithError(c, 500, utils.RetrieveShare, utils.ErrorDecrypt, err.Error())
+	}
+
+	copies := map[string]*models.ShareSecret{}
+
+	for i := range shareSecrets {
+		copies[shareSecrets[i].SecretSlug] = &shareSecrets[i]
+	}
+
+	for _, secret := range entry.Secrets {
+		shareSecret, ok := copies[secret.Slug]
+
+		if !ok {
+			continue
+		}
+
+		if secret.String, err = decryptShareSecretString(shareSecret, shareKey); err != nil {
+			return utils.RespondWithError(c, 500, utils.RetrieveShare, utils.ErrorDecrypt, err.Error())
+		}
+
+		body.Secrets = append(body.Secrets, secret)
+	}
+
+	return c.Status(200).JSON(&body)
+}
@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+// RetrieveUserKDF returns how the user's key is derived from their master password, for
+// clients that derive it themselves. A `kdf` of "" means the user still sends a raw key.
+// The parameters change when they're upgraded, so they're only good until the next unlock.
+func (H Handler) RetrieveUserKDF(c *fiber.Ctx) error {
+	slug := c.Params("slug")
+
+	if !utils.SlugRegexp.MatchString(slug) {
+		return utils.RespondWithError(c, 400, utils.RetrieveUserKDF, utils.ErrorUserSlug, slug)
+	}
+
+	if slug != requestUserSlug(c) {
+		return utils.RespondWithError(
+			c, 400, utils.RetrieveUserKDF, utils.ErrorUserSlug, utils.ErrorUserSlugHeader,
+		)
+	}
+
+	var user models.User
+
+	if result := H.DB.First(&user, "slug = ?", slug); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return utils.RespondWithError(c, 404, utils.RetrieveUserKDF, utils.ErrorNotFound, slug)
+		}
+
+		return utils.RespondWithError(
+			c, 500, utils.RetrieveUserKDF, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	return c.Status(200).JSON(userKDFParams(&user))
+}
@@ -11,6 +11,13 @@ import (
 	"github.com/liobrdev/simplepasswords_vaults/utils"
 )
 
+type RetrieveVaultResponseBody struct {
+	models.Vault
+	NextCursor string `json:"next_cursor"`
+}
+
+// RetrieveVault pages through the vault's entries; the query parameters and `next_cursor`
+// work the same way as in ListVaults. Any member of a team vault can retrieve it.
 func (H Handler) RetrieveVault(c *fiber.Ctx) error {
 	slug := c.Params("slug")
 
@@ -18,10 +25,26 @@ func (H Handler) RetrieveVault(c *fiber.Ctx) error {
 		return utils.RespondWithError(c, 400, utils.RetrieveVault, utils.ErrorVaultSlug, slug)
 	}
 
+	params, paramsErr := parsePageParams(c)
+
+	if paramsErr != nil {
+		return utils.RespondWithError(
+			c, 400, utils.RetrieveVault, paramsErr.message, paramsErr.detail,
+		)
+	}
+
+	userSlug := requestUserSlug(c)
+	access, err := H.accessVault(slug, userSlug, models.RoleViewer)
+
+	if err != nil {
+		return respondWithAccessError(c, utils.RetrieveVault, slug, &access, err)
+	}
+
 	var vault models.Vault
 
 	if result := H.DB.Preload("Entries", func(db *gorm.DB) *gorm.DB {
-		return db.Order("entries.created_at DESC")
+		return db.Where("entries.user_slug = ?", access.vault.UserSlug).
+		Scopes(params.scope("entries"))
 	}).First(&vault, "slug = ?", slug); result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return utils.RespondWithError(c, 404, utils.RetrieveVault, utils.ErrorNotFound, slug)
@@ -36,5 +59,14 @@ func (H Handler) RetrieveVault(c *fiber.Ctx) error {
 		)
 	}
 
-	return c.Status(200).JSON(&vault)
+	vault.Role = access.role
+	body := RetrieveVaultResponseBody{Vault: vault}
+
+	if len(vault.Entries) > params.limit {
+		body.Entries = vault.Entries[:params.limit]
+		last := body.Entries[params.limit - 1]
+		body.NextCursor = params.nextCursor(last.Slug, last.Title, last.CreatedAt, last.UpdatedAt)
+	}
+
+	return c.Status(200).JSON(&body)
 }
@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+const (
+	SearchKindVault  string = "vault"
+	SearchKindEntry  string = "entry"
+	SearchKindSecret string = "secret"
+)
+
+var searchKindOrder = map[string]int{SearchKindVault: 0, SearchKindEntry: 1, SearchKindSecret: 2}
+
+type SearchResult struct {
+	Kind      string `json:"kind"`
+	Slug      string `json:"slug"`
+	Title     string `json:"title"`
+	VaultSlug string `json:"vault_slug"`
+	EntrySlug string `json:"entry_slug,omitempty"`
+	MatchRank int    `json:"-"`
+}
+
+type SearchResponseBody struct {
+	Results []SearchResult `json:"results"`
+}
+
+// Search matches `q` case-insensitively against the vault titles, entry titles and secret
+// labels of the user's vaults, team vaults included; secret strings are never searched or
+// returned. Exact matches rank first,
+// then title prefixes, then word prefixes, then any other substring, with shorter titles
+// ahead of longer ones within a rank.
+func (H Handler) Search(c *fiber.Ctx) error {
+	q := c.Query("q")
+
+	if q == "" {
+		return utils.RespondWithError(c, 400, utils.Search, utils.ErrorSearchQuery, "")
+	} else if len(q) > 255 {
+		return utils.RespondWithError(c, 400, utils.Search, utils.ErrorSearchQuery, "Too long")
+	}
+
+	limit := defaultPageLimit
+
+	if l := c.Query("limit"); l != "" {
+		if n, err := strconv.Atoi(l); err != nil || n < 1 || n > maxPageLimit {
+			return utils.RespondWithError(c, 400, utils.Search, utils.ErrorLimit, l)
+		} else {
+			limit = n
+		}
+	}
+
+	userSlug := requestUserSlug(c)
+	results := []SearchResult{}
+
+	for _, search := range []struct {
+		kind        string
+		model       interface{}
+		columns     string
+		matched     string
+		vaultColumn string
+	}{
+		{
+			SearchKindVault, &models.Vault{}, "slug, slug AS vault_slug, '' AS entry_slug",
+			"title", "slug",
+		},
+		{
+			SearchKindEntry, &models.Entry{}, "slug, vault_slug, slug AS entry_slug", "title",
+			"vault_slug",
+		},
+		{SearchKindSecret, &models.Secret{}, "slug, vault_slug, entry_slug", "label", "vault_slug"},
+	} {
+		var kindResults []SearchResult
+
+		if result := H.DB.Model(search.model).
+		Scopes(searchScope(search.columns, search.matched, strings.ToLower(q))).
+		Scopes(inUserVaults(userSlug, search.vaultColumn, models.RoleViewer)).
+		Limit(limit).Find(&kindResults); result.Error != nil {
+			return utils.RespondWithError(
+				c, 500, utils.Search, utils.ErrorFailedDB, result.Error.Error(),
+			)
+		}
+
+		for i := range kindResults {
+			kindResults[i].Kind = search.kind
+		}
+
+		results = append(results, kindResults...)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+
+		if a.MatchRank != b.MatchRank {
+			return a.MatchRank < b.MatchRank
+		} else if len(a.Title) != len(b.Title) {
+			return len(a.Title) < len(b.Title)
+		} else if a.Kind != b.Kind {
+			return searchKindOrder[a.Kind] < searchKindOrder[b.Kind]
+		}
+
+		return a.Title < b.Title
+	})
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return c.Status(200).JSON(&SearchResponseBody{Results: results})
+}
+
+// searchScope selects `columns` plus the matched column as `title` and its rank against
+// the lowercased query `q`. LOWER() and LIKE behave the same on Postgres and SQLite; on
+// Postgres the trigram indexes from migration 0002, where pg_trgm is available, keep the
+// LIKEs fast.
+func searchScope(columns, matched, q string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		lowered := "LOWER(" + matched + ")"
+		escaped := escapeLike(q)
+		rank := fmt.Sprintf(
+			"CASE WHEN %[1]s = ? THEN 0 WHEN %[1]s LIKE ? ESCAPE '\\' THEN 1 " +
+			"WHEN %[1]s LIKE ? ESCAPE '\\' THEN 2 ELSE 3 END",
+			lowered,
+		)
+
+		return db.Select(
+			columns + ", " + matched + " AS title, " + rank + " AS match_rank",
+			q, escaped + "%", "% " + escaped + "%",
+		).
+		Where(lowered + " LIKE ? ESCAPE '\\'", "%" + escaped + "%").
+		Order("match_rank").Order("LENGTH(" + matched + ")").Order(matched)
+	}
+}
@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+// Every secret ciphertext is bound to the secret, entry and user it was written
+// for. `secret.Slug`, `secret.EntrySlug` and `secret.UserSlug` must be set first.
+func secretAdditionalData(secret *models.Secret) []byte {
+	return utils.SecretAdditionalData(secret.Slug, secret.EntrySlug, secret.UserSlug)
+}
+
+func encryptSecretString(secret *models.Secret, plaintext, hexEncodedKey string) (err error) {
+	secret.String, err = utils.Encrypt(plaintext, hexEncodedKey, secretAdditionalData(secret))
+	return
+}
+
+// decryptSecretString refuses ciphertexts that aren't bound to the secret, since nothing
+// shows whether they were copied onto it from another one. See bindSecrets.
+func decryptSecretString(secret *models.Secret, hexEncodedKey string) (string, error) {
+	if !utils.IsBoundCiphertext(secret.String) {
+		return "", utils.ErrUnboundCiphertext
+	}
+
+	return utils.Decrypt(secret.String, hexEncodedKey, secretAdditionalData(secret))
+}
+
+// decryptUnboundSecretString also opens ciphertexts sealed before they were bound, for
+// binding them, and for confirming a key against them.
+func decryptUnboundSecretString(secret *models.Secret, hexEncodedKey string) (string, error) {
+	return utils.Decrypt(secret.String, hexEncodedKey, secretAdditionalData(secret))
+}
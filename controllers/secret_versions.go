@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+// archiveSecretVersion keeps the label and ciphertext a secret has right now, before
+// they're overwritten, then prunes all but the newest `retention` versions of it.
+// A retention of zero or less disables version history.
+func archiveSecretVersion(tx *gorm.DB, secret *models.Secret, retention int) error {
+	if retention <= 0 {
+		return tx.Delete(&models.SecretVersion{}, "secret_slug = ?", secret.Slug).Error
+	}
+
+	version := models.SecretVersion{
+		Label:           secret.Label,
+		String:          secret.String,
+		SecretUpdatedAt: secret.UpdatedAt,
+		SecretSlug:      secret.Slug,
+		EntrySlug:       secret.EntrySlug,
+		VaultSlug:       secret.VaultSlug,
+		UserSlug:        secret.UserSlug,
+	}
+
+	if slug, err := utils.GenerateSlug(16); err != nil {
+		return fmt.Errorf("`secret_version.Slug` generation failed: %s", err.Error())
+	} else {
+		version.Slug = slug
+	}
+
+	if result := tx.Create(&version); result.Error != nil {
+		return result.Error
+	}
+
+	return tx.Where(
+		"secret_slug = ? AND slug NOT IN (?)", secret.Slug,
+		tx.Model(&models.SecretVersion{}).Select("slug").Where("secret_slug = ?", secret.Slug).
+		Order("created_at DESC").Limit(retention),
+	).Delete(&models.SecretVersion{}).Error
+}
+
+// A version's ciphertext was sealed for the secret it was taken from.
+func versionSecret(version *models.SecretVersion) *models.Secret {
+	return &models.Secret{
+		Slug:      version.SecretSlug,
+		EntrySlug: version.EntrySlug,
+		UserSlug:  version.UserSlug,
+		String:    version.String,
+	}
+}
@@ -0,0 +1,278 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/liobrdev/simplepasswords_vaults/database"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+// newShareKeys returns a new sharing key pair for the user, with the private key encrypted
+// under `key`, the key of the user's secrets.
+func newShareKeys(userSlug, key string) (publicKey, privateKey string, err error) {
+	if publicKey, privateKey, err = utils.NewShareKeyPair(); err != nil {
+		return
+	}
+
+	privateKey, err = utils.Encrypt(privateKey, key, utils.PrivateKeyAdditionalData(userSlug))
+
+	return
+}
+
+// ensureShareKeys gives a user without a sharing key pair one, once `key` is confirmed to
+// be theirs. Until then, nothing can be shared with them.
+func (H Handler) ensureShareKeys(user *models.User, key string) error {
+	if user.PublicKey != "" {
+		return nil
+	}
+
+	publicKey, privateKey, err := newShareKeys(user.Slug, key)
+
+	if err != nil {
+		return err
+	}
+
+	// Only the first of concurrent requests sets them.
+	if result := H.DB.Model(&models.User{}).Where("slug = ? AND public_key = ?", user.Slug, "").
+	UpdateColumns(map[string]interface{}{"public_key": publicKey, "private_key": privateKey});
+	result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 1 {
+		user.PublicKey, user.PrivateKey = publicKey, privateKey
+	}
+
+	return nil
+}
+
+// ownerShareKey returns the key of the share, from the owner's key.
+func ownerShareKey(share *models.Share, ownerKey string) (string, error) {
+	return utils.Decrypt(share.OwnerKey, ownerKey, utils.ShareKeyAdditionalData(share.Slug))
+}
+
+// granteeShareKey returns the key of the share, from the grantee's key.
+func granteeShareKey(share *models.Share, grantee *models.User, granteeKey string) (
+	string, error,
+) {
+	privateKey, err := utils.Decrypt(
+		grantee.PrivateKey, granteeKey, utils.PrivateKeyAdditionalData(grantee.Slug),
+	)
+
+	if err != nil {
+		return "", err
+	}
+
+	return utils.ShareKey(privateKey, share.PublicKey)
+}
+
+func encryptShareSecretString(shareSlug, secretSlug, plaintext, shareKey string) (
+	string, error,
+) {
+	return utils.Encrypt(
+		plaintext, shareKey, utils.ShareSecretAdditionalData(shareSlug, secretSlug),
+	)
+}
+
+func decryptShareSecretString(shareSecret *models.ShareSecret, shareKey string) (
+	string, error,
+) {
+	return utils.Decrypt(
+		shareSecret.String, shareKey,
+		utils.ShareSecretAdditionalData(shareSecret.ShareSlug, shareSecret.SecretSlug),
+	)
+}
+
+// propagateSharedSecret writes the secret's `plaintext` to the copy of every grantee of
+// its entry, except those of `exceptShareSlug`. Any write of theirs still pending is
+// overwritten, since the owner's is newer.
+func propagateSharedSecret(
+	tx *gorm.DB, secret *models.Secret, plaintext, ownerKey, exceptShareSlug string,
+) error {
+	var shares []models.Share
+
+	if result := tx.Where("entry_slug = ? AND slug <> ?", secret.EntrySlug, exceptShareSlug).
+	Find(&shares); result.Error != nil {
+		return result.Error
+	}
+
+	for _, share := range shares {
+		shareKey, err := ownerShareKey(&share, ownerKey)
+
+		if err != nil {
+			return errors.New(utils.ErrorDecrypt)
+		}
+
+		shareSecret := models.ShareSecret{ShareSlug: share.Slug, SecretSlug: secret.Slug}
+
+		if shareSecret.String, err = encryptShareSecretString(
+			share.Slug, secret.Slug, plaintext, shareKey,
+		); err != nil {
+			return errors.New(utils.ErrorEncrypt)
+		}
+
+		if result := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "share_slug"}, {Name: "secret_slug"}},
+			DoUpdates: clause.AssignmentColumns([]string{"string", "updated_at", "written"}),
+		}).Create(&shareSecret); result.Error != nil {
+			return result.Error
+		}
+	}
+
+	return nil
+}
+
+// rekeyShareKeys re-encrypts the user's private key, and the user's copies of the keys of
+// the shares they own, from `oldKey` to `newKey`.
+func rekeyShareKeys(tx *gorm.DB, userSlug, oldKey, newKey string) (failedSlug string, err error) {
+	var user models.User
+
+	if result := tx.First(&user, "slug = ?", userSlug); result.Error != nil {
+		return "", result.Error
+	}
+
+	if user.PrivateKey != "" {
+		additionalData := utils.PrivateKeyAdditionalData(userSlug)
+		privateKey, err := utils.Decrypt(user.PrivateKey, oldKey, additionalData)
+
+		if err != nil {
+			return userSlug, errors.New(utils.ErrorDecrypt)
+		}
+
+		if privateKey, err = utils.Encrypt(privateKey, newKey, additionalData); err != nil {
+			return userSlug, errors.New(utils.ErrorEncrypt)
+		}
+
+		if result := tx.Model(&models.User{}).Where("slug = ?", userSlug).
+		UpdateColumn("private_key", privateKey); result.Error != nil {
+			return "", result.Error
+		}
+	}
+
+	var shares []models.Share
+
+	if result := tx.Where("owner_slug = ?", userSlug).Find(&shares); result.Error != nil {
+		return "", result.Error
+	}
+
+	for _, share := range shares {
+		shareKey, err := ownerShareKey(&share, oldKey)
+
+		if err != nil {
+			return share.Slug, errors.New(utils.ErrorDecrypt)
+		}
+
+		ownerKey, err := utils.Encrypt(shareKey, newKey, utils.ShareKeyAdditionalData(share.Slug))
+
+		if err != nil {
+			return share.Slug, errors.New(utils.ErrorEncrypt)
+		}
+
+		if result := tx.Model(&models.Share{}).Where("slug = ?", share.Slug).
+		UpdateColumn("owner_key", ownerKey); result.Error != nil {
+			return "", result.Error
+		}
+	}
+
+	return "", nil
+}
+
+// applySharedWrites brings the user's secrets up to date with what grantees with write
+// permission wrote to their copies, and passes those on to the other grantees. Call it
+// once `key` is confirmed to be the user's. A grantee's write the owner has since written
+// over is no longer pending, so it isn't applied. Failures are only logged, since the
+// request itself has nothing to do with them, and the writes are tried again on the next
+// unlock.
+func (H Handler) applySharedWrites(user *models.User, key string) {
+	var shareSecrets []models.ShareSecret
+
+	if result := H.DB.Joins("JOIN shares ON shares.slug = share_secrets.share_slug").
+	Where("shares.owner_slug = ? AND share_secrets.written = ?", user.Slug, true).
+	Order("share_secrets.updated_at").Find(&shareSecrets); result.Error != nil {
+		log.Printf("Failed shared writes of user %s: %s", user.Slug, result.Error)
+		return
+	}
+
+	for _, shareSecret := range shareSecrets {
+		if err := H.DB.Transaction(func(tx *gorm.DB) error {
+			if err := lockUserKey(tx, user); err != nil {
+				return err
+			}
+
+			return applySharedWrite(tx, &shareSecret, key, H.Conf.SECRET_VERSION_RETENTION)
+		}); err != nil {
+			log.Printf(
+				"Failed shared write of secret %s from share %s: %s",
+				shareSecret.SecretSlug, shareSecret.ShareSlug, err,
+			)
+		}
+	}
+}
+
+func applySharedWrite(
+	tx *gorm.DB, shareSecret *models.ShareSecret, key string, retention int,
+) error {
+	var share models.Share
+
+	if result := tx.First(&share, "slug = ?", shareSecret.ShareSlug); result.Error != nil {
+		return result.Error
+	}
+
+	// Once applied, or dropped, the copy is no longer pending.
+	if result := tx.Model(&models.ShareSecret{}).
+	Where(
+		"share_slug = ? AND secret_slug = ? AND written = ?",
+		shareSecret.ShareSlug, shareSecret.SecretSlug, true,
+	).
+	UpdateColumn("written", false); result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected != 1 {
+		// A concurrent request got to it first.
+		return nil
+	}
+
+	var secret models.Secret
+
+	if result := tx.First(
+		&secret, "slug = ? AND entry_slug = ? AND user_slug = ?",
+		shareSecret.SecretSlug, share.EntrySlug, share.OwnerSlug,
+	); errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil
+	} else if result.Error != nil {
+		return result.Error
+	}
+
+	shareKey, err := ownerShareKey(&share, key)
+
+	if err != nil {
+		return errors.New(utils.ErrorDecrypt)
+	}
+
+	plaintext, err := decryptShareSecretString(shareSecret, shareKey)
+
+	if err != nil {
+		return errors.New(utils.ErrorDecrypt)
+	}
+
+	if err := archiveSecretVersion(tx, &secret, retention); err != nil {
+		return err
+	}
+
+	if err := encryptSecretString(&secret, plaintext, key); err != nil {
+		return errors.New(utils.ErrorEncrypt)
+	}
+
+	if result := tx.Model(&models.Secret{}).Where("slug = ?", secret.Slug).
+	Update("string", secret.String); result.Error != nil {
+		return result.Error
+	}
+
+	if err := propagateSharedSecret(tx, &secret, plaintext, key, share.Slug); err != nil {
+		return err
+	}
+
+	return database.RefreshIntegrity(tx, secret.UserSlug, database.IntegritySecretRow(secret.Slug))
+}
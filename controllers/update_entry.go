@@ -1,10 +1,13 @@
 package controllers
 
 import (
-	"strconv"
+	"errors"
+	"fmt"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 
+	"github.com/liobrdev/simplepasswords_vaults/database"
 	"github.com/liobrdev/simplepasswords_vaults/models"
 	"github.com/liobrdev/simplepasswords_vaults/utils"
 )
@@ -29,20 +32,39 @@ func (H Handler) UpdateEntry(c *fiber.Ctx) error {
 	}
 
 	slug := c.Params("slug")
+	userSlug := requestUserSlug(c)
 
-	if result := H.DB.Model(&models.Entry{}).Where("slug = ?", slug).Update("title", body.Title);
-	result.Error != nil {
-		return utils.RespondWithError(
-			c, 500, utils.UpdateEntry, utils.ErrorFailedDB, result.Error.Error(),
-		)
-	} else if n := result.RowsAffected; n == 0 {
-		return utils.RespondWithError(
-			c, 404, utils.UpdateEntry, utils.ErrorNoRowsAffected, "Likely that slug was not found.",
-		)
-	} else if n > 1 {
-		return utils.RespondWithError(
-			c, 500, utils.UpdateEntry, "result.RowsAffected > 1", strconv.FormatInt(n, 10),
-		)
+	// Editors of a team vault update its entries on behalf of its creator.
+	if entry, access, err := H.entryAccess(slug, userSlug, models.RoleEditor); err == nil {
+		userSlug = entry.UserSlug
+	} else if errors.Is(err, errVaultRole) {
+		return utils.RespondWithError(c, 403, utils.UpdateEntry, utils.ErrorVaultRole, access.role)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.RespondWithError(c, 500, utils.UpdateEntry, utils.ErrorFailedDB, err.Error())
+	}
+
+	if err := H.DB.Transaction(func(tx *gorm.DB) error {
+		if result := tx.Model(&models.Entry{}).
+		Where("slug = ? AND user_slug = ?", slug, userSlug).Update("title", body.Title);
+		result.Error != nil {
+			return result.Error
+		} else if n := result.RowsAffected; n == 0 {
+			return errors.New(utils.ErrorNoRowsAffected)
+		} else if n > 1 {
+			return fmt.Errorf("result.RowsAffected (%d) > 1", n)
+		}
+
+		return database.RefreshIntegrity(tx, userSlug, database.IntegrityEntryRow(slug))
+	}); err != nil {
+		if errText := err.Error(); errText == utils.ErrorNoRowsAffected {
+			return utils.RespondWithError(
+				c, 404, utils.UpdateEntry, errText, "Likely that slug was not found.",
+			)
+		} else if utils.RowsRegexp.MatchString(errText) {
+			return utils.RespondWithError(c, 500, utils.UpdateEntry, errText, "")
+		}
+
+		return respondWithDBError(c, utils.UpdateEntry, err)
 	}
 
 	return c.SendStatus(204)
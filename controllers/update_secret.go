@@ -1,10 +1,13 @@
 package controllers
 
 import (
-	"strconv"
+	"errors"
+	"fmt"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 
+	"github.com/liobrdev/simplepasswords_vaults/database"
 	"github.com/liobrdev/simplepasswords_vaults/models"
 	"github.com/liobrdev/simplepasswords_vaults/utils"
 )
@@ -14,6 +17,8 @@ type UpdateSecretRequestBody struct {
 	String	 	string `json:"secret_string"`
 }
 
+// UpdateSecret passes requests that say they're moves on to MoveSecret, as moves were
+// sent before they had a route of their own. Those still have their key checked.
 func (H Handler) UpdateSecret(c *fiber.Ctx) error {
 	if clientOperation := c.Get("Client-Operation"); clientOperation == utils.MoveSecret {
 		return c.Next()
@@ -39,32 +44,87 @@ func (H Handler) UpdateSecret(c *fiber.Ctx) error {
 		return utils.RespondWithError(c, 400, utils.UpdateSecret, utils.ErrorSecretString, "Too long")
 	}
 
+	slug := c.Params("slug")
+	userSlug := requestUserSlug(c)
+
+	secret, access, err := H.secretAccess(slug, userSlug, models.RoleEditor)
+
+	if err != nil {
+		return respondWithAccessError(c, utils.UpdateSecret, slug, &access, err)
+	}
+
+	ownerSlug := secret.UserSlug
+
+	previousSecret := secret
+	plaintext := body.String
+	var key string
+	var user models.User
+
 	if body.String != "" {
-		password := c.Get(H.Conf.PASSWORD_HEADER_KEY)
+		var userKey string
 
-		var err error
+		if userKey, user, err = H.userKey(c, userSlug); err != nil {
+			return respondWithKeyError(c, utils.UpdateSecret, userSlug, err)
+		}
+
+		if key, err = access.secretsKey(&user, userKey); err != nil {
+			return respondWithKeyError(c, utils.UpdateSecret, userSlug, err)
+		}
 
-		if body.String, err = utils.Encrypt(body.String, password); err != nil {
+		if err := encryptSecretString(&secret, body.String, key); err != nil {
 			return utils.RespondWithError(c, 500, utils.UpdateSecret, utils.ErrorEncrypt, err.Error())
+		} else {
+			body.String = secret.String
 		}
 	}
 
-	slug := c.Params("slug")
+	if err := H.DB.Transaction(func(tx *gorm.DB) error {
+		if plaintext != "" {
+			if err := lockUserKey(tx, &user); err != nil {
+				return err
+			}
+		}
 
-	if result := H.DB.Model(&models.Secret{}).
-	Where("slug = ?", slug).Updates(models.Secret{Label: body.Label, String: body.String});
-	result.Error != nil {
-		return utils.RespondWithError(
-			c, 500, utils.UpdateSecret, utils.ErrorFailedDB, result.Error.Error(),
-		)
-	} else if n := result.RowsAffected; n == 0 {
-		return utils.RespondWithError(
-			c, 404, utils.UpdateSecret, utils.ErrorNoRowsAffected, "Likely that slug was not found.",
-		)
-	} else if n > 1 {
-		return utils.RespondWithError(
-			c, 500, utils.UpdateSecret, "result.RowsAffected > 1", strconv.FormatInt(n, 10),
-		)
+		if err := archiveSecretVersion(tx, &previousSecret, H.Conf.SECRET_VERSION_RETENTION);
+		err != nil {
+			return err
+		}
+
+		if result := tx.Model(&models.Secret{}).
+		Where("slug = ? AND user_slug = ?", slug, ownerSlug).
+		Updates(models.Secret{Label: body.Label, String: body.String}); result.Error != nil {
+			return result.Error
+		} else if n := result.RowsAffected; n == 0 {
+			return errors.New(utils.ErrorNoRowsAffected)
+		} else if n > 1 {
+			return fmt.Errorf("result.RowsAffected (%d) > 1", n)
+		}
+
+		// Grantees of the secret's entry get the new string too.
+		if plaintext != "" {
+			if err := propagateSharedSecret(tx, &secret, plaintext, key, ""); err != nil {
+				return err
+			}
+		}
+
+		return database.RefreshIntegrity(tx, ownerSlug, database.IntegritySecretRow(slug))
+	}); errors.Is(err, errWrongVaultKey) {
+		// The user's key derivation was upgraded since the key was derived.
+		if H.rederiveUpgradedKey(c, &user) {
+			return H.UpdateSecret(c)
+		}
+
+		return respondWithKeyError(c, utils.UpdateSecret, userSlug, err)
+	} else if err != nil {
+		if errText := err.Error(); errText == utils.ErrorNoRowsAffected {
+			return utils.RespondWithError(
+				c, 404, utils.UpdateSecret, errText, "Likely that slug was not found.",
+			)
+		} else if utils.RowsRegexp.MatchString(errText) {
+			return utils.RespondWithError(c, 500, utils.UpdateSecret, errText, "")
+		}
+
+		return respondWithDBError(c, utils.UpdateSecret, err)
 	}
 
 	return c.SendStatus(204)
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+type UpdateSharedSecretRequestBody struct {
+	String string `json:"secret_string"`
+}
+
+// UpdateSharedSecret writes a new string to the user's copy of a secret shared with them,
+// if the share permits writes. The owner's secret, and the other grantees' copies, are
+// brought up to date with it the next time the owner unlocks their secrets, since only the
+// owner's key can encrypt to them.
+func (H Handler) UpdateSharedSecret(c *fiber.Ctx) error {
+	slug := c.Params("slug")
+
+	if !utils.SlugRegexp.MatchString(slug) {
+		return utils.RespondWithError(c, 400, utils.UpdateSharedSecret, utils.ErrorShareSlug, slug)
+	}
+
+	secretSlug := c.Params("secret_slug")
+
+	if !utils.SlugRegexp.MatchString(secretSlug) {
+		return utils.RespondWithError(
+			c, 400, utils.UpdateSharedSecret, utils.ErrorSecretSlug, secretSlug,
+		)
+	}
+
+	body := UpdateSharedSecretRequestBody{}
+
+	if err := c.BodyParser(&body); err != nil {
+		return utils.RespondWithError(c, 400, utils.UpdateSharedSecret, utils.ErrorParse, err.Error())
+	}
+
+	if body.String == "" {
+		return utils.RespondWithError(c, 400, utils.UpdateSharedSecret, utils.ErrorSecretString, "")
+	}
+
+	if len(body.String) > 1000 {
+		return utils.RespondWithError(
+			c, 400, utils.UpdateSharedSecret, utils.ErrorSecretString, "Too long",
+		)
+	}
+
+	userSlug := requestUserSlug(c)
+	var share models.Share
+
+	if result := H.DB.First(&share, "slug = ? AND grantee_slug = ?", slug, userSlug);
+	result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return utils.RespondWithError(c, 404, utils.UpdateSharedSecret, utils.ErrorNotFound, slug)
+		}
+
+		return utils.RespondWithError(
+			c, 500, utils.UpdateSharedSecret, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	if share.Permission != models.PermissionWrite {
+		return utils.RespondWithError(c, 403, utils.UpdateSharedSecret, utils.ErrorShareReadOnly, slug)
+	}
+
+	var count int64
+
+	// The secret must still be in the shared entry, and out of the trash.
+	if result := H.DB.Model(&models.Secret{}).
+	Joins("JOIN share_secrets ON share_secrets.secret_slug = secrets.slug").
+	Where(
+		"secrets.slug = ? AND secrets.entry_slug = ? AND share_secrets.share_slug = ?",
+		secretSlug, share.EntrySlug, slug,
+	).
+	Count(&count); result.Error != nil {
+		return utils.RespondWithError(
+			c, 500, utils.UpdateSharedSecret, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	} else if count == 0 {
+		return utils.RespondWithError(
+			c, 404, utils.UpdateSharedSecret, utils.ErrorNotFound, secretSlug,
+		)
+	}
+
+	key, user, err := H.userKey(c, userSlug)
+
+	if err != nil {
+		return respondWithKeyError(c, utils.UpdateSharedSecret, userSlug, err)
+	}
+
+	shareKey, err := granteeShareKey(&share, &user, key)
+
+	if err != nil {
+		return utils.RespondWithError(
+			c, 500, utils.UpdateSharedSecret, utils.ErrorDecrypt, err.Error(),
+		)
+	}
+
+	ciphertext, err := encryptShareSecretString(slug, secretSlug, body.String, shareKey)
+
+	if err != nil {
+		return utils.RespondWithError(
+			c, 500, utils.UpdateSharedSecret, utils.ErrorEncrypt, err.Error(),
+		)
+	}
+
+	if result := H.DB.Model(&models.ShareSecret{}).
+	Where("share_slug = ? AND secret_slug = ?", slug, secretSlug).
+	Updates(map[string]interface{}{"string": ciphertext, "written": true}); result.Error != nil {
+		return utils.RespondWithError(
+			c, 500, utils.UpdateSharedSecret, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	return c.SendStatus(204)
+}
@@ -1,10 +1,13 @@
 package controllers
 
 import (
-	"strconv"
+	"errors"
+	"fmt"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 
+	"github.com/liobrdev/simplepasswords_vaults/database"
 	"github.com/liobrdev/simplepasswords_vaults/models"
 	"github.com/liobrdev/simplepasswords_vaults/utils"
 )
@@ -29,20 +32,30 @@ func (H Handler) UpdateVault(c *fiber.Ctx) error {
 	}
 
 	slug := c.Params("slug")
-
-	if result := H.DB.Model(&models.Vault{}).Where("slug = ?", slug).Update("title", body.Title);
-	result.Error != nil {
-		return utils.RespondWithError(
-			c, 500, utils.UpdateVault, utils.ErrorFailedDB, result.Error.Error(),
-		)
-	} else if n := result.RowsAffected; n == 0 {
-		return utils.RespondWithError(
-			c, 404, utils.UpdateVault, utils.ErrorNoRowsAffected, "Likely that slug was not found.",
-		)
-	} else if n > 1 {
-		return utils.RespondWithError(
-			c, 500, utils.UpdateVault, "result.RowsAffected > 1", strconv.FormatInt(n, 10),
-		)
+	userSlug := requestUserSlug(c)
+
+	if err := H.DB.Transaction(func(tx *gorm.DB) error {
+		if result := tx.Model(&models.Vault{}).
+		Where("slug = ? AND user_slug = ?", slug, userSlug).Update("title", body.Title);
+		result.Error != nil {
+			return result.Error
+		} else if n := result.RowsAffected; n == 0 {
+			return errors.New(utils.ErrorNoRowsAffected)
+		} else if n > 1 {
+			return fmt.Errorf("result.RowsAffected (%d) > 1", n)
+		}
+
+		return database.RefreshIntegrity(tx, userSlug, database.IntegrityVaultRow(slug))
+	}); err != nil {
+		if errText := err.Error(); errText == utils.ErrorNoRowsAffected {
+			return utils.RespondWithError(
+				c, 404, utils.UpdateVault, errText, "Likely that slug was not found.",
+			)
+		} else if utils.RowsRegexp.MatchString(errText) {
+			return utils.RespondWithError(c, 500, utils.UpdateVault, errText, "")
+		}
+
+		return respondWithDBError(c, utils.UpdateVault, err)
 	}
 
 	return c.SendStatus(204)
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+type UpdateVaultMemberRequestBody struct {
+	Role string `json:"member_role"`
+}
+
+// UpdateVaultMember changes a member's role in a vault the user owns. The vault's creator
+// stays an owner, so that the vault always has one.
+func (H Handler) UpdateVaultMember(c *fiber.Ctx) error {
+	slug := c.Params("slug")
+
+	if !utils.SlugRegexp.MatchString(slug) {
+		return utils.RespondWithError(c, 400, utils.UpdateVaultMember, utils.ErrorVaultSlug, slug)
+	}
+
+	memberSlug := c.Params("member_slug")
+
+	if !utils.SlugRegexp.MatchString(memberSlug) {
+		return utils.RespondWithError(
+			c, 400, utils.UpdateVaultMember, utils.ErrorMemberSlug, memberSlug,
+		)
+	}
+
+	body := UpdateVaultMemberRequestBody{}
+
+	if err := c.BodyParser(&body); err != nil {
+		return utils.RespondWithError(c, 400, utils.UpdateVaultMember, utils.ErrorParse, err.Error())
+	}
+
+	if _, ok := roleRanks[body.Role]; !ok {
+		return utils.RespondWithError(
+			c, 400, utils.UpdateVaultMember, utils.ErrorMemberRole, body.Role,
+		)
+	}
+
+	access, err := H.accessVault(slug, requestUserSlug(c), models.RoleOwner)
+
+	if err != nil {
+		return respondWithAccessError(c, utils.UpdateVaultMember, slug, &access, err)
+	}
+
+	if memberSlug == access.vault.UserSlug {
+		return utils.RespondWithError(
+			c, 400, utils.UpdateVaultMember, utils.ErrorMemberRole,
+			"Vault creator is always an owner.",
+		)
+	}
+
+	if result := H.DB.Model(&models.VaultMember{}).
+	Where("vault_slug = ? AND user_slug = ?", slug, memberSlug).
+	UpdateColumn("role", body.Role); result.Error != nil {
+		return utils.RespondWithError(
+			c, 500, utils.UpdateVaultMember, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	} else if result.RowsAffected == 0 {
+		return utils.RespondWithError(c, 404, utils.UpdateVaultMember, utils.ErrorNotFound, memberSlug)
+	}
+
+	return c.SendStatus(204)
+}
@@ -0,0 +1,368 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+// keyCheckPlaintext is what every user's key check is the encryption of, under their key.
+const keyCheckPlaintext string = "simplepasswords_vaults key check"
+
+var errWrongVaultKey = errors.New(utils.ErrorVaultKey)
+
+// passwordError is a password header, or rekey field, that can't be the user's key.
+type passwordError struct {
+	message string
+	detail  string
+}
+
+func (e *passwordError) Error() string {
+	return e.message + " " + e.detail
+}
+
+// kdfTarget is the Argon2id parameters new keys are derived with. Users whose parameters
+// are weaker are upgraded to these the next time they unlock their secrets.
+func (H Handler) kdfTarget() utils.KDFParams {
+	return utils.KDFParams{
+		KDF:     utils.KDFArgon2id,
+		Time:    uint32(H.Conf.KDF_TIME),
+		Memory:  uint32(H.Conf.KDF_MEMORY_KIB),
+		Threads: uint8(H.Conf.KDF_THREADS),
+	}
+}
+
+// newUserKDFParams returns the target parameters with a new salt.
+func (H Handler) newUserKDFParams() (utils.KDFParams, error) {
+	target := H.kdfTarget()
+	return utils.NewKDFParams(target.Time, target.Memory, target.Threads)
+}
+
+func userKDFParams(user *models.User) utils.KDFParams {
+	return utils.KDFParams{
+		KDF:     user.KDF,
+		Salt:    user.KDFSalt,
+		Time:    user.KDFTime,
+		Memory:  user.KDFMemory,
+		Threads: user.KDFThreads,
+	}
+}
+
+// userKeyColumns are the users columns to update to a user returned by keyedUser.
+func userKeyColumns(user *models.User) map[string]interface{} {
+	return map[string]interface{}{
+		"kdf":         user.KDF,
+		"kdf_salt":    user.KDFSalt,
+		"kdf_time":    user.KDFTime,
+		"kdf_memory":  user.KDFMemory,
+		"kdf_threads": user.KDFThreads,
+		"key_check":   user.KeyCheck,
+		"data_key":    user.DataKey,
+	}
+}
+
+// keyedUser returns the key the user's secrets are to be encrypted under, from the client
+// key derived with `params`, and the user with those parameters and that key's check.
+// Users without a data key get one.
+func (H Handler) keyedUser(
+	user models.User, params utils.KDFParams, clientKey string,
+) (key string, _ models.User, err error) {
+	var wrappedKey, dataKey string
+
+	if wrappedKey, dataKey, err = H.userDataKey(&user); err != nil {
+		return "", user, err
+	} else if key, err = utils.CombineKeys(clientKey, dataKey); err != nil {
+		return "", user, err
+	}
+
+	user.KDF = params.KDF
+	user.KDFSalt = params.Salt
+	user.KDFTime = params.Time
+	user.KDFMemory = params.Memory
+	user.KDFThreads = params.Threads
+	user.DataKey = wrappedKey
+
+	if user.KeyCheck, err = newKeyCheck(user.Slug, key); err != nil {
+		return "", user, err
+	}
+
+	return key, user, nil
+}
+
+// userDataKey returns the user's wrapped and unwrapped data key, or a new one if the user
+// doesn't have one yet.
+func (H Handler) userDataKey(user *models.User) (wrappedKey, dataKey string, err error) {
+	if user.DataKey == "" {
+		if dataKey, err = utils.NewDataKey(); err != nil {
+			return
+		}
+
+		wrappedKey, err = H.Keys.WrapKey(dataKey, utils.DataKeyAdditionalData(user.Slug))
+
+		return
+	}
+
+	dataKey, err = H.Keys.UnwrapKey(user.DataKey, utils.DataKeyAdditionalData(user.Slug))
+
+	return user.DataKey, dataKey, err
+}
+
+// ensureDataKey gives a user created before data keys existed one, once `key`, their
+// client key alone, is confirmed to be theirs, and rekeys their secrets to the client key
+// combined with it, which it returns. If another request gave them one first, it returns
+// the key combined with that one instead. Other failures are only logged, and the user
+// keeps the key they have until the next unlock tries again.
+func (H Handler) ensureDataKey(user *models.User, key string) (string, error) {
+	if user.DataKey != "" {
+		return key, nil
+	}
+
+	newKey, keyed, err := H.keyedUser(*user, userKDFParams(user), key)
+
+	if err != nil {
+		log.Printf("Failed data key creation of user %s: %s", user.Slug, err)
+		return key, nil
+	}
+
+	if err = H.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockUserKey(tx, user); err != nil {
+			return err
+		}
+
+		if failedSlug, err := rekeySecrets(tx, user.Slug, key, newKey); err != nil {
+			return fmt.Errorf("%s: %w", failedSlug, err)
+		}
+
+		return tx.Model(&models.User{}).Where("slug = ?", user.Slug).
+		Updates(userKeyColumns(&keyed)).Error
+	}); err == nil {
+		*user = keyed
+		return newKey, nil
+	} else if !errors.Is(err, errWrongVaultKey) {
+		log.Printf("Failed data key creation of user %s: %s", user.Slug, err)
+		return key, nil
+	}
+
+	var current models.User
+
+	if result := H.DB.First(&current, "slug = ?", user.Slug); result.Error != nil {
+		return "", result.Error
+	} else if current.DataKey == "" {
+		// Only the key check was set by another request, to one of the same key.
+		return key, nil
+	}
+
+	_, dataKey, err := H.userDataKey(&current)
+
+	if err != nil {
+		return "", err
+	} else if newKey, err = utils.CombineKeys(key, dataKey); err != nil {
+		return "", err
+	} else if err = H.checkUserKey(&current, newKey); err != nil {
+		return "", err
+	}
+
+	*user = current
+
+	return newKey, nil
+}
+
+// newKeyCheck returns what the user's key is checked against, before it's used.
+func newKeyCheck(userSlug, key string) (string, error) {
+	return utils.Encrypt(keyCheckPlaintext, key, utils.KeyCheckAdditionalData(userSlug))
+}
+
+// lockUser locks the user's row until the transaction ends. Rekeys and key derivation
+// upgrades replace the user's key in a transaction that updates the row first, so one
+// can't rekey the user's secrets while another writes them.
+func lockUser(tx *gorm.DB, userSlug string) error {
+	return tx.Model(&models.User{}).Where("slug = ?", userSlug).
+	UpdateColumn("key_check", gorm.Expr("key_check")).Error
+}
+
+// lockUserKey is lockUser for a transaction that writes secrets under the user's key, and
+// returns errWrongVaultKey if the key was replaced since `user` was read, so that nothing
+// is encrypted under a key that's no longer theirs.
+func lockUserKey(tx *gorm.DB, user *models.User) error {
+	if result := tx.Model(&models.User{}).
+	Where("slug = ? AND key_check = ?", user.Slug, user.KeyCheck).
+	UpdateColumn("key_check", gorm.Expr("key_check")); result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected != 1 {
+		return errWrongVaultKey
+	}
+
+	return nil
+}
+
+// userKey returns the user, and the hex-encoded key of the user's secrets from the
+// password header. Behind RequireVaultKey, that's the key it already checked.
+func (H Handler) userKey(c *fiber.Ctx, userSlug string) (key string, user models.User, err error) {
+	if checked, ok := c.Locals(vaultKeyLocal).(vaultKey); ok && checked.user.Slug == userSlug {
+		return checked.key, checked.user, nil
+	}
+
+	if result := H.DB.First(&user, "slug = ?", userSlug); result.Error != nil {
+		return "", user, result.Error
+	}
+
+	key, err = H.deriveUserKey(&user, c.Get(H.Conf.PASSWORD_HEADER_KEY), utils.ErrorPassword)
+
+	return
+}
+
+// deriveUserKey returns the key of the user's secrets, from the client key derived from
+// the user's master password, or for users still on raw keys, the client key itself, and
+// the user's data key. `message` is for the field that held the password.
+func (H Handler) deriveUserKey(user *models.User, password, message string) (string, error) {
+	clientKey := password
+
+	if user.KDF == utils.KDFRawKey {
+		if !utils.HexKeyRegexp.MatchString(password) {
+			return "", &passwordError{message, "Expected a hex-encoded key."}
+		}
+	} else if password == "" {
+		return "", &passwordError{message, "Empty"}
+	} else if len(password) > utils.MaxPasswordSize {
+		return "", &passwordError{message, "Too long"}
+	} else if derivedKey, err := userKDFParams(user).DeriveKey(password); err != nil {
+		return "", err
+	} else {
+		clientKey = derivedKey
+	}
+
+	// Users created before data keys existed have their secrets under the client key alone.
+	if user.DataKey == "" {
+		return clientKey, nil
+	}
+
+	_, dataKey, err := H.userDataKey(user)
+
+	if err != nil {
+		return "", err
+	}
+
+	return utils.CombineKeys(clientKey, dataKey)
+}
+
+func respondWithKeyError(c *fiber.Ctx, operation, userSlug string, err error) error {
+	var passwordErr *passwordError
+
+	if errors.As(err, &passwordErr) {
+		return utils.RespondWithError(c, 400, operation, passwordErr.message, passwordErr.detail)
+	} else if errors.Is(err, errWrongVaultKey) {
+		return utils.RespondWithError(c, 401, operation, utils.ErrorVaultKey, "")
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.RespondWithError(c, 404, operation, utils.ErrorNotFound, userSlug)
+	} else if errors.Is(err, utils.ErrInvalidKDFParams) || errors.Is(err, utils.ErrUnwrapKey) ||
+	errors.Is(err, errUnwrapVaultKey) {
+		return utils.RespondWithError(c, 500, operation, utils.ErrorDecrypt, err.Error())
+	}
+
+	return utils.RespondWithError(c, 500, operation, utils.ErrorFailedDB, err.Error())
+}
+
+// rederiveUpgradedKey derives the user's key again, and keeps it for userKey, if the
+// user's key derivation was upgraded since `user` was read. A request that read secrets
+// rekeyed by the upgrade, with the key derived before it, can then read them again.
+func (H Handler) rederiveUpgradedKey(c *fiber.Ctx, user *models.User) bool {
+	var upgraded models.User
+
+	if user.KDF != utils.KDFArgon2id {
+		return false
+	} else if result := H.DB.Where("slug = ? AND kdf_salt <> ?", user.Slug, user.KDFSalt).
+	Limit(1).Find(&upgraded); result.Error != nil || result.RowsAffected == 0 {
+		return false
+	}
+
+	key, err := H.deriveUserKey(&upgraded, c.Get(H.Conf.PASSWORD_HEADER_KEY), utils.ErrorPassword)
+
+	// A rekey to a new password replaces the salt too, and the old one no longer derives it.
+	if err != nil || H.checkUserKey(&upgraded, key) != nil {
+		return false
+	}
+
+	c.Locals(vaultKeyLocal, vaultKey{key, upgraded})
+
+	return true
+}
+
+// upgradeUserKDF starts upgrading the user's key derivation in the background, if the
+// user's parameters are weaker than the target ones. Call it once `key` has decrypted one
+// of the user's secrets.
+func (H Handler) upgradeUserKDF(user *models.User, password, key string) {
+	if user.KDF != utils.KDFArgon2id || !userKDFParams(user).WeakerThan(H.kdfTarget()) {
+		return
+	}
+
+	// The header is only valid until the request ends.
+	userSlug, salt, password := user.Slug, user.KDFSalt, strings.Clone(password)
+
+	H.KDFUpgrades.start(userSlug, func() {
+		H.runUserKDFUpgrade(userSlug, salt, password, key)
+	})
+}
+
+// runUserKDFUpgrade derives the user's key again with the target parameters and a new
+// salt, and rekeys every secret to it, unless the user's salt is no longer `salt`, the one
+// `key` was derived with. A failure is only logged, since the request that started the
+// upgrade has succeeded, and the upgrade is tried again on the next unlock.
+func (H Handler) runUserKDFUpgrade(userSlug, salt, password, key string) {
+	var user models.User
+
+	// A user who was upgraded, or rekeyed, since isn't derived again.
+	if result := H.DB.Where("slug = ? AND kdf_salt = ?", userSlug, salt).Limit(1).Find(&user);
+	result.Error != nil {
+		log.Printf("Failed key derivation upgrade of user %s: %s", userSlug, result.Error)
+		return
+	} else if result.RowsAffected == 0 {
+		return
+	}
+
+	params, err := H.newUserKDFParams()
+
+	if err != nil {
+		log.Println("Failed key derivation upgrade:", err)
+		return
+	}
+
+	clientKey, err := params.DeriveKey(password)
+
+	if err != nil {
+		log.Println("Failed key derivation upgrade:", err)
+		return
+	}
+
+	newKey, upgraded, err := H.keyedUser(user, params, clientKey)
+
+	if err != nil {
+		log.Println("Failed key derivation upgrade:", err)
+		return
+	}
+
+	if err := H.DB.Transaction(func(tx *gorm.DB) error {
+		// Only one concurrent upgrade gets to replace the salt the key was derived with. The
+		// update also locks the user's row, so writes under the old key either finish first,
+		// and are rekeyed here, or wait, and find that the key was replaced (see lockUserKey).
+		if result := tx.Model(&models.User{}).
+		Where("slug = ? AND kdf_salt = ?", userSlug, salt).
+		Updates(userKeyColumns(&upgraded)); result.Error != nil {
+			return result.Error
+		} else if result.RowsAffected != 1 {
+			return nil
+		}
+
+		_, err := rekeySecrets(tx, userSlug, key, newKey)
+
+		return err
+	}); err != nil {
+		log.Printf("Failed key derivation upgrade of user %s: %s", userSlug, err)
+	}
+}
@@ -0,0 +1,244 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+// teamVaultsExcluded leaves out the secrets, or secret versions, of team vaults, which are
+// encrypted under their vault's key instead of the user's.
+const teamVaultsExcluded string = "vault_slug NOT IN (SELECT vault_slug FROM vault_members)"
+
+var (
+	errVaultRole      = errors.New(utils.ErrorVaultRole)
+	errUnwrapVaultKey = errors.New("vault key unwrap failed")
+)
+
+// roleRanks orders the roles; each permits everything the ones below it do. Viewers read
+// the vault's entries and secrets, editors also write them, and owners also manage members.
+var roleRanks = map[string]int{models.RoleViewer: 1, models.RoleEditor: 2, models.RoleOwner: 3}
+
+type vaultAccess struct {
+	vault models.Vault
+	role  string
+	// member is the user's membership of a team vault, or nil for a personal vault.
+	member *models.VaultMember
+}
+
+// accessVault returns the user's access to the vault, if their role in it is at least
+// `role`. The creator of a personal vault is its owner, and anyone else needs to be a
+// member. A vault the user has no role in at all is gorm.ErrRecordNotFound, so that its
+// slug isn't confirmed to exist.
+func (H Handler) accessVault(vaultSlug, userSlug, role string) (access vaultAccess, err error) {
+	if result := H.DB.First(&access.vault, "slug = ?", vaultSlug); result.Error != nil {
+		return access, result.Error
+	}
+
+	return access, H.checkVaultRole(&access, userSlug, role)
+}
+
+// accessTrashedVault is accessVault for a vault that may be in the trash itself, so that
+// what was trashed from it can be listed and restored by whoever could trash it.
+func (H Handler) accessTrashedVault(vaultSlug, userSlug, role string) (
+	access vaultAccess, err error,
+) {
+	if result := H.DB.Unscoped().First(&access.vault, "slug = ?", vaultSlug);
+	result.Error != nil {
+		return access, result.Error
+	}
+
+	return access, H.checkVaultRole(&access, userSlug, role)
+}
+
+// checkVaultRole finds the user's role in the vault of `access`, as accessVault does.
+func (H Handler) checkVaultRole(access *vaultAccess, userSlug, role string) error {
+	vaultSlug := access.vault.Slug
+	var member models.VaultMember
+
+	if result := H.DB.Limit(1).
+	Find(&member, "vault_slug = ? AND user_slug = ?", vaultSlug, userSlug); result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 1 {
+		access.member = &member
+		access.role = member.Role
+	} else if access.vault.UserSlug == userSlug {
+		access.role = models.RoleOwner
+	} else {
+		return gorm.ErrRecordNotFound
+	}
+
+	if roleRanks[access.role] < roleRanks[role] {
+		return errVaultRole
+	}
+
+	return nil
+}
+
+// inUserVaults scopes rows to those of the vaults the user created, or has a role of at
+// least `role` in, by the column holding their vault's slug.
+func inUserVaults(userSlug, vaultColumn, role string) func(*gorm.DB) *gorm.DB {
+	var roles []string
+
+	for _, r := range []string{models.RoleViewer, models.RoleEditor, models.RoleOwner} {
+		if roleRanks[r] >= roleRanks[role] {
+			roles = append(roles, r)
+		}
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(
+			"(user_slug = ? OR " + vaultColumn +
+			" IN (SELECT vault_slug FROM vault_members WHERE user_slug = ? AND role IN ?))",
+			userSlug, userSlug, roles,
+		)
+	}
+}
+
+// entryAccess returns the entry, and the user's access to its vault, if their role in it
+// is at least `role`.
+func (H Handler) entryAccess(slug, userSlug, role string) (
+	entry models.Entry, access vaultAccess, err error,
+) {
+	if result := H.DB.First(&entry, "slug = ?", slug); result.Error != nil {
+		return entry, access, result.Error
+	}
+
+	access, err = H.accessVault(entry.VaultSlug, userSlug, role)
+
+	return
+}
+
+// secretAccess returns the secret, and the user's access to its vault, if their role in it
+// is at least `role`.
+func (H Handler) secretAccess(slug, userSlug, role string) (
+	secret models.Secret, access vaultAccess, err error,
+) {
+	if result := H.DB.First(&secret, "slug = ?", slug); result.Error != nil {
+		return secret, access, result.Error
+	}
+
+	access, err = H.accessVault(secret.VaultSlug, userSlug, role)
+
+	return
+}
+
+// secretsKey returns the key of the vault's secrets, from `key`, the key of the user's
+// own secrets: for a personal vault, that's the same key, and for a team vault, it's the
+// vault key the user's membership holds.
+func (access *vaultAccess) secretsKey(user *models.User, key string) (string, error) {
+	if access.member == nil {
+		return key, nil
+	}
+
+	return memberVaultKey(access.member, user, key)
+}
+
+func respondWithAccessError(
+	c *fiber.Ctx, operation, slug string, access *vaultAccess, err error,
+) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.RespondWithError(c, 404, operation, utils.ErrorNotFound, slug)
+	} else if errors.Is(err, errVaultRole) {
+		return utils.RespondWithError(c, 403, operation, utils.ErrorVaultRole, access.role)
+	}
+
+	return utils.RespondWithError(c, 500, operation, utils.ErrorFailedDB, err.Error())
+}
+
+// newVaultMember returns the membership of the user, with `vaultKey` encrypted to the
+// user's sharing public key.
+func newVaultMember(vaultSlug string, user *models.User, role, vaultKey string) (
+	member models.VaultMember, err error,
+) {
+	member = models.VaultMember{VaultSlug: vaultSlug, UserSlug: user.Slug, Role: role}
+	publicKey, privateKey, err := utils.NewShareKeyPair()
+
+	if err != nil {
+		return
+	}
+
+	memberKey, err := utils.ShareKey(privateKey, user.PublicKey)
+
+	if err != nil {
+		return
+	}
+
+	member.PublicKey = publicKey
+	member.VaultKey, err = utils.Encrypt(
+		vaultKey, memberKey, utils.VaultKeyAdditionalData(vaultSlug, user.Slug),
+	)
+
+	return
+}
+
+// memberVaultKey returns the key of a team vault, from the member's key.
+func memberVaultKey(member *models.VaultMember, user *models.User, key string) (
+	string, error,
+) {
+	privateKey, err := utils.Decrypt(
+		user.PrivateKey, key, utils.PrivateKeyAdditionalData(user.Slug),
+	)
+
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", errUnwrapVaultKey, err)
+	}
+
+	memberKey, err := utils.ShareKey(privateKey, member.PublicKey)
+
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", errUnwrapVaultKey, err)
+	}
+
+	vaultKey, err := utils.Decrypt(
+		member.VaultKey, memberKey, utils.VaultKeyAdditionalData(member.VaultSlug, user.Slug),
+	)
+
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", errUnwrapVaultKey, err)
+	}
+
+	return vaultKey, nil
+}
+
+// vaultKeys resolves the keys of the secrets of each of a user's vaults, for handlers that
+// go through many of them, from the key of the user's own secrets.
+type vaultKeys struct {
+	user models.User
+	key  string
+	keys map[string]string
+}
+
+func newVaultKeys(user models.User, key string) *vaultKeys {
+	return &vaultKeys{user: user, key: key, keys: map[string]string{}}
+}
+
+func (k *vaultKeys) forVault(db *gorm.DB, vaultSlug string) (string, error) {
+	if key, ok := k.keys[vaultSlug]; ok {
+		return key, nil
+	}
+
+	key := k.key
+	var member models.VaultMember
+
+	if result := db.Limit(1).
+	Find(&member, "vault_slug = ? AND user_slug = ?", vaultSlug, k.user.Slug);
+	result.Error != nil {
+		return "", result.Error
+	} else if result.RowsAffected == 1 {
+		var err error
+
+		if key, err = memberVaultKey(&member, &k.user, k.key); err != nil {
+			return "", err
+		}
+	}
+
+	k.keys[vaultSlug] = key
+
+	return key, nil
+}
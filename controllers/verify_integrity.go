@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/database"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+// VerifyIntegrity checks the user's vaults, entries and secrets against their integrity
+// manifest, and reports each record that was added, removed or altered other than through
+// the API. A report that isn't `intact` is still a 200; it's the answer to the request.
+func (H Handler) VerifyIntegrity(c *fiber.Ctx) error {
+	slug := c.Params("slug")
+
+	if !utils.SlugRegexp.MatchString(slug) {
+		return utils.RespondWithError(c, 400, utils.VerifyIntegrity, utils.ErrorUserSlug, slug)
+	}
+
+	if slug != requestUserSlug(c) {
+		return utils.RespondWithError(
+			c, 400, utils.VerifyIntegrity, utils.ErrorUserSlug, utils.ErrorUserSlugHeader,
+		)
+	}
+
+	if result := H.DB.First(&models.User{}, "slug = ?", slug); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return utils.RespondWithError(c, 404, utils.VerifyIntegrity, utils.ErrorNotFound, slug)
+		}
+
+		return utils.RespondWithError(
+			c, 500, utils.VerifyIntegrity, utils.ErrorFailedDB, result.Error.Error(),
+		)
+	}
+
+	report, err := database.VerifyIntegrity(H.DB, slug)
+
+	if err != nil {
+		return utils.RespondWithError(c, 500, utils.VerifyIntegrity, utils.ErrorFailedDB, err.Error())
+	}
+
+	return c.Status(200).JSON(&report)
+}
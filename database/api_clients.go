@@ -0,0 +1,131 @@
+package database
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+// A client's last use is recorded at most this often, so that every request isn't a write.
+const apiClientUsedInterval = time.Minute
+
+var (
+	apiClientNameRegexp  = regexp.MustCompile(`^[\w.-]{1,64}$`)
+	apiClientTokenRegexp = regexp.MustCompile(`^[\w-]{80}$`)
+	apiClientScopes      = []string{models.ScopeRead, models.ScopeWrite, models.ScopeAdmin}
+)
+
+// APIClientTokenHash is the digest stored in place of a client's token.
+func APIClientTokenHash(token string) string {
+	return hex.EncodeToString(utils.HashToken(token))
+}
+
+// CreateAPIClient registers a client named `name` with `scopes`, authenticated by `token`,
+// or by a newly generated token if `token` is empty. The token is returned, since it's
+// the only time it's known.
+func CreateAPIClient(
+	db *gorm.DB, name, token string, scopes []string,
+) (client models.APIClient, _ string, err error) {
+	if !apiClientNameRegexp.MatchString(name) {
+		return client, "", fmt.Errorf("Invalid client name %q.", name)
+	} else if len(scopes) == 0 {
+		return client, "", errors.New("Expected at least one scope.")
+	}
+
+	for _, scope := range scopes {
+		valid := false
+
+		for _, known := range apiClientScopes {
+			valid = valid || scope == known
+		}
+
+		if !valid {
+			return client, "", fmt.Errorf(
+				"Invalid scope %q, expected one of %s.", scope, strings.Join(apiClientScopes, ", "),
+			)
+		}
+	}
+
+	if token == "" {
+		if token, err = utils.GenerateSlug(80); err != nil {
+			return
+		}
+	} else if !apiClientTokenRegexp.MatchString(token) {
+		return client, "", errors.New("Invalid token, expected 80 letters, digits, `-` or `_`.")
+	}
+
+	if client.Slug, err = utils.GenerateSlug(16); err != nil {
+		return
+	}
+
+	client.Name = name
+	client.TokenHash = APIClientTokenHash(token)
+	client.Scopes = strings.Join(scopes, " ")
+
+	if result := db.Create(&client); result.Error != nil {
+		if constraintErr := ClassifyConstraintError(result.Error);
+		constraintErr != nil && constraintErr.Kind == ConstraintUnique {
+			return client, "", fmt.Errorf("A client named %q, or with that token, exists.", name)
+		}
+
+		return client, "", result.Error
+	}
+
+	return client, token, nil
+}
+
+// RevokeAPIClient revokes the client named `name`, which then can't authenticate.
+func RevokeAPIClient(db *gorm.DB, name string) error {
+	result := db.Model(&models.APIClient{}).Where("name = ? AND revoked_at IS NULL", name).
+	Update("revoked_at", time.Now().UTC())
+
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return fmt.Errorf("No unrevoked client named %q.", name)
+	}
+
+	return nil
+}
+
+// FindAPIClient returns the unrevoked client authenticated by `token`, and whether there
+// is one, recording that it was used. The client is looked up by the token's digest, so
+// how long the lookup takes says nothing about the token itself, and the digests are then
+// compared in constant time regardless.
+func FindAPIClient(db *gorm.DB, token string) (client models.APIClient, found bool, err error) {
+	tokenHash := APIClientTokenHash(token)
+	result := db.Where("token_hash = ? AND revoked_at IS NULL", tokenHash).Limit(1).Find(&client)
+
+	if result.Error != nil || result.RowsAffected == 0 {
+		return client, false, result.Error
+	} else if subtle.ConstantTimeCompare([]byte(client.TokenHash), []byte(tokenHash)) != 1 {
+		return models.APIClient{}, false, nil
+	}
+
+	now := time.Now().UTC()
+
+	if client.LastUsedAt == nil || now.Sub(*client.LastUsedAt) >= apiClientUsedInterval {
+		client.LastUsedAt = &now
+		err = db.Model(&models.APIClient{}).Where("slug = ?", client.Slug).
+		UpdateColumn("last_used_at", now).Error
+	}
+
+	return client, true, err
+}
+
+// HasAPIClients reports whether any client was ever registered, revoked or not.
+func HasAPIClients(db *gorm.DB) (bool, error) {
+	var count int64
+	err := db.Model(&models.APIClient{}).Limit(1).Count(&count).Error
+
+	return count > 0, err
+}
@@ -0,0 +1,338 @@
+package database
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/goccy/go-json"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"github.com/liobrdev/simplepasswords_vaults/models"
+)
+
+const (
+	BackupFormat    string = "simplepasswords-vaults-backup"
+	BackupVersion   uint8  = 1
+	backupManifest  string = "manifest.json"
+	backupBatchSize int    = 500
+)
+
+// Tables are backed up and restored parents first. Trashed rows are included, and every
+// column is kept as stored, so secrets stay encrypted under their users' keys, and API
+// clients keep only their token digests. Authorization failure counts are only kept for
+// as long as their window, and aren't backed up.
+var backupModels = []interface{}{
+	&models.User{}, &models.Vault{}, &models.VaultMember{}, &models.Entry{}, &models.Secret{},
+	&models.SecretVersion{}, &models.Share{}, &models.ShareSecret{}, &models.AuditEvent{},
+	&models.IntegrityRecord{}, &models.IntegrityRoot{}, &models.APIClient{},
+}
+
+type BackupTable struct {
+	Name   string `json:"name"`
+	Rows   int64  `json:"rows"`
+	SHA256 string `json:"sha256"`
+}
+
+type BackupManifest struct {
+	Format        string        `json:"format"`
+	Version       uint8         `json:"version"`
+	SchemaVersion int           `json:"schema_version"`
+	CreatedAt     time.Time     `json:"created_at"`
+	Tables        []BackupTable `json:"tables"`
+}
+
+// Backup writes an archive of every table to `w` and returns its manifest. The archive
+// is a gzipped tar holding one `<table>.jsonl` file per table, one row per line keyed by
+// column name, followed by `manifest.json` with each file's row count and SHA-256
+// checksum. The manifest comes last so that Restore can insert rows as it reads them and
+// check the totals once it gets there.
+func Backup(db *gorm.DB, w io.Writer) (manifest BackupManifest, err error) {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	manifest = BackupManifest{
+		Format: BackupFormat, Version: BackupVersion, CreatedAt: time.Now().UTC(),
+	}
+
+	// Everything is read in one read-only transaction, which at least repeatable read
+	// isolation gives a single snapshot of, so the tables are consistent with each other.
+	if err = db.Transaction(func(tx *gorm.DB) error {
+		if version, err := SchemaVersion(tx); err != nil {
+			return err
+		} else {
+			manifest.SchemaVersion = version
+		}
+
+		for _, model := range backupModels {
+			if table, err := backupTable(tx, tw, model); err != nil {
+				return err
+			} else {
+				manifest.Tables = append(manifest.Tables, table)
+			}
+		}
+
+		return nil
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}); err != nil {
+		return
+	}
+
+	if data, err := json.MarshalIndent(&manifest, "", "  "); err != nil {
+		return manifest, err
+	} else if err := writeTarFile(tw, backupManifest, data); err != nil {
+		return manifest, err
+	}
+
+	if err = tw.Close(); err != nil {
+		return
+	}
+
+	err = gz.Close()
+
+	return
+}
+
+// Each table's rows are staged in a temporary file before being added to the archive,
+// since tar needs a file's size up front.
+func backupTable(tx *gorm.DB, tw *tar.Writer, model interface{}) (table BackupTable, err error) {
+	stmt := &gorm.Statement{DB: tx}
+
+	if err = stmt.Parse(model); err != nil {
+		return
+	}
+
+	table.Name = stmt.Schema.Table
+	staging, err := os.CreateTemp("", table.Name + "-*.jsonl")
+
+	if err != nil {
+		return
+	}
+
+	defer os.Remove(staging.Name())
+	defer staging.Close()
+
+	checksum := sha256.New()
+	w := bufio.NewWriter(io.MultiWriter(staging, checksum))
+	rows := reflect.New(reflect.SliceOf(stmt.Schema.ModelType))
+
+	if result := tx.Unscoped().Model(model).
+	FindInBatches(rows.Interface(), backupBatchSize, func(batchTx *gorm.DB, batch int) error {
+		for i := 0; i < rows.Elem().Len(); i++ {
+			row := map[string]interface{}{}
+
+			for _, field := range stmt.Schema.Fields {
+				if field.DBName != "" {
+					row[field.DBName], _ = field.ValueOf(context.Background(), rows.Elem().Index(i))
+				}
+			}
+
+			if line, err := json.Marshal(row); err != nil {
+				return err
+			} else if _, err := w.Write(append(line, '\n')); err != nil {
+				return err
+			}
+		}
+
+		table.Rows += int64(rows.Elem().Len())
+
+		return nil
+	}); result.Error != nil {
+		return table, result.Error
+	}
+
+	if err = w.Flush(); err != nil {
+		return
+	}
+
+	table.SHA256 = hexSum(checksum)
+	size, err := staging.Seek(0, io.SeekCurrent)
+
+	if err != nil {
+		return
+	} else if _, err = staging.Seek(0, io.SeekStart); err != nil {
+		return
+	} else if err = tw.WriteHeader(&tar.Header{
+		Name: table.Name + ".jsonl", Mode: 0600, Size: size, ModTime: time.Now(),
+	}); err != nil {
+		return
+	}
+
+	_, err = io.Copy(tw, staging)
+
+	return
+}
+
+func writeTarFile(tw *tar.Writer, name string, data []byte) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name: name, Mode: 0600, Size: int64(len(data)), ModTime: time.Now(),
+	}); err != nil {
+		return err
+	}
+
+	_, err := tw.Write(data)
+
+	return err
+}
+
+var (
+	ErrRestoreNotEmpty = errors.New("database isn't empty")
+	ErrRestoreChecksum = errors.New("backup doesn't match its manifest")
+	ErrRestoreSchema   = errors.New("backup was taken at a different schema version")
+)
+
+// Restore loads an archive written by Backup into a database whose tables exist but are
+// empty, and returns the archive's manifest. Rows are inserted as they're read, inside
+// one transaction that's rolled back unless every table's row count and checksum match
+// the manifest at the end of the archive, and the manifest's schema version matches the
+// database's.
+func Restore(db *gorm.DB, r io.Reader) (manifest BackupManifest, err error) {
+	gz, err := gzip.NewReader(r)
+
+	if err != nil {
+		return
+	}
+
+	tr := tar.NewReader(gz)
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range backupModels {
+			var count int64
+
+			if result := tx.Unscoped().Model(model).Count(&count); result.Error != nil {
+				return result.Error
+			} else if count > 0 {
+				return ErrRestoreNotEmpty
+			}
+		}
+
+		var restored []BackupTable
+
+		for _, model := range backupModels {
+			if table, err := restoreTable(tx, tr, model); err != nil {
+				return err
+			} else {
+				restored = append(restored, table)
+			}
+		}
+
+		if header, err := tr.Next(); err != nil {
+			return fmt.Errorf("Reading %s: %w", backupManifest, err)
+		} else if header.Name != backupManifest {
+			return fmt.Errorf("Expected %s, found %s", backupManifest, header.Name)
+		} else if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+			return fmt.Errorf("Reading %s: %w", backupManifest, err)
+		} else if manifest.Format != BackupFormat || manifest.Version != BackupVersion {
+			return fmt.Errorf("Unsupported backup format %s v%d", manifest.Format, manifest.Version)
+		} else if !reflect.DeepEqual(manifest.Tables, restored) {
+			return ErrRestoreChecksum
+		} else if version, err := SchemaVersion(tx); err != nil {
+			return err
+		} else if manifest.SchemaVersion != version {
+			return fmt.Errorf("%w: %d != %d", ErrRestoreSchema, manifest.SchemaVersion, version)
+		}
+
+		return nil
+	})
+
+	return
+}
+
+func restoreTable(tx *gorm.DB, tr *tar.Reader, model interface{}) (table BackupTable, err error) {
+	stmt := &gorm.Statement{DB: tx}
+
+	if err = stmt.Parse(model); err != nil {
+		return
+	}
+
+	table.Name = stmt.Schema.Table
+
+	if header, err := tr.Next(); err != nil {
+		return table, fmt.Errorf("Reading %s.jsonl: %w", table.Name, err)
+	} else if header.Name != table.Name + ".jsonl" {
+		return table, fmt.Errorf("Expected %s.jsonl, found %s", table.Name, header.Name)
+	}
+
+	checksum := sha256.New()
+	scanner := bufio.NewScanner(io.TeeReader(tr, checksum))
+	scanner.Buffer(make([]byte, 64 * 1024), 16 * 1024 * 1024)
+	rows := reflect.MakeSlice(reflect.SliceOf(stmt.Schema.ModelType), 0, backupBatchSize)
+
+	for scanner.Scan() {
+		row := reflect.New(stmt.Schema.ModelType)
+
+		if err = restoreRow(stmt.Schema, scanner.Bytes(), row.Elem()); err != nil {
+			return table, fmt.Errorf("%s row %d: %w", table.Name, table.Rows + 1, err)
+		}
+
+		rows = reflect.Append(rows, row.Elem())
+		table.Rows++
+
+		if rows.Len() == backupBatchSize {
+			if err = insertRestoredRows(tx, rows); err != nil {
+				return
+			}
+
+			rows = rows.Slice(0, 0)
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return
+	} else if rows.Len() > 0 {
+		if err = insertRestoredRows(tx, rows); err != nil {
+			return
+		}
+	}
+
+	table.SHA256 = hexSum(checksum)
+
+	return
+}
+
+func restoreRow(sch *schema.Schema, line []byte, row reflect.Value) error {
+	var columns map[string]json.RawMessage
+
+	if err := json.Unmarshal(line, &columns); err != nil {
+		return err
+	}
+
+	for _, field := range sch.Fields {
+		if raw, ok := columns[field.DBName]; ok && field.DBName != "" {
+			value := reflect.New(field.FieldType)
+
+			if err := json.Unmarshal(raw, value.Interface()); err != nil {
+				return fmt.Errorf("%s: %w", field.DBName, err)
+			} else if err := field.Set(context.Background(), row, value.Elem().Interface());
+			err != nil {
+				return fmt.Errorf("%s: %w", field.DBName, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Rows are inserted exactly as they were backed up, with no associations and no hooks.
+func insertRestoredRows(tx *gorm.DB, rows reflect.Value) error {
+	dest := reflect.New(rows.Type())
+	dest.Elem().Set(rows)
+
+	return tx.Session(&gorm.Session{SkipHooks: true}).Omit(clause.Associations).
+	Create(dest.Interface()).Error
+}
+
+func hexSum(h hash.Hash) string {
+	return hex.EncodeToString(h.Sum(nil))
+}
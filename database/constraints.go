@@ -0,0 +1,121 @@
+package database
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgconn"
+)
+
+const (
+	ConstraintUnique     string = "UNIQUE"
+	ConstraintForeignKey string = "FOREIGN KEY"
+	ConstraintNotNull    string = "NOT NULL"
+)
+
+// Postgres error codes of the constraint violations, from the `integrity_constraint_violation`
+// class.
+var pgConstraintCodes = map[string]string{
+	"23505": ConstraintUnique,
+	"23503": ConstraintForeignKey,
+	"23502": ConstraintNotNull,
+}
+
+var (
+	// SQLite reports each column of the constraint as `table.column`, e.g.
+	// "UNIQUE constraint failed: vaults.title, vaults.user_slug". Foreign key violations
+	// don't name any.
+	sqliteConstraintRegexp = regexp.MustCompile(
+		`^(UNIQUE|FOREIGN KEY|NOT NULL) constraint failed(?:: (.+))?$`,
+	)
+	// Postgres details name the columns of unique and foreign key violations, e.g.
+	// `Key (title, user_slug)=(...) already exists.`
+	pgKeyDetailRegexp = regexp.MustCompile(`^Key \(([^)]+)\)=`)
+)
+
+// ConstraintError is a violation of a unique, foreign key or not-null constraint, the same
+// whichever database reported it.
+type ConstraintError struct {
+	Kind    string
+	Table   string
+	Columns []string
+	Err     error
+}
+
+// Error describes the violation the way SQLite does, whichever database reported it, and
+// without any of the values involved.
+func (e *ConstraintError) Error() string {
+	columns := []string{}
+
+	for _, column := range e.Columns {
+		if e.Table != "" {
+			column = e.Table + "." + column
+		}
+
+		columns = append(columns, column)
+	}
+
+	if len(columns) == 0 {
+		return e.Kind + " constraint failed"
+	}
+
+	return e.Kind + " constraint failed: " + strings.Join(columns, ", ")
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
+}
+
+// ClassifyConstraintError returns the constraint violation `err` is, or wraps, from either
+// the Postgres or SQLite driver, or nil if it isn't one.
+func ClassifyConstraintError(err error) *ConstraintError {
+	if err == nil {
+		return nil
+	}
+
+	var constraintErr *ConstraintError
+
+	if errors.As(err, &constraintErr) {
+		return constraintErr
+	}
+
+	var pgErr *pgconn.PgError
+
+	if errors.As(err, &pgErr) {
+		kind, ok := pgConstraintCodes[pgErr.Code]
+
+		if !ok {
+			return nil
+		}
+
+		constraintErr = &ConstraintError{Kind: kind, Table: pgErr.TableName, Err: err}
+
+		if pgErr.ColumnName != "" {
+			constraintErr.Columns = []string{pgErr.ColumnName}
+		} else if match := pgKeyDetailRegexp.FindStringSubmatch(pgErr.Detail); match != nil {
+			constraintErr.Columns = strings.Split(match[1], ", ")
+		}
+
+		return constraintErr
+	}
+
+	// The SQLite driver is only linked into the tests, so its errors are recognized by their
+	// message, which is the same for every version.
+	for unwrapped := err; unwrapped != nil; unwrapped = errors.Unwrap(unwrapped) {
+		if match := sqliteConstraintRegexp.FindStringSubmatch(unwrapped.Error()); match != nil {
+			constraintErr = &ConstraintError{Kind: match[1], Err: err}
+
+			for _, column := range strings.Split(match[2], ", ") {
+				if table, name, ok := strings.Cut(column, "."); ok {
+					constraintErr.Table = table
+					constraintErr.Columns = append(constraintErr.Columns, name)
+				}
+			}
+
+			return constraintErr
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,395 @@
+package database
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+// The integrity manifest keeps a SHA-512 digest of every vault, entry and secret row,
+// trashed ones included, in `integrity_records`, and for each user a root digest over
+// all of that user's records in `integrity_roots`. Write handlers refresh only the
+// records they touched, in the same transaction as the write, so a row that's added,
+// removed or changed by anything else keeps its old digest and shows up in VerifyIntegrity.
+// A root that doesn't match the records means the manifest itself was edited.
+const (
+	IntegrityVault  string = "vault"
+	IntegrityEntry  string = "entry"
+	IntegritySecret string = "secret"
+
+	IntegrityAdded   string = "added"
+	IntegrityRemoved string = "removed"
+	IntegrityAltered string = "altered"
+)
+
+var integrityModels = map[string]interface{}{
+	IntegrityVault:  &models.Vault{},
+	IntegrityEntry:  &models.Entry{},
+	IntegritySecret: &models.Secret{},
+}
+
+var integrityKinds = []string{IntegrityVault, IntegrityEntry, IntegritySecret}
+
+// IntegrityScope selects the records a write touched. For each kind it names the column
+// that must equal the scope's slug, or "" to select every row of that kind.
+type IntegrityScope struct {
+	slug    string
+	columns map[string]string
+}
+
+// IntegrityVaultRow is the vault's own row.
+func IntegrityVaultRow(slug string) IntegrityScope {
+	return IntegrityScope{slug, map[string]string{IntegrityVault: "slug"}}
+}
+
+// IntegrityVaultTree is the vault with all its entries and secrets.
+func IntegrityVaultTree(slug string) IntegrityScope {
+	return IntegrityScope{slug, map[string]string{
+		IntegrityVault: "slug", IntegrityEntry: "vault_slug", IntegritySecret: "vault_slug",
+	}}
+}
+
+// IntegrityEntryRow is the entry's own row.
+func IntegrityEntryRow(slug string) IntegrityScope {
+	return IntegrityScope{slug, map[string]string{IntegrityEntry: "slug"}}
+}
+
+// IntegrityEntryTree is the entry with all its secrets.
+func IntegrityEntryTree(slug string) IntegrityScope {
+	return IntegrityScope{slug, map[string]string{
+		IntegrityEntry: "slug", IntegritySecret: "entry_slug",
+	}}
+}
+
+// IntegrityEntrySecrets is every secret of the entry, for writes that reorder them.
+func IntegrityEntrySecrets(slug string) IntegrityScope {
+	return IntegrityScope{slug, map[string]string{IntegritySecret: "entry_slug"}}
+}
+
+// IntegritySecretRow is the secret's own row.
+func IntegritySecretRow(slug string) IntegrityScope {
+	return IntegrityScope{slug, map[string]string{IntegritySecret: "slug"}}
+}
+
+// IntegrityAllSecrets is every secret of the user.
+func IntegrityAllSecrets() IntegrityScope {
+	return IntegrityScope{"", map[string]string{IntegritySecret: ""}}
+}
+
+// IntegrityAll is every record of the user.
+func IntegrityAll() IntegrityScope {
+	return IntegrityScope{"", map[string]string{
+		IntegrityVault: "", IntegrityEntry: "", IntegritySecret: "",
+	}}
+}
+
+func (scope IntegrityScope) where(db *gorm.DB, kind string) *gorm.DB {
+	if column := scope.columns[kind]; column != "" {
+		return db.Where(column + " = ?", scope.slug)
+	}
+
+	return db
+}
+
+type IntegrityDiscrepancy struct {
+	Kind   string `json:"kind"`
+	Slug   string `json:"slug"`
+	Change string `json:"change"`
+}
+
+type IntegrityReport struct {
+	UserSlug        string                 `json:"user_slug"`
+	Intact          bool                   `json:"intact"`
+	Records         int                    `json:"records"`
+	Root            string                 `json:"root"`
+	ManifestAltered bool                   `json:"manifest_altered"`
+	Discrepancies   []IntegrityDiscrepancy `json:"discrepancies"`
+	// UnboundSecrets counts the secrets and secret versions whose ciphertexts aren't bound
+	// to their record yet, which can't be read until they are.
+	UnboundSecrets  int64                  `json:"unbound_secrets"`
+}
+
+// RefreshIntegrity replaces the user's manifest records within each scope with digests
+// of the rows as they are now, and updates the user's root. Call it in the transaction
+// that made the write. The user's root row is locked first, so concurrent refreshes for
+// the same user don't interleave.
+func RefreshIntegrity(tx *gorm.DB, userSlug string, scopes ...IntegrityScope) error {
+	if err := lockIntegrityRoot(tx, userSlug); err != nil {
+		return err
+	}
+
+	for _, scope := range scopes {
+		for _, kind := range integrityKinds {
+			if _, ok := scope.columns[kind]; !ok {
+				continue
+			}
+
+			if result := scope.where(tx.Where("user_slug = ? AND kind = ?", userSlug, kind), kind).
+			Delete(&models.IntegrityRecord{}); result.Error != nil {
+				return result.Error
+			}
+
+			records, err := computeIntegrityRecords(tx, userSlug, kind, scope)
+
+			if err != nil {
+				return err
+			} else if len(records) == 0 {
+				continue
+			}
+
+			if result := tx.Clauses(clause.OnConflict{UpdateAll: true}).
+			CreateInBatches(&records, backupBatchSize); result.Error != nil {
+				return result.Error
+			}
+		}
+	}
+
+	var records []models.IntegrityRecord
+
+	if result := tx.Where("user_slug = ?", userSlug).Find(&records); result.Error != nil {
+		return result.Error
+	}
+
+	return tx.Model(&models.IntegrityRoot{}).Where("user_slug = ?", userSlug).
+	Updates(map[string]interface{}{
+		"root": integrityRoot(records), "records": len(records), "updated_at": time.Now().UTC(),
+	}).Error
+}
+
+// VerifyIntegrity compares the user's rows against the manifest, and reports each record
+// that was added, removed or altered since the manifest last recorded it.
+func VerifyIntegrity(db *gorm.DB, userSlug string) (report IntegrityReport, err error) {
+	report = IntegrityReport{UserSlug: userSlug, Discrepancies: []IntegrityDiscrepancy{}}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var root models.IntegrityRoot
+		var stored []models.IntegrityRecord
+
+		if result := tx.Where("user_slug = ?", userSlug).Limit(1).Find(&root);
+		result.Error != nil {
+			return result.Error
+		} else if result := tx.Where("user_slug = ?", userSlug).Find(&stored);
+		result.Error != nil {
+			return result.Error
+		}
+
+		report.Records = len(stored)
+		report.Root = integrityRoot(stored)
+
+		// A user whose manifest was never written has an empty one.
+		if root.UserSlug == "" {
+			root.Root = integrityRoot(nil)
+		}
+
+		report.ManifestAltered = root.Root != report.Root || root.Records != len(stored)
+		digests := map[string]string{}
+
+		for _, record := range stored {
+			digests[record.Kind + "/" + record.Slug] = record.Digest
+		}
+
+		for _, kind := range integrityKinds {
+			current, err := computeIntegrityRecords(tx, userSlug, kind, IntegrityAll())
+
+			if err != nil {
+				return err
+			}
+
+			for _, record := range current {
+				key := kind + "/" + record.Slug
+
+				if digest, ok := digests[key]; !ok {
+					report.Discrepancies = append(report.Discrepancies, IntegrityDiscrepancy{
+						Kind: kind, Slug: record.Slug, Change: IntegrityAdded,
+					})
+				} else if digest != record.Digest {
+					report.Discrepancies = append(report.Discrepancies, IntegrityDiscrepancy{
+						Kind: kind, Slug: record.Slug, Change: IntegrityAltered,
+					})
+				}
+
+				delete(digests, key)
+			}
+		}
+
+		for _, record := range stored {
+			if _, ok := digests[record.Kind + "/" + record.Slug]; ok {
+				report.Discrepancies = append(report.Discrepancies, IntegrityDiscrepancy{
+					Kind: record.Kind, Slug: record.Slug, Change: IntegrityRemoved,
+				})
+			}
+		}
+
+		for _, model := range []interface{}{&models.Secret{}, &models.SecretVersion{}} {
+			var n int64
+
+			if result := tx.Unscoped().Model(model).Where("user_slug = ?", userSlug).
+			Scopes(UnboundCiphertexts).Count(&n); result.Error != nil {
+				return result.Error
+			}
+
+			report.UnboundSecrets += n
+		}
+
+		return nil
+	})
+
+	order := map[string]int{IntegrityVault: 0, IntegrityEntry: 1, IntegritySecret: 2}
+	sort.Slice(report.Discrepancies, func(i, j int) bool {
+		a, b := report.Discrepancies[i], report.Discrepancies[j]
+
+		if a.Kind != b.Kind {
+			return order[a.Kind] < order[b.Kind]
+		}
+
+		return a.Slug < b.Slug
+	})
+
+	report.Intact = err == nil && !report.ManifestAltered && len(report.Discrepancies) == 0
+
+	return
+}
+
+// UnalteredSecrets returns the slugs of the user's secrets, trashed ones included, that are
+// as the manifest last recorded them. A user whose manifest was never written has nothing
+// to check them against, so all of theirs are.
+func UnalteredSecrets(tx *gorm.DB, userSlug string) (map[string]bool, error) {
+	var root models.IntegrityRoot
+	var stored []models.IntegrityRecord
+
+	if result := tx.Where("user_slug = ?", userSlug).Limit(1).Find(&root); result.Error != nil {
+		return nil, result.Error
+	} else if result := tx.Where("user_slug = ? AND kind = ?", userSlug, IntegritySecret).
+	Find(&stored); result.Error != nil {
+		return nil, result.Error
+	}
+
+	digests := map[string]string{}
+
+	for _, record := range stored {
+		digests[record.Slug] = record.Digest
+	}
+
+	current, err := computeIntegrityRecords(tx, userSlug, IntegritySecret, IntegrityAllSecrets())
+
+	if err != nil {
+		return nil, err
+	}
+
+	unaltered := map[string]bool{}
+
+	for _, record := range current {
+		if root.UserSlug == "" || digests[record.Slug] == record.Digest {
+			unaltered[record.Slug] = true
+		}
+	}
+
+	return unaltered, nil
+}
+
+// UnboundCiphertexts selects the rows whose `string` is a legacy ciphertext or a version 1
+// envelope, neither of which is bound to its record.
+func UnboundCiphertexts(db *gorm.DB) *gorm.DB {
+	return db.Where(
+		"(string NOT LIKE ? OR string LIKE ?)", "$%", fmt.Sprintf("$%d$%%", utils.EnvelopeVersion1),
+	)
+}
+
+func lockIntegrityRoot(tx *gorm.DB, userSlug string) error {
+	if result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.IntegrityRoot{
+		UserSlug: userSlug, Root: integrityRoot(nil), UpdatedAt: time.Now().UTC(),
+	}); result.Error != nil {
+		return result.Error
+	}
+
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+	First(&models.IntegrityRoot{}, "user_slug = ?", userSlug).Error
+}
+
+func computeIntegrityRecords(
+	tx *gorm.DB, userSlug, kind string, scope IntegrityScope,
+) ([]models.IntegrityRecord, error) {
+	model := integrityModels[kind]
+	stmt := &gorm.Statement{DB: tx}
+
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+
+	rows := stmt.Schema.MakeSlice()
+
+	if result := scope.where(tx.Unscoped().Where("user_slug = ?", userSlug), kind).
+	Order("slug").Find(rows.Interface()); result.Error != nil {
+		return nil, result.Error
+	}
+
+	records := []models.IntegrityRecord{}
+
+	for i := 0; i < rows.Elem().Len(); i++ {
+		row := rows.Elem().Index(i)
+		values := []interface{}{}
+		record := models.IntegrityRecord{Kind: kind, UserSlug: userSlug}
+
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+
+			value, _ := field.ValueOf(context.Background(), row)
+
+			switch v := value.(type) {
+			case time.Time:
+				value = v.UTC().Format(time.RFC3339Nano)
+			case gorm.DeletedAt:
+				if value = nil; v.Valid {
+					value = v.Time.UTC().Format(time.RFC3339Nano)
+				}
+			}
+
+			switch field.DBName {
+			case "slug":
+				record.Slug = value.(string)
+			case "vault_slug":
+				record.VaultSlug = value.(string)
+			case "entry_slug":
+				record.EntrySlug = value.(string)
+			}
+
+			values = append(values, field.DBName, value)
+		}
+
+		data, err := json.Marshal(values)
+
+		if err != nil {
+			return nil, err
+		}
+
+		record.Digest = hex.EncodeToString(utils.HashToken(kind + "\n" + string(data)))
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// integrityRoot is the digest of every record's kind, slug and digest, in that order.
+func integrityRoot(records []models.IntegrityRecord) string {
+	lines := make([]string, 0, len(records))
+
+	for _, record := range records {
+		lines = append(lines, record.Kind + " " + record.Slug + " " + record.Digest + "\n")
+	}
+
+	sort.Strings(lines)
+
+	return hex.EncodeToString(utils.HashToken(strings.Join(lines, "")))
+}
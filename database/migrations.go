@@ -0,0 +1,297 @@
+package database
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migrations are SQL files in database/migrations, named
+//
+//	<version>_<name>.<up|down>[.<dialect>].sql
+//
+// A file with a dialect (`postgres` or `sqlite`) is used instead of the plain one on that
+// dialect, and a migration without a file for the current dialect does nothing there but
+// is still recorded. Statements are separated by lines ending in a semicolon, other than
+// those within a $$-quoted body, such as a DO block's.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var migrationFileRegexp = regexp.MustCompile(
+	`^(\d{4})_([a-z0-9_]+)\.(up|down)(?:\.(postgres|sqlite))?\.sql$`,
+)
+
+type Migration struct {
+	Version int
+	Name    string
+	up      map[string]string
+	down    map[string]string
+}
+
+// SchemaMigration records one applied migration.
+type SchemaMigration struct {
+	Version   int       `gorm:"primaryKey"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+var (
+	ErrSchemaAhead  = errors.New("database schema is newer than this binary")
+	ErrSchemaBehind = errors.New("database schema has pending migrations")
+)
+
+var migrations = mustLoadMigrations()
+
+func mustLoadMigrations() []Migration {
+	paths, err := fs.Glob(migrationFiles, "migrations/*.sql")
+
+	if err != nil {
+		panic(err)
+	}
+
+	byVersion := map[int]*Migration{}
+
+	for _, path := range paths {
+		name := strings.TrimPrefix(path, "migrations/")
+		match := migrationFileRegexp.FindStringSubmatch(name)
+
+		if match == nil {
+			panic(fmt.Sprintf("Invalid migration file name %s", name))
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		migration, ok := byVersion[version]
+
+		if !ok {
+			migration = &Migration{
+				Version: version, Name: match[2], up: map[string]string{}, down: map[string]string{},
+			}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			panic(fmt.Sprintf("Migration %04d has two names", version))
+		}
+
+		data, err := migrationFiles.ReadFile(path)
+
+		if err != nil {
+			panic(err)
+		}
+
+		if match[3] == "up" {
+			migration.up[match[4]] = string(data)
+		} else {
+			migration.down[match[4]] = string(data)
+		}
+	}
+
+	loaded := []Migration{}
+
+	for _, migration := range byVersion {
+		loaded = append(loaded, *migration)
+	}
+
+	sort.Slice(loaded, func(i, j int) bool { return loaded[i].Version < loaded[j].Version })
+
+	for i, migration := range loaded {
+		if migration.Version != i + 1 {
+			panic(fmt.Sprintf("Migration %04d is missing", i + 1))
+		}
+	}
+
+	return loaded
+}
+
+// LatestSchemaVersion is the version of the last migration built into this binary.
+func LatestSchemaVersion() int {
+	return len(migrations)
+}
+
+// SchemaVersion is the version of the last migration applied to the database, or 0 if
+// none has been.
+func SchemaVersion(db *gorm.DB) (version int, err error) {
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		return
+	}
+
+	err = db.Model(&SchemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+
+	return
+}
+
+// CheckSchemaVersion returns ErrSchemaAhead or ErrSchemaBehind unless the database is at
+// exactly LatestSchemaVersion.
+func CheckSchemaVersion(db *gorm.DB) error {
+	if version, err := SchemaVersion(db); err != nil {
+		return err
+	} else if version > LatestSchemaVersion() {
+		return fmt.Errorf("%w: %d > %d", ErrSchemaAhead, version, LatestSchemaVersion())
+	} else if version < LatestSchemaVersion() {
+		return fmt.Errorf("%w: %d < %d", ErrSchemaBehind, version, LatestSchemaVersion())
+	}
+
+	return nil
+}
+
+// Migrate applies every pending migration, and returns the ones it applied.
+func Migrate(db *gorm.DB) ([]Migration, error) {
+	return MigrateUp(db, LatestSchemaVersion())
+}
+
+// MigrateUp applies pending migrations up to and including `target`, each in its own
+// transaction, and returns the ones it applied. A database that's ahead of this binary
+// is left alone, with ErrSchemaAhead, since its schema can't be known.
+func MigrateUp(db *gorm.DB, target int) (applied []Migration, err error) {
+	version, err := prepareMigrations(db, target)
+
+	if err != nil || version >= target {
+		return
+	}
+
+	for _, migration := range migrations[version:target] {
+		if err = db.Transaction(func(tx *gorm.DB) error {
+			if migration.Version == 1 {
+				if err := adoptAutoMigratedSchema(tx); err != nil {
+					return err
+				}
+			}
+
+			if err := execMigration(tx, migration.up); err != nil {
+				return err
+			}
+
+			return tx.Create(&SchemaMigration{
+				Version: migration.Version, Name: migration.Name, AppliedAt: time.Now().UTC(),
+			}).Error
+		}); err != nil {
+			return applied, fmt.Errorf("Migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		applied = append(applied, migration)
+	}
+
+	return
+}
+
+// MigrateDown reverts applied migrations down to, but not including, `target`, each in
+// its own transaction, and returns the ones it reverted, latest first.
+func MigrateDown(db *gorm.DB, target int) (reverted []Migration, err error) {
+	version, err := prepareMigrations(db, target)
+
+	if err != nil {
+		return
+	}
+
+	for i := version - 1; i >= target; i-- {
+		migration := migrations[i]
+
+		if err = db.Transaction(func(tx *gorm.DB) error {
+			if err := execMigration(tx, migration.down); err != nil {
+				return err
+			}
+
+			return tx.Delete(&SchemaMigration{}, migration.Version).Error
+		}); err != nil {
+			return reverted, fmt.Errorf("Migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		reverted = append(reverted, migration)
+	}
+
+	return
+}
+
+// deletedAtColumns define the one column the first migration's tables have that the
+// tables AutoMigrate created, before there were migrations, don't.
+var deletedAtColumns = map[string]string{
+	"postgres": `"deleted_at" timestamptz`,
+	"sqlite":   "`deleted_at` datetime",
+}
+
+// adoptAutoMigratedSchema adds the columns the first migration expects to the tables
+// AutoMigrate created, if the database has them, so that the migration's `IF NOT EXISTS`
+// statements can skip those tables and go on to index the new columns. A new database has
+// none of the tables, and nothing to adopt.
+func adoptAutoMigratedSchema(tx *gorm.DB) error {
+	column, ok := deletedAtColumns[tx.Dialector.Name()]
+
+	if !ok {
+		return nil
+	}
+
+	for _, table := range []string{"vaults", "entries", "secrets"} {
+		if !tx.Migrator().HasTable(table) || tx.Migrator().HasColumn(table, "deleted_at") {
+			continue
+		}
+
+		if result := tx.Exec("ALTER TABLE " + table + " ADD COLUMN " + column); result.Error != nil {
+			return result.Error
+		}
+	}
+
+	return nil
+}
+
+func prepareMigrations(db *gorm.DB, target int) (version int, err error) {
+	if target < 0 || target > LatestSchemaVersion() {
+		return 0, fmt.Errorf("No migration %04d", target)
+	} else if err = db.Exec(
+		"CREATE TABLE IF NOT EXISTS schema_migrations " +
+		"(version integer PRIMARY KEY, name text NOT NULL, applied_at timestamp NOT NULL)",
+	).Error; err != nil {
+		return
+	} else if version, err = SchemaVersion(db); err != nil {
+		return
+	} else if version > LatestSchemaVersion() {
+		err = fmt.Errorf("%w: %d > %d", ErrSchemaAhead, version, LatestSchemaVersion())
+	}
+
+	return
+}
+
+// execMigration runs the statements for the database's dialect one at a time, since
+// prepared statements can't hold more than one.
+func execMigration(tx *gorm.DB, files map[string]string) error {
+	sql, ok := files[tx.Dialector.Name()]
+
+	if !ok {
+		sql = files[""]
+	}
+
+	var statement strings.Builder
+	inBody := false
+
+	for _, line := range strings.Split(sql, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		statement.WriteString(line + "\n")
+
+		if strings.Count(line, "$$") % 2 == 1 {
+			inBody = !inBody
+		}
+
+		if !inBody && strings.HasSuffix(strings.TrimSpace(line), ";") {
+			if result := tx.Exec(statement.String()); result.Error != nil {
+				return result.Error
+			}
+
+			statement.Reset()
+		}
+	}
+
+	if strings.TrimSpace(statement.String()) != "" {
+		return fmt.Errorf("Unterminated statement: %s", statement.String())
+	}
+
+	return nil
+}
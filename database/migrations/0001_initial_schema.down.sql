@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS secret_versions;
+DROP TABLE IF EXISTS secrets;
+DROP TABLE IF EXISTS entries;
+DROP TABLE IF EXISTS vaults;
+DROP TABLE IF EXISTS users;
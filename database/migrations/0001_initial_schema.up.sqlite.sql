@@ -0,0 +1,78 @@
+CREATE TABLE IF NOT EXISTS `users` (
+	`slug` text NOT NULL,
+	`created_at` datetime NOT NULL,
+	`updated_at` datetime NOT NULL,
+	PRIMARY KEY (`slug`)
+);
+
+CREATE TABLE IF NOT EXISTS `vaults` (
+	`slug` text NOT NULL,
+	`created_at` datetime NOT NULL,
+	`updated_at` datetime NOT NULL,
+	`deleted_at` datetime,
+	`title` text NOT NULL,
+	`user_slug` text NOT NULL,
+	PRIMARY KEY (`slug`),
+	CONSTRAINT `fk_users_vaults` FOREIGN KEY (`user_slug`) REFERENCES `users`(`slug`)
+		ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS `idx_vaults_user_slug` ON `vaults`(`user_slug`);
+CREATE UNIQUE INDEX IF NOT EXISTS `unique_title_user_slug` ON `vaults`(`title`,`user_slug`);
+CREATE INDEX IF NOT EXISTS `idx_vaults_deleted_at` ON `vaults`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `entries` (
+	`slug` text NOT NULL,
+	`created_at` datetime NOT NULL,
+	`updated_at` datetime NOT NULL,
+	`deleted_at` datetime,
+	`title` text NOT NULL,
+	`vault_slug` text NOT NULL,
+	`user_slug` text NOT NULL,
+	PRIMARY KEY (`slug`),
+	CONSTRAINT `fk_vaults_entries` FOREIGN KEY (`vault_slug`) REFERENCES `vaults`(`slug`)
+		ON DELETE CASCADE,
+	CONSTRAINT `fk_users_entries` FOREIGN KEY (`user_slug`) REFERENCES `users`(`slug`)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS `unique_title_vault_slug` ON `entries`(`title`,`vault_slug`);
+CREATE INDEX IF NOT EXISTS `idx_entries_deleted_at` ON `entries`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_entries_vault_slug` ON `entries`(`vault_slug`);
+
+CREATE TABLE IF NOT EXISTS `secrets` (
+	`slug` text NOT NULL,
+	`created_at` datetime NOT NULL,
+	`updated_at` datetime NOT NULL,
+	`deleted_at` datetime,
+	`label` text NOT NULL,
+	`string` text NOT NULL,
+	`priority` integer NOT NULL,
+	`entry_slug` text NOT NULL,
+	`vault_slug` text NOT NULL,
+	`user_slug` text NOT NULL,
+	PRIMARY KEY (`slug`),
+	CONSTRAINT `fk_entries_secrets` FOREIGN KEY (`entry_slug`) REFERENCES `entries`(`slug`)
+		ON DELETE CASCADE,
+	CONSTRAINT `fk_users_secrets` FOREIGN KEY (`user_slug`) REFERENCES `users`(`slug`)
+);
+
+CREATE INDEX IF NOT EXISTS `idx_secrets_entry_slug` ON `secrets`(`entry_slug`);
+CREATE UNIQUE INDEX IF NOT EXISTS `unique_label_entry_slug` ON `secrets`(`label`,`entry_slug`);
+CREATE INDEX IF NOT EXISTS `idx_secrets_deleted_at` ON `secrets`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `secret_versions` (
+	`slug` text NOT NULL,
+	`created_at` datetime NOT NULL,
+	`label` text NOT NULL,
+	`string` text NOT NULL,
+	`secret_updated_at` datetime NOT NULL,
+	`secret_slug` text NOT NULL,
+	`entry_slug` text NOT NULL,
+	`vault_slug` text NOT NULL,
+	`user_slug` text NOT NULL,
+	PRIMARY KEY (`slug`),
+	CONSTRAINT `fk_secrets_versions` FOREIGN KEY (`secret_slug`) REFERENCES `secrets`(`slug`)
+		ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS `idx_secret_versions_secret_slug` ON `secret_versions`(`secret_slug`);
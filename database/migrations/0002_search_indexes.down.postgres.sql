@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_secrets_label_trgm;
+DROP INDEX IF EXISTS idx_entries_title_trgm;
+DROP INDEX IF EXISTS idx_vaults_title_trgm;
+DROP INDEX IF EXISTS idx_secrets_label_lower;
+DROP INDEX IF EXISTS idx_entries_title_lower;
+DROP INDEX IF EXISTS idx_vaults_title_lower;
@@ -0,0 +1,24 @@
+-- Trigram indexes for the substring matches done by the search endpoint. Creating the
+-- pg_trgm extension takes privileges the migrating role may not have, so without it, the
+-- LOWER() columns get plain indexes instead, which only serve exact and prefix matches.
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm') THEN
+		BEGIN
+			CREATE EXTENSION pg_trgm;
+		EXCEPTION WHEN OTHERS THEN
+			RAISE NOTICE 'pg_trgm unavailable, creating plain search indexes: %', SQLERRM;
+		END;
+	END IF;
+
+	IF EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm') THEN
+		CREATE INDEX IF NOT EXISTS idx_vaults_title_trgm ON vaults USING gin (LOWER(title) gin_trgm_ops);
+		CREATE INDEX IF NOT EXISTS idx_entries_title_trgm ON entries USING gin (LOWER(title) gin_trgm_ops);
+		CREATE INDEX IF NOT EXISTS idx_secrets_label_trgm ON secrets USING gin (LOWER(label) gin_trgm_ops);
+	ELSE
+		CREATE INDEX IF NOT EXISTS idx_vaults_title_lower ON vaults (LOWER(title) text_pattern_ops);
+		CREATE INDEX IF NOT EXISTS idx_entries_title_lower ON entries (LOWER(title) text_pattern_ops);
+		CREATE INDEX IF NOT EXISTS idx_secrets_label_lower ON secrets (LOWER(label) text_pattern_ops);
+	END IF;
+END
+$$;
@@ -0,0 +1,17 @@
+CREATE TABLE `audit_events` (
+	`slug` text NOT NULL,
+	`created_at` datetime NOT NULL,
+	`user_slug` text NOT NULL,
+	`operation` text NOT NULL,
+	`target_slug` text NOT NULL,
+	`outcome` text NOT NULL,
+	`status` integer NOT NULL,
+	`request_id` text NOT NULL,
+	PRIMARY KEY (`slug`)
+);
+
+CREATE INDEX `idx_audit_events_user_slug_created_at` ON `audit_events`(`user_slug`,`created_at`);
+
+-- The audit log is append-only.
+CREATE TRIGGER `audit_events_no_update` BEFORE UPDATE ON `audit_events` BEGIN SELECT RAISE(ABORT, 'audit_events is append-only'); END;
+CREATE TRIGGER `audit_events_no_delete` BEFORE DELETE ON `audit_events` BEGIN SELECT RAISE(ABORT, 'audit_events is append-only'); END;
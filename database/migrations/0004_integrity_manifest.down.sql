@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS integrity_roots;
+DROP TABLE IF EXISTS integrity_records;
@@ -0,0 +1,19 @@
+CREATE TABLE `integrity_records` (
+	`kind` text NOT NULL,
+	`slug` text NOT NULL,
+	`user_slug` text NOT NULL,
+	`vault_slug` text NOT NULL,
+	`entry_slug` text NOT NULL,
+	`digest` text NOT NULL,
+	PRIMARY KEY (`kind`,`slug`)
+);
+
+CREATE INDEX `idx_integrity_records_user_slug` ON `integrity_records`(`user_slug`);
+
+CREATE TABLE `integrity_roots` (
+	`user_slug` text NOT NULL,
+	`root` text NOT NULL,
+	`records` integer NOT NULL,
+	`updated_at` datetime NOT NULL,
+	PRIMARY KEY (`user_slug`)
+);
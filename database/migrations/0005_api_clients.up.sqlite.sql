@@ -0,0 +1,13 @@
+CREATE TABLE `api_clients` (
+	`slug` text NOT NULL,
+	`created_at` datetime NOT NULL,
+	`name` text NOT NULL,
+	`token_hash` text NOT NULL,
+	`scopes` text NOT NULL,
+	`last_used_at` datetime,
+	`revoked_at` datetime,
+	PRIMARY KEY (`slug`)
+);
+
+CREATE UNIQUE INDEX `idx_api_clients_name` ON `api_clients`(`name`);
+CREATE UNIQUE INDEX `idx_api_clients_token_hash` ON `api_clients`(`token_hash`);
@@ -0,0 +1,5 @@
+ALTER TABLE users DROP COLUMN kdf_threads;
+ALTER TABLE users DROP COLUMN kdf_memory;
+ALTER TABLE users DROP COLUMN kdf_time;
+ALTER TABLE users DROP COLUMN kdf_salt;
+ALTER TABLE users DROP COLUMN kdf;
@@ -0,0 +1,6 @@
+-- Existing users keep sending raw keys until they're rekeyed with a password.
+ALTER TABLE "users" ADD COLUMN "kdf" text NOT NULL DEFAULT '';
+ALTER TABLE "users" ADD COLUMN "kdf_salt" text NOT NULL DEFAULT '';
+ALTER TABLE "users" ADD COLUMN "kdf_time" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "users" ADD COLUMN "kdf_memory" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "users" ADD COLUMN "kdf_threads" smallint NOT NULL DEFAULT 0;
@@ -0,0 +1,6 @@
+-- Existing users keep sending raw keys until they're rekeyed with a password.
+ALTER TABLE `users` ADD COLUMN `kdf` text NOT NULL DEFAULT '';
+ALTER TABLE `users` ADD COLUMN `kdf_salt` text NOT NULL DEFAULT '';
+ALTER TABLE `users` ADD COLUMN `kdf_time` integer NOT NULL DEFAULT 0;
+ALTER TABLE `users` ADD COLUMN `kdf_memory` integer NOT NULL DEFAULT 0;
+ALTER TABLE `users` ADD COLUMN `kdf_threads` integer NOT NULL DEFAULT 0;
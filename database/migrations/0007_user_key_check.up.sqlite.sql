@@ -0,0 +1,2 @@
+-- Existing users get theirs the first time they send a key that their secrets confirm.
+ALTER TABLE `users` ADD COLUMN `key_check` text NOT NULL DEFAULT '';
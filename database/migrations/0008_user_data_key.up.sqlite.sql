@@ -0,0 +1,2 @@
+-- Existing users get theirs the next time their secrets are rekeyed.
+ALTER TABLE `users` ADD COLUMN `data_key` text NOT NULL DEFAULT '';
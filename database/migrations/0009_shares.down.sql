@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS share_secrets;
+DROP TABLE IF EXISTS shares;
+ALTER TABLE users DROP COLUMN private_key;
+ALTER TABLE users DROP COLUMN public_key;
@@ -0,0 +1,41 @@
+-- Existing users get their sharing keys the next time their key is confirmed.
+ALTER TABLE `users` ADD COLUMN `public_key` text NOT NULL DEFAULT '';
+ALTER TABLE `users` ADD COLUMN `private_key` text NOT NULL DEFAULT '';
+
+CREATE TABLE `shares` (
+	`slug` text NOT NULL,
+	`created_at` datetime NOT NULL,
+	`updated_at` datetime NOT NULL,
+	`entry_slug` text NOT NULL,
+	`owner_slug` text NOT NULL,
+	`grantee_slug` text NOT NULL,
+	`permission` text NOT NULL,
+	`public_key` text NOT NULL,
+	`owner_key` text NOT NULL,
+	PRIMARY KEY (`slug`),
+	CONSTRAINT `fk_entries_shares` FOREIGN KEY (`entry_slug`) REFERENCES `entries`(`slug`)
+		ON DELETE CASCADE,
+	CONSTRAINT `fk_users_owned_shares` FOREIGN KEY (`owner_slug`) REFERENCES `users`(`slug`)
+		ON DELETE CASCADE,
+	CONSTRAINT `fk_users_granted_shares` FOREIGN KEY (`grantee_slug`) REFERENCES `users`(`slug`)
+		ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX `unique_entry_slug_grantee_slug` ON `shares`(`entry_slug`,`grantee_slug`);
+CREATE INDEX `idx_shares_owner_slug` ON `shares`(`owner_slug`);
+CREATE INDEX `idx_shares_grantee_slug` ON `shares`(`grantee_slug`);
+
+CREATE TABLE `share_secrets` (
+	`share_slug` text NOT NULL,
+	`secret_slug` text NOT NULL,
+	`string` text NOT NULL,
+	`updated_at` datetime NOT NULL,
+	`written` numeric NOT NULL DEFAULT false,
+	PRIMARY KEY (`share_slug`,`secret_slug`),
+	CONSTRAINT `fk_shares_secrets` FOREIGN KEY (`share_slug`) REFERENCES `shares`(`slug`)
+		ON DELETE CASCADE,
+	CONSTRAINT `fk_secrets_share_secrets` FOREIGN KEY (`secret_slug`) REFERENCES `secrets`(`slug`)
+		ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_share_secrets_secret_slug` ON `share_secrets`(`secret_slug`);
@@ -0,0 +1,15 @@
+CREATE TABLE `vault_members` (
+	`vault_slug` text NOT NULL,
+	`user_slug` text NOT NULL,
+	`created_at` datetime NOT NULL,
+	`role` text NOT NULL,
+	`public_key` text NOT NULL,
+	`vault_key` text NOT NULL,
+	PRIMARY KEY (`vault_slug`,`user_slug`),
+	CONSTRAINT `fk_vaults_members` FOREIGN KEY (`vault_slug`) REFERENCES `vaults`(`slug`)
+		ON DELETE CASCADE,
+	CONSTRAINT `fk_users_memberships` FOREIGN KEY (`user_slug`) REFERENCES `users`(`slug`)
+		ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_vault_members_user_slug` ON `vault_members`(`user_slug`);
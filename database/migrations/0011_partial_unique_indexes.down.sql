@@ -0,0 +1,10 @@
+-- Fails if a trashed record shares its title or label with a live one, which has to be
+-- purged or renamed first.
+DROP INDEX IF EXISTS unique_title_user_slug;
+CREATE UNIQUE INDEX unique_title_user_slug ON vaults(title,user_slug);
+
+DROP INDEX IF EXISTS unique_title_vault_slug;
+CREATE UNIQUE INDEX unique_title_vault_slug ON entries(title,vault_slug);
+
+DROP INDEX IF EXISTS unique_label_entry_slug;
+CREATE UNIQUE INDEX unique_label_entry_slug ON secrets(label,entry_slug);
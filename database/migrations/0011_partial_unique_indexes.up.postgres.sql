@@ -0,0 +1,12 @@
+-- Trashed records no longer hold on to their titles and labels.
+DROP INDEX IF EXISTS "unique_title_user_slug";
+CREATE UNIQUE INDEX "unique_title_user_slug" ON "vaults"("title","user_slug")
+	WHERE "deleted_at" IS NULL;
+
+DROP INDEX IF EXISTS "unique_title_vault_slug";
+CREATE UNIQUE INDEX "unique_title_vault_slug" ON "entries"("title","vault_slug")
+	WHERE "deleted_at" IS NULL;
+
+DROP INDEX IF EXISTS "unique_label_entry_slug";
+CREATE UNIQUE INDEX "unique_label_entry_slug" ON "secrets"("label","entry_slug")
+	WHERE "deleted_at" IS NULL;
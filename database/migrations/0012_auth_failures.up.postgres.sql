@@ -0,0 +1,8 @@
+CREATE TABLE "auth_failures" (
+	"ip" text NOT NULL,
+	"failures" integer NOT NULL,
+	"window_start" bigint NOT NULL,
+	PRIMARY KEY ("ip")
+);
+
+CREATE INDEX "idx_auth_failures_window_start" ON "auth_failures"("window_start");
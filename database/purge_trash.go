@@ -0,0 +1,88 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/models"
+)
+
+// PurgeTrash permanently deletes every vault, entry and secret that was trashed before
+// `cutoff`, along with the purged secrets' versions and the purged entries' shares, and
+// returns the number of vaults, entries and secrets it removed. Children are never trashed
+// after their parent, so purging by age alone can't leave a restorable record without its
+// parent. The purged records are dropped from their users' integrity manifests too.
+func PurgeTrash(db *gorm.DB, cutoff time.Time) (purged int64, err error) {
+	if err = db.Transaction(func(tx *gorm.DB) error {
+		scopes := map[string][]IntegrityScope{}
+		rowScopes := map[string]func(string) IntegrityScope{
+			IntegrityVault: IntegrityVaultRow, IntegrityEntry: IntegrityEntryRow,
+			IntegritySecret: IntegritySecretRow,
+		}
+
+		for _, kind := range integrityKinds {
+			var rows []struct{ Slug, UserSlug string }
+
+			if result := tx.Unscoped().Model(integrityModels[kind]).Select("slug", "user_slug").
+			Where("deleted_at < ?", cutoff).Find(&rows); result.Error != nil {
+				return result.Error
+			}
+
+			for _, row := range rows {
+				scopes[row.UserSlug] = append(scopes[row.UserSlug], rowScopes[kind](row.Slug))
+			}
+		}
+
+		if result := tx.Where(
+			"secret_slug IN (?)", tx.Unscoped().Model(&models.Secret{}).Select("slug").
+			Where("deleted_at < ?", cutoff),
+		).Delete(&models.SecretVersion{}); result.Error != nil {
+			return result.Error
+		}
+
+		purgedEntries := tx.Unscoped().Model(&models.Entry{}).Select("slug").
+		Where("deleted_at < ?", cutoff)
+
+		if result := tx.Where(
+			"secret_slug IN (?) OR share_slug IN (?)",
+			tx.Unscoped().Model(&models.Secret{}).Select("slug").Where("deleted_at < ?", cutoff),
+			tx.Model(&models.Share{}).Select("slug").Where("entry_slug IN (?)", purgedEntries),
+		).Delete(&models.ShareSecret{}); result.Error != nil {
+			return result.Error
+		}
+
+		if result := tx.Where("entry_slug IN (?)", purgedEntries).Delete(&models.Share{});
+		result.Error != nil {
+			return result.Error
+		}
+
+		if result := tx.Where(
+			"vault_slug IN (?)",
+			tx.Unscoped().Model(&models.Vault{}).Select("slug").Where("deleted_at < ?", cutoff),
+		).Delete(&models.VaultMember{}); result.Error != nil {
+			return result.Error
+		}
+
+		for _, model := range []interface{}{&models.Secret{}, &models.Entry{}, &models.Vault{}} {
+			if result := tx.Unscoped().Where("deleted_at < ?", cutoff).Delete(model);
+			result.Error != nil {
+				return result.Error
+			} else {
+				purged += result.RowsAffected
+			}
+		}
+
+		for userSlug, userScopes := range scopes {
+			if err := RefreshIntegrity(tx, userSlug, userScopes...); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		purged = 0
+	}
+
+	return
+}
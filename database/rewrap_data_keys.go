@@ -0,0 +1,53 @@
+package database
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+// RewrapDataKeys unwraps every user's data key that isn't wrapped under the current KEK of
+// `keys`, and wraps it again under the current KEK. Only the data keys change, so no secret
+// is re-encrypted. It's safe to run repeatedly, and returns the number of data keys it
+// rewrapped; once that's 0, the previous KEKs can be dropped.
+func RewrapDataKeys(db *gorm.DB, keys utils.KeyProvider) (rewrapped int64, err error) {
+	var users []models.User
+
+	result := db.Select("slug", "data_key").Where("data_key <> ?", "").
+	FindInBatches(&users, upgradeBatchSize, func(_ *gorm.DB, _ int) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			for _, user := range users {
+				if keys.IsCurrent(user.DataKey) {
+					continue
+				}
+
+				additionalData := utils.DataKeyAdditionalData(user.Slug)
+				dataKey, err := keys.UnwrapKey(user.DataKey, additionalData)
+
+				if err != nil {
+					return err
+				}
+
+				wrappedKey, err := keys.WrapKey(dataKey, additionalData)
+
+				if err != nil {
+					return err
+				}
+
+				// Unless a rekey replaced the data key in the meantime.
+				if result := tx.Model(&models.User{}).
+				Where("slug = ? AND data_key = ?", user.Slug, user.DataKey).
+				UpdateColumn("data_key", wrappedKey); result.Error != nil {
+					return result.Error
+				} else {
+					rewrapped += result.RowsAffected
+				}
+			}
+
+			return nil
+		})
+	})
+
+	return rewrapped, result.Error
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+const upgradeBatchSize = 100
+
+// UpgradeLegacyCiphertexts reframes every secret, trashed ones included, still stored
+// as a bare hex nonce||ciphertext into a versioned envelope. It needs no keys, is safe to run
+// repeatedly, and returns the number of rows it upgraded. Each upgraded secret's integrity
+// manifest record is refreshed with it. A row that can't be parsed is logged and skipped,
+// and counted in `skipped`, so that it doesn't hold up the rest.
+func UpgradeLegacyCiphertexts(db *gorm.DB) (upgraded, skipped int64, err error) {
+	var secrets []models.Secret
+
+	result := db.Unscoped().Select("slug", "user_slug", "string").Where("string NOT LIKE ?", "$%").
+	FindInBatches(&secrets, upgradeBatchSize, func(_ *gorm.DB, _ int) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			for _, secret := range secrets {
+				envelope, err := utils.UpgradeLegacyCiphertext(secret.String)
+
+				if err != nil {
+					log.Printf("Skipped legacy ciphertext upgrade of secret %s: %s", secret.Slug, err)
+					skipped++
+					continue
+				}
+
+				// Update the column directly so the upgrade doesn't touch `updated_at`.
+				if result := tx.Unscoped().Model(&models.Secret{}).
+				Where("slug = ? AND string = ?", secret.Slug, secret.String).
+				UpdateColumn("string", envelope); result.Error != nil {
+					return result.Error
+				} else {
+					upgraded += result.RowsAffected
+				}
+
+				if err := RefreshIntegrity(
+					tx, secret.UserSlug, IntegritySecretRow(secret.Slug),
+				); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	})
+
+	return upgraded, skipped, result.Error
+}
@@ -2,13 +2,16 @@ package main
 
 import (
 	"log"
+	"os"
+	"time"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/healthcheck"
+	"gorm.io/gorm"
 
 	"github.com/liobrdev/simplepasswords_vaults/app"
 	"github.com/liobrdev/simplepasswords_vaults/config"
 	"github.com/liobrdev/simplepasswords_vaults/database"
-	"github.com/liobrdev/simplepasswords_vaults/models"
 	"github.com/liobrdev/simplepasswords_vaults/routes"
 )
 
@@ -19,16 +22,43 @@ func main() {
 		log.Fatalln("Failed to load config from environment:", err)
 	}
 
-	app := app.CreateApp(&conf)
 	db := database.Init(&conf)
 
-	if err := db.AutoMigrate(
-		&models.User{},
-		&models.Vault{},
-		&models.Entry{},
-		&models.Secret{},
-	); err != nil {
-		log.Fatalln("Failed database auto-migrate:", err)
+	if len(os.Args) > 1 {
+		runCommand(db, &conf, os.Args[1:])
+		return
+	}
+
+	// The parent applies pending migrations before any prefork child is started, so the
+	// children only check that there's nothing left to apply.
+	if !fiber.IsChild() {
+		if applied, err := database.Migrate(db); err != nil {
+			log.Fatalln("Failed database migration:", err)
+		} else {
+			for _, migration := range applied {
+				log.Printf("Applied migration %04d_%s", migration.Version, migration.Name)
+			}
+		}
+	} else if err := database.CheckSchemaVersion(db); err != nil {
+		log.Fatalln("Failed database schema check:", err)
+	}
+
+	app := app.CreateApp(&conf)
+
+	if !fiber.IsChild() {
+		go func() {
+			if n, skipped, err := database.UpgradeLegacyCiphertexts(db); err != nil {
+				log.Println("Failed legacy ciphertext upgrade:", err)
+			} else if n > 0 || skipped > 0 {
+				log.Printf(
+					"Upgraded %d legacy ciphertext(s) to versioned envelopes, skipped %d", n, skipped,
+				)
+			}
+		}()
+
+		if conf.TRASH_RETENTION_DAYS > 0 {
+			go purgeTrashPeriodically(db, &conf)
+		}
 	}
 
 	app.Use(healthcheck.New())
@@ -36,3 +66,21 @@ func main() {
 
 	log.Fatal(app.Listen(conf.VAULTS_HOST + ":" + conf.VAULTS_PORT))
 }
+
+// purgeTrashPeriodically permanently deletes trashed records once they're older than
+// TRASH_RETENTION_DAYS, checking once an hour.
+func purgeTrashPeriodically(db *gorm.DB, conf *config.AppConfig) {
+	retention := time.Duration(conf.TRASH_RETENTION_DAYS) * 24 * time.Hour
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		if n, err := database.PurgeTrash(db, time.Now().UTC().Add(-retention)); err != nil {
+			log.Println("Failed trash purge:", err)
+		} else if n > 0 {
+			log.Printf("Purged %d record(s) from the trash", n)
+		}
+
+		<-ticker.C
+	}
+}
@@ -1,23 +1,39 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type User struct {
-	Slug      string    `json:"user_slug" gorm:"primaryKey;not null"`
-	CreatedAt time.Time `json:"-" gorm:"autoCreateTime:nano;not null"`
-	UpdatedAt time.Time `json:"-" gorm:"autoUpdateTime:nano;not null"`
-	Vaults    []Vault   `json:"-" gorm:"foreignKey:UserSlug;references:Slug;constraint:OnDelete:CASCADE"`
-	Entries   []Entry   `json:"-" gorm:"foreignKey:UserSlug;references:Slug"`
-	Secrets   []Secret  `json:"-" gorm:"foreignKey:UserSlug;references:Slug"`
+	Slug       string    `json:"user_slug" gorm:"primaryKey;not null"`
+	CreatedAt  time.Time `json:"-" gorm:"autoCreateTime:nano;not null"`
+	UpdatedAt  time.Time `json:"-" gorm:"autoUpdateTime:nano;not null"`
+	KDF        string    `json:"-" gorm:"not null;default:''"`
+	KDFSalt    string    `json:"-" gorm:"not null;default:''"`
+	KDFTime    uint32    `json:"-" gorm:"not null;default:0"`
+	KDFMemory  uint32    `json:"-" gorm:"not null;default:0"`
+	KDFThreads uint8     `json:"-" gorm:"not null;default:0"`
+	KeyCheck   string    `json:"-" gorm:"not null;default:''"`
+	DataKey    string    `json:"-" gorm:"not null;default:''"`
+	PublicKey  string    `json:"-" gorm:"not null;default:''"`
+	PrivateKey string    `json:"-" gorm:"not null;default:''"`
+	Vaults     []Vault   `json:"-" gorm:"foreignKey:UserSlug;references:Slug;constraint:OnDelete:CASCADE"`
+	Entries    []Entry   `json:"-" gorm:"foreignKey:UserSlug;references:Slug"`
+	Secrets    []Secret  `json:"-" gorm:"foreignKey:UserSlug;references:Slug"`
 }
 
 type Vault struct {
 	Slug      string    `json:"vault_slug" gorm:"primaryKey;not null"`
 	CreatedAt time.Time `json:"vault_created_at" gorm:"autoCreateTime:nano;not null"`
 	UpdatedAt time.Time `json:"vault_updated_at" gorm:"autoUpdateTime:nano;not null"`
-	Title     string    `json:"vault_title" gorm:"uniqueIndex:unique_title_user_slug;not null"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	Title     string    `json:"vault_title" gorm:"uniqueIndex:unique_title_user_slug,where:deleted_at IS NULL;not null"`
 	UserSlug  string    `json:"-" gorm:"uniqueIndex:unique_title_user_slug;index;not null"`
 	User      User      `json:"-" gorm:"foreignKey:UserSlug"`
+	Role      string    `json:"vault_role,omitempty" gorm:"-"`
 	Entries   []Entry   `json:"entries" gorm:"foreignKey:VaultSlug;references:Slug;constraint:OnDelete:CASCADE"`
 }
 
@@ -25,7 +41,8 @@ type Entry struct {
 	Slug      string    `json:"entry_slug" gorm:"primaryKey;not null"`
 	CreatedAt time.Time `json:"entry_created_at" gorm:"autoCreateTime:nano;not null"`
 	UpdatedAt time.Time `json:"entry_updated_at" gorm:"autoUpdateTime:nano;not null"`
-	Title     string    `json:"entry_title" gorm:"uniqueIndex:unique_title_vault_slug;not null"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	Title     string    `json:"entry_title" gorm:"uniqueIndex:unique_title_vault_slug,where:deleted_at IS NULL;not null"`
 	VaultSlug string    `json:"-" gorm:"uniqueIndex:unique_title_vault_slug;index;not null"`
 	Vault     Vault     `json:"-" gorm:"foreignKey:VaultSlug"`
 	UserSlug  string    `json:"-" gorm:"not null"`
@@ -40,11 +57,144 @@ type Secret struct {
 	Slug      string    `json:"secret_slug" gorm:"primaryKey;not null"`
 	CreatedAt time.Time `json:"secret_created_at" gorm:"autoCreateTime:nano;not null"`
 	UpdatedAt time.Time `json:"secret_updated_at" gorm:"autoUpdateTime:nano;not null"`
-	Label     string    `json:"secret_label" gorm:"uniqueIndex:unique_label_entry_slug;not null"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	Label     string    `json:"secret_label" gorm:"uniqueIndex:unique_label_entry_slug,where:deleted_at IS NULL;not null"`
 	String    string    `json:"secret_string" gorm:"not null"`
 	Priority	uint8			`json:"secret_priority" gorm:"not null"`
 	EntrySlug string    `json:"-" gorm:"uniqueIndex:unique_label_entry_slug;index;not null"`
 	Entry     Entry     `json:"-" gorm:"foreignKey:EntrySlug"`
 	VaultSlug string    `json:"-" gorm:"not null"`
 	UserSlug  string    `json:"-" gorm:"not null"`
+	Versions  []SecretVersion `json:"-" gorm:"foreignKey:SecretSlug;references:Slug;constraint:OnDelete:CASCADE"`
+}
+
+type SecretVersion struct {
+	Slug            string    `json:"secret_version_slug" gorm:"primaryKey;not null"`
+	CreatedAt       time.Time `json:"secret_version_created_at" gorm:"autoCreateTime:nano;not null"`
+	Label           string    `json:"secret_label" gorm:"not null"`
+	String          string    `json:"secret_string" gorm:"not null"`
+	SecretUpdatedAt time.Time `json:"secret_updated_at" gorm:"not null"`
+	SecretSlug      string    `json:"-" gorm:"index;not null"`
+	EntrySlug       string    `json:"-" gorm:"not null"`
+	VaultSlug       string    `json:"-" gorm:"not null"`
+	UserSlug        string    `json:"-" gorm:"not null"`
+}
+
+// AuditEvent records one request made on behalf of a user. Events are only ever
+// inserted; the table refuses updates and deletes.
+type AuditEvent struct {
+	Slug       string    `json:"audit_event_slug" gorm:"primaryKey;not null"`
+	CreatedAt  time.Time `json:"audit_event_created_at" gorm:"autoCreateTime:nano;index:idx_audit_events_user_slug_created_at,priority:2;not null"`
+	UserSlug   string    `json:"-" gorm:"index:idx_audit_events_user_slug_created_at,priority:1;not null"`
+	Operation  string    `json:"operation" gorm:"not null"`
+	TargetSlug string    `json:"target_slug" gorm:"not null"`
+	Outcome    string    `json:"outcome" gorm:"not null"`
+	Status     int       `json:"status" gorm:"not null"`
+	RequestID  string    `json:"request_id" gorm:"not null"`
+}
+
+// IntegrityRecord is the manifest's digest of one vault, entry or secret row.
+type IntegrityRecord struct {
+	Kind      string `gorm:"primaryKey;not null"`
+	Slug      string `gorm:"primaryKey;not null"`
+	UserSlug  string `gorm:"index;not null"`
+	VaultSlug string `gorm:"not null"`
+	EntrySlug string `gorm:"not null"`
+	Digest    string `gorm:"not null"`
+}
+
+// IntegrityRoot is the digest over all of a user's integrity records.
+type IntegrityRoot struct {
+	UserSlug  string    `gorm:"primaryKey;not null"`
+	Root      string    `gorm:"not null"`
+	Records   int       `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+}
+
+const (
+	PermissionRead  string = "read"
+	PermissionWrite string = "write"
+)
+
+// Share grants another user, the grantee, access to one of the owner's entries. The grantee
+// gets a copy of each of the entry's secrets, encrypted under a share key that only the
+// grantee's private key and the owner's own key can recover.
+type Share struct {
+	Slug        string    `json:"share_slug" gorm:"primaryKey;not null"`
+	CreatedAt   time.Time `json:"share_created_at" gorm:"autoCreateTime:nano;not null"`
+	UpdatedAt   time.Time `json:"share_updated_at" gorm:"autoUpdateTime:nano;not null"`
+	EntrySlug   string    `json:"entry_slug" gorm:"uniqueIndex:unique_entry_slug_grantee_slug;not null"`
+	OwnerSlug   string    `json:"owner_slug" gorm:"index;not null"`
+	GranteeSlug string    `json:"grantee_slug" gorm:"uniqueIndex:unique_entry_slug_grantee_slug;index;not null"`
+	Permission  string    `json:"permission" gorm:"not null"`
+	PublicKey   string    `json:"-" gorm:"not null"`
+	OwnerKey    string    `json:"-" gorm:"not null"`
+	Secrets     []ShareSecret `json:"-" gorm:"foreignKey:ShareSlug;references:Slug;constraint:OnDelete:CASCADE"`
+}
+
+// ShareSecret is the grantee's copy of one of a shared entry's secrets. Written marks a
+// copy the grantee changed, until the owner's secret is brought up to date with it.
+type ShareSecret struct {
+	ShareSlug  string    `gorm:"primaryKey;not null"`
+	SecretSlug string    `gorm:"primaryKey;index;not null"`
+	String     string    `gorm:"not null"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime:nano;not null"`
+	Written    bool      `gorm:"not null;default:false"`
+}
+
+const (
+	RoleOwner  string = "owner"
+	RoleEditor string = "editor"
+	RoleViewer string = "viewer"
+)
+
+// VaultMember gives a user a role in a team vault. A vault becomes a team vault when its
+// first member is added, and from then on its secrets are encrypted under a vault key
+// instead of its creator's key. Each member, the creator included, holds the vault key
+// encrypted to their sharing public key, the same way shares hold theirs.
+type VaultMember struct {
+	VaultSlug string    `json:"vault_slug" gorm:"primaryKey;not null"`
+	UserSlug  string    `json:"member_slug" gorm:"primaryKey;index;not null"`
+	CreatedAt time.Time `json:"member_created_at" gorm:"autoCreateTime:nano;not null"`
+	Role      string    `json:"member_role" gorm:"not null"`
+	PublicKey string    `json:"-" gorm:"not null"`
+	VaultKey  string    `json:"-" gorm:"not null"`
+}
+
+const (
+	ScopeRead  string = "read"
+	ScopeWrite string = "write"
+	ScopeAdmin string = "admin"
+)
+
+// AuthFailure counts an IP's failed authorizations in the window that started at
+// WindowStart, in Unix milliseconds.
+type AuthFailure struct {
+	IP          string `gorm:"primaryKey;not null"`
+	Failures    int    `gorm:"not null"`
+	WindowStart int64  `gorm:"index;not null"`
+}
+
+// APIClient is a named credential for calling the API. Only a digest of its token is
+// stored. Revoked clients are kept, so their names aren't reused.
+type APIClient struct {
+	Slug       string     `json:"api_client_slug" gorm:"primaryKey;not null"`
+	CreatedAt  time.Time  `json:"api_client_created_at" gorm:"autoCreateTime:nano;not null"`
+	Name       string     `json:"name" gorm:"uniqueIndex;not null"`
+	TokenHash  string     `json:"-" gorm:"uniqueIndex;not null"`
+	Scopes     string     `json:"scopes" gorm:"not null"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// HasScope reports whether the client was granted `scope`. Scopes are stored separated
+// by spaces.
+func (client APIClient) HasScope(scope string) bool {
+	for _, granted := range strings.Fields(client.Scopes) {
+		if granted == scope {
+			return true
+		}
+	}
+
+	return false
 }
@@ -1,15 +1,37 @@
 package routes
 
 import (
+	"log"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/gofiber/fiber/v2/utils"
 	"gorm.io/gorm"
 
 	"github.com/liobrdev/simplepasswords_vaults/config"
 	"github.com/liobrdev/simplepasswords_vaults/controllers"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	ops "github.com/liobrdev/simplepasswords_vaults/utils"
 )
 
 func Register(app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
-	H := controllers.Handler{DB: db, Conf: conf}
+	keys, err := ops.NewLocalKeyProvider(conf.VAULTS_KEK)
+
+	if err != nil {
+		log.Fatalln("Failed to load VAULTS_KEK:", err)
+	}
+
+	H := controllers.Handler{
+		DB: db, Conf: conf, AuthFailures: controllers.NewAuthFailureTracker(db, conf),
+		KDFUpgrades: controllers.NewKDFUpgrades(), Keys: keys,
+	}
+	app.Use(requestid.New(requestid.Config{
+		Generator: utils.UUIDv4, ContextKey: controllers.RequestIDLocal,
+	}))
+	app.Use(ops.ConfigureErrorResponses(ops.ErrorResponseConfig{
+		Verbose: conf.ENVIRONMENT != "production",
+		SensitiveHeaders: []string{conf.PASSWORD_HEADER_KEY, conf.EXPORT_PASSPHRASE_HEADER_KEY},
+	}))
 	app.Use(H.AuthorizeRequest)
 
 	api := app.Group("/api")
@@ -18,24 +40,83 @@ func Register(app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
 		api.Get("/restricted", H.Restricted)
 	}
 
+	read, write := H.RequireScope(models.ScopeRead), H.RequireScope(models.ScopeWrite)
+	admin := H.RequireScope(models.ScopeAdmin)
+
 	usersApi := api.Group("/users")
-	usersApi.Post("/", H.CreateUser)
+	usersApi.Post("/", admin, H.Audit(ops.CreateUser), H.CreateUser)
+	usersApi.Post("/:slug/rekey", admin, H.Audit(ops.RekeyUser), H.RekeyUser)
+	usersApi.Get(
+		"/:slug/kdf", read, H.RequireUserSlug, H.Audit(ops.RetrieveUserKDF), H.RetrieveUserKDF,
+	)
+	usersApi.Get(
+		"/:slug/export", admin, H.RequireUserSlug, H.Audit(ops.ExportUser), H.RequireVaultKey,
+		H.ExportUser,
+	)
+	usersApi.Get(
+		"/:slug/audit", admin, H.RequireUserSlug, H.Audit(ops.ListAuditEvents), H.ListAuditEvents,
+	)
+	usersApi.Get(
+		"/:slug/integrity", admin, H.RequireUserSlug, H.Audit(ops.VerifyIntegrity),
+		H.VerifyIntegrity,
+	)
+
+	api.Get("/trash", read, H.RequireUserSlug, H.Audit(ops.ListTrash), H.ListTrash)
+	api.Get("/search", read, H.RequireUserSlug, H.Audit(ops.Search), H.Search)
+	api.Post(
+		"/import", write, H.RequireUserSlug, H.Audit(ops.Import), H.RequireVaultKey, H.Import,
+	)
 	
-	vaultsApi := api.Group("/vaults")
-	vaultsApi.Post("/", H.CreateVault)
-	vaultsApi.Get("/", H.ListVaults)
-	vaultsApi.Get("/:slug", H.RetrieveVault)
-	vaultsApi.Patch("/:slug", H.UpdateVault)
-	vaultsApi.Delete("/:slug", H.DeleteVault)
-
-	entriesApi := api.Group("/entries")
-	entriesApi.Post("/", H.CreateEntry)
-	entriesApi.Get("/:slug", H.RetrieveEntry)
-	entriesApi.Patch("/:slug", H.UpdateEntry)
-	entriesApi.Delete("/:slug", H.DeleteEntry)
-
-	secretsApi := api.Group("/secrets")
-	secretsApi.Post("/", H.CreateSecret)
-	secretsApi.Patch("/:slug", H.UpdateSecret, H.MoveSecret)
-	secretsApi.Delete("/:slug", H.DeleteSecret)
+	vaultsApi := api.Group("/vaults", H.RequireMethodScope, H.RequireUserSlug)
+	vaultsApi.Post("/", H.Audit(ops.CreateVault), H.CreateVault)
+	vaultsApi.Get("/", H.Audit(ops.ListVaults), H.ListVaults)
+	vaultsApi.Get("/:slug", H.Audit(ops.RetrieveVault), H.RetrieveVault)
+	vaultsApi.Patch("/:slug", H.Audit(ops.UpdateVault), H.UpdateVault)
+	vaultsApi.Delete("/:slug", H.Audit(ops.DeleteVault), H.DeleteVault)
+	vaultsApi.Post("/:slug/restore", H.Audit(ops.RestoreVault), H.RestoreVault)
+	vaultsApi.Get("/:slug/members", H.Audit(ops.ListVaultMembers), H.ListVaultMembers)
+	vaultsApi.Post(
+		"/:slug/members", H.Audit(ops.AddVaultMember), H.RequireVaultKey, H.AddVaultMember,
+	)
+	vaultsApi.Patch(
+		"/:slug/members/:member_slug", H.Audit(ops.UpdateVaultMember), H.UpdateVaultMember,
+	)
+	vaultsApi.Delete(
+		"/:slug/members/:member_slug", H.Audit(ops.RemoveVaultMember), H.RemoveVaultMember,
+	)
+
+	entriesApi := api.Group("/entries", H.RequireMethodScope, H.RequireUserSlug)
+	entriesApi.Post("/", H.Audit(ops.CreateEntry), H.RequireVaultKey, H.CreateEntry)
+	entriesApi.Get("/:slug", H.Audit(ops.RetrieveEntry), H.RequireVaultKey, H.RetrieveEntry)
+	entriesApi.Patch("/:slug", H.Audit(ops.UpdateEntry), H.UpdateEntry)
+	entriesApi.Delete("/:slug", H.Audit(ops.DeleteEntry), H.DeleteEntry)
+	entriesApi.Post("/:slug/restore", H.Audit(ops.RestoreEntry), H.RestoreEntry)
+
+	secretsApi := api.Group("/secrets", H.RequireMethodScope, H.RequireUserSlug)
+	secretsApi.Post("/", H.Audit(ops.CreateSecret), H.RequireVaultKey, H.CreateSecret)
+	secretsApi.Patch(
+		"/:slug", H.Audit(ops.UpdateSecret), H.RequireVaultKey, H.UpdateSecret, H.MoveSecret,
+	)
+	// Moving a secret doesn't need its key, so the key isn't checked on this route.
+	secretsApi.Patch("/:slug/move", H.Audit(ops.MoveSecret), H.MoveSecret)
+	secretsApi.Get(
+		"/:slug/versions", H.Audit(ops.ListSecretVersions), H.RequireVaultKey,
+		H.ListSecretVersions,
+	)
+	secretsApi.Post(
+		"/:slug/versions/:version_slug/restore",
+		H.Audit(ops.RestoreSecretVersion), H.RequireVaultKey, H.RestoreSecretVersion,
+	)
+	secretsApi.Delete("/:slug", H.Audit(ops.DeleteSecret), H.DeleteSecret)
+	secretsApi.Post("/:slug/restore", H.Audit(ops.RestoreSecret), H.RestoreSecret)
+
+	sharesApi := api.Group("/shares", H.RequireMethodScope, H.RequireUserSlug)
+	sharesApi.Post("/", H.Audit(ops.CreateShare), H.RequireVaultKey, H.CreateShare)
+	sharesApi.Get("/", H.Audit(ops.ListShares), H.ListShares)
+	sharesApi.Get("/:slug", H.Audit(ops.RetrieveShare), H.RequireVaultKey, H.RetrieveShare)
+	sharesApi.Patch(
+		"/:slug/secrets/:secret_slug", H.Audit(ops.UpdateSharedSecret), H.RequireVaultKey,
+		H.UpdateSharedSecret,
+	)
+	sharesApi.Delete("/:slug", H.Audit(ops.DeleteShare), H.DeleteShare)
 }
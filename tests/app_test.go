@@ -10,6 +10,7 @@ import (
 	"github.com/liobrdev/simplepasswords_vaults/config"
 	"github.com/liobrdev/simplepasswords_vaults/routes"
 	testDB "github.com/liobrdev/simplepasswords_vaults/tests/database"
+	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
 )
 
 func TestApp(t *testing.T) {
@@ -21,10 +22,15 @@ func TestApp(t *testing.T) {
 
 	conf.ENVIRONMENT = "testing"
 	conf.GO_TESTING_CONTEXT = t
+	// Throttling is tested on apps of its own, so that the rest of the suite, which fails
+	// authorization on purpose, doesn't depend on AUTH_FAILURE_LIMIT.
+	conf.AUTH_FAILURE_LIMIT = 0
 	app := app.CreateApp(&conf)
 	db := testDB.Init(&conf)
 	routes.Register(app, db, &conf)
 
+	// Tests that don't set up their own data still need the schema.
+	setup.SetUp(t, db)
 	runTests(t, app, db, &conf)
 }
 
@@ -33,9 +39,53 @@ func runTests(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig)
 		testAuthorizeRequest(t, app, conf)
 	})
 
+	t.Run("test_auth_failures", func(t *testing.T) {
+		testAuthFailures(t, app, db, conf)
+	})
+
+	t.Run("test_api_clients", func(t *testing.T) {
+		testAPIClients(t, app, db, conf)
+	})
+
+	t.Run("test_error_responses", func(t *testing.T) {
+		testErrorResponses(t, app, db, conf)
+	})
+
+	t.Run("test_upgrade_ciphertexts", func(t *testing.T) {
+		testUpgradeCiphertexts(t, app, db, conf)
+	})
+
 	t.Run("test_create_user", func(t *testing.T) {
 		testCreateUser(t, app, db, conf)
 	})
+
+	t.Run("test_rekey_user", func(t *testing.T) {
+		testRekeyUser(t, app, db, conf)
+	})
+
+	t.Run("test_user_kdf", func(t *testing.T) {
+		testUserKDF(t, app, db, conf)
+	})
+
+	t.Run("test_vault_key", func(t *testing.T) {
+		testVaultKey(t, app, db, conf)
+	})
+
+	t.Run("test_data_keys", func(t *testing.T) {
+		testDataKeys(t, app, db, conf)
+	})
+
+	t.Run("test_shares", func(t *testing.T) {
+		testShares(t, app, db, conf)
+	})
+
+	t.Run("test_vault_members", func(t *testing.T) {
+		testVaultMembers(t, app, db, conf)
+	})
+
+	t.Run("test_export_user", func(t *testing.T) {
+		testExportUser(t, app, db, conf)
+	})
 	
 	t.Run("test_create_vault", func(t *testing.T) {
 		testCreateVault(t, app, db, conf)
@@ -85,7 +135,63 @@ func runTests(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig)
 		testMoveSecret(t, app, db, conf)
 	})
 
+	t.Run("test_list_secret_versions", func(t *testing.T) {
+		testListSecretVersions(t, app, db, conf)
+	})
+
+	t.Run("test_restore_secret_version", func(t *testing.T) {
+		testRestoreSecretVersion(t, app, db, conf)
+	})
+
 	t.Run("test_delete_secret", func(t *testing.T) {
 		testDeleteSecret(t, app, db, conf)
 	})
+
+	t.Run("test_search", func(t *testing.T) {
+		testSearch(t, app, db, conf)
+	})
+
+	t.Run("test_import", func(t *testing.T) {
+		testImport(t, app, db, conf)
+	})
+
+	t.Run("test_list_trash", func(t *testing.T) {
+		testListTrash(t, app, db, conf)
+	})
+
+	t.Run("test_restore_vault", func(t *testing.T) {
+		testRestoreVault(t, app, db, conf)
+	})
+
+	t.Run("test_restore_entry", func(t *testing.T) {
+		testRestoreEntry(t, app, db, conf)
+	})
+
+	t.Run("test_restore_secret", func(t *testing.T) {
+		testRestoreSecret(t, app, db, conf)
+	})
+
+	t.Run("test_purge_trash", func(t *testing.T) {
+		testPurgeTrash(t, app, db, conf)
+	})
+
+	t.Run("test_backup", func(t *testing.T) {
+		testBackup(t, app, db, conf)
+	})
+
+	t.Run("test_list_audit_events", func(t *testing.T) {
+		testListAuditEvents(t, app, db, conf)
+	})
+
+	t.Run("test_verify_integrity", func(t *testing.T) {
+		testVerifyIntegrity(t, app, db, conf)
+	})
+
+	t.Run("test_constraint_errors", func(t *testing.T) {
+		testConstraintErrors(t, app, db, conf)
+	})
+
+	t.Run("test_migrations", func(t *testing.T) {
+		testMigrations(t, app, db, conf)
+	})
 }
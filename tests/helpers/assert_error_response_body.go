@@ -15,15 +15,19 @@ func AssertErrorResponseBody(t *testing.T, resp *http.Response, expected utils.E
 	if respBody, err := io.ReadAll(resp.Body); err != nil {
 		t.Fatalf("Read response body failed: %s", err.Error())
 	} else {
-		errRespBody := utils.ErrorResponseBody{}
+		problem := utils.ProblemResponseBody{}
 
-		if err := json.Unmarshal(respBody, &errRespBody); err != nil {
+		if err := json.Unmarshal(respBody, &problem); err != nil {
 			t.Fatalf("JSON unmarshal error response body failed: %s", err.Error())
 		}
 
-		require.Equal(t, expected.Message, errRespBody.Message)
-		require.Equal(t, expected.Detail, errRespBody.Detail)
-		require.Equal(t, expected.RequestBody, errRespBody.RequestBody)
-		require.Equal(t, expected.ClientOperation, errRespBody.ClientOperation)
+		require.Equal(t, utils.MIMEApplicationProblemJSON, resp.Header.Get("Content-Type"))
+		require.Equal(t, resp.StatusCode, problem.Status)
+		require.NotEmpty(t, problem.Code)
+		require.Equal(t, utils.ProblemTypePrefix + problem.Code, problem.Type)
+		require.Equal(t, expected.Message, problem.Title)
+		require.Equal(t, expected.Detail, problem.Detail)
+		require.Equal(t, utils.RedactRequestBody(expected.RequestBody), problem.RequestBody)
+		require.Equal(t, expected.ClientOperation, problem.ClientOperation)
 	}
 }
@@ -13,19 +13,28 @@ func CountUsers(t *testing.T, db *gorm.DB, userCount *int64) {
 }
 
 func CountVaults(t *testing.T, db *gorm.DB, vaultCount *int64) {
-	if result := db.Table("vaults").Count(vaultCount); result.Error != nil {
+	if result := db.Table("vaults").Where("deleted_at IS NULL").Count(vaultCount);
+	result.Error != nil {
 		t.Fatalf("Vault count failed: %s", result.Error.Error())
 	}
 }
 
 func CountEntries(t *testing.T, db *gorm.DB, entryCount *int64) {
-	if result := db.Table("entries").Count(entryCount); result.Error != nil {
+	if result := db.Table("entries").Where("deleted_at IS NULL").Count(entryCount);
+	result.Error != nil {
 		t.Fatalf("Entry count failed: %s", result.Error.Error())
 	}
 }
 
+func CountSecretVersions(t *testing.T, db *gorm.DB, versionCount *int64) {
+	if result := db.Table("secret_versions").Count(versionCount); result.Error != nil {
+		t.Fatalf("Secret version count failed: %s", result.Error.Error())
+	}
+}
+
 func CountSecrets(t *testing.T, db *gorm.DB, secretCount *int64) {
-	if result := db.Table("secrets").Count(secretCount); result.Error != nil {
+	if result := db.Table("secrets").Where("deleted_at IS NULL").Count(secretCount);
+	result.Error != nil {
 		t.Fatalf("Secret count failed: %s", result.Error.Error())
 	}
 }
@@ -0,0 +1,40 @@
+package helpers
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"testing"
+)
+
+// EncryptLegacy produces a ciphertext in the pre-envelope format, a bare hex
+// string of nonce||ciphertext.
+func EncryptLegacy(t *testing.T, plaintext, hexEncodedKey string) string {
+	key, err := hex.DecodeString(hexEncodedKey)
+
+	if err != nil {
+		t.Fatalf("Decode legacy key failed: %s", err.Error())
+	}
+
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		t.Fatalf("New legacy cipher failed: %s", err.Error())
+	}
+
+	gcm, err := cipher.NewGCM(block)
+
+	if err != nil {
+		t.Fatalf("New legacy GCM failed: %s", err.Error())
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		t.Fatalf("Read legacy nonce failed: %s", err.Error())
+	}
+
+	return hex.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil))
+}
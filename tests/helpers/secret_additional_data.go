@@ -0,0 +1,10 @@
+package helpers
+
+import (
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+func SecretAdditionalData(secret *models.Secret) []byte {
+	return utils.SecretAdditionalData(secret.Slug, secret.EntrySlug, secret.UserSlug)
+}
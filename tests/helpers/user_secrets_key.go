@@ -0,0 +1,44 @@
+package helpers
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+// UserSecretsKey returns the key the user's secrets are encrypted under, from the client
+// key and the user's data key, if the user has one.
+func UserSecretsKey(
+	t *testing.T, db *gorm.DB, conf *config.AppConfig, userSlug, clientKey string,
+) string {
+	var user models.User
+	QueryTestUser(t, db, &user, userSlug)
+
+	if user.DataKey == "" {
+		return clientKey
+	}
+
+	keys, err := utils.NewLocalKeyProvider(conf.VAULTS_KEK)
+
+	if err != nil {
+		t.Fatalf("Key provider failed: %s", err.Error())
+	}
+
+	dataKey, err := keys.UnwrapKey(user.DataKey, utils.DataKeyAdditionalData(userSlug))
+
+	if err != nil {
+		t.Fatalf("Data key unwrap failed: %s", err.Error())
+	}
+
+	key, err := utils.CombineKeys(clientKey, dataKey)
+
+	if err != nil {
+		t.Fatalf("Key combination failed: %s", err.Error())
+	}
+
+	return key
+}
@@ -13,13 +13,13 @@ import (
 func createTestSecrets(
 	users *[]models.User, vaults *[]models.Vault, entries *[]models.Entry, t *testing.T, db *gorm.DB,
 ) (secrets []models.Secret) {
-	encryptedStrings := encryptTestStrings(t)
+	testStrings := testSecretStrings()
 
 	secrets = []models.Secret{
 		{
 			Slug:      helpers.NewSlug(t),
 			Label:     "secret[_label='username']@0.0.0.0",
-			String:    encryptedStrings[0],
+			String:    testStrings[0],
 			EntrySlug: (*entries)[0].Slug,
 			VaultSlug: (*vaults)[0].Slug,
 			UserSlug:  (*users)[0].Slug,
@@ -28,7 +28,7 @@ func createTestSecrets(
 		{
 			Slug:      helpers.NewSlug(t),
 			Label:     "secret[_label='password']@0.0.0.1",
-			String:    encryptedStrings[1],
+			String:    testStrings[1],
 			EntrySlug: (*entries)[0].Slug,
 			VaultSlug: (*vaults)[0].Slug,
 			UserSlug:  (*users)[0].Slug,
@@ -37,7 +37,7 @@ func createTestSecrets(
 		{
 			Slug:      helpers.NewSlug(t),
 			Label:     "secret[_label='username']@0.0.1.0",
-			String:    encryptedStrings[2],
+			String:    testStrings[2],
 			EntrySlug: (*entries)[1].Slug,
 			VaultSlug: (*vaults)[0].Slug,
 			UserSlug:  (*users)[0].Slug,
@@ -46,7 +46,7 @@ func createTestSecrets(
 		{
 			Slug:      helpers.NewSlug(t),
 			Label:     "secret[_label='password']@0.0.1.1",
-			String:    encryptedStrings[3],
+			String:    testStrings[3],
 			EntrySlug: (*entries)[1].Slug,
 			VaultSlug: (*vaults)[0].Slug,
 			UserSlug:  (*users)[0].Slug,
@@ -55,7 +55,7 @@ func createTestSecrets(
 		{
 			Slug:      helpers.NewSlug(t),
 			Label:     "secret[_label='username']@0.1.0.0",
-			String:    encryptedStrings[4],
+			String:    testStrings[4],
 			EntrySlug: (*entries)[2].Slug,
 			VaultSlug: (*vaults)[1].Slug,
 			UserSlug:  (*users)[0].Slug,
@@ -64,7 +64,7 @@ func createTestSecrets(
 		{
 			Slug:      helpers.NewSlug(t),
 			Label:     "secret[_label='password']@0.1.0.1",
-			String:    encryptedStrings[5],
+			String:    testStrings[5],
 			EntrySlug: (*entries)[2].Slug,
 			VaultSlug: (*vaults)[1].Slug,
 			UserSlug:  (*users)[0].Slug,
@@ -73,7 +73,7 @@ func createTestSecrets(
 		{
 			Slug:      helpers.NewSlug(t),
 			Label:     "secret[_label='username']@0.1.1.0",
-			String:    encryptedStrings[6],
+			String:    testStrings[6],
 			EntrySlug: (*entries)[3].Slug,
 			VaultSlug: (*vaults)[1].Slug,
 			UserSlug:  (*users)[0].Slug,
@@ -82,7 +82,7 @@ func createTestSecrets(
 		{
 			Slug:      helpers.NewSlug(t),
 			Label:     "secret[_label='password']@0.1.1.1",
-			String:    encryptedStrings[7],
+			String:    testStrings[7],
 			EntrySlug: (*entries)[3].Slug,
 			VaultSlug: (*vaults)[1].Slug,
 			UserSlug:  (*users)[0].Slug,
@@ -91,7 +91,7 @@ func createTestSecrets(
 		{
 			Slug:      helpers.NewSlug(t),
 			Label:     "secret[_label='username']@1.0.0.0",
-			String:    encryptedStrings[8],
+			String:    testStrings[8],
 			EntrySlug: (*entries)[4].Slug,
 			VaultSlug: (*vaults)[2].Slug,
 			UserSlug:  (*users)[1].Slug,
@@ -100,7 +100,7 @@ func createTestSecrets(
 		{
 			Slug:      helpers.NewSlug(t),
 			Label:     "secret[_label='password']@1.0.0.1",
-			String:    encryptedStrings[9],
+			String:    testStrings[9],
 			EntrySlug: (*entries)[4].Slug,
 			VaultSlug: (*vaults)[2].Slug,
 			UserSlug:  (*users)[1].Slug,
@@ -109,7 +109,7 @@ func createTestSecrets(
 		{
 			Slug:      helpers.NewSlug(t),
 			Label:     "secret[_label='username']@1.0.1.0",
-			String:    encryptedStrings[10],
+			String:    testStrings[10],
 			EntrySlug: (*entries)[5].Slug,
 			VaultSlug: (*vaults)[2].Slug,
 			UserSlug:  (*users)[1].Slug,
@@ -118,7 +118,7 @@ func createTestSecrets(
 		{
 			Slug:      helpers.NewSlug(t),
 			Label:     "secret[_label='password']@1.0.1.1",
-			String:    encryptedStrings[11],
+			String:    testStrings[11],
 			EntrySlug: (*entries)[5].Slug,
 			VaultSlug: (*vaults)[2].Slug,
 			UserSlug:  (*users)[1].Slug,
@@ -127,7 +127,7 @@ func createTestSecrets(
 		{
 			Slug:      helpers.NewSlug(t),
 			Label:     "secret[_label='username']@1.1.0.0",
-			String:    encryptedStrings[12],
+			String:    testStrings[12],
 			EntrySlug: (*entries)[6].Slug,
 			VaultSlug: (*vaults)[3].Slug,
 			UserSlug:  (*users)[1].Slug,
@@ -136,7 +136,7 @@ func createTestSecrets(
 		{
 			Slug:      helpers.NewSlug(t),
 			Label:     "secret[_label='username']@1.1.1.0",
-			String:    encryptedStrings[13],
+			String:    testStrings[13],
 			EntrySlug: (*entries)[7].Slug,
 			VaultSlug: (*vaults)[3].Slug,
 			UserSlug:  (*users)[1].Slug,
@@ -145,7 +145,7 @@ func createTestSecrets(
 		{
 			Slug:      helpers.NewSlug(t),
 			Label:     "secret[_label='password']@1.1.1.1",
-			String:    encryptedStrings[14],
+			String:    testStrings[14],
 			EntrySlug: (*entries)[7].Slug,
 			VaultSlug: (*vaults)[3].Slug,
 			UserSlug:  (*users)[1].Slug,
@@ -154,7 +154,7 @@ func createTestSecrets(
 		{
 			Slug:      helpers.NewSlug(t),
 			Label:     "secret[_label='email']@1.1.1.2",
-			String:    encryptedStrings[15],
+			String:    testStrings[15],
 			EntrySlug: (*entries)[7].Slug,
 			VaultSlug: (*vaults)[3].Slug,
 			UserSlug:  (*users)[1].Slug,
@@ -163,7 +163,7 @@ func createTestSecrets(
 		{
 			Slug:      helpers.NewSlug(t),
 			Label:     "secret[_label='foo']@1.1.1.3",
-			String:    encryptedStrings[16],
+			String:    testStrings[16],
 			EntrySlug: (*entries)[7].Slug,
 			VaultSlug: (*vaults)[3].Slug,
 			UserSlug:  (*users)[1].Slug,
@@ -172,7 +172,7 @@ func createTestSecrets(
 		{
 			Slug:      helpers.NewSlug(t),
 			Label:     "secret[_label='bar']@1.1.1.4",
-			String:    encryptedStrings[17],
+			String:    testStrings[17],
 			EntrySlug: (*entries)[7].Slug,
 			VaultSlug: (*vaults)[3].Slug,
 			UserSlug:  (*users)[1].Slug,
@@ -181,7 +181,7 @@ func createTestSecrets(
 		{
 			Slug:      helpers.NewSlug(t),
 			Label:     "secret[_label='this']@1.1.1.5",
-			String:    encryptedStrings[18],
+			String:    testStrings[18],
 			EntrySlug: (*entries)[7].Slug,
 			VaultSlug: (*vaults)[3].Slug,
 			UserSlug:  (*users)[1].Slug,
@@ -190,7 +190,7 @@ func createTestSecrets(
 		{
 			Slug:      helpers.NewSlug(t),
 			Label:     "secret[_label='that']@1.1.1.6",
-			String:    encryptedStrings[19],
+			String:    testStrings[19],
 			EntrySlug: (*entries)[7].Slug,
 			VaultSlug: (*vaults)[3].Slug,
 			UserSlug:  (*users)[1].Slug,
@@ -198,6 +198,8 @@ func createTestSecrets(
 		},
 	}
 
+	encryptTestSecrets(t, secrets)
+
 	if result := db.Create(&secrets); result.Error != nil {
 		t.Fatalf("Create test secrets failed: %s", result.Error.Error())
 	}
@@ -205,8 +207,8 @@ func createTestSecrets(
 	return
 }
 
-func encryptTestStrings(t *testing.T) (encryptedStrings []string) {
-	testStrings := []string{
+func testSecretStrings() []string {
+	return []string{
 		"secret[_string='foodeater1234']@0.0.0.0",
 		"secret[_string='3a7!ng40oD']@0.0.0.1",
 		"secret[_string='foodeater1234']@0.0.1.0",
@@ -228,14 +230,16 @@ func encryptTestStrings(t *testing.T) (encryptedStrings []string) {
 		"secret[_string='thiseater']@1.1.1.5",
 		"secret[_string='thateater']@1.1.1.6",
 	}
+}
 
-	for _, str := range testStrings {
-		if encryptedString, err := utils.Encrypt(str, helpers.HexHash[:64]); err != nil {
+func encryptTestSecrets(t *testing.T, secrets []models.Secret) {
+	for i := range secrets {
+		if encryptedString, err := utils.Encrypt(
+			secrets[i].String, helpers.HexHash[:64], helpers.SecretAdditionalData(&secrets[i]),
+		); err != nil {
 			t.Fatal("Failed encryption: ", err.Error())
 		} else {
-			encryptedStrings = append(encryptedStrings, encryptedString)
+			secrets[i].String = encryptedString
 		}
 	}
-
-	return
 }
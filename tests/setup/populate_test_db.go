@@ -5,6 +5,7 @@ import (
 
 	"gorm.io/gorm"
 
+	"github.com/liobrdev/simplepasswords_vaults/database"
 	"github.com/liobrdev/simplepasswords_vaults/models"
 )
 
@@ -18,5 +19,13 @@ func populateTestDB(t *testing.T, db *gorm.DB) (
 	vaults = createTestVaults(&users, t, db)
 	entries = createTestEntries(&users, &vaults, t, db)
 	secrets = createTestSecrets(&users, &vaults, &entries, t, db)
+
+	// As if the records had been written by the handlers, which keep the manifest current.
+	for _, user := range users {
+		if err := database.RefreshIntegrity(db, user.Slug, database.IntegrityAll()); err != nil {
+			t.Fatalf("Refresh test integrity manifest failed: %s", err.Error())
+		}
+	}
+
 	return users, vaults, entries, secrets
 }
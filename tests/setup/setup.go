@@ -5,19 +5,15 @@ import (
 
 	"gorm.io/gorm"
 
+	"github.com/liobrdev/simplepasswords_vaults/database"
 	"github.com/liobrdev/simplepasswords_vaults/models"
 )
 
 func SetUp(t *testing.T, db *gorm.DB) {
 	TearDown(t, db)
 
-	if err := db.AutoMigrate(
-		&models.User{},
-		&models.Vault{},
-		&models.Entry{},
-		&models.Secret{},
-	); err != nil {
-		t.Fatalf("Failed database auto-migrate: %s", err)
+	if _, err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed database migration: %s", err)
 	}
 }
 
@@ -32,6 +28,46 @@ func SetUpWithData(t *testing.T, db *gorm.DB) (
 }
 
 func TearDown(t *testing.T, db *gorm.DB) {
+	if result := db.Exec("DROP TABLE IF EXISTS share_secrets"); result.Error != nil {
+		t.Fatalf("Test database tearDown failed: %s", result.Error.Error())
+	}
+
+	if result := db.Exec("DROP TABLE IF EXISTS shares"); result.Error != nil {
+		t.Fatalf("Test database tearDown failed: %s", result.Error.Error())
+	}
+
+	if result := db.Exec("DROP TABLE IF EXISTS vault_members"); result.Error != nil {
+		t.Fatalf("Test database tearDown failed: %s", result.Error.Error())
+	}
+
+	if result := db.Exec("DROP TABLE IF EXISTS auth_failures"); result.Error != nil {
+		t.Fatalf("Test database tearDown failed: %s", result.Error.Error())
+	}
+
+	if result := db.Exec("DROP TABLE IF EXISTS api_clients"); result.Error != nil {
+		t.Fatalf("Test database tearDown failed: %s", result.Error.Error())
+	}
+
+	if result := db.Exec("DROP TABLE IF EXISTS integrity_records"); result.Error != nil {
+		t.Fatalf("Test database tearDown failed: %s", result.Error.Error())
+	}
+
+	if result := db.Exec("DROP TABLE IF EXISTS integrity_roots"); result.Error != nil {
+		t.Fatalf("Test database tearDown failed: %s", result.Error.Error())
+	}
+
+	if result := db.Exec("DROP TABLE IF EXISTS audit_events"); result.Error != nil {
+		t.Fatalf("Test database tearDown failed: %s", result.Error.Error())
+	}
+
+	if result := db.Exec("DROP TABLE IF EXISTS schema_migrations"); result.Error != nil {
+		t.Fatalf("Test database tearDown failed: %s", result.Error.Error())
+	}
+
+	if result := db.Exec("DROP TABLE IF EXISTS secret_versions"); result.Error != nil {
+		t.Fatalf("Test database tearDown failed: %s", result.Error.Error())
+	}
+
 	if result := db.Exec("DROP TABLE IF EXISTS secrets"); result.Error != nil {
 		t.Fatalf("Test database tearDown failed: %s", result.Error.Error())
 	}
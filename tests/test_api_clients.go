@@ -0,0 +1,204 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/database"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/tests/helpers"
+	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+func testAPIClients(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
+	// The tests after these send the configured token, which works only without clients.
+	defer setup.SetUp(t, db)
+
+	t.Run("configured_token_until_first_client", func(t *testing.T) {
+		users, _, _, _ := setup.SetUpWithData(t, db)
+		slug := users[0].Slug
+
+		require.Equal(t, 200, newRequestListVaults(t, app, conf, slug, "").StatusCode)
+
+		otherConf := newTestAPIClient(t, db, conf, "reporting", models.ScopeRead)
+		require.Equal(t, 200, newRequestListVaults(t, app, otherConf, slug, "").StatusCode)
+
+		resp := newRequestListVaults(t, app, conf, slug, "")
+		require.Equal(t, 401, resp.StatusCode)
+		helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+			ClientOperation: utils.ListVaults,
+			Message:         utils.ErrorToken,
+			Detail:          "Unrecognized token.",
+		})
+
+		// Importing the configured token keeps its holder working, now as a client.
+		_, token, err := database.CreateAPIClient(
+			db, "gateway", conf.VAULTS_ACCESS_TOKEN, []string{models.ScopeRead},
+		)
+		require.NoError(t, err)
+		require.Equal(t, conf.VAULTS_ACCESS_TOKEN, token)
+		require.Equal(t, 200, newRequestListVaults(t, app, conf, slug, "").StatusCode)
+
+		var client models.APIClient
+		require.NoError(t, db.First(&client, "name = ?", "gateway").Error)
+		require.Equal(t, database.APIClientTokenHash(token), client.TokenHash)
+		require.NotContains(t, client.TokenHash, token)
+	})
+
+	t.Run("scopes_per_route_group_403_forbidden", func(t *testing.T) {
+		users, vaults, _, _ := setup.SetUpWithData(t, db)
+		slug := users[0].Slug
+		readConf := newTestAPIClient(t, db, conf, "reader", models.ScopeRead)
+		writeConf := newTestAPIClient(t, db, conf, "writer", models.ScopeWrite)
+		adminConf := newTestAPIClient(t, db, conf, "admin", models.ScopeAdmin)
+		vaultBody := `{"user_slug":"` + slug + `","vault_title":"Scoped"}`
+
+		for _, tc := range []struct {
+			resp      *http.Response
+			status    int
+			operation string
+			scope     string
+			body      string
+		}{
+			{newRequestListVaults(t, app, readConf, slug, ""), 200, "", "", ""},
+			{newRequestRetrieveVault(t, app, readConf, slug, vaults[0].Slug, ""), 200, "", "", ""},
+			{newRequestSearch(t, app, readConf, slug, "?q=vault"), 200, "", "", ""},
+			{
+				newRequestCreateVault(t, app, readConf, slug, vaultBody),
+				403, utils.CreateVault, models.ScopeWrite, vaultBody,
+			},
+			{
+				newRequestDeleteVault(t, app, readConf, slug, vaults[0].Slug),
+				403, utils.DeleteVault, models.ScopeWrite, "",
+			},
+			{
+				newRequestVerifyIntegrity(t, app, readConf, slug, slug),
+				403, utils.VerifyIntegrity, models.ScopeAdmin, "",
+			},
+			{newRequestCreateVault(t, app, writeConf, slug, vaultBody), 204, "", "", ""},
+			{
+				newRequestListVaults(t, app, writeConf, slug, ""),
+				403, utils.ListVaults, models.ScopeRead, "",
+			},
+			{
+				newRequestSearch(t, app, writeConf, slug, "?q=vault"),
+				403, utils.Search, models.ScopeRead, "",
+			},
+			{newRequestVerifyIntegrity(t, app, adminConf, slug, slug), 200, "", "", ""},
+			{
+				newRequestRetrieveVault(t, app, adminConf, slug, vaults[0].Slug, ""),
+				403, utils.RetrieveVault, models.ScopeRead, "",
+			},
+		} {
+			require.Equal(t, tc.status, tc.resp.StatusCode)
+
+			if tc.status == 403 {
+				helpers.AssertErrorResponseBody(t, tc.resp, utils.ErrorResponseBody{
+					ClientOperation: tc.operation,
+					Message:         utils.ErrorTokenScope,
+					Detail:          tc.scope,
+					RequestBody:     tc.body,
+				})
+			}
+		}
+	})
+
+	t.Run("last_used_recorded_200_ok", func(t *testing.T) {
+		users, _, _, _ := setup.SetUpWithData(t, db)
+		clientConf := newTestAPIClient(t, db, conf, "reader", models.ScopeRead)
+
+		var client models.APIClient
+		require.NoError(t, db.First(&client, "name = ?", "reader").Error)
+		require.Nil(t, client.LastUsedAt)
+
+		require.Equal(t, 200, newRequestListVaults(t, app, clientConf, users[0].Slug, "").StatusCode)
+		require.NoError(t, db.First(&client, "name = ?", "reader").Error)
+		require.NotNil(t, client.LastUsedAt)
+		require.WithinDuration(t, time.Now(), *client.LastUsedAt, time.Minute)
+
+		// Within the minute, a later request isn't another write.
+		lastUsed := *client.LastUsedAt
+		require.Equal(t, 200, newRequestListVaults(t, app, clientConf, users[0].Slug, "").StatusCode)
+		require.NoError(t, db.First(&client, "name = ?", "reader").Error)
+		require.True(t, lastUsed.Equal(*client.LastUsedAt))
+	})
+
+	t.Run("revoked_401_unauthorized", func(t *testing.T) {
+		users, _, _, _ := setup.SetUpWithData(t, db)
+		slug := users[0].Slug
+		oldConf := newTestAPIClient(t, db, conf, "gateway", models.ScopeRead)
+		newConf := newTestAPIClient(t, db, conf, "gateway-2", models.ScopeRead)
+
+		// Both tokens work while the gateway switches over, then the old one is revoked.
+		require.Equal(t, 200, newRequestListVaults(t, app, oldConf, slug, "").StatusCode)
+		require.Equal(t, 200, newRequestListVaults(t, app, newConf, slug, "").StatusCode)
+		require.NoError(t, database.RevokeAPIClient(db, "gateway"))
+
+		resp := newRequestListVaults(t, app, oldConf, slug, "")
+		require.Equal(t, 401, resp.StatusCode)
+		helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+			ClientOperation: utils.ListVaults,
+			Message:         utils.ErrorToken,
+			Detail:          "Unrecognized token.",
+		})
+		require.Equal(t, 200, newRequestListVaults(t, app, newConf, slug, "").StatusCode)
+
+		// The configured token isn't accepted again once every client is revoked.
+		require.NoError(t, database.RevokeAPIClient(db, "gateway-2"))
+		require.Equal(t, 401, newRequestListVaults(t, app, conf, slug, "").StatusCode)
+
+		require.Error(t, database.RevokeAPIClient(db, "gateway"))
+		require.Error(t, database.RevokeAPIClient(db, "unknown"))
+	})
+
+	t.Run("invalid_clients", func(t *testing.T) {
+		setup.SetUp(t, db)
+		token := helpers.HexHash[:80]
+		_, _, err := database.CreateAPIClient(db, "gateway", token, []string{models.ScopeRead})
+		require.NoError(t, err)
+
+		for _, tc := range []struct {
+			name, token string
+			scopes      []string
+		}{
+			{"", "", []string{models.ScopeRead}},
+			{"has space", "", []string{models.ScopeRead}},
+			{"reader", "", nil},
+			{"reader", "", []string{"root"}},
+			{"reader", helpers.HexHash[:79], []string{models.ScopeRead}},
+			{"reader", helpers.HexHash[:79] + "!", []string{models.ScopeRead}},
+			{"gateway", "", []string{models.ScopeRead}},
+			{"reader", token, []string{models.ScopeRead}},
+		} {
+			_, _, err := database.CreateAPIClient(db, tc.name, tc.token, tc.scopes)
+			require.Error(t, err, tc.name)
+		}
+
+		var count int64
+		require.NoError(t, db.Model(&models.APIClient{}).Count(&count).Error)
+		require.Equal(t, int64(1), count)
+	})
+}
+
+// newTestAPIClient registers a client, and returns a copy of conf whose access token is
+// the client's, for the request helpers to send.
+func newTestAPIClient(
+	t *testing.T, db *gorm.DB, conf *config.AppConfig, name string, scopes ...string,
+) *config.AppConfig {
+	client, token, err := database.CreateAPIClient(db, name, "", scopes)
+	require.NoError(t, err)
+	require.Len(t, token, 80)
+	require.Equal(t, name, client.Name)
+
+	clientConf := *conf
+	clientConf.VAULTS_ACCESS_TOKEN = token
+
+	return &clientConf
+}
@@ -0,0 +1,186 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	vaultsApp "github.com/liobrdev/simplepasswords_vaults/app"
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/routes"
+	"github.com/liobrdev/simplepasswords_vaults/tests/helpers"
+	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+func testAuthFailures(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
+	validHeader := "Token " + conf.VAULTS_ACCESS_TOKEN
+	invalidHeader := "Token " + helpers.HexHash[:80]
+
+	t.Run("limit_reached_429_too_many_requests", func(t *testing.T) {
+		setup.SetUp(t, db)
+		throttledApp := newTestThrottledApp(db, conf, 3, 60)
+
+		for i := 0; i < 3; i++ {
+			require.Equal(t, 401, newRequestAuthorizeRequest(t, throttledApp, invalidHeader).StatusCode)
+		}
+
+		// Once refused, even the right token is, until the window ends.
+		for _, authHeader := range []string{invalidHeader, validHeader, "Token null"} {
+			resp := newRequestAuthorizeRequest(t, throttledApp, authHeader)
+			require.Equal(t, 429, resp.StatusCode)
+
+			retryAfter, err := strconv.Atoi(resp.Header.Get(fiber.HeaderRetryAfter))
+			require.NoError(t, err)
+			require.True(t, retryAfter > 0 && retryAfter <= 60, retryAfter)
+
+			helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+				ClientOperation: utils.TestAuthReq,
+				Message:         utils.ErrorAuthFailures,
+				Detail:          "Retry after " + strconv.Itoa(retryAfter) + " second(s).",
+			})
+		}
+
+		// Apps without a limit, like the one the rest of the suite runs on, refuse no one.
+		testAuthorizeRequestSuccess(t, app, validHeader)
+	})
+
+	t.Run("shared_across_processes_429_too_many_requests", func(t *testing.T) {
+		setup.SetUp(t, db)
+
+		// Other apps stand in for other prefork processes, or the same one after a restart.
+		throttledApp := newTestThrottledApp(db, conf, 3, 60)
+		otherApp := newTestThrottledApp(db, conf, 3, 60)
+
+		for i := 0; i < 2; i++ {
+			require.Equal(t, 401, newRequestAuthorizeRequest(t, throttledApp, invalidHeader).StatusCode)
+		}
+
+		require.Equal(t, 401, newRequestAuthorizeRequest(t, otherApp, invalidHeader).StatusCode)
+		require.Equal(t, 429, newRequestAuthorizeRequest(t, throttledApp, validHeader).StatusCode)
+
+		restartedApp := newTestThrottledApp(db, conf, 3, 60)
+		require.Equal(t, 429, newRequestAuthorizeRequest(t, restartedApp, validHeader).StatusCode)
+	})
+
+	t.Run("malformed_headers_counted_429_too_many_requests", func(t *testing.T) {
+		setup.SetUp(t, db)
+		throttledApp := newTestThrottledApp(db, conf, 2, 60)
+		require.Equal(t, 401, newRequestAuthorizeRequest(t, throttledApp, "").StatusCode)
+		require.Equal(t, 401, newRequestAuthorizeRequest(t, throttledApp, "Token ").StatusCode)
+		require.Equal(t, 429, newRequestAuthorizeRequest(t, throttledApp, validHeader).StatusCode)
+	})
+
+	t.Run("successes_not_counted_204_no_content", func(t *testing.T) {
+		setup.SetUp(t, db)
+		throttledApp := newTestThrottledApp(db, conf, 3, 60)
+
+		for i := 0; i < 5; i++ {
+			testAuthorizeRequestSuccess(t, throttledApp, validHeader)
+		}
+
+		for i := 0; i < 2; i++ {
+			require.Equal(t, 401, newRequestAuthorizeRequest(t, throttledApp, invalidHeader).StatusCode)
+		}
+
+		testAuthorizeRequestSuccess(t, throttledApp, validHeader)
+	})
+
+	t.Run("window_ended_204_no_content", func(t *testing.T) {
+		setup.SetUp(t, db)
+		throttledApp := newTestThrottledApp(db, conf, 1, 1)
+		require.Equal(t, 401, newRequestAuthorizeRequest(t, throttledApp, invalidHeader).StatusCode)
+
+		resp := newRequestAuthorizeRequest(t, throttledApp, validHeader)
+		require.Equal(t, 429, resp.StatusCode)
+		require.Equal(t, "1", resp.Header.Get(fiber.HeaderRetryAfter))
+
+		time.Sleep(1100 * time.Millisecond)
+		testAuthorizeRequestSuccess(t, throttledApp, validHeader)
+	})
+
+	t.Run("trusted_proxy_clients_counted_apart_429_too_many_requests", func(t *testing.T) {
+		setup.SetUp(t, db)
+		proxyConf := *conf
+		proxyConf.PROXY_HEADER = "X-Real-IP"
+		proxyConf.TRUSTED_PROXIES = "0.0.0.0"
+		throttledApp := newTestThrottledApp(db, &proxyConf, 2, 60)
+
+		for i := 0; i < 2; i++ {
+			require.Equal(t, 401, newRequestAuthorizeRequestFrom(
+				t, throttledApp, invalidHeader, "10.0.0.1",
+			).StatusCode)
+		}
+
+		require.Equal(t, 429, newRequestAuthorizeRequestFrom(
+			t, throttledApp, validHeader, "10.0.0.1",
+		).StatusCode)
+		require.Equal(t, 204, newRequestAuthorizeRequestFrom(
+			t, throttledApp, validHeader, "10.0.0.2",
+		).StatusCode)
+	})
+
+	t.Run("untrusted_proxy_header_ignored_429_too_many_requests", func(t *testing.T) {
+		setup.SetUp(t, db)
+		proxyConf := *conf
+		proxyConf.PROXY_HEADER = "X-Real-IP"
+		proxyConf.TRUSTED_PROXIES = "10.0.0.254"
+		throttledApp := newTestThrottledApp(db, &proxyConf, 2, 60)
+
+		for i := 0; i < 2; i++ {
+			require.Equal(t, 401, newRequestAuthorizeRequestFrom(
+				t, throttledApp, invalidHeader, "10.0.0." + strconv.Itoa(i + 1),
+			).StatusCode)
+		}
+
+		require.Equal(t, 429, newRequestAuthorizeRequestFrom(
+			t, throttledApp, validHeader, "10.0.0.3",
+		).StatusCode)
+	})
+
+	t.Run("no_limit_401_unauthorized", func(t *testing.T) {
+		setup.SetUp(t, db)
+		throttledApp := newTestThrottledApp(db, conf, 0, 60)
+
+		for i := 0; i < 10; i++ {
+			require.Equal(t, 401, newRequestAuthorizeRequest(t, throttledApp, invalidHeader).StatusCode)
+		}
+	})
+}
+
+func newTestThrottledApp(
+	db *gorm.DB, conf *config.AppConfig, limit, windowSeconds int,
+) *fiber.App {
+	throttledConf := *conf
+	throttledConf.AUTH_FAILURE_LIMIT = limit
+	throttledConf.AUTH_FAILURE_WINDOW_SECONDS = windowSeconds
+	throttledApp := vaultsApp.CreateApp(&throttledConf)
+	routes.Register(throttledApp, db, &throttledConf)
+
+	return throttledApp
+}
+
+// newRequestAuthorizeRequestFrom sends the request as the proxy would, on behalf of `ip`.
+func newRequestAuthorizeRequestFrom(
+	t *testing.T, app *fiber.App, authHeader, ip string,
+) *http.Response {
+	req := httptest.NewRequest("GET", "/api/restricted", nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Client-Operation", utils.TestAuthReq)
+	req.Header.Set("Authorization", authHeader)
+	req.Header.Set("X-Real-IP", ip)
+
+	resp, err := app.Test(req, -1)
+
+	if err != nil {
+		t.Fatalf("Send test request failed: %s", err.Error())
+	}
+
+	return resp
+}
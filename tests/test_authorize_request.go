@@ -22,49 +22,63 @@ func testAuthorizeRequest(t *testing.T, app *fiber.App, conf *config.AppConfig)
 		t.Fatalf("Generate dummyToken failed: %s", err.Error())
 	}
 
+	malformed := "Malformed `Authorization` header."
+
 	t.Run("null_empty_auth_header_401_unauthorized", func(t *testing.T) {
-		testAuthorizeRequestClientError(t, app, 401, utils.ErrorToken, "", "")
-		testAuthorizeRequestClientError(t, app, 401, utils.ErrorToken, "null", "null")
+		testAuthorizeRequestClientError(t, app, 401, utils.ErrorToken, malformed, "")
+		testAuthorizeRequestClientError(t, app, 401, utils.ErrorToken, malformed, "null")
 	})
 
 	t.Run("null_empty_token_401_unauthorized", func(t *testing.T) {
-		testAuthorizeRequestClientError(t, app, 401, utils.ErrorToken, "Token null", "Token null")
-		testAuthorizeRequestClientError(t, app, 401, utils.ErrorToken, "token null", "token null")
-		testAuthorizeRequestClientError(t, app, 401, utils.ErrorToken, "Token", "Token ")
-		testAuthorizeRequestClientError(t, app, 401, utils.ErrorToken, "token", "token ")
+		testAuthorizeRequestClientError(t, app, 401, utils.ErrorToken, malformed, "Token null")
+		testAuthorizeRequestClientError(t, app, 401, utils.ErrorToken, malformed, "token null")
+		testAuthorizeRequestClientError(t, app, 401, utils.ErrorToken, malformed, "Token ")
+		testAuthorizeRequestClientError(t, app, 401, utils.ErrorToken, malformed, "token ")
 	})
 
 	t.Run("invalid_token_regexp_401_unauthorized", func(t *testing.T) {
 		testAuthorizeRequestClientError(
-			t, app, 401, utils.ErrorToken, "T0ken " + dummyToken, "T0ken " + dummyToken,
+			t, app, 401, utils.ErrorToken, malformed, "T0ken " + dummyToken,
 		)
 
 		testAuthorizeRequestClientError(
-			t, app, 401, utils.ErrorToken, "Bearer " + dummyToken, "Bearer " + dummyToken,
+			t, app, 401, utils.ErrorToken, malformed, "Bearer " + dummyToken,
 		)
 
-		testAuthorizeRequestClientError(t, app, 401, utils.ErrorToken, dummyToken, dummyToken)
+		testAuthorizeRequestClientError(t, app, 401, utils.ErrorToken, malformed, dummyToken)
 
 		testAuthorizeRequestClientError(
-			t, app, 401, utils.ErrorToken, "Token " + dummyToken[:79], "Token " + dummyToken[:79],
+			t, app, 401, utils.ErrorToken, malformed, "Token " + dummyToken[:79],
 		)
 
 		testAuthorizeRequestClientError(
-			t, app, 401, utils.ErrorToken, "Token a " + dummyToken[2:80], "Token a " + dummyToken[2:80],
+			t, app, 401, utils.ErrorToken, malformed, "Token a " + dummyToken[2:80],
 		)
 
 		testAuthorizeRequestClientError(
-			t, app, 401, utils.ErrorToken,
-			"Token " + dummyToken[:79] + "!", "Token " + dummyToken[:79] + "!",
+			t, app, 401, utils.ErrorToken, malformed, "Token " + dummyToken[:79] + "!",
 		)
 	})
 
 	t.Run("valid_token_no_match_401_unauthorized", func(t *testing.T) {
 		testAuthorizeRequestClientError(
-			t, app, 401, utils.ErrorToken, dummyToken, "Token " + dummyToken,
+			t, app, 401, utils.ErrorToken, "Unrecognized token.", "Token " + dummyToken,
 		)
 	})
 
+	t.Run("token_not_echoed_401_unauthorized", func(t *testing.T) {
+		for _, authHeader := range []string{"Token " + dummyToken, "Bearer " + dummyToken} {
+			resp := newRequestAuthorizeRequest(t, app, authHeader)
+			require.Equal(t, 401, resp.StatusCode)
+
+			if respBody, err := io.ReadAll(resp.Body); err != nil {
+				t.Fatalf("Read response body failed: %s", err.Error())
+			} else {
+				require.NotContains(t, string(respBody), dummyToken)
+			}
+		}
+	})
+
 	t.Run("valid_token_204_no_content", func(t *testing.T) {
 		testAuthorizeRequestSuccess(t, app, "Token " + conf.VAULTS_ACCESS_TOKEN)
 	})
@@ -0,0 +1,183 @@
+package tests
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/database"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
+)
+
+type testBackupSnapshot struct {
+	Users    []models.User
+	Vaults   []models.Vault
+	Entries  []models.Entry
+	Secrets  []models.Secret
+	Versions []models.SecretVersion
+	Events   []models.AuditEvent
+	Records  []models.IntegrityRecord
+	Roots    []models.IntegrityRoot
+	Clients  []models.APIClient
+}
+
+func testBackup(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
+	t.Run("backup_and_restore_round_trip", func(t *testing.T) {
+		_, vaults, _, secrets := setup.SetUpWithData(t, db)
+		createTestSecretVersion(t, app, db, conf, &secrets[0])
+
+		resp := newRequestDeleteVault(t, app, conf, vaults[1].UserSlug, vaults[1].Slug)
+		require.Equal(t, 204, resp.StatusCode)
+
+		// Clients are registered last, or the access token would no longer be accepted.
+		_, _, err := database.CreateAPIClient(db, "gateway", "", []string{models.ScopeRead})
+		require.NoError(t, err)
+
+		before := takeTestBackupSnapshot(t, db)
+		archive := testBackupArchive(t, db)
+
+		setup.SetUp(t, db)
+		manifest, err := database.Restore(db, bytes.NewReader(archive))
+		require.NoError(t, err)
+
+		rows := map[string]int64{}
+
+		for _, table := range manifest.Tables {
+			rows[table.Name] = table.Rows
+		}
+
+		require.Equal(t, map[string]int64{
+			"users": 2, "vaults": 4, "vault_members": 0, "entries": 8, "secrets": 20,
+			"secret_versions": 1, "shares": 0, "share_secrets": 0, "audit_events": 2,
+			"integrity_records": 32, "integrity_roots": 2, "api_clients": 1,
+		}, rows)
+		require.Equal(t, before, takeTestBackupSnapshot(t, db))
+
+		// The trashed vault comes back still trashed.
+		trashed := 0
+
+		for _, vault := range before.Vaults {
+			if vault.DeletedAt.Valid {
+				trashed++
+			}
+		}
+
+		require.Equal(t, 1, trashed)
+	})
+
+	t.Run("restore_into_non_empty_database_fails", func(t *testing.T) {
+		setup.SetUpWithData(t, db)
+		archive := testBackupArchive(t, db)
+
+		_, err := database.Restore(db, bytes.NewReader(archive))
+		require.ErrorIs(t, err, database.ErrRestoreNotEmpty)
+	})
+
+	t.Run("restore_tampered_archive_fails", func(t *testing.T) {
+		setup.SetUpWithData(t, db)
+		archive := rewriteTestBackupArchive(t, testBackupArchive(t, db), func(data []byte) []byte {
+			return bytes.Replace(data, []byte("vault@0.0.*.*"), []byte("vault@0.0.*.X"), 1)
+		})
+
+		setup.SetUp(t, db)
+		_, err := database.Restore(db, bytes.NewReader(archive))
+		require.ErrorIs(t, err, database.ErrRestoreChecksum)
+		requireEmptyTestDatabase(t, db)
+	})
+
+	t.Run("restore_other_schema_version_fails", func(t *testing.T) {
+		setup.SetUpWithData(t, db)
+		archive := rewriteTestBackupArchive(t, testBackupArchive(t, db), func(data []byte) []byte {
+			return bytes.Replace(data, []byte(`"schema_version": `), []byte(`"schema_version":9`), 1)
+		})
+
+		setup.SetUp(t, db)
+		_, err := database.Restore(db, bytes.NewReader(archive))
+		require.ErrorIs(t, err, database.ErrRestoreSchema)
+		requireEmptyTestDatabase(t, db)
+	})
+
+	t.Run("restore_truncated_archive_fails", func(t *testing.T) {
+		setup.SetUpWithData(t, db)
+		archive := rewriteTestBackupArchive(t, testBackupArchive(t, db), func(data []byte) []byte {
+			return data[:len(data) / 2]
+		})
+
+		setup.SetUp(t, db)
+		_, err := database.Restore(db, bytes.NewReader(archive))
+		require.Error(t, err)
+		requireEmptyTestDatabase(t, db)
+	})
+}
+
+func testBackupArchive(t *testing.T, db *gorm.DB) []byte {
+	var archive bytes.Buffer
+
+	if _, err := database.Backup(db, &archive); err != nil {
+		t.Fatalf("Backup failed: %s", err.Error())
+	}
+
+	return archive.Bytes()
+}
+
+// rewriteTestBackupArchive applies `rewrite` to the archive's uncompressed tar stream.
+// Rewrites that keep the length leave the tar headers valid.
+func rewriteTestBackupArchive(t *testing.T, archive []byte, rewrite func([]byte) []byte) []byte {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	require.NoError(t, err)
+
+	data, err := io.ReadAll(gz)
+	require.NoError(t, err)
+
+	// Make sure the archive really is a tar before rewriting it.
+	_, err = tar.NewReader(bytes.NewReader(data)).Next()
+	require.NoError(t, err)
+
+	var rewritten bytes.Buffer
+	w := gzip.NewWriter(&rewritten)
+	_, err = w.Write(rewrite(data))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	return rewritten.Bytes()
+}
+
+func takeTestBackupSnapshot(t *testing.T, db *gorm.DB) (snapshot testBackupSnapshot) {
+	for _, dest := range []interface{}{
+		&snapshot.Users, &snapshot.Vaults, &snapshot.Entries, &snapshot.Secrets, &snapshot.Versions,
+		&snapshot.Events, &snapshot.Clients,
+	} {
+		if result := db.Unscoped().Order("slug").Find(dest); result.Error != nil {
+			t.Fatalf("Snapshot query failed: %s", result.Error.Error())
+		}
+	}
+
+	if result := db.Order("kind").Order("slug").Find(&snapshot.Records); result.Error != nil {
+		t.Fatalf("Snapshot query failed: %s", result.Error.Error())
+	} else if result := db.Order("user_slug").Find(&snapshot.Roots); result.Error != nil {
+		t.Fatalf("Snapshot query failed: %s", result.Error.Error())
+	}
+
+	return
+}
+
+func requireEmptyTestDatabase(t *testing.T, db *gorm.DB) {
+	snapshot := takeTestBackupSnapshot(t, db)
+	require.Empty(t, snapshot.Users)
+	require.Empty(t, snapshot.Vaults)
+	require.Empty(t, snapshot.Entries)
+	require.Empty(t, snapshot.Secrets)
+	require.Empty(t, snapshot.Versions)
+	require.Empty(t, snapshot.Events)
+	require.Empty(t, snapshot.Records)
+	require.Empty(t, snapshot.Roots)
+	require.Empty(t, snapshot.Clients)
+}
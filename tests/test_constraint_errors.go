@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/database"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/tests/helpers"
+	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
+)
+
+func testConstraintErrors(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
+	t.Run("sqlite_violations", func(t *testing.T) {
+		users, vaults, _, _ := setup.SetUpWithData(t, db)
+
+		err := db.Create(&models.User{Slug: users[0].Slug}).Error
+		requireTestConstraintError(
+			t, err, database.ConstraintUnique, "users", "UNIQUE constraint failed: users.slug",
+		)
+
+		vault := vaults[1]
+		vault.Slug = helpers.NewSlug(t)
+		vault.Title = vaults[0].Title
+		err = db.Create(&vault).Error
+		requireTestConstraintError(
+			t, err, database.ConstraintUnique, "vaults",
+			"UNIQUE constraint failed: vaults.title, vaults.user_slug",
+		)
+
+		err = db.Exec(
+			"INSERT INTO users (slug, created_at, updated_at) VALUES (?, ?, NULL)",
+			helpers.NewSlug(t), time.Now(),
+		).Error
+		requireTestConstraintError(
+			t, err, database.ConstraintNotNull, "users",
+			"NOT NULL constraint failed: users.updated_at",
+		)
+
+		// SQLite only enforces foreign keys when asked to, one connection at a time.
+		require.NoError(t, db.Connection(func(tx *gorm.DB) error {
+			require.NoError(t, tx.Exec("PRAGMA foreign_keys = ON").Error)
+			defer tx.Exec("PRAGMA foreign_keys = OFF")
+
+			vault.Slug = helpers.NewSlug(t)
+			vault.Title = "Orphan"
+			vault.UserSlug = helpers.NewSlug(t)
+			err := fmt.Errorf("wrapped: %w", tx.Create(&vault).Error)
+			requireTestConstraintError(
+				t, err, database.ConstraintForeignKey, "", "FOREIGN KEY constraint failed",
+			)
+
+			return nil
+		}))
+	})
+
+	t.Run("postgres_violations", func(t *testing.T) {
+		for _, tc := range []struct {
+			pgErr             pgconn.PgError
+			kind, table, text string
+		}{
+			{
+				pgconn.PgError{
+					Code: "23505", TableName: "vaults", ConstraintName: "unique_title_user_slug",
+					Detail: "Key (title, user_slug)=(Vault, abc) already exists.",
+				},
+				database.ConstraintUnique, "vaults",
+				"UNIQUE constraint failed: vaults.title, vaults.user_slug",
+			},
+			{
+				pgconn.PgError{
+					Code: "23503", TableName: "entries", ConstraintName: "fk_vaults_entries",
+					Detail: `Key (vault_slug)=(abc) is not present in table "vaults".`,
+				},
+				database.ConstraintForeignKey, "entries",
+				"FOREIGN KEY constraint failed: entries.vault_slug",
+			},
+			{
+				pgconn.PgError{Code: "23502", TableName: "secrets", ColumnName: "label"},
+				database.ConstraintNotNull, "secrets", "NOT NULL constraint failed: secrets.label",
+			},
+		} {
+			pgErr := tc.pgErr
+			err := fmt.Errorf("wrapped: %w", &pgErr)
+			requireTestConstraintError(t, err, tc.kind, tc.table, tc.text)
+
+			// Nothing of the values involved is repeated.
+			require.NotContains(t, database.ClassifyConstraintError(err).Error(), "abc")
+		}
+	})
+
+	t.Run("other_errors", func(t *testing.T) {
+		for _, err := range []error{
+			nil,
+			gorm.ErrRecordNotFound,
+			errors.New("database is locked"),
+			&pgconn.PgError{Code: "40001", Message: "could not serialize access"},
+		} {
+			require.Nil(t, database.ClassifyConstraintError(err))
+		}
+	})
+}
+
+func requireTestConstraintError(t *testing.T, err error, kind, table, text string) {
+	require.Error(t, err)
+
+	constraintErr := database.ClassifyConstraintError(err)
+	require.NotNil(t, constraintErr, err.Error())
+	require.Equal(t, kind, constraintErr.Kind)
+	require.Equal(t, table, constraintErr.Table)
+	require.Equal(t, text, constraintErr.Error())
+	require.ErrorIs(t, constraintErr, err)
+}
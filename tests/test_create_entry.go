@@ -21,30 +21,31 @@ import (
 
 func testCreateEntry(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
 	bodyFmt := `{"user_slug":"%s","vault_slug":"%s","entry_title":"%s","secrets":%s}`
+	userSlug := helpers.NewSlug(t)
 
 	t.Run("empty_body_400_bad_request", func(t *testing.T) {
 		testCreateEntryClientError(
 			t, app, conf, 400, utils.ErrorParse,
-			"invalid character '\x00' looking for beginning of value", "",
+			"invalid character '\x00' looking for beginning of value", userSlug, "",
 		)
 	})
 
 	t.Run("array_body_400_bad_request", func(t *testing.T) {
 		testCreateEntryClientError(
 			t, app, conf, 400, utils.ErrorParse,
-			"invalid character '[' looking for beginning of value", "[]",
+			"invalid character '[' looking for beginning of value", userSlug, "[]",
 		)
 
 		testCreateEntryClientError(
 			t, app, conf, 400, utils.ErrorParse,
-			"invalid character '[' looking for beginning of value", "[{}]",
+			"invalid character '[' looking for beginning of value", userSlug, "[{}]",
 		)
 
 		testCreateEntryClientError(
 			t, app, conf, 400, utils.ErrorParse,
-			"invalid character '[' looking for beginning of value", fmt.Sprintf(
+			"invalid character '[' looking for beginning of value", userSlug, fmt.Sprintf(
 				`[{"user_slug":"%s","vault_slug":"%s","entry_title":"%s","secrets":[]}]`,
-				helpers.NewSlug(t), helpers.NewSlug(t), "entry@0.0.2.*",
+				userSlug, helpers.NewSlug(t), "entry@0.0.2.*",
 			),
 		)
 	})
@@ -52,33 +53,34 @@ func testCreateEntry(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 	t.Run("boolean_body_400_bad_request", func(t *testing.T) {
 		testCreateEntryClientError(
 			t, app, conf, 400, utils.ErrorParse,
-			"invalid character 't' looking for beginning of value", "true",
+			"invalid character 't' looking for beginning of value", userSlug, "true",
 		)
 
 		testCreateEntryClientError(
 			t, app, conf, 400, utils.ErrorParse,
-			"invalid character 'f' looking for beginning of value", "false",
+			"invalid character 'f' looking for beginning of value", userSlug, "false",
 		)
 	})
 
 	t.Run("string_body_400_bad_request", func(t *testing.T) {
 		testCreateEntryClientError(
 			t, app, conf, 400, utils.ErrorParse,
-			`invalid character '"' looking for beginning of value`, `"Valid JSON, but not an object."`,
+			`invalid character '"' looking for beginning of value`, userSlug,
+			`"Valid JSON, but not an object."`,
 		)
 	})
 
 	t.Run("null_body_400_bad_request", func(t *testing.T) {
-		testCreateEntryClientError(t, app, conf, 400, utils.ErrorUserSlug, "", "null")
+		testCreateEntryClientError(t, app, conf, 400, utils.ErrorUserSlug, "", userSlug, "null")
 	})
 
 	t.Run("empty_object_body_400_bad_request", func(t *testing.T) {
-		testCreateEntryClientError(t, app, conf, 400, utils.ErrorUserSlug, "", "{}")
+		testCreateEntryClientError(t, app, conf, 400, utils.ErrorUserSlug, "", userSlug, "{}")
 	})
 
 	t.Run("missing_user_slug_400_bad_request", func(t *testing.T) {
 		testCreateEntryClientError(
-			t, app, conf, 400, utils.ErrorUserSlug, "", fmt.Sprintf(
+			t, app, conf, 400, utils.ErrorUserSlug, "", userSlug, fmt.Sprintf(
 				`{"userr_slug":"%s","vault_slug":"%s","entry_title":"%s","secrets":[]}`,
 				"Spelled wrong!", helpers.NewSlug(t), "entry@0.0.2.*",
 			),
@@ -87,34 +89,34 @@ func testCreateEntry(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 
 	t.Run("missing_vault_slug_400_bad_request", func(t *testing.T) {
 		testCreateEntryClientError(
-			t, app, conf, 400, utils.ErrorVaultSlug, "", fmt.Sprintf(
+			t, app, conf, 400, utils.ErrorVaultSlug, "", userSlug, fmt.Sprintf(
 				`{"user_slug":"%s","vualt_slug":"%s","entry_title":"%s","secrets":[]}`,
-				helpers.NewSlug(t), "Spelled wrong!", "entry@0.0.2.*",
+				userSlug, "Spelled wrong!", "entry@0.0.2.*",
 			),
 		)
 	})
 
 	t.Run("missing_entry_title_400_bad_request", func(t *testing.T) {
 		testCreateEntryClientError(
-			t, app, conf, 400, utils.ErrorEntryTitle, "", fmt.Sprintf(
+			t, app, conf, 400, utils.ErrorEntryTitle, "", userSlug, fmt.Sprintf(
 				`{"user_slug":"%s","vault_slug":"%s","enrty_title":"%s","secrets":[]}`,
-				helpers.NewSlug(t), helpers.NewSlug(t), "Spelled wrong!",
+				userSlug, helpers.NewSlug(t), "Spelled wrong!",
 			),
 		)
 	})
 
 	t.Run("missing_secrets_400_bad_request", func(t *testing.T) {
 		testCreateEntryClientError(
-			t, app, conf, 400, utils.ErrorSecrets, "", fmt.Sprintf(
+			t, app, conf, 400, utils.ErrorSecrets, "", userSlug, fmt.Sprintf(
 				`{"user_slug":"%s","vault_slug":"%s","entry_title":"%s","secerts":[]}`,
-				helpers.NewSlug(t), helpers.NewSlug(t), "entry@0.0.2.*",
+				userSlug, helpers.NewSlug(t), "entry@0.0.2.*",
 			),
 		)
 	})
 
 	t.Run("null_user_slug_400_bad_request", func(t *testing.T) {
 		testCreateEntryClientError(
-			t, app, conf, 400, utils.ErrorUserSlug, "", fmt.Sprintf(
+			t, app, conf, 400, utils.ErrorUserSlug, "", userSlug, fmt.Sprintf(
 				`{"user_slug":%s,"vault_slug":"%s","entry_title":"%s","secrets":[]}`,
 				"null", helpers.NewSlug(t), "entry@0.0.2.*",
 			),
@@ -123,18 +125,18 @@ func testCreateEntry(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 
 	t.Run("null_vault_slug_400_bad_request", func(t *testing.T) {
 		testCreateEntryClientError(
-			t, app, conf, 400, utils.ErrorVaultSlug, "", fmt.Sprintf(
+			t, app, conf, 400, utils.ErrorVaultSlug, "", userSlug, fmt.Sprintf(
 				`{"user_slug":"%s","vault_slug":%s,"entry_title":"%s","secrets":[]}`,
-				helpers.NewSlug(t), "null", "entry@0.0.2.*",
+				userSlug, "null", "entry@0.0.2.*",
 			),
 		)
 	})
 
 	t.Run("null_entry_title_400_bad_request", func(t *testing.T) {
 		testCreateEntryClientError(
-			t, app, conf, 400, utils.ErrorEntryTitle, "", fmt.Sprintf(
+			t, app, conf, 400, utils.ErrorEntryTitle, "", userSlug, fmt.Sprintf(
 				`{"user_slug":"%s","vault_slug":"%s","entry_title":%s,"secrets":[]}`,
-				helpers.NewSlug(t), helpers.NewSlug(t), "null",
+				userSlug, helpers.NewSlug(t), "null",
 			),
 		)
 	})
@@ -142,28 +144,41 @@ func testCreateEntry(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 	t.Run("null_secrets_400_bad_request", func(t *testing.T) {
 		testCreateEntryClientError(
 			t, app, conf, 400, utils.ErrorSecrets, "",
-			fmt.Sprintf(bodyFmt, helpers.NewSlug(t), helpers.NewSlug(t), "entry@0.0.2.*", "null"),
+			userSlug, fmt.Sprintf(bodyFmt, userSlug, helpers.NewSlug(t), "entry@0.0.2.*", "null"),
 		)
 	})
 
 	t.Run("empty_user_slug_400_bad_request", func(t *testing.T) {
 		testCreateEntryClientError(
 			t, app, conf, 400, utils.ErrorUserSlug, "",
-			fmt.Sprintf(bodyFmt, "", helpers.NewSlug(t), "entry@0.0.2.*", "[]"),
+			userSlug, fmt.Sprintf(bodyFmt, "", helpers.NewSlug(t), "entry@0.0.2.*", "[]"),
 		)
 	})
 
+	t.Run("other_users_slug_400_bad_request", func(t *testing.T) {
+		users, vaults, _, _ := setup.SetUpWithData(t, db)
+
+		testCreateEntryClientError(
+			t, app, conf, 400, utils.ErrorUserSlug, utils.ErrorUserSlugHeader, users[1].Slug,
+			fmt.Sprintf(bodyFmt, users[0].Slug, vaults[0].Slug, "entry@0.0.2.*", "[]"),
+		)
+
+		var entryCount int64
+		helpers.CountEntries(t, db, &entryCount)
+		require.EqualValues(t, 8, entryCount)
+	})
+
 	t.Run("empty_vault_slug_400_bad_request", func(t *testing.T) {
 		testCreateEntryClientError(
 			t, app, conf, 400, utils.ErrorVaultSlug, "",
-			fmt.Sprintf(bodyFmt, helpers.NewSlug(t), "", "entry@0.0.2.*", "[]"),
+			userSlug, fmt.Sprintf(bodyFmt, userSlug, "", "entry@0.0.2.*", "[]"),
 		)
 	})
 
 	t.Run("empty_entry_title_400_bad_request", func(t *testing.T) {
 		testCreateEntryClientError(
 			t, app, conf, 400, utils.ErrorEntryTitle, "",
-			fmt.Sprintf(bodyFmt, helpers.NewSlug(t), helpers.NewSlug(t), "", "[]"),
+			userSlug, fmt.Sprintf(bodyFmt, userSlug, helpers.NewSlug(t), "", "[]"),
 		)
 	})
 
@@ -173,7 +188,7 @@ func testCreateEntry(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 		} else {
 			testCreateEntryClientError(
 				t, app, conf, 400, utils.ErrorEntryTitle, "Too long",
-				fmt.Sprintf(bodyFmt, helpers.NewSlug(t), helpers.NewSlug(t), title, "[]"),
+				userSlug, fmt.Sprintf(bodyFmt, userSlug, helpers.NewSlug(t), title, "[]"),
 			)
 		}
 	})
@@ -187,7 +202,7 @@ func testCreateEntry(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 
 		testCreateEntryClientError(
 			t, app, conf, 400, utils.ErrorItemSecrets, "secrets[1].Label; len(secrets) == 2",
-			fmt.Sprintf(bodyFmt, helpers.NewSlug(t), helpers.NewSlug(t), "entry@0.0.2.*", secretsStr),
+			userSlug, fmt.Sprintf(bodyFmt, userSlug, helpers.NewSlug(t), "entry@0.0.2.*", secretsStr),
 		)
 	})
 
@@ -203,7 +218,7 @@ func testCreateEntry(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 
 		testCreateEntryClientError(
 			t, app, conf, 400, utils.ErrorItemSecrets, "secrets[1].Label; len(secrets) == 2",
-			fmt.Sprintf(bodyFmt, helpers.NewSlug(t), helpers.NewSlug(t), "entry@0.0.2.*", secretsStr),
+			userSlug, fmt.Sprintf(bodyFmt, userSlug, helpers.NewSlug(t), "entry@0.0.2.*", secretsStr),
 		)
 	})
 
@@ -219,7 +234,7 @@ func testCreateEntry(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 
 		testCreateEntryClientError(
 			t, app, conf, 400, utils.ErrorItemSecrets, "secrets[1].String; len(secrets) == 2",
-			fmt.Sprintf(bodyFmt, helpers.NewSlug(t), helpers.NewSlug(t), "entry@0.0.2.*", secretsStr),
+			userSlug, fmt.Sprintf(bodyFmt, userSlug, helpers.NewSlug(t), "entry@0.0.2.*", secretsStr),
 		)
 	})
 
@@ -236,7 +251,7 @@ func testCreateEntry(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 
 		testCreateEntryClientError(
 			t, app, conf, 400, utils.ErrorItemSecrets, "secrets[1].Label; len(secrets) == 2",
-			fmt.Sprintf(bodyFmt, helpers.NewSlug(t), helpers.NewSlug(t), "entry@0.0.2.*", secretsStr),
+			userSlug, fmt.Sprintf(bodyFmt, userSlug, helpers.NewSlug(t), "entry@0.0.2.*", secretsStr),
 		)
 	})
 
@@ -253,7 +268,7 @@ func testCreateEntry(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 
 		testCreateEntryClientError(
 			t, app, conf, 400, utils.ErrorItemSecrets, "secrets[1].String; len(secrets) == 2",
-			fmt.Sprintf(bodyFmt, helpers.NewSlug(t), helpers.NewSlug(t), "entry@0.0.2.*", secretsStr),
+			userSlug, fmt.Sprintf(bodyFmt, userSlug, helpers.NewSlug(t), "entry@0.0.2.*", secretsStr),
 		)
 	})
 
@@ -273,7 +288,7 @@ func testCreateEntry(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 
 			testCreateEntryClientError(
 				t, app, conf, 400, utils.ErrorItemSecrets, "secrets[1].Label; len(secrets) == 2",
-				fmt.Sprintf(bodyFmt, helpers.NewSlug(t), helpers.NewSlug(t), "entry@0.0.2.*", secretsStr),
+				userSlug, fmt.Sprintf(bodyFmt, userSlug, helpers.NewSlug(t), "entry@0.0.2.*", secretsStr),
 			)
 		}
 	})
@@ -294,12 +309,42 @@ func testCreateEntry(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 
 			testCreateEntryClientError(
 				t, app, conf, 400, utils.ErrorItemSecrets, "secrets[1].String; len(secrets) == 2",
-				fmt.Sprintf(bodyFmt, helpers.NewSlug(t), helpers.NewSlug(t), "entry@0.0.2.*", secretsStr),
+				userSlug, fmt.Sprintf(bodyFmt, userSlug, helpers.NewSlug(t), "entry@0.0.2.*", secretsStr),
 			)
 		}
 	})
 
-	t.Run("valid_body_entry_title_already_exists_500_error", func(t *testing.T) {
+	t.Run("vault_slug_404_not_found", func(t *testing.T) {
+		users, _, _, _ := setup.SetUpWithData(t, db)
+		userSlug := users[0].Slug
+		vaultSlug := helpers.NewSlug(t)
+
+		testCreateEntryClientError(
+			t, app, conf, 404, utils.ErrorNotFound, vaultSlug, userSlug,
+			fmt.Sprintf(bodyFmt, userSlug, vaultSlug, "entry@0.0.2.*", "[]"),
+		)
+
+		var entryCount int64
+		helpers.CountEntries(t, db, &entryCount)
+		require.EqualValues(t, 8, entryCount)
+	})
+
+	t.Run("other_users_vault_slug_404_not_found", func(t *testing.T) {
+		users, vaults, _, _ := setup.SetUpWithData(t, db)
+		userSlug := users[0].Slug
+		vaultSlug := vaults[2].Slug
+
+		testCreateEntryClientError(
+			t, app, conf, 404, utils.ErrorNotFound, vaultSlug, userSlug,
+			fmt.Sprintf(bodyFmt, userSlug, vaultSlug, "entry@0.0.2.*", "[]"),
+		)
+
+		var entryCount int64
+		helpers.CountEntries(t, db, &entryCount)
+		require.EqualValues(t, 8, entryCount)
+	})
+
+	t.Run("valid_body_entry_title_already_exists_409_conflict", func(t *testing.T) {
 		users, vaults, _, _ := setup.SetUpWithData(t, db)
 		userSlug := users[0].Slug
 		vaultSlug := vaults[0].Slug
@@ -318,8 +363,8 @@ func testCreateEntry(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 		body := fmt.Sprintf(bodyFmt, userSlug, vaultSlug, entryTitle, secretsStr)
 
 		testCreateEntryClientError(
-			t, app, conf, 500, utils.ErrorFailedDB,
-			"UNIQUE constraint failed: entries.title, entries.vault_slug", body,
+			t, app, conf, 409, utils.ErrorDuplicateEntry,
+			"UNIQUE constraint failed: entries.title, entries.vault_slug", userSlug, body,
 		)
 	})
 
@@ -337,11 +382,11 @@ func testCreateEntry(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 			`}]`
 
 		body := fmt.Sprintf(
-			bodyFmt, helpers.NewSlug(t), helpers.NewSlug(t), "entry@0.0.2.*", secretsStr,
+			bodyFmt, userSlug, helpers.NewSlug(t), "entry@0.0.2.*", secretsStr,
 		)
 
 		testCreateEntryClientError(
-			t, app, conf, 400, utils.ErrorDuplicateSecretsLabel, secretLabel, body,
+			t, app, conf, 400, utils.ErrorDuplicateSecretsLabel, secretLabel, userSlug, body,
 		)
 	})
 
@@ -357,11 +402,11 @@ func testCreateEntry(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 			`}]`
 
 		body := fmt.Sprintf(
-			bodyFmt, helpers.NewSlug(t), helpers.NewSlug(t), "entry@0.0.2.*", secretsStr,
+			bodyFmt, userSlug, helpers.NewSlug(t), "entry@0.0.2.*", secretsStr,
 		)
 
 		testCreateEntryClientError(
-			t, app, conf, 400, utils.ErrorDuplicateSecretsPriority, "", body,
+			t, app, conf, 400, utils.ErrorDuplicateSecretsPriority, "", userSlug, body,
 		)
 	})
 
@@ -439,9 +484,9 @@ func testCreateEntry(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 
 func testCreateEntryClientError(
 	t *testing.T, app *fiber.App, conf *config.AppConfig, expectedStatus int,
-	expectedMessage, expectedDetail, body string,
+	expectedMessage, expectedDetail, userSlug, body string,
 ) {
-	resp := newRequestCreateEntry(t, app, conf, body)
+	resp := newRequestCreateEntry(t, app, conf, userSlug, body)
 	require.Equal(t, expectedStatus, resp.StatusCode)
 	helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
 		ClientOperation: utils.CreateEntry,
@@ -459,7 +504,7 @@ func testCreateEntrySuccess(
 	require.EqualValues(t, 8, entryCount)
 	require.EqualValues(t, 20, secretCount)
 
-	resp := newRequestCreateEntry(t, app, conf, body)
+	resp := newRequestCreateEntry(t, app, conf, userSlug, body)
 	require.Equal(t, 204, resp.StatusCode)
 
 	if respBody, err := io.ReadAll(resp.Body); err != nil {
@@ -478,7 +523,11 @@ func testCreateEntrySuccess(
 	var secrets []models.Secret
 	helpers.QueryTestSecretsByEntry(t, db, &secrets, entry.Slug)
 
-	if plaintext, err := utils.Decrypt(secrets[0].String, helpers.HexHash[:64]); err != nil {
+	key := helpers.UserSecretsKey(t, db, conf, userSlug, helpers.HexHash[:64])
+
+	if plaintext, err := utils.Decrypt(
+		secrets[0].String, key, helpers.SecretAdditionalData(&secrets[0]),
+	); err != nil {
 		t.Fatalf("Password decryption failed: %s", err.Error())
 	} else {
 		require.Equal(t, "secret[_string='foodeater1234']@0.0.2.0", plaintext)
@@ -486,7 +535,9 @@ func testCreateEntrySuccess(
 		require.EqualValues(t, 0, secrets[0].Priority)
 	}
 
-	if plaintext, err := utils.Decrypt(secrets[1].String, helpers.HexHash[:64]); err != nil {
+	if plaintext, err := utils.Decrypt(
+		secrets[1].String, key, helpers.SecretAdditionalData(&secrets[1]),
+	); err != nil {
 		t.Fatalf("Password decryption failed: %s", err.Error())
 	} else {
 		require.Equal(t, "secret[_string='3a7!ng40oD']@0.0.2.1", plaintext)
@@ -501,7 +552,7 @@ func testCreateEntrySuccess(
 }
 
 func newRequestCreateEntry(
-	t *testing.T, app *fiber.App, conf *config.AppConfig, body string,
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, body string,
 ) *http.Response {
 
 	reqBody := strings.NewReader(body)
@@ -509,6 +560,7 @@ func newRequestCreateEntry(
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Client-Operation", utils.CreateEntry)
 	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+	req.Header.Set("User-Slug", userSlug)
 	req.Header.Set(conf.PASSWORD_HEADER_KEY, helpers.HexHash[:64])
 
 	resp, err := app.Test(req, -1)
@@ -33,57 +33,57 @@ func testCreateSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.Ap
 	t.Run("empty_body_400_bad_request", func(t *testing.T) {
 		testCreateSecretClientError(
 			t, app, conf, 400, utils.ErrorParse,
-			"invalid character '\x00' looking for beginning of value", "",
+			"invalid character '\x00' looking for beginning of value", dummySlug, "",
 		)
 	})
 
 	t.Run("array_body_400_bad_request", func(t *testing.T) {
 		testCreateSecretClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character '[' looking for beginning of value",
-			"[]",
+			dummySlug, "[]",
 		)
 
 		testCreateSecretClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character '[' looking for beginning of value",
-			"[{}]",
+			dummySlug, "[{}]",
 		)
 
 		testCreateSecretClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character '[' looking for beginning of value",
-			fmt.Sprintf("[" + bodyFmt + "]", dummySlug, dummySlug, dummySlug, "abc", "123"),
+			dummySlug, fmt.Sprintf("[" + bodyFmt + "]", dummySlug, dummySlug, dummySlug, "abc", "123"),
 		)
 	})
 
 	t.Run("boolean_body_400_bad_request", func(t *testing.T) {
 		testCreateSecretClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character 't' looking for beginning of value",
-			"true",
+			dummySlug, "true",
 		)
 
 		testCreateSecretClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character 'f' looking for beginning of value",
-			"false",
+			dummySlug, "false",
 		)
 	})
 
 	t.Run("string_body_400_bad_request", func(t *testing.T) {
 		testCreateSecretClientError(
 			t, app, conf, 400, utils.ErrorParse, `invalid character '"' looking for beginning of value`,
-			`"Valid JSON, but not an object."`,
+			dummySlug, `"Valid JSON, but not an object."`,
 		)
 	})
 
 	t.Run("null_body_400_bad_request", func(t *testing.T) {
-		testCreateSecretClientError(t, app, conf, 400, utils.ErrorUserSlug, "", "null")
+		testCreateSecretClientError(t, app, conf, 400, utils.ErrorUserSlug, "", dummySlug, "null")
 	})
 
 	t.Run("empty_object_body_400_bad_request", func(t *testing.T) {
-		testCreateSecretClientError(t, app, conf, 400, utils.ErrorUserSlug, "", "{}")
+		testCreateSecretClientError(t, app, conf, 400, utils.ErrorUserSlug, "", dummySlug, "{}")
 	})
 
 	t.Run("missing_user_slug_400_bad_request", func(t *testing.T) {
 		testCreateSecretClientError(
-			t, app, conf, 400, utils.ErrorUserSlug, "", fmt.Sprintf(
+			t, app, conf, 400, utils.ErrorUserSlug, "", dummySlug, fmt.Sprintf(
 				`{` +
 					`"usr_slug":"Spelled wrong!",` +
 					`"vault_slug":"%s",` +
@@ -98,7 +98,7 @@ func testCreateSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.Ap
 
 	t.Run("missing_vault_slug_400_bad_request", func(t *testing.T) {
 		testCreateSecretClientError(
-			t, app, conf, 400, utils.ErrorVaultSlug, "", fmt.Sprintf(
+			t, app, conf, 400, utils.ErrorVaultSlug, "", dummySlug, fmt.Sprintf(
 				`{` +
 					`"user_slug":"%s",` +
 					`"vualt_slug":"Spelled wrong!",` +
@@ -113,7 +113,7 @@ func testCreateSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.Ap
 
 	t.Run("missing_entry_slug_400_bad_request", func(t *testing.T) {
 		testCreateSecretClientError(
-			t, app, conf, 400, utils.ErrorEntrySlug, "", fmt.Sprintf(
+			t, app, conf, 400, utils.ErrorEntrySlug, "", dummySlug, fmt.Sprintf(
 				`{` +
 					`"user_slug":"%s",` +
 					`"vault_slug":"%s",` +
@@ -128,7 +128,7 @@ func testCreateSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.Ap
 
 	t.Run("missing_secret_label_400_bad_request", func(t *testing.T) {
 		testCreateSecretClientError(
-			t, app, conf, 400, utils.ErrorSecretLabel, "", fmt.Sprintf(
+			t, app, conf, 400, utils.ErrorSecretLabel, "", dummySlug, fmt.Sprintf(
 				`{` +
 					`"user_slug":"%s",` +
 					`"vault_slug":"%s",` +
@@ -143,7 +143,7 @@ func testCreateSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.Ap
 
 	t.Run("missing_secret_string_400_bad_request", func(t *testing.T) {
 		testCreateSecretClientError(
-			t, app, conf, 400, utils.ErrorSecretString, "", fmt.Sprintf(
+			t, app, conf, 400, utils.ErrorSecretString, "", dummySlug, fmt.Sprintf(
 				`{` +
 					`"user_slug":"%s",` +
 					`"vault_slug":"%s",` +
@@ -158,7 +158,7 @@ func testCreateSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.Ap
 
 	t.Run("null_user_slug_400_bad_request", func(t *testing.T) {
 		testCreateSecretClientError(
-			t, app, conf, 400, utils.ErrorUserSlug, "", fmt.Sprintf(
+			t, app, conf, 400, utils.ErrorUserSlug, "", dummySlug, fmt.Sprintf(
 				`{` +
 					`"user_slug":null,` +
 					`"vault_slug":"%s",` +
@@ -173,7 +173,7 @@ func testCreateSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.Ap
 
 	t.Run("null_vault_slug_400_bad_request", func(t *testing.T) {
 		testCreateSecretClientError(
-			t, app, conf, 400, utils.ErrorVaultSlug, "", fmt.Sprintf(
+			t, app, conf, 400, utils.ErrorVaultSlug, "", dummySlug, fmt.Sprintf(
 				`{` +
 					`"user_slug":"%s",` +
 					`"vault_slug":null,` +
@@ -188,7 +188,7 @@ func testCreateSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.Ap
 
 	t.Run("null_entry_slug_400_bad_request", func(t *testing.T) {
 		testCreateSecretClientError(
-			t, app, conf, 400, utils.ErrorEntrySlug, "", fmt.Sprintf(
+			t, app, conf, 400, utils.ErrorEntrySlug, "", dummySlug, fmt.Sprintf(
 				`{` +
 					`"user_slug":"%s",` +
 					`"vault_slug":"%s",` +
@@ -203,7 +203,7 @@ func testCreateSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.Ap
 
 	t.Run("null_secret_label_400_bad_request", func(t *testing.T) {
 		testCreateSecretClientError(
-			t, app, conf, 400, utils.ErrorSecretLabel, "", fmt.Sprintf(
+			t, app, conf, 400, utils.ErrorSecretLabel, "", dummySlug, fmt.Sprintf(
 				`{` +
 					`"user_slug":"%s",` +
 					`"vault_slug":"%s",` +
@@ -218,7 +218,7 @@ func testCreateSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.Ap
 
 	t.Run("null_secret_string_400_bad_request", func(t *testing.T) {
 		testCreateSecretClientError(
-			t, app, conf, 400, utils.ErrorSecretString, "", fmt.Sprintf(
+			t, app, conf, 400, utils.ErrorSecretString, "", dummySlug, fmt.Sprintf(
 				`{` +
 					`"user_slug":"%s",` +
 					`"vault_slug":"%s",` +
@@ -234,35 +234,48 @@ func testCreateSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.Ap
 	t.Run("empty_user_slug_400_bad_request", func(t *testing.T) {
 		testCreateSecretClientError(
 			t, app, conf, 400, utils.ErrorUserSlug, "",
-			fmt.Sprintf(bodyFmt, "", dummySlug, dummySlug, "abc", "123"),
+			dummySlug, fmt.Sprintf(bodyFmt, "", dummySlug, dummySlug, "abc", "123"),
 		)
 	})
 
+	t.Run("other_users_slug_400_bad_request", func(t *testing.T) {
+		users, vaults, entries, _ := setup.SetUpWithData(t, db)
+
+		testCreateSecretClientError(
+			t, app, conf, 400, utils.ErrorUserSlug, utils.ErrorUserSlugHeader, users[1].Slug,
+			fmt.Sprintf(bodyFmt, users[0].Slug, vaults[0].Slug, entries[0].Slug, "abc", "123"),
+		)
+
+		var secretCount int64
+		helpers.CountSecrets(t, db, &secretCount)
+		require.EqualValues(t, 20, secretCount)
+	})
+
 	t.Run("empty_vault_slug_400_bad_request", func(t *testing.T) {
 		testCreateSecretClientError(
 			t, app, conf, 400, utils.ErrorVaultSlug, "",
-			fmt.Sprintf(bodyFmt, dummySlug, "", dummySlug, "abc", "123"),
+			dummySlug, fmt.Sprintf(bodyFmt, dummySlug, "", dummySlug, "abc", "123"),
 		)
 	})
 
 	t.Run("empty_entry_slug_400_bad_request", func(t *testing.T) {
 		testCreateSecretClientError(
 			t, app, conf, 400, utils.ErrorEntrySlug, "",
-			fmt.Sprintf(bodyFmt, dummySlug, dummySlug, "", "abc", "123"),
+			dummySlug, fmt.Sprintf(bodyFmt, dummySlug, dummySlug, "", "abc", "123"),
 		)
 	})
 
 	t.Run("empty_secret_label_400_bad_request", func(t *testing.T) {
 		testCreateSecretClientError(
 			t, app, conf, 400, utils.ErrorSecretLabel, "",
-			fmt.Sprintf(bodyFmt, dummySlug, dummySlug, dummySlug, "", "123"),
+			dummySlug, fmt.Sprintf(bodyFmt, dummySlug, dummySlug, dummySlug, "", "123"),
 		)
 	})
 
 	t.Run("empty_secret_string_400_bad_request", func(t *testing.T) {
 		testCreateSecretClientError(
 			t, app, conf, 400, utils.ErrorSecretString, "",
-			fmt.Sprintf(bodyFmt, dummySlug, dummySlug, dummySlug, "abc", ""),
+			dummySlug, fmt.Sprintf(bodyFmt, dummySlug, dummySlug, dummySlug, "abc", ""),
 		)
 	})
 
@@ -272,7 +285,7 @@ func testCreateSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.Ap
 		} else {
 			testCreateSecretClientError(
 				t, app, conf, 400, utils.ErrorSecretLabel, "Too long",
-				fmt.Sprintf(bodyFmt, dummySlug, dummySlug, dummySlug, label, "123"),
+				dummySlug, fmt.Sprintf(bodyFmt, dummySlug, dummySlug, dummySlug, label, "123"),
 			)
 		}
 	})
@@ -283,21 +296,62 @@ func testCreateSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.Ap
 		} else {
 			testCreateSecretClientError(
 				t, app, conf, 400, utils.ErrorSecretString, "Too long",
-				fmt.Sprintf(bodyFmt, dummySlug, dummySlug, dummySlug, "abc", str),
+				dummySlug, fmt.Sprintf(bodyFmt, dummySlug, dummySlug, dummySlug, "abc", str),
 			)
 		}
 	})
 
-	t.Run("valid_body_secret_label_already_exists_500_error", func(t *testing.T) {
+	t.Run("entry_slug_404_not_found", func(t *testing.T) {
+		users, vaults, _, _ := setup.SetUpWithData(t, db)
+		entrySlug := helpers.NewSlug(t)
+
+		testCreateSecretClientError(
+			t, app, conf, 404, utils.ErrorNotFound, entrySlug, users[0].Slug,
+			fmt.Sprintf(bodyFmt, users[0].Slug, vaults[0].Slug, entrySlug, "abc", "123"),
+		)
+
+		var secretCount int64
+		helpers.CountSecrets(t, db, &secretCount)
+		require.EqualValues(t, 20, secretCount)
+	})
+
+	t.Run("other_users_entry_slug_404_not_found", func(t *testing.T) {
+		users, vaults, entries, _ := setup.SetUpWithData(t, db)
+		entrySlug := entries[4].Slug
+
+		testCreateSecretClientError(
+			t, app, conf, 404, utils.ErrorNotFound, entrySlug, users[0].Slug,
+			fmt.Sprintf(bodyFmt, users[0].Slug, vaults[2].Slug, entrySlug, "abc", "123"),
+		)
+
+		var secretCount int64
+		helpers.CountSecrets(t, db, &secretCount)
+		require.EqualValues(t, 20, secretCount)
+	})
+
+	t.Run("vault_slug_not_entry_vault_400_bad_request", func(t *testing.T) {
+		users, vaults, entries, _ := setup.SetUpWithData(t, db)
+
+		testCreateSecretClientError(
+			t, app, conf, 400, utils.ErrorVaultSlug, utils.ErrorEntryVaultSlug, users[0].Slug,
+			fmt.Sprintf(bodyFmt, users[0].Slug, vaults[0].Slug, entries[3].Slug, "abc", "123"),
+		)
+
+		var secretCount int64
+		helpers.CountSecrets(t, db, &secretCount)
+		require.EqualValues(t, 20, secretCount)
+	})
+
+	t.Run("valid_body_secret_label_already_exists_409_conflict", func(t *testing.T) {
 		users, vaults, entries, secrets := setup.SetUpWithData(t, db)
 		userSlug := users[0].Slug
 		vaultSlug := vaults[1].Slug
 		entrySlug := entries[3].Slug
 
 		testCreateSecretClientError(
-			t, app, conf, 500, utils.ErrorFailedDB,
+			t, app, conf, 409, utils.ErrorDuplicateSecret,
 			"UNIQUE constraint failed: secrets.label, secrets.entry_slug",
-			fmt.Sprintf(bodyFmt, userSlug, vaultSlug, entrySlug, secrets[7].Label, "123"),
+			userSlug, fmt.Sprintf(bodyFmt, userSlug, vaultSlug, entrySlug, secrets[7].Label, "123"),
 		)
 	})
 
@@ -310,7 +364,7 @@ func testCreateSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.Ap
 		secretString := "secret[_string='food.eater@email.dev']@0.1.1.2"
 
 		testCreateSecretSuccess(
-			t, app, db, conf, 2, secretLabel, secretString,
+			t, app, db, conf, 2, userSlug, secretLabel, secretString,
 			fmt.Sprintf(bodyFmt, userSlug, vaultSlug, entrySlug, secretLabel, secretString),
 		)
 	})
@@ -336,15 +390,17 @@ func testCreateSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.Ap
 			userSlug, vaultSlug, entrySlug, secretLabel, secretString,
 		)
 
-		testCreateSecretSuccess(t, app, db, conf, 2, secretLabel, secretString, validBodyIrrelevantData)
+		testCreateSecretSuccess(
+			t, app, db, conf, 2, userSlug, secretLabel, secretString, validBodyIrrelevantData,
+		)
 	})
 }
 
 func testCreateSecretClientError(
 	t *testing.T, app *fiber.App, conf *config.AppConfig, expectedStatus int,
-	expectedMessage, expectedDetail, body string,
+	expectedMessage, expectedDetail, userSlug, body string,
 ) {
-	resp := newRequestCreateSecret(t, app, conf, body)
+	resp := newRequestCreateSecret(t, app, conf, userSlug, body)
 	require.Equal(t, expectedStatus, resp.StatusCode)
 	helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
 		ClientOperation: utils.CreateSecret,
@@ -356,13 +412,13 @@ func testCreateSecretClientError(
 
 func testCreateSecretSuccess(
 	t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig,
-	secretPriority uint8, secretLabel, secretString, body string,
+	secretPriority uint8, userSlug, secretLabel, secretString, body string,
 ) {
 	var secretCount int64
 	helpers.CountSecrets(t, db, &secretCount)
 	require.EqualValues(t, 20, secretCount)
 
-	resp := newRequestCreateSecret(t, app, conf, body)
+	resp := newRequestCreateSecret(t, app, conf, userSlug, body)
 	require.Equal(t, 204, resp.StatusCode)
 
 	if respBody, err := io.ReadAll(resp.Body); err != nil {
@@ -374,8 +430,10 @@ func testCreateSecretSuccess(
 	var secret models.Secret
 	helpers.QueryTestSecretByLabel(t, db, &secret, secretLabel)
 
-	if plaintext, err := utils.Decrypt(secret.String, helpers.HexHash[:64]);
-	err != nil {
+	if plaintext, err := utils.Decrypt(
+		secret.String, helpers.UserSecretsKey(t, db, conf, secret.UserSlug, helpers.HexHash[:64]),
+		helpers.SecretAdditionalData(&secret),
+	); err != nil {
 		t.Fatalf("Password decryption failed: %s", err.Error())
 	} else {
 		require.Equal(t, secretString, plaintext)
@@ -389,7 +447,7 @@ func testCreateSecretSuccess(
 }
 
 func newRequestCreateSecret(
-	t *testing.T, app *fiber.App, conf *config.AppConfig, body string,
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, body string,
 ) *http.Response {
 
 	reqBody := strings.NewReader(body)
@@ -397,6 +455,7 @@ func newRequestCreateSecret(
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Client-Operation", utils.CreateSecret)
 	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+	req.Header.Set("User-Slug", userSlug)
 	req.Header.Set(conf.PASSWORD_HEADER_KEY, helpers.HexHash[:64])
 
 	resp, err := app.Test(req, -1)
@@ -112,9 +112,37 @@ func testCreateUser(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppC
 		)
 	})
 
+	t.Run("invalid_password_400_bad_request", func(t *testing.T) {
+		setup.SetUp(t, db)
+		body := fmt.Sprintf(bodyFmt, helpers.NewSlug(t))
+
+		for password, detail := range map[string]string{
+			helpers.VALID_PW[:7]: "Too short",
+			strings.Repeat("a", utils.MaxPasswordSize + 1): "Too long",
+		} {
+			resp := newRequestCreateUser(t, app, conf, body, password)
+			require.Equal(t, 400, resp.StatusCode)
+			helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+				ClientOperation: utils.CreateUser,
+				Message:         utils.ErrorPassword,
+				Detail:          detail,
+				RequestBody:     body,
+			})
+		}
+
+		var userCount int64
+		helpers.CountUsers(t, db, &userCount)
+		require.EqualValues(t, 0, userCount)
+	})
+
 	t.Run("valid_body_204_no_content", func(t *testing.T) {
 		slug := helpers.NewSlug(t)
-		testCreateUserSuccess(t, app, db, conf, slug, fmt.Sprintf(bodyFmt, slug))
+		testCreateUserSuccess(t, app, db, conf, slug, fmt.Sprintf(bodyFmt, slug), "")
+	})
+
+	t.Run("valid_body_password_204_no_content", func(t *testing.T) {
+		slug := helpers.NewSlug(t)
+		testCreateUserSuccess(t, app, db, conf, slug, fmt.Sprintf(bodyFmt, slug), helpers.VALID_PW)
 	})
 
 	t.Run("valid_body_irrelevant_data_204_no_content", func(t *testing.T) {
@@ -126,7 +154,7 @@ func testCreateUser(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppC
 			`"user_created_at":"10/12/22"` +
 			`}`
 
-		testCreateUserSuccess(t, app, db, conf, slug, validBodyIrrelevantData)
+		testCreateUserSuccess(t, app, db, conf, slug, validBodyIrrelevantData, "")
 	})
 }
 
@@ -134,7 +162,7 @@ func testCreateUserClientError(
 	t *testing.T, app *fiber.App, conf *config.AppConfig, expectedStatus int,
 	expectedMessage, expectedDetail, body string,
 ) {
-	resp := newRequestCreateUser(t, app, conf, body)
+	resp := newRequestCreateUser(t, app, conf, body, "")
 	require.Equal(t, expectedStatus, resp.StatusCode)
 	helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
 		ClientOperation: utils.CreateUser,
@@ -145,7 +173,8 @@ func testCreateUserClientError(
 }
 
 func testCreateUserSuccess(
-	t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig, slug, body string,
+	t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig,
+	slug, body, password string,
 ) {
 	setup.SetUp(t, db)
 
@@ -153,7 +182,7 @@ func testCreateUserSuccess(
 	helpers.CountUsers(t, db, &userCount)
 	require.EqualValues(t, 0, userCount)
 
-	resp := newRequestCreateUser(t, app, conf, body)
+	resp := newRequestCreateUser(t, app, conf, body, password)
 	require.Equal(t, 204, resp.StatusCode)
 
 	if respBody, err := io.ReadAll(resp.Body); err != nil {
@@ -172,10 +201,29 @@ func testCreateUserSuccess(
 
 	helpers.CountUsers(t, db, &userCount)
 	require.EqualValues(t, 1, userCount)
+
+	// Without a password, the user is on the raw key they'll send when they first unlock.
+	if password == "" {
+		require.Equal(t, utils.KDFRawKey, user.KDF)
+		require.Empty(t, user.KeyCheck)
+		return
+	}
+
+	// The key check is of the key derived from the password, combined with the data key.
+	clientKey, err := utils.KDFParams{
+		KDF: user.KDF, Salt: user.KDFSalt, Time: user.KDFTime, Memory: user.KDFMemory,
+		Threads: user.KDFThreads,
+	}.DeriveKey(password)
+	require.NoError(t, err)
+
+	require.NotEmpty(t, user.DataKey)
+	key := helpers.UserSecretsKey(t, db, conf, slug, clientKey)
+	_, err = utils.Decrypt(user.KeyCheck, key, utils.KeyCheckAdditionalData(slug))
+	require.NoError(t, err)
 }
 
 func newRequestCreateUser(
-	t *testing.T, app *fiber.App, conf *config.AppConfig, body string,
+	t *testing.T, app *fiber.App, conf *config.AppConfig, body, password string,
 ) *http.Response {
 
 	reqBody := strings.NewReader(body)
@@ -184,6 +232,10 @@ func newRequestCreateUser(
 	req.Header.Set("Client-Operation", utils.CreateUser)
 	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
 
+	if password != "" {
+		req.Header.Set(conf.PASSWORD_HEADER_KEY, password)
+	}
+
 	resp, err := app.Test(req)
 
 	if err != nil {
@@ -21,94 +21,110 @@ import (
 
 func testCreateVault(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
 	bodyFmt := `{"user_slug":"%s","vault_title":"%s"}`
+	userSlug := helpers.NewSlug(t)
 
 	t.Run("empty_body_400_bad_request", func(t *testing.T) {
 		testCreateVaultClientError(
 			t, app, conf, 400, utils.ErrorParse,
-			"invalid character '\x00' looking for beginning of value", "",
+			"invalid character '\x00' looking for beginning of value", userSlug, "",
 		)
 	})
 
 	t.Run("array_body_400_bad_request", func(t *testing.T) {
 		testCreateVaultClientError(
 			t, app, conf, 400, utils.ErrorParse,
-			"invalid character '[' looking for beginning of value", "[]",
+			"invalid character '[' looking for beginning of value", userSlug, "[]",
 		)
 
 		testCreateVaultClientError(
 			t, app, conf, 400, utils.ErrorParse,
-			"invalid character '[' looking for beginning of value", "[{}]",
+			"invalid character '[' looking for beginning of value", userSlug, "[{}]",
 		)
 
 		testCreateVaultClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character '[' looking for beginning of value",
-			fmt.Sprintf("[" + bodyFmt + "]", helpers.NewSlug(t), "vault@0.2.*.*"),
+			userSlug, fmt.Sprintf("[" + bodyFmt + "]", userSlug, "vault@0.2.*.*"),
 		)
 	})
 
 	t.Run("boolean_body_400_bad_request", func(t *testing.T) {
 		testCreateVaultClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character 't' looking for beginning of value",
-			"true",
+			userSlug, "true",
 		)
 
 		testCreateVaultClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character 'f' looking for beginning of value",
-			"false",
+			userSlug, "false",
 		)
 	})
 
 	t.Run("string_body_400_bad_request", func(t *testing.T) {
 		testCreateVaultClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character '\"' looking for beginning of value",
-			"\"Valid JSON, but not an object.\"",
+			userSlug, "\"Valid JSON, but not an object.\"",
 		)
 	})
 
 	t.Run("null_body_400_bad_request", func(t *testing.T) {
-		testCreateVaultClientError(t, app, conf, 400, utils.ErrorUserSlug, "", "null")
+		testCreateVaultClientError(t, app, conf, 400, utils.ErrorUserSlug, "", userSlug, "null")
 	})
 
 	t.Run("empty_object_body_400_bad_request", func(t *testing.T) {
-		testCreateVaultClientError(t, app, conf, 400, utils.ErrorUserSlug, "", "{}")
+		testCreateVaultClientError(t, app, conf, 400, utils.ErrorUserSlug, "", userSlug, "{}")
 	})
 
 	t.Run("missing_user_slug_400_bad_request", func(t *testing.T) {
 		testCreateVaultClientError(
 			t, app, conf, 400, utils.ErrorUserSlug, "",
-			`{"userr_slug":"Spelled wrong!","vault_title":"vault@0.2.*.*"}`,
+			userSlug, `{"userr_slug":"Spelled wrong!","vault_title":"vault@0.2.*.*"}`,
 		)
 	})
 
 	t.Run("missing_vault_title_400_bad_request", func(t *testing.T) {
 		testCreateVaultClientError(
 			t, app, conf, 400, utils.ErrorVaultTitle, "",
-			fmt.Sprintf(`{"user_slug":"%s","vault_titel":"Spelled wrong!"}`, helpers.NewSlug(t)),
+			userSlug, fmt.Sprintf(`{"user_slug":"%s","vault_titel":"Spelled wrong!"}`, userSlug),
 		)
 	})
 
 	t.Run("null_user_slug_400_bad_request", func(t *testing.T) {
 		testCreateVaultClientError(
-			t, app, conf, 400, utils.ErrorUserSlug, "", `{"user_slug":null,"vault_title":"vault@0.2.*.*"}`,
+			t, app, conf, 400, utils.ErrorUserSlug, "", userSlug,
+			`{"user_slug":null,"vault_title":"vault@0.2.*.*"}`,
 		)
 	})
 
 	t.Run("null_vault_title_400_bad_request", func(t *testing.T) {
 		testCreateVaultClientError(
 			t, app, conf, 400, utils.ErrorVaultTitle, "",
-			fmt.Sprintf(`{"user_slug":"%s","vault_title":null}`, helpers.NewSlug(t)),
+			userSlug, fmt.Sprintf(`{"user_slug":"%s","vault_title":null}`, userSlug),
 		)
 	})
 
 	t.Run("empty_user_slug_400_bad_request", func(t *testing.T) {
 		testCreateVaultClientError(
-			t, app, conf, 400, utils.ErrorUserSlug, "", fmt.Sprintf(bodyFmt, "", "vault@0.2.*.*"),
+			t, app, conf, 400, utils.ErrorUserSlug, "", userSlug,
+			fmt.Sprintf(bodyFmt, "", "vault@0.2.*.*"),
 		)
 	})
 
+	t.Run("other_users_slug_400_bad_request", func(t *testing.T) {
+		users, _, _, _ := setup.SetUpWithData(t, db)
+
+		testCreateVaultClientError(
+			t, app, conf, 400, utils.ErrorUserSlug, utils.ErrorUserSlugHeader,
+			users[1].Slug, fmt.Sprintf(bodyFmt, users[0].Slug, "vault@0.2.*.*"),
+		)
+
+		var vaultCount int64
+		helpers.CountVaults(t, db, &vaultCount)
+		require.EqualValues(t, 4, vaultCount)
+	})
+
 	t.Run("empty_vault_title_400_bad_request", func(t *testing.T) {
 		testCreateVaultClientError(
-			t, app, conf, 400, utils.ErrorVaultTitle, "", fmt.Sprintf(bodyFmt, helpers.NewSlug(t), ""),
+			t, app, conf, 400, utils.ErrorVaultTitle, "", userSlug, fmt.Sprintf(bodyFmt, userSlug, ""),
 		)
 	})
 
@@ -118,7 +134,7 @@ func testCreateVault(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 		} else {
 			testCreateVaultClientError(
 				t, app, conf, 400, utils.ErrorVaultTitle, "Too long",
-				fmt.Sprintf(bodyFmt, helpers.NewSlug(t), title),
+				userSlug, fmt.Sprintf(bodyFmt, userSlug, title),
 			)
 		}
 	})
@@ -130,6 +146,7 @@ func testCreateVault(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 		testCreateVaultClientError(
 			t, app, conf, 409, utils.ErrorDuplicateVault,
 			"UNIQUE constraint failed: vaults.title, vaults.user_slug",
+			slug,
 			fmt.Sprintf(bodyFmt, slug, "vault@0.1.*.*"),
 		)
 	})
@@ -161,9 +178,9 @@ func testCreateVault(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 
 func testCreateVaultClientError(
 	t *testing.T, app *fiber.App, conf *config.AppConfig, expectedStatus int,
-	expectedMessage, expectedDetail, body string,
+	expectedMessage, expectedDetail, userSlug, body string,
 ) {
-	resp := newRequestCreateVault(t, app, conf, body)
+	resp := newRequestCreateVault(t, app, conf, userSlug, body)
 	require.Equal(t, expectedStatus, resp.StatusCode)
 	helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
 		ClientOperation: utils.CreateVault,
@@ -181,7 +198,7 @@ func testCreateVaultSuccess(
 	helpers.CountVaults(t, db, &vaultCount)
 	require.EqualValues(t, 4, vaultCount)
 
-	resp := newRequestCreateVault(t, app, conf, body)
+	resp := newRequestCreateVault(t, app, conf, userSlug, body)
 
 	require.Equal(t, 204, resp.StatusCode)
 
@@ -203,7 +220,7 @@ func testCreateVaultSuccess(
 }
 
 func newRequestCreateVault(
-	t *testing.T, app *fiber.App, conf *config.AppConfig, body string,
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, body string,
 ) *http.Response {
 
 	reqBody := strings.NewReader(body)
@@ -211,6 +228,7 @@ func newRequestCreateVault(
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Client-Operation", utils.CreateVault)
 	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+	req.Header.Set("User-Slug", userSlug)
 
 	resp, err := app.Test(req)
 
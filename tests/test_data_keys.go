@@ -0,0 +1,238 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	vaultsApp "github.com/liobrdev/simplepasswords_vaults/app"
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/database"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/routes"
+	"github.com/liobrdev/simplepasswords_vaults/tests/helpers"
+	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+func testDataKeys(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
+	otherKEK := helpers.HexHash[64:128]
+
+	t.Run("invalid_kek_error", func(t *testing.T) {
+		for _, keks := range []string{
+			"", otherKEK[:63], otherKEK[:63] + "z", otherKEK + ",", otherKEK + "," + otherKEK[1:],
+		} {
+			_, err := utils.NewLocalKeyProvider(keks)
+			require.Error(t, err, keks)
+		}
+	})
+
+	t.Run("secrets_under_combined_key_200_ok", func(t *testing.T) {
+		entry := newTestPasswordUserEntry(t, app, db, conf)
+
+		var user models.User
+		helpers.QueryTestUser(t, db, &user, entry.UserSlug)
+		require.NotEmpty(t, user.DataKey)
+
+		clientKey, err := utils.KDFParams{
+			KDF: user.KDF, Salt: user.KDFSalt, Time: user.KDFTime, Memory: user.KDFMemory,
+			Threads: user.KDFThreads,
+		}.DeriveKey(helpers.VALID_PW)
+		require.NoError(t, err)
+
+		// Neither the client key nor the database alone is enough to decrypt the secret.
+		_, err = utils.Decrypt(
+			entry.Secrets[0].String, clientKey, helpers.SecretAdditionalData(&entry.Secrets[0]),
+		)
+		require.Error(t, err)
+
+		plaintext, err := utils.Decrypt(
+			entry.Secrets[0].String, helpers.UserSecretsKey(t, db, conf, user.Slug, clientKey),
+			helpers.SecretAdditionalData(&entry.Secrets[0]),
+		)
+		require.NoError(t, err)
+		require.Equal(t, "string", plaintext)
+
+		resp := newRequestWithVaultKey(
+			t, app, conf, http.MethodGet, "/api/entries/" + entry.Slug, utils.RetrieveEntry,
+			entry.UserSlug, helpers.VALID_PW, "",
+		)
+		require.Equal(t, 200, resp.StatusCode)
+	})
+
+	t.Run("other_kek_500_error", func(t *testing.T) {
+		entry := newTestPasswordUserEntry(t, app, db, conf)
+		otherApp, otherConf := newTestKEKApp(db, conf, otherKEK)
+
+		resp := newRequestWithVaultKey(
+			t, otherApp, otherConf, http.MethodGet, "/api/entries/" + entry.Slug,
+			utils.RetrieveEntry, entry.UserSlug, helpers.VALID_PW, "",
+		)
+		require.Equal(t, 500, resp.StatusCode)
+		helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+			ClientOperation: utils.RetrieveEntry,
+			Message:         utils.ErrorDecrypt,
+			Detail: fmt.Sprintf(
+				"%s: no KEK with key id %q", utils.ErrUnwrapKey, newTestKEKID(t, conf.VAULTS_KEK),
+			),
+		})
+	})
+
+	t.Run("kek_rotated_200_ok", func(t *testing.T) {
+		entry := newTestPasswordUserEntry(t, app, db, conf)
+
+		var userBefore, userAfter models.User
+		helpers.QueryTestUser(t, db, &userBefore, entry.UserSlug)
+
+		// While rotating, both the new and the previous KEK can unwrap.
+		rotatingKEKs := otherKEK + "," + conf.VAULTS_KEK
+		rotatingApp, rotatingConf := newTestKEKApp(db, conf, rotatingKEKs)
+		keys, err := utils.NewLocalKeyProvider(rotatingKEKs)
+		require.NoError(t, err)
+		require.False(t, keys.IsCurrent(userBefore.DataKey))
+
+		resp := newRequestWithVaultKey(
+			t, rotatingApp, rotatingConf, http.MethodGet, "/api/entries/" + entry.Slug,
+			utils.RetrieveEntry, entry.UserSlug, helpers.VALID_PW, "",
+		)
+		require.Equal(t, 200, resp.StatusCode)
+
+		n, err := database.RewrapDataKeys(db, keys)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, n)
+
+		helpers.QueryTestUser(t, db, &userAfter, entry.UserSlug)
+		require.NotEqual(t, userBefore.DataKey, userAfter.DataKey)
+		require.True(t, keys.IsCurrent(userAfter.DataKey))
+		require.Equal(t, userBefore.KeyCheck, userAfter.KeyCheck)
+
+		// Only the data key was rewrapped, and no secret was re-encrypted.
+		var secretAfter models.Secret
+		helpers.QueryTestSecretBySlug(t, db, &secretAfter, entry.Secrets[0].Slug)
+		require.Equal(t, entry.Secrets[0].String, secretAfter.String)
+
+		// Once rotated, the previous KEK is no longer needed, and no longer enough.
+		rotatedApp, rotatedConf := newTestKEKApp(db, conf, otherKEK)
+
+		resp = newRequestWithVaultKey(
+			t, rotatedApp, rotatedConf, http.MethodGet, "/api/entries/" + entry.Slug,
+			utils.RetrieveEntry, entry.UserSlug, helpers.VALID_PW, "",
+		)
+		require.Equal(t, 200, resp.StatusCode)
+
+		resp = newRequestWithVaultKey(
+			t, app, conf, http.MethodGet, "/api/entries/" + entry.Slug, utils.RetrieveEntry,
+			entry.UserSlug, helpers.VALID_PW, "",
+		)
+		require.Equal(t, 500, resp.StatusCode)
+
+		n, err = database.RewrapDataKeys(db, keys)
+		require.NoError(t, err)
+		require.EqualValues(t, 0, n)
+	})
+
+	t.Run("rekeyed_user_given_data_key_204_no_content", func(t *testing.T) {
+		users, _, _, _ := setup.SetUpWithData(t, db)
+		require.Empty(t, users[0].DataKey)
+
+		resp := newRequestRekeyUser(t, app, conf, users[0].Slug, fmt.Sprintf(
+			`{"old_key":"%s","new_key":"%s"}`, helpers.HexHash[:64], otherKEK,
+		))
+		require.Equal(t, 204, resp.StatusCode)
+
+		var user models.User
+		helpers.QueryTestUser(t, db, &user, users[0].Slug)
+		require.NotEmpty(t, user.DataKey)
+	})
+
+	t.Run("unlocked_user_given_data_key_200_ok", func(t *testing.T) {
+		_, _, entries, _ := setup.SetUpWithData(t, db)
+
+		var userBefore, userAfter models.User
+		helpers.QueryTestUser(t, db, &userBefore, entries[0].UserSlug)
+		require.Empty(t, userBefore.DataKey)
+
+		resp := newRequestRetrieveEntry(t, app, conf, entries[0].UserSlug, entries[0].Slug)
+		require.Equal(t, 200, resp.StatusCode)
+
+		helpers.QueryTestUser(t, db, &userAfter, entries[0].UserSlug)
+		require.NotEmpty(t, userAfter.DataKey)
+		require.NotEqual(t, userBefore.KeyCheck, userAfter.KeyCheck)
+
+		// The secrets were rekeyed to the raw key combined with the new data key.
+		var secrets []models.Secret
+		helpers.QueryTestSecretsByEntry(t, db, &secrets, entries[0].Slug)
+		require.NotEmpty(t, secrets)
+		key := helpers.UserSecretsKey(t, db, conf, userAfter.Slug, helpers.HexHash[:64])
+
+		for _, secret := range secrets {
+			_, err := utils.Decrypt(
+				secret.String, helpers.HexHash[:64], helpers.SecretAdditionalData(&secret),
+			)
+			require.Error(t, err)
+
+			_, err = utils.Decrypt(secret.String, key, helpers.SecretAdditionalData(&secret))
+			require.NoError(t, err)
+		}
+
+		// The same raw key still unlocks them, and the data key isn't replaced again.
+		resp = newRequestRetrieveEntry(t, app, conf, entries[0].UserSlug, entries[0].Slug)
+		require.Equal(t, 200, resp.StatusCode)
+
+		var userAgain models.User
+		helpers.QueryTestUser(t, db, &userAgain, entries[0].UserSlug)
+		require.Equal(t, userAfter.DataKey, userAgain.DataKey)
+	})
+}
+
+// newTestPasswordUserEntry creates a user with a master password, and an entry of theirs
+// with one secret, through the API.
+func newTestPasswordUserEntry(
+	t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig,
+) (entry models.Entry) {
+	setup.SetUp(t, db)
+	userSlug := helpers.NewSlug(t)
+
+	resp := newRequestCreateUser(
+		t, app, conf, fmt.Sprintf(`{"user_slug":"%s"}`, userSlug), helpers.VALID_PW,
+	)
+	require.Equal(t, 204, resp.StatusCode)
+
+	vault := models.Vault{Slug: helpers.NewSlug(t), UserSlug: userSlug, Title: "vault"}
+	require.NoError(t, db.Create(&vault).Error)
+
+	resp = newRequestWithVaultKey(
+		t, app, conf, http.MethodPost, "/api/entries", utils.CreateEntry, userSlug,
+		helpers.VALID_PW, fmt.Sprintf(
+			`{"user_slug":"%s","vault_slug":"%s","entry_title":"entry","secrets":[{` +
+			`"secret_label":"label","secret_string":"string"}]}`,
+			userSlug, vault.Slug,
+		),
+	)
+	require.Equal(t, 204, resp.StatusCode)
+	helpers.QueryTestEntryEager(t, db, &entry, "entry")
+
+	return
+}
+
+func newTestKEKApp(
+	db *gorm.DB, conf *config.AppConfig, keks string,
+) (*fiber.App, *config.AppConfig) {
+	kekConf := *conf
+	kekConf.VAULTS_KEK = keks
+	kekApp := vaultsApp.CreateApp(&kekConf)
+	routes.Register(kekApp, db, &kekConf)
+
+	return kekApp, &kekConf
+}
+
+func newTestKEKID(t *testing.T, kek string) string {
+	keyID, err := utils.KeyID(kek)
+	require.NoError(t, err)
+
+	return keyID
+}
@@ -21,13 +21,30 @@ func testDeleteEntry(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 	t.Run("valid_slug_404_not_found", func(t *testing.T) {
 		testDeleteEntryClientError(
 			t, app, conf, 404, utils.ErrorNoRowsAffected, "Likely that slug was not found.",
-			helpers.NewSlug(t),
+			helpers.NewSlug(t), helpers.NewSlug(t),
 		)
 	})
 
+	t.Run("other_users_slug_404_not_found", func(t *testing.T) {
+		users, _, entries, _ := setup.SetUpWithData(t, db)
+
+		testDeleteEntryClientError(
+			t, app, conf, 404, utils.ErrorNoRowsAffected, "Likely that slug was not found.",
+			users[1].Slug, entries[0].Slug,
+		)
+
+		var entryCount, secretCount int64
+		helpers.CountEntries(t, db, &entryCount)
+		helpers.CountSecrets(t, db, &secretCount)
+		require.EqualValues(t, 8, entryCount)
+		require.EqualValues(t, 20, secretCount)
+	})
+
 	t.Run("invalid_slug_400_bad_request", func(t *testing.T) {
 		slug := "notARealSlug"
-		testDeleteEntryClientError(t, app, conf, 400, utils.ErrorEntrySlug, slug, slug)
+		testDeleteEntryClientError(
+			t, app, conf, 400, utils.ErrorEntrySlug, slug, helpers.NewSlug(t), slug,
+		)
 	})
 
 	t.Run("valid_slug_204_no_content", func(t *testing.T) {
@@ -37,9 +54,9 @@ func testDeleteEntry(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 
 func testDeleteEntryClientError(
 	t *testing.T, app *fiber.App, conf *config.AppConfig, expectedStatus int,
-	expectedMessage, expectedDetail, slug string,
+	expectedMessage, expectedDetail, userSlug, slug string,
 ) {
-	resp := newRequestDeleteEntry(t, app, conf, slug)
+	resp := newRequestDeleteEntry(t, app, conf, userSlug, slug)
 	require.Equal(t, expectedStatus, resp.StatusCode)
 	helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
 		ClientOperation: utils.DeleteEntry,
@@ -58,14 +75,18 @@ func testDeleteEntrySuccess(t *testing.T, app *fiber.App, db *gorm.DB, conf *con
 	secret1 := entry.Secrets[0]
 	secret2 := entry.Secrets[1]
 
-	if plaintext, err := utils.Decrypt(secret1.String, helpers.HexHash[:64]); err != nil {
+	if plaintext, err := utils.Decrypt(
+		secret1.String, helpers.HexHash[:64], helpers.SecretAdditionalData(&secret1),
+	); err != nil {
 		t.Fatalf("Password decryption failed: %s", err.Error())
 	} else {
 		require.Equal(t, "secret[_string='foodeater1234']@0.1.1.0", plaintext)
 		require.Equal(t, "secret[_label='username']@0.1.1.0", secret1.Label)
 	}
 
-	if plaintext, err := utils.Decrypt(secret2.String, helpers.HexHash[:64]); err != nil {
+	if plaintext, err := utils.Decrypt(
+		secret2.String, helpers.HexHash[:64], helpers.SecretAdditionalData(&secret2),
+	); err != nil {
 		t.Fatalf("Password decryption failed: %s", err.Error())
 	} else {
 		require.Equal(t, "secret[_string='3a7!ng40oD']@0.1.1.1", plaintext)
@@ -80,7 +101,7 @@ func testDeleteEntrySuccess(t *testing.T, app *fiber.App, db *gorm.DB, conf *con
 	helpers.CountSecrets(t, db, &secretCount)
 	require.EqualValues(t, 20, secretCount)
 
-	resp := newRequestDeleteEntry(t, app, conf, entry.Slug)
+	resp := newRequestDeleteEntry(t, app, conf, entry.UserSlug, entry.Slug)
 	require.Equal(t, 204, resp.StatusCode)
 
 	if respBody, err := io.ReadAll(resp.Body); err != nil {
@@ -115,13 +136,14 @@ func testDeleteEntrySuccess(t *testing.T, app *fiber.App, db *gorm.DB, conf *con
 }
 
 func newRequestDeleteEntry(
-	t *testing.T, app *fiber.App, conf *config.AppConfig, slug string,
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, slug string,
 ) *http.Response {
 
 	req := httptest.NewRequest("DELETE", "/api/entries/" + slug, nil)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Client-Operation", utils.CreateEntry)
 	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+	req.Header.Set("User-Slug", userSlug)
 
 	resp, err := app.Test(req)
 
@@ -20,24 +20,55 @@ import (
 func testDeleteSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
 	t.Run("valid_slug_404_not_found", func(t *testing.T) {
 		slug := helpers.NewSlug(t)
-		testDeleteSecretClientError(t, app, conf, 404, utils.ErrorNotFound, slug, slug)
+		testDeleteSecretClientError(
+			t, app, conf, 404, utils.ErrorNotFound, slug, helpers.NewSlug(t), slug,
+		)
+	})
+
+	t.Run("other_users_slug_404_not_found", func(t *testing.T) {
+		users, _, _, secrets := setup.SetUpWithData(t, db)
+		slug := secrets[0].Slug
+		testDeleteSecretClientError(t, app, conf, 404, utils.ErrorNotFound, slug, users[1].Slug, slug)
+
+		var secretCount int64
+		helpers.CountSecrets(t, db, &secretCount)
+		require.EqualValues(t, 20, secretCount)
 	})
 
 	t.Run("invalid_slug_400_bad_request", func(t *testing.T) {
 		slug := "notARealSlug"
-		testDeleteSecretClientError(t, app, conf, 400, utils.ErrorSecretSlug, slug, slug)
+		testDeleteSecretClientError(
+			t, app, conf, 400, utils.ErrorSecretSlug, slug, helpers.NewSlug(t), slug,
+		)
 	})
 
 	t.Run("valid_slug_204_no_content", func(t *testing.T) {
 		testDeleteSecretSuccess(t, app, db, conf)
 	})
+
+	t.Run("secret_versions_kept_204_no_content", func(t *testing.T) {
+		_, _, _, secrets := setup.SetUpWithData(t, db)
+		createTestSecretVersion(t, app, db, conf, &secrets[0])
+		createTestSecretVersion(t, app, db, conf, &secrets[19])
+
+		var versionCount int64
+		helpers.CountSecretVersions(t, db, &versionCount)
+		require.EqualValues(t, 2, versionCount)
+
+		resp := newRequestDeleteSecret(t, app, conf, secrets[0].UserSlug, secrets[0].Slug)
+		require.Equal(t, 204, resp.StatusCode)
+
+		// Versions stay with the trashed secret until it's restored or purged.
+		helpers.CountSecretVersions(t, db, &versionCount)
+		require.EqualValues(t, 2, versionCount)
+	})
 }
 
 func testDeleteSecretClientError(
 	t *testing.T, app *fiber.App, conf *config.AppConfig, expectedStatus int,
-	expectedMessage, expectedDetail, slug string,
+	expectedMessage, expectedDetail, userSlug, slug string,
 ) {
-	resp := newRequestDeleteSecret(t, app, conf, slug)
+	resp := newRequestDeleteSecret(t, app, conf, userSlug, slug)
 	require.Equal(t, expectedStatus, resp.StatusCode)
 	helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
 		ClientOperation: utils.DeleteSecret,
@@ -58,7 +89,7 @@ func testDeleteSecretSuccess(t *testing.T, app *fiber.App, db *gorm.DB, conf *co
 	helpers.QueryTestSecretsByEntry(t, db, &secretsBeforeDelete, secret.EntrySlug)
 	require.Equal(t, 7, len(secretsBeforeDelete))
 
-	resp := newRequestDeleteSecret(t, app, conf, secret.Slug)
+	resp := newRequestDeleteSecret(t, app, conf, secret.UserSlug, secret.Slug)
 	require.Equal(t, 204, resp.StatusCode)
 
 	if respBody, err := io.ReadAll(resp.Body); err != nil {
@@ -96,13 +127,14 @@ func testDeleteSecretSuccess(t *testing.T, app *fiber.App, db *gorm.DB, conf *co
 }
 
 func newRequestDeleteSecret(
-	t *testing.T, app *fiber.App, conf *config.AppConfig, slug string,
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, slug string,
 ) *http.Response {
 
 	req := httptest.NewRequest("DELETE", "/api/secrets/" + slug, nil)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Client-Operation", utils.DeleteSecret)
 	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+	req.Header.Set("User-Slug", userSlug)
 	resp, err := app.Test(req)
 
 	if err != nil {
@@ -21,13 +21,32 @@ func testDeleteVault(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 	t.Run("valid_slug_404_not_found", func(t *testing.T) {
 		testDeleteVaultClientError(
 			t, app, conf, 404, utils.ErrorNoRowsAffected, "Likely that slug was not found.",
-			helpers.NewSlug(t),
+			helpers.NewSlug(t), helpers.NewSlug(t),
 		)
 	})
 
+	t.Run("other_users_slug_404_not_found", func(t *testing.T) {
+		users, vaults, _, _ := setup.SetUpWithData(t, db)
+
+		testDeleteVaultClientError(
+			t, app, conf, 404, utils.ErrorNoRowsAffected, "Likely that slug was not found.",
+			users[1].Slug, vaults[0].Slug,
+		)
+
+		var vaultCount, entryCount, secretCount int64
+		helpers.CountVaults(t, db, &vaultCount)
+		helpers.CountEntries(t, db, &entryCount)
+		helpers.CountSecrets(t, db, &secretCount)
+		require.EqualValues(t, 4, vaultCount)
+		require.EqualValues(t, 8, entryCount)
+		require.EqualValues(t, 20, secretCount)
+	})
+
 	t.Run("invalid_slug_400_bad_request", func(t *testing.T) {
 		slug := "notARealSlug"
-		testDeleteVaultClientError(t, app, conf, 400, utils.ErrorVaultSlug, slug, slug)
+		testDeleteVaultClientError(
+			t, app, conf, 400, utils.ErrorVaultSlug, slug, helpers.NewSlug(t), slug,
+		)
 	})
 
 	t.Run("valid_slug_204_no_content", func(t *testing.T) {
@@ -37,9 +56,9 @@ func testDeleteVault(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 
 func testDeleteVaultClientError(
 	t *testing.T, app *fiber.App, conf *config.AppConfig,
-	expectedStatus int, expectedMessage, expectedDetail, slug string,
+	expectedStatus int, expectedMessage, expectedDetail, userSlug, slug string,
 ) {
-	resp := newRequestDeleteVault(t, app, conf, slug)
+	resp := newRequestDeleteVault(t, app, conf, userSlug, slug)
 	require.Equal(t, expectedStatus, resp.StatusCode)
 	helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
 		ClientOperation: utils.DeleteVault,
@@ -62,14 +81,18 @@ func testDeleteVaultSuccess(t *testing.T, app *fiber.App, db *gorm.DB, conf *con
 	secret1 := entry1.Secrets[0]
 	secret2 := entry1.Secrets[1]
 
-	if plaintext, err := utils.Decrypt(secret1.String, helpers.HexHash[:64]); err != nil {
+	if plaintext, err := utils.Decrypt(
+		secret1.String, helpers.HexHash[:64], helpers.SecretAdditionalData(&secret1),
+	); err != nil {
 		t.Fatalf("Password decryption failed: %s", err.Error())
 	} else {
 		require.Equal(t, "secret[_string='foodeater1234']@0.1.0.0", plaintext)
 		require.Equal(t, "secret[_label='username']@0.1.0.0", secret1.Label)
 	}
 
-	if plaintext, err := utils.Decrypt(secret2.String, helpers.HexHash[:64]); err != nil {
+	if plaintext, err := utils.Decrypt(
+		secret2.String, helpers.HexHash[:64], helpers.SecretAdditionalData(&secret2),
+	); err != nil {
 		t.Fatalf("Password decryption failed: %s", err.Error())
 	} else {
 		require.Equal(t, "secret[_string='3a7!ng40oD']@0.1.0.1", plaintext)
@@ -83,14 +106,18 @@ func testDeleteVaultSuccess(t *testing.T, app *fiber.App, db *gorm.DB, conf *con
 	secret3 := entry2.Secrets[0]
 	secret4 := entry2.Secrets[1]
 
-	if plaintext, err := utils.Decrypt(secret3.String, helpers.HexHash[:64]); err != nil {
+	if plaintext, err := utils.Decrypt(
+		secret3.String, helpers.HexHash[:64], helpers.SecretAdditionalData(&secret3),
+	); err != nil {
 		t.Fatalf("Password decryption failed: %s", err.Error())
 	} else {
 		require.Equal(t, "secret[_string='foodeater1234']@0.1.1.0", plaintext)
 		require.Equal(t, "secret[_label='username']@0.1.1.0", secret3.Label)
 	}
 
-	if plaintext, err := utils.Decrypt(secret4.String, helpers.HexHash[:64]); err != nil {
+	if plaintext, err := utils.Decrypt(
+		secret4.String, helpers.HexHash[:64], helpers.SecretAdditionalData(&secret4),
+	); err != nil {
 		t.Fatalf("Password decryption failed: %s", err.Error())
 	} else {
 		require.Equal(t, "secret[_string='3a7!ng40oD']@0.1.1.1", plaintext)
@@ -109,7 +136,7 @@ func testDeleteVaultSuccess(t *testing.T, app *fiber.App, db *gorm.DB, conf *con
 	helpers.CountSecrets(t, db, &secretCount)
 	require.EqualValues(t, 20, secretCount)
 
-	resp := newRequestDeleteVault(t, app, conf, vault.Slug)
+	resp := newRequestDeleteVault(t, app, conf, vault.UserSlug, vault.Slug)
 	require.Equal(t, 204, resp.StatusCode)
 
 	if respBody, err := io.ReadAll(resp.Body); err != nil {
@@ -171,13 +198,14 @@ func testDeleteVaultSuccess(t *testing.T, app *fiber.App, db *gorm.DB, conf *con
 }
 
 func newRequestDeleteVault(
-	t *testing.T, app *fiber.App, conf *config.AppConfig, slug string,
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, slug string,
 ) *http.Response {
 
 	req := httptest.NewRequest(http.MethodDelete, "/api/vaults/" + slug, nil)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Client-Operation", utils.DeleteVault)
 	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+	req.Header.Set("User-Slug", userSlug)
 	resp, err := app.Test(req)
 
 	if err != nil {
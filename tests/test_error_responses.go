@@ -0,0 +1,204 @@
+package tests
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	vaultsApp "github.com/liobrdev/simplepasswords_vaults/app"
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/routes"
+	"github.com/liobrdev/simplepasswords_vaults/tests/helpers"
+	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+func testErrorResponses(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
+	t.Run("secret_fields_redacted", func(t *testing.T) {
+		slug := helpers.NewSlug(t)
+
+		for _, tc := range []struct{ body, expected string }{
+			{`{"secret_string":"hunter2hunter2",`, `{"secret_string":"[REDACTED]",`},
+			{`{"Secret_String" : "hunter2\"hunter2"}x`, `{"Secret_String" : "[REDACTED]"}x`},
+			{`{"secret_string":"hunter2hunter2`, `{"secret_string":"[REDACTED]"`},
+			{`[{"secret_label":"a","secret_string":12345678}]`, `[{"secret_label":"a",` +
+			`"secret_string":"[REDACTED]"}]`},
+			{
+				`{"old_key":"` + helpers.HexHash[64:128] + `","new_key":"` + helpers.HexHash[:64],
+				`{"old_key":"[REDACTED]","new_key":"[REDACTED]"`,
+			},
+		} {
+			resp := newRequestUpdateSecret(t, app, conf, slug, slug, tc.body)
+			body := readTestErrorResponse(t, resp, 400)
+			require.Equal(t, tc.expected, body.RequestBody)
+			require.Equal(t, "BODY_UNPARSABLE", body.Code)
+		}
+	})
+
+	t.Run("sensitive_headers_redacted", func(t *testing.T) {
+		slug := helpers.NewSlug(t)
+
+		// The password header's value, and the access token, wherever they're echoed.
+		resp := newRequestUpdateSecret(t, app, conf, slug, slug, `{"secret_label":"` +
+		helpers.HexHash[:64] + `","secret_priority":"` + conf.VAULTS_ACCESS_TOKEN + `"`)
+		body := readTestErrorResponse(t, resp, 400)
+		require.Equal(
+			t, `{"secret_label":"[REDACTED]","secret_priority":"[REDACTED]"`, body.RequestBody,
+		)
+	})
+
+	t.Run("request_id_and_code_200_ok", func(t *testing.T) {
+		setup.SetUpWithData(t, db)
+		slug := helpers.NewSlug(t)
+
+		resp := newRequestRetrieveEntry(t, app, conf, slug, slug)
+		requestID := resp.Header.Get(fiber.HeaderXRequestID)
+		body := readTestErrorResponse(t, resp, 404)
+		require.NotEmpty(t, requestID)
+		require.Equal(t, requestID, body.RequestID)
+		require.Equal(t, "NOT_FOUND", body.Code)
+		require.Equal(t, utils.ErrorNotFound, body.Title)
+	})
+
+	t.Run("codes_and_field_pointers", func(t *testing.T) {
+		users, vaults, _, _ := setup.SetUpWithData(t, db)
+		slug := users[0].Slug
+		secretFmt := `{"secret_label":"%s","secret_string":"foodeater","secret_priority":%d}`
+
+		for _, tc := range []struct {
+			resp                     *http.Response
+			status                   int
+			code, pointer, parameter string
+		}{
+			{
+				newRequestCreateVault(t, app, conf, slug, fmt.Sprintf(
+					`{"user_slug":"%s","vault_title":"%s"}`, slug, strings.Repeat("a", 256),
+				)),
+				400, "VAULT_TITLE_TOO_LONG", "/vault_title", "",
+			},
+			{
+				newRequestCreateVault(t, app, conf, slug, fmt.Sprintf(
+					`{"user_slug":"%s","vault_title":"%s"}`, slug, vaults[0].Title,
+				)),
+				409, "VAULT_DUPLICATE", "/vault_title", "",
+			},
+			{
+				newRequestCreateEntry(t, app, conf, slug, fmt.Sprintf(
+					`{"user_slug":"%s","vault_slug":"%s","entry_title":"Entry","secrets":[%s,{}]}`,
+					slug, vaults[0].Slug, fmt.Sprintf(secretFmt, "username", 0),
+				)),
+				400, "SECRETS_ITEM_INVALID", "/secrets/1/secret_label", "",
+			},
+			{
+				newRequestCreateEntry(t, app, conf, slug, fmt.Sprintf(
+					`{"user_slug":"%s","vault_slug":"%s","entry_title":"Entry","secrets":[%s,%s]}`,
+					slug, vaults[0].Slug, fmt.Sprintf(secretFmt, "username", 0),
+					fmt.Sprintf(secretFmt, "username", 1),
+				)),
+				400, "SECRET_LABEL_DUPLICATE", "/secrets", "",
+			},
+			{
+				newRequestListVaults(t, app, conf, slug, "?limit=0"),
+				400, "LIMIT_INVALID", "", "limit",
+			},
+			{
+				newRequestRetrieveVault(t, app, conf, slug, "notARealSlug", ""),
+				400, "VAULT_SLUG_INVALID", "", "vault_slug",
+			},
+		} {
+			body := readTestErrorResponse(t, tc.resp, tc.status)
+			require.Equal(t, tc.code, body.Code)
+			require.Equal(t, utils.ProblemTypePrefix + tc.code, body.Type)
+			require.Equal(t, tc.pointer, body.Pointer)
+			require.Equal(t, tc.parameter, body.Parameter)
+		}
+	})
+
+	t.Run("legacy_error_format", func(t *testing.T) {
+		slug := helpers.NewSlug(t)
+		req := httptest.NewRequest("PATCH", "/api/secrets/" + slug, strings.NewReader("{"))
+		req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+		req.Header.Set("Client-Operation", utils.UpdateSecret)
+		req.Header.Set("User-Slug", slug)
+		req.Header.Set(utils.ErrorFormatHeader, utils.ErrorFormatLegacy)
+
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		require.Equal(t, 400, resp.StatusCode)
+		require.Equal(t, fiber.MIMEApplicationJSON, resp.Header.Get(fiber.HeaderContentType))
+
+		var body utils.ErrorResponseBody
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		require.Equal(t, utils.UpdateSecret, body.ClientOperation)
+		require.Equal(t, "BODY_UNPARSABLE", body.Code)
+		require.Equal(t, utils.ErrorParse, body.Message)
+		require.Equal(t, "{", body.RequestBody)
+		require.NotEmpty(t, body.Caller)
+	})
+
+	t.Run("production_code_and_request_id_only", func(t *testing.T) {
+		prodConf := *conf
+		prodConf.ENVIRONMENT = "production"
+		prodApp := vaultsApp.CreateApp(&prodConf)
+		routes.Register(prodApp, db, &prodConf)
+		slug := helpers.NewSlug(t)
+
+		resp := newRequestUpdateSecret(
+			t, prodApp, &prodConf, slug, slug, `{"secret_string":"hunter2hunter2",`,
+		)
+		require.Equal(t, 400, resp.StatusCode)
+		require.Equal(
+			t, utils.MIMEApplicationProblemJSON, resp.Header.Get(fiber.HeaderContentType),
+		)
+
+		respBody, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NotContains(t, string(respBody), "hunter2")
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(respBody, &body))
+		require.NotEmpty(t, body["request_id"])
+		require.Equal(t, map[string]interface{}{
+			"type": utils.ProblemTypePrefix + "BODY_UNPARSABLE", "title": utils.ErrorParse,
+			"status": float64(400), "code": "BODY_UNPARSABLE",
+			"request_id": resp.Header.Get(fiber.HeaderXRequestID),
+		}, body)
+
+		resp = newRequestAuthorizeRequest(t, prodApp, "Token " + helpers.HexHash[:80])
+		require.Equal(t, 401, resp.StatusCode)
+		respBody, err = io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NotContains(t, string(respBody), helpers.HexHash[:80])
+
+		// The legacy shape has only the code and request ID.
+		req := httptest.NewRequest("GET", "/api/restricted", nil)
+		req.Header.Set("Authorization", "Token " + helpers.HexHash[:80])
+		req.Header.Set(utils.ErrorFormatHeader, utils.ErrorFormatLegacy)
+		resp, err = prodApp.Test(req, -1)
+		require.NoError(t, err)
+		respBody, err = io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"code":"TOKEN_INVALID","request_id":"` +
+		resp.Header.Get(fiber.HeaderXRequestID) + `"}`, string(respBody))
+	})
+}
+
+func readTestErrorResponse(
+	t *testing.T, resp *http.Response, expectedStatus int,
+) (body utils.ProblemResponseBody) {
+	require.Equal(t, expectedStatus, resp.StatusCode)
+
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("JSON decode failed: %s", err.Error())
+	}
+
+	return
+}
@@ -0,0 +1,242 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/tests/helpers"
+	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+const testExportPassphrase string = "correct horse battery staple"
+
+type testExportFile struct {
+	UserSlug   string         `json:"user_slug"`
+	ExportedAt time.Time      `json:"exported_at"`
+	Vaults     []models.Vault `json:"vaults"`
+}
+
+func testExportUser(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
+	t.Run("invalid_slug_400_bad_request", func(t *testing.T) {
+		slug := "notARealSlug"
+		testExportUserClientError(
+			t, app, conf, 400, utils.ErrorUserSlug, slug, helpers.NewSlug(t), slug,
+			testExportPassphrase, helpers.HexHash[:64],
+		)
+	})
+
+	t.Run("user_slug_header_mismatch_400_bad_request", func(t *testing.T) {
+		testExportUserClientError(
+			t, app, conf, 400, utils.ErrorUserSlug, utils.ErrorUserSlugHeader, helpers.NewSlug(t),
+			helpers.NewSlug(t), testExportPassphrase, helpers.HexHash[:64],
+		)
+	})
+
+	t.Run("invalid_passphrase_400_bad_request", func(t *testing.T) {
+		slug := helpers.NewSlug(t)
+		testExportUserClientError(
+			t, app, conf, 400, utils.ErrorExportPassphrase, "Too short", slug, slug, "",
+			helpers.HexHash[:64],
+		)
+		testExportUserClientError(
+			t, app, conf, 400, utils.ErrorExportPassphrase, "Too short", slug, slug,
+			"eleven char", helpers.HexHash[:64],
+		)
+		testExportUserClientError(
+			t, app, conf, 400, utils.ErrorExportPassphrase, "Too long", slug, slug,
+			string(bytes.Repeat([]byte("a"), 1025)), helpers.HexHash[:64],
+		)
+	})
+
+	t.Run("valid_slug_404_not_found", func(t *testing.T) {
+		setup.SetUpWithData(t, db)
+		slug := helpers.NewSlug(t)
+		testExportUserClientError(
+			t, app, conf, 404, utils.ErrorNotFound, slug, slug, slug, testExportPassphrase,
+			helpers.HexHash[:64],
+		)
+	})
+
+	t.Run("wrong_key_401_unauthorized", func(t *testing.T) {
+		users, _, _, _ := setup.SetUpWithData(t, db)
+		slug := users[0].Slug
+		testExportUserClientError(
+			t, app, conf, 401, utils.ErrorVaultKey, "",
+			slug, slug, testExportPassphrase, helpers.HexHash[64:],
+		)
+	})
+
+	t.Run("valid_slug_200_ok", func(t *testing.T) {
+		users, vaults, entries, secrets := setup.SetUpWithData(t, db)
+		slug := users[0].Slug
+
+		resp := newRequestDeleteEntry(t, app, conf, slug, entries[1].Slug)
+		require.Equal(t, 204, resp.StatusCode)
+
+		resp = newRequestExportUser(
+			t, app, conf, slug, slug, testExportPassphrase, helpers.HexHash[:64],
+		)
+		require.Equal(t, 200, resp.StatusCode)
+		require.Equal(t, fiber.MIMEOctetStream, resp.Header.Get(fiber.HeaderContentType))
+
+		export := readTestExport(t, resp, testExportPassphrase)
+		require.Equal(t, slug, export.UserSlug)
+		require.WithinDuration(t, time.Now(), export.ExportedAt, time.Minute)
+		require.Equal(t, 2, len(export.Vaults))
+
+		exportedVaults := map[string]models.Vault{}
+
+		for _, vault := range export.Vaults {
+			exportedVaults[vault.Slug] = vault
+		}
+
+		vault := exportedVaults[vaults[0].Slug]
+		require.Equal(t, vaults[0].Title, vault.Title)
+		require.Equal(t, 1, len(vault.Entries))
+		require.Equal(t, entries[0].Slug, vault.Entries[0].Slug)
+		require.Equal(t, 2, len(vault.Entries[0].Secrets))
+		require.Equal(t, secrets[0].Slug, vault.Entries[0].Secrets[0].Slug)
+		require.Equal(t, secrets[0].Label, vault.Entries[0].Secrets[0].Label)
+		require.Equal(
+			t, "secret[_string='foodeater1234']@0.0.0.0", vault.Entries[0].Secrets[0].String,
+		)
+
+		vault = exportedVaults[vaults[1].Slug]
+		require.Equal(t, 2, len(vault.Entries))
+		require.Equal(t, 2, len(vault.Entries[0].Secrets))
+		require.Equal(t, 2, len(vault.Entries[1].Secrets))
+	})
+
+	t.Run("team_vault_200_ok", func(t *testing.T) {
+		memberPassword := "m3mb3rVal!dPW"
+		entry, memberSlug := newTestTeamVault(t, app, db, conf, memberPassword, models.RoleViewer)
+
+		// The member's export has the team vault, decrypted under the vault's key.
+		resp := newRequestExportUser(
+			t, app, conf, memberSlug, memberSlug, testExportPassphrase, memberPassword,
+		)
+		require.Equal(t, 200, resp.StatusCode)
+
+		export := readTestExport(t, resp, testExportPassphrase)
+		require.Equal(t, 1, len(export.Vaults))
+		require.Equal(t, entry.VaultSlug, export.Vaults[0].Slug)
+		require.Equal(t, 1, len(export.Vaults[0].Entries))
+		require.Equal(t, entry.Slug, export.Vaults[0].Entries[0].Slug)
+		require.Equal(t, "string", export.Vaults[0].Entries[0].Secrets[0].String)
+
+		resp = newRequestExportUser(
+			t, app, conf, entry.UserSlug, entry.UserSlug, testExportPassphrase, helpers.VALID_PW,
+		)
+		require.Equal(t, 200, resp.StatusCode)
+
+		export = readTestExport(t, resp, testExportPassphrase)
+		require.Equal(t, 1, len(export.Vaults))
+		require.Equal(t, "string", export.Vaults[0].Entries[0].Secrets[0].String)
+	})
+
+	t.Run("multiple_chunks_200_ok", func(t *testing.T) {
+		users, _, _, _ := setup.SetUpWithData(t, db)
+		slug := users[0].Slug
+		titles := []string{}
+
+		for i := 0; i < 500; i++ {
+			titles = append(titles, fmt.Sprintf("export@%03d", i))
+		}
+
+		createTestVaults(t, db, slug, titles...)
+
+		resp := newRequestExportUser(
+			t, app, conf, slug, slug, testExportPassphrase, helpers.HexHash[:64],
+		)
+		require.Equal(t, 200, resp.StatusCode)
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Greater(t, len(body), utils.ExportChunkSize)
+
+		reader, err := utils.NewExportReader(bytes.NewReader(body), testExportPassphrase)
+		require.NoError(t, err)
+
+		var export testExportFile
+		plaintext, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(plaintext, &export))
+		require.Equal(t, 502, len(export.Vaults))
+
+		// Cutting the file short at a chunk boundary drops the final chunk.
+		reader, err = utils.NewExportReader(
+			bytes.NewReader(body[:bytes.IndexByte(body, '\n') + 1 + 5 + utils.ExportChunkSize + 16]),
+			testExportPassphrase,
+		)
+		require.NoError(t, err)
+		_, err = io.ReadAll(reader)
+		require.ErrorIs(t, err, utils.ErrTruncatedExport)
+
+		reader, err = utils.NewExportReader(bytes.NewReader(body), "wrong passphrase")
+		require.NoError(t, err)
+		_, err = io.ReadAll(reader)
+		require.EqualError(t, err, "cipher: message authentication failed")
+	})
+}
+
+func readTestExport(t *testing.T, resp *http.Response, passphrase string) (export testExportFile) {
+	reader, err := utils.NewExportReader(resp.Body, passphrase)
+
+	if err != nil {
+		t.Fatalf("Open export failed: %s", err.Error())
+	}
+
+	if plaintext, err := io.ReadAll(reader); err != nil {
+		t.Fatalf("Decrypt export failed: %s", err.Error())
+	} else if err := json.Unmarshal(plaintext, &export); err != nil {
+		t.Fatalf("JSON unmarshal failed: %s", err.Error())
+	}
+
+	return
+}
+
+func testExportUserClientError(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, expectedStatus int,
+	expectedMessage, expectedDetail, userSlug, slug, passphrase, key string,
+) {
+	resp := newRequestExportUser(t, app, conf, userSlug, slug, passphrase, key)
+	require.Equal(t, expectedStatus, resp.StatusCode)
+	helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+		ClientOperation: utils.ExportUser,
+		Message:         expectedMessage,
+		Detail:          expectedDetail,
+	})
+}
+
+func newRequestExportUser(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, slug, passphrase, key string,
+) *http.Response {
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/" + slug + "/export", nil)
+	req.Header.Set("Client-Operation", utils.ExportUser)
+	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+	req.Header.Set("User-Slug", userSlug)
+	req.Header.Set(conf.EXPORT_PASSPHRASE_HEADER_KEY, passphrase)
+	req.Header.Set(conf.PASSWORD_HEADER_KEY, key)
+
+	resp, err := app.Test(req, -1)
+
+	if err != nil {
+		t.Fatalf("Send test request failed: %s", err.Error())
+	}
+
+	return resp
+}
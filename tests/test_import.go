@@ -0,0 +1,440 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/controllers"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/tests/helpers"
+	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+const bitwardenTestExport = `{
+	"encrypted": false,
+	"folders": [{"id": "f-1", "name": "bitwarden@folder"}],
+	"items": [
+		{
+			"folderId": "f-1",
+			"type": 1,
+			"name": "bitwarden@login",
+			"notes": "some notes",
+			"login": {
+				"username": "bw_user",
+				"password": "bw_pass",
+				"totp": null,
+				"uris": [{"uri": "https://a.example"}, {"uri": "https://b.example"}]
+			},
+			"fields": [{"name": "pin", "value": "1234", "type": 1}]
+		},
+		{
+			"folderId": null,
+			"type": 3,
+			"name": "bitwarden@card",
+			"notes": null,
+			"card": {"cardholderName": "A B", "brand": "Visa", "number": "4111", "code": null}
+		}
+	]
+}`
+
+const keePassTestExport = `<?xml version="1.0" encoding="utf-8" standalone="yes"?>
+<KeePassFile>
+	<Meta><RecycleBinUUID>cmVjeWNsZQ==</RecycleBinUUID></Meta>
+	<Root>
+		<Group>
+			<UUID>cm9vdA==</UUID>
+			<Name>Database</Name>
+			<Entry>
+				<String><Key>Notes</Key><Value></Value></String>
+				<String><Key>Password</Key><Value>kp_pass</Value></String>
+				<String><Key>Title</Key><Value>keepass@root</Value></String>
+				<String><Key>UserName</Key><Value>kp_user</Value></String>
+			</Entry>
+			<Group>
+				<UUID>ZW1haWw=</UUID>
+				<Name>Email</Name>
+				<Group>
+					<UUID>d29yaw==</UUID>
+					<Name>Work</Name>
+					<Entry>
+						<String><Key>Password</Key><Value>kp_work_pass</Value></String>
+						<String><Key>Recovery</Key><Value>kp_recovery</Value></String>
+						<String><Key>Title</Key><Value>keepass@work</Value></String>
+						<String><Key>URL</Key><Value>https://mail.example</Value></String>
+						<History>
+							<Entry>
+								<String><Key>Password</Key><Value>kp_old_pass</Value></String>
+								<String><Key>Title</Key><Value>keepass@work</Value></String>
+							</Entry>
+						</History>
+					</Entry>
+				</Group>
+			</Group>
+			<Group>
+				<UUID>cmVjeWNsZQ==</UUID>
+				<Name>Recycle Bin</Name>
+				<Entry>
+					<String><Key>Title</Key><Value>keepass@recycled</Value></String>
+				</Entry>
+			</Group>
+		</Group>
+	</Root>
+</KeePassFile>`
+
+func testImport(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
+	t.Run("user_slug_header_mismatch_400_bad_request", func(t *testing.T) {
+		testImportClientError(
+			t, app, conf, 400, utils.ErrorUserSlug, utils.ErrorUserSlugHeader, helpers.NewSlug(t),
+			newImportRequestBody(t, helpers.NewSlug(t), "csv", "title\nfoo", ""),
+		)
+	})
+
+	t.Run("invalid_format_400_bad_request", func(t *testing.T) {
+		userSlug := helpers.NewSlug(t)
+		testImportClientError(
+			t, app, conf, 400, utils.ErrorImportFormat, "lastpass", userSlug,
+			newImportRequestBody(t, userSlug, "lastpass", "title\nfoo", ""),
+		)
+	})
+
+	t.Run("empty_data_400_bad_request", func(t *testing.T) {
+		userSlug := helpers.NewSlug(t)
+		testImportClientError(
+			t, app, conf, 400, utils.ErrorImportData, "", userSlug,
+			newImportRequestBody(t, userSlug, "csv", "", ""),
+		)
+	})
+
+	t.Run("too_long_vault_title_400_bad_request", func(t *testing.T) {
+		userSlug := helpers.NewSlug(t)
+		testImportClientError(
+			t, app, conf, 400, utils.ErrorVaultTitle, "Too long", userSlug,
+			newImportRequestBody(t, userSlug, "csv", "title\nfoo", strings.Repeat("a", 256)),
+		)
+	})
+
+	t.Run("invalid_data_400_bad_request", func(t *testing.T) {
+		userSlug := helpers.NewSlug(t)
+		testImportClientError(
+			t, app, conf, 400, utils.ErrorImportData, "No title column.", userSlug,
+			newImportRequestBody(t, userSlug, "csv", "username,password\nfoo,bar", ""),
+		)
+
+		testImportClientError(
+			t, app, conf, 400, utils.ErrorImportData, "Encrypted exports aren't supported.",
+			userSlug, newImportRequestBody(t, userSlug, "bitwarden", `{"encrypted":true}`, ""),
+		)
+
+		testImportClientError(
+			t, app, conf, 400, utils.ErrorImportData, "No root group.", userSlug,
+			newImportRequestBody(t, userSlug, "keepass", "<KeePassFile></KeePassFile>", ""),
+		)
+	})
+
+	t.Run("bitwarden_200_ok", func(t *testing.T) {
+		users, _, _, _ := setup.SetUpWithData(t, db)
+		userSlug := users[0].Slug
+
+		var vaultCount int64
+		helpers.CountVaults(t, db, &vaultCount)
+		require.EqualValues(t, 4, vaultCount)
+
+		respBody := testImportSuccess(
+			t, app, conf, userSlug,
+			newImportRequestBody(t, userSlug, "bitwarden", bitwardenTestExport, ""),
+		)
+		require.Equal(t, 13, len(respBody.Created))
+		require.Empty(t, respBody.Skipped)
+		require.Empty(t, respBody.Conflicts)
+
+		helpers.CountVaults(t, db, &vaultCount)
+		require.EqualValues(t, 6, vaultCount)
+
+		assertImportedEntry(t, db, conf, userSlug, "bitwarden@folder", "bitwarden@login", [][2]string{
+			{"Username", "bw_user"},
+			{"Password", "bw_pass"},
+			{"URI", "https://a.example"},
+			{"URI 2", "https://b.example"},
+			{"Notes", "some notes"},
+			{"pin", "1234"},
+		})
+		assertImportedEntry(t, db, conf, userSlug, "Imported", "bitwarden@card", [][2]string{
+			{"Cardholder name", "A B"},
+			{"Brand", "Visa"},
+			{"Number", "4111"},
+		})
+	})
+
+	t.Run("keepass_200_ok", func(t *testing.T) {
+		users, _, _, _ := setup.SetUpWithData(t, db)
+		userSlug := users[0].Slug
+
+		respBody := testImportSuccess(
+			t, app, conf, userSlug,
+			newImportRequestBody(t, userSlug, "keepass", keePassTestExport, "keepass@vault"),
+		)
+		require.Equal(t, 9, len(respBody.Created))
+		require.Empty(t, respBody.Skipped)
+		require.Empty(t, respBody.Conflicts)
+
+		assertImportedEntry(t, db, conf, userSlug, "keepass@vault", "keepass@root", [][2]string{
+			{"UserName", "kp_user"},
+			{"Password", "kp_pass"},
+		})
+		assertImportedEntry(t, db, conf, userSlug, "Email/Work", "keepass@work", [][2]string{
+			{"Password", "kp_work_pass"},
+			{"URL", "https://mail.example"},
+			{"Recovery", "kp_recovery"},
+		})
+
+		var entryCount int64
+
+		if result := db.Model(&models.Entry{}).Where("title = ?", "keepass@recycled").
+		Count(&entryCount); result.Error != nil {
+			t.Fatalf("Entry count failed: %s", result.Error.Error())
+		}
+
+		require.Zero(t, entryCount)
+	})
+
+	t.Run("csv_200_ok", func(t *testing.T) {
+		users, _, _, _ := setup.SetUpWithData(t, db)
+		userSlug := users[0].Slug
+		data := "\xef\xbb\xbfName,Folder,username,password,url\n" +
+			"csv@entry0,csv@vault,csv_user0,csv_pass0,\n" +
+			"csv@entry1,,csv_user1,csv_pass1,https://csv.example\n"
+
+		respBody := testImportSuccess(
+			t, app, conf, userSlug, newImportRequestBody(t, userSlug, "csv", data, ""),
+		)
+		require.Equal(t, 9, len(respBody.Created))
+
+		assertImportedEntry(t, db, conf, userSlug, "csv@vault", "csv@entry0", [][2]string{
+			{"username", "csv_user0"},
+			{"password", "csv_pass0"},
+		})
+		assertImportedEntry(t, db, conf, userSlug, "Imported", "csv@entry1", [][2]string{
+			{"username", "csv_user1"},
+			{"password", "csv_pass1"},
+			{"url", "https://csv.example"},
+		})
+	})
+
+	t.Run("conflicts_and_skips_200_ok", func(t *testing.T) {
+		users, vaults, _, _ := setup.SetUpWithData(t, db)
+		userSlug := users[0].Slug
+
+		resp := newRequestDeleteVault(t, app, conf, userSlug, vaults[1].Slug)
+		require.Equal(t, 204, resp.StatusCode)
+
+		data := "vault,title,label,label,long\n" +
+			"vault@0.0.*.*,entry@0.0.0.*,a,b,\n" +
+			"vault@0.0.*.*,csv@new,a,b," + strings.Repeat("c", 1001) + "\n" +
+			"vault@0.0.*.*,csv@new,a,,\n" +
+			"vault@0.0.*.*,,a,,\n" +
+			"vault@0.1.*.*,csv@trashed,a,,\n"
+
+		var entryCount, secretCount int64
+		helpers.CountEntries(t, db, &entryCount)
+		helpers.CountSecrets(t, db, &secretCount)
+
+		respBody := testImportSuccess(
+			t, app, conf, userSlug, newImportRequestBody(t, userSlug, "csv", data, ""),
+		)
+
+		require.Equal(t, []controllers.ImportReportItem{
+			{
+				Kind: controllers.ImportKindEntry, VaultTitle: "vault@0.0.*.*", EntryTitle: "csv@new",
+				Slug: respBody.Created[0].Slug,
+			},
+			{
+				Kind: controllers.ImportKindSecret, VaultTitle: "vault@0.0.*.*",
+				EntryTitle: "csv@new", SecretLabel: "label", Slug: respBody.Created[1].Slug,
+			},
+		}, respBody.Created)
+		require.Equal(t, []controllers.ImportReportItem{
+			{
+				Kind: controllers.ImportKindSecret, VaultTitle: "vault@0.0.*.*",
+				EntryTitle: "csv@new", SecretLabel: "long", Reason: utils.ErrorSecretString,
+			},
+			{
+				Kind: controllers.ImportKindEntry, VaultTitle: "vault@0.0.*.*",
+				Reason: utils.ErrorEntryTitle,
+			},
+		}, respBody.Skipped)
+		require.Equal(t, []controllers.ImportReportItem{
+			{
+				Kind: controllers.ImportKindEntry, VaultTitle: "vault@0.0.*.*",
+				EntryTitle: "entry@0.0.0.*", Reason: utils.ErrorDuplicateEntry,
+			},
+			{
+				Kind: controllers.ImportKindSecret, VaultTitle: "vault@0.0.*.*",
+				EntryTitle: "csv@new", SecretLabel: "label", Reason: utils.ErrorDuplicateSecretsLabel,
+			},
+			{
+				Kind: controllers.ImportKindEntry, VaultTitle: "vault@0.0.*.*",
+				EntryTitle: "csv@new", Reason: utils.ErrorDuplicateEntry,
+			},
+			{
+				Kind: controllers.ImportKindVault, VaultTitle: "vault@0.1.*.*",
+				Reason: utils.ErrorImportTrashed,
+			},
+		}, respBody.Conflicts)
+
+		var newEntryCount, newSecretCount int64
+		helpers.CountEntries(t, db, &newEntryCount)
+		helpers.CountSecrets(t, db, &newSecretCount)
+		require.Equal(t, entryCount + 1, newEntryCount)
+		require.Equal(t, secretCount + 1, newSecretCount)
+
+		var entry models.Entry
+		helpers.QueryTestEntry(t, db, &entry, "csv@new")
+		require.Equal(t, vaults[0].Slug, entry.VaultSlug)
+	})
+
+	t.Run("invalid_key_400_bad_request", func(t *testing.T) {
+		users, _, _, _ := setup.SetUpWithData(t, db)
+		userSlug := users[0].Slug
+		body := newImportRequestBody(t, userSlug, "csv", "title,password\ncsv@entry,foo", "")
+
+		var vaultCount, entryCount int64
+		helpers.CountVaults(t, db, &vaultCount)
+		helpers.CountEntries(t, db, &entryCount)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/import", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Client-Operation", utils.Import)
+		req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+		req.Header.Set("User-Slug", userSlug)
+		req.Header.Set(conf.PASSWORD_HEADER_KEY, "not_a_key")
+
+		if resp, err := app.Test(req, -1); err != nil {
+			t.Fatalf("Send test request failed: %s", err.Error())
+		} else {
+			require.Equal(t, 400, resp.StatusCode)
+			helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+				ClientOperation: utils.Import,
+				Message:         utils.ErrorPassword,
+				RequestBody:     body,
+				Detail:          "Expected a hex-encoded key.",
+			})
+		}
+
+		var newVaultCount, newEntryCount int64
+		helpers.CountVaults(t, db, &newVaultCount)
+		helpers.CountEntries(t, db, &newEntryCount)
+		require.Equal(t, vaultCount, newVaultCount)
+		require.Equal(t, entryCount, newEntryCount)
+	})
+}
+
+// assertImportedEntry checks an imported entry's vault, and its secrets' labels and
+// decrypted values in priority order.
+func assertImportedEntry(
+	t *testing.T, db *gorm.DB, conf *config.AppConfig, userSlug, vaultTitle, entryTitle string,
+	expected [][2]string,
+) {
+	var entry models.Entry
+	helpers.QueryTestEntryEager(t, db, &entry, entryTitle)
+	require.Equal(t, userSlug, entry.UserSlug)
+
+	var vault models.Vault
+	helpers.QueryTestVault(t, db, &vault, vaultTitle)
+	require.Equal(t, vault.Slug, entry.VaultSlug)
+	require.Equal(t, userSlug, vault.UserSlug)
+
+	sort.Slice(entry.Secrets, func(i, j int) bool {
+		return entry.Secrets[i].Priority < entry.Secrets[j].Priority
+	})
+
+	actual := [][2]string{}
+	key := helpers.UserSecretsKey(t, db, conf, userSlug, helpers.HexHash[:64])
+
+	for i, secret := range entry.Secrets {
+		require.EqualValues(t, i, secret.Priority)
+		require.Equal(t, vault.Slug, secret.VaultSlug)
+		require.Equal(t, userSlug, secret.UserSlug)
+
+		if plaintext, err := utils.Decrypt(
+			secret.String, key, helpers.SecretAdditionalData(&secret),
+		); err != nil {
+			t.Fatalf("Decrypt failed: %s", err.Error())
+		} else {
+			actual = append(actual, [2]string{secret.Label, plaintext})
+		}
+	}
+
+	require.Equal(t, expected, actual)
+}
+
+func newImportRequestBody(t *testing.T, userSlug, format, data, vaultTitle string) string {
+	body, err := json.Marshal(&controllers.ImportRequestBody{
+		UserSlug: userSlug, Format: format, Data: data, VaultTitle: vaultTitle,
+	})
+
+	if err != nil {
+		t.Fatalf("JSON marshal failed: %s", err.Error())
+	}
+
+	return string(body)
+}
+
+func testImportClientError(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, expectedStatus int,
+	expectedMessage, expectedDetail, userSlug, body string,
+) {
+	resp := newRequestImport(t, app, conf, userSlug, body)
+	require.Equal(t, expectedStatus, resp.StatusCode)
+	helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+		ClientOperation: utils.Import,
+		Message:         expectedMessage,
+		RequestBody:     body,
+		Detail:          expectedDetail,
+	})
+}
+
+func testImportSuccess(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, body string,
+) (respBody controllers.ImportResponseBody) {
+	resp := newRequestImport(t, app, conf, userSlug, body)
+	require.Equal(t, 200, resp.StatusCode)
+
+	if body, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("Read response body failed: %s", err.Error())
+	} else if err := json.Unmarshal(body, &respBody); err != nil {
+		t.Fatalf("JSON unmarshal failed: %s", err.Error())
+	}
+
+	return
+}
+
+func newRequestImport(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, body string,
+) *http.Response {
+
+	req := httptest.NewRequest(http.MethodPost, "/api/import", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Client-Operation", utils.Import)
+	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+	req.Header.Set("User-Slug", userSlug)
+	req.Header.Set(conf.PASSWORD_HEADER_KEY, helpers.HexHash[:64])
+
+	resp, err := app.Test(req, -1)
+
+	if err != nil {
+		t.Fatalf("Send test request failed: %s", err.Error())
+	}
+
+	return resp
+}
@@ -0,0 +1,243 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/controllers"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/tests/helpers"
+	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+func testListAuditEvents(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
+	t.Run("invalid_slug_400_bad_request", func(t *testing.T) {
+		slug := "notARealSlug"
+		testListAuditEventsClientError(
+			t, app, conf, 400, utils.ErrorUserSlug, slug, helpers.NewSlug(t), slug, "",
+		)
+	})
+
+	t.Run("user_slug_header_mismatch_400_bad_request", func(t *testing.T) {
+		testListAuditEventsClientError(
+			t, app, conf, 400, utils.ErrorUserSlug, utils.ErrorUserSlugHeader,
+			helpers.NewSlug(t), helpers.NewSlug(t), "",
+		)
+	})
+
+	t.Run("invalid_query_400_bad_request", func(t *testing.T) {
+		slug := helpers.NewSlug(t)
+
+		for _, tc := range []struct{ query, message, detail string }{
+			{"?limit=0", utils.ErrorLimit, "0"},
+			{"?cursor=foo", utils.ErrorCursor, "foo"},
+			{"?operation=Retrieve-Entry", utils.ErrorAuditOperation, "Retrieve-Entry"},
+			{"?target_slug=foo", utils.ErrorAuditTargetSlug, "foo"},
+			{"?outcome=maybe", utils.ErrorAuditOutcome, "maybe"},
+			{"?since=yesterday", utils.ErrorAuditSince, "yesterday"},
+			{"?until=tomorrow", utils.ErrorAuditUntil, "tomorrow"},
+		} {
+			testListAuditEventsClientError(
+				t, app, conf, 400, tc.message, tc.detail, slug, slug, tc.query,
+			)
+		}
+	})
+
+	t.Run("valid_slug_404_not_found", func(t *testing.T) {
+		setup.SetUpWithData(t, db)
+		slug := helpers.NewSlug(t)
+		testListAuditEventsClientError(t, app, conf, 404, utils.ErrorNotFound, slug, slug, slug, "")
+	})
+
+	t.Run("records_operations_200_ok", func(t *testing.T) {
+		users, _, entries, secrets := setup.SetUpWithData(t, db)
+		slug := users[0].Slug
+
+		retrieveResp := newRequestRetrieveEntry(t, app, conf, slug, entries[0].Slug)
+		require.Equal(t, 200, retrieveResp.StatusCode)
+
+		resp := newRequestUpdateSecret(
+			t, app, conf, slug, secrets[0].Slug, `{"secret_string":"new string"}`,
+		)
+		require.Equal(t, 204, resp.StatusCode)
+
+		resp = newRequestCreateVault(
+			t, app, conf, slug, `{"user_slug":"` + slug + `","vault_title":"Audited"}`,
+		)
+		require.Equal(t, 204, resp.StatusCode)
+
+		var vault models.Vault
+		helpers.QueryTestVault(t, db, &vault, "Audited")
+
+		missingSlug := helpers.NewSlug(t)
+		resp = newRequestRetrieveEntry(t, app, conf, slug, missingSlug)
+		require.Equal(t, 404, resp.StatusCode)
+
+		// Other users' events and requests without a valid user aren't listed.
+		resp = newRequestRetrieveEntry(t, app, conf, users[1].Slug, entries[4].Slug)
+		require.Equal(t, 200, resp.StatusCode)
+		resp = newRequestRetrieveEntry(t, app, conf, "notARealSlug", entries[0].Slug)
+		require.Equal(t, 400, resp.StatusCode)
+
+		events := listTestAuditEvents(t, app, conf, slug, "").Events
+		require.Equal(t, 4, len(events))
+
+		for i, expected := range []struct {
+			operation, target, outcome string
+			status                     int
+		}{
+			{utils.RetrieveEntry, missingSlug, controllers.AuditOutcomeFailure, 404},
+			{utils.CreateVault, vault.Slug, controllers.AuditOutcomeSuccess, 204},
+			{utils.UpdateSecret, secrets[0].Slug, controllers.AuditOutcomeSuccess, 204},
+			{utils.RetrieveEntry, entries[0].Slug, controllers.AuditOutcomeSuccess, 200},
+		} {
+			require.Equal(t, expected.operation, events[i].Operation)
+			require.Equal(t, expected.target, events[i].TargetSlug)
+			require.Equal(t, expected.outcome, events[i].Outcome)
+			require.Equal(t, expected.status, events[i].Status)
+			require.NotEmpty(t, events[i].RequestID)
+			require.WithinDuration(t, time.Now(), events[i].CreatedAt, time.Minute)
+		}
+
+		require.Equal(t, retrieveResp.Header.Get(fiber.HeaderXRequestID), events[3].RequestID)
+
+		var total int64
+		require.NoError(t, db.Model(&models.AuditEvent{}).Count(&total).Error)
+		require.Equal(t, int64(6), total)
+	})
+
+	t.Run("filters_200_ok", func(t *testing.T) {
+		users, _, entries, _ := setup.SetUpWithData(t, db)
+		slug := users[0].Slug
+
+		for _, entrySlug := range []string{entries[0].Slug, entries[1].Slug, entries[0].Slug} {
+			resp := newRequestRetrieveEntry(t, app, conf, slug, entrySlug)
+			require.Equal(t, 200, resp.StatusCode)
+		}
+
+		resp := newRequestDeleteEntry(t, app, conf, slug, entries[1].Slug)
+		require.Equal(t, 204, resp.StatusCode)
+		resp = newRequestDeleteEntry(t, app, conf, slug, entries[1].Slug)
+		require.Equal(t, 404, resp.StatusCode)
+
+		require.Equal(t, 3, len(listTestAuditEvents(
+			t, app, conf, slug, "?operation=" + utils.RetrieveEntry,
+		).Events))
+		require.Equal(t, 2, len(listTestAuditEvents(
+			t, app, conf, slug, "?operation=retrieve_entry&target_slug=" + entries[0].Slug,
+		).Events))
+		require.Equal(t, 3, len(listTestAuditEvents(
+			t, app, conf, slug, "?target_slug=" + entries[1].Slug,
+		).Events))
+
+		failures := listTestAuditEvents(t, app, conf, slug, "?outcome=failure").Events
+		require.Equal(t, 1, len(failures))
+		require.Equal(t, utils.DeleteEntry, failures[0].Operation)
+
+		// Includes the listings above, which are audited too.
+		all := listTestAuditEvents(t, app, conf, slug, "").Events
+		require.Equal(t, 9, len(all))
+
+		// The listing of `all` is recorded after it, so it's one of the events since.
+		since := url.QueryEscape(all[4].CreatedAt.Format(time.RFC3339Nano))
+		require.Equal(t, 6, len(listTestAuditEvents(t, app, conf, slug, "?since=" + since).Events))
+		require.Equal(t, 4, len(listTestAuditEvents(t, app, conf, slug, "?until=" + since).Events))
+	})
+
+	t.Run("pagination_200_ok", func(t *testing.T) {
+		users, _, entries, _ := setup.SetUpWithData(t, db)
+		slug := users[0].Slug
+
+		for i := 0; i < 5; i++ {
+			resp := newRequestRetrieveEntry(t, app, conf, slug, entries[0].Slug)
+			require.Equal(t, 200, resp.StatusCode)
+		}
+
+		query := "?operation=retrieve_entry&limit=2"
+		seen := map[string]bool{}
+		page := listTestAuditEvents(t, app, conf, slug, query)
+
+		for pages := 1; ; pages++ {
+			for _, event := range page.Events {
+				require.False(t, seen[event.Slug])
+				seen[event.Slug] = true
+			}
+
+			if page.NextCursor == "" {
+				require.Equal(t, 3, pages)
+				break
+			}
+
+			page = listTestAuditEvents(t, app, conf, slug, query + "&cursor=" + page.NextCursor)
+		}
+
+		require.Equal(t, 5, len(seen))
+	})
+
+	t.Run("append_only", func(t *testing.T) {
+		users, _, entries, _ := setup.SetUpWithData(t, db)
+		resp := newRequestRetrieveEntry(t, app, conf, users[0].Slug, entries[0].Slug)
+		require.Equal(t, 200, resp.StatusCode)
+
+		var event models.AuditEvent
+		require.NoError(t, db.First(&event).Error)
+		require.Error(t, db.Model(&event).Update("outcome", controllers.AuditOutcomeFailure).Error)
+		require.Error(t, db.Delete(&event).Error)
+		require.NoError(t, db.First(&event, "slug = ?", event.Slug).Error)
+		require.Equal(t, controllers.AuditOutcomeSuccess, event.Outcome)
+	})
+}
+
+func listTestAuditEvents(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, slug, query string,
+) (body controllers.ListAuditEventsResponseBody) {
+	resp := newRequestListAuditEvents(t, app, conf, slug, slug, query)
+	require.Equal(t, 200, resp.StatusCode)
+
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("JSON decode failed: %s", err.Error())
+	}
+
+	return
+}
+
+func testListAuditEventsClientError(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, expectedStatus int,
+	expectedMessage, expectedDetail, userSlug, slug, query string,
+) {
+	resp := newRequestListAuditEvents(t, app, conf, userSlug, slug, query)
+	require.Equal(t, expectedStatus, resp.StatusCode)
+	helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+		ClientOperation: utils.ListAuditEvents,
+		Message:         expectedMessage,
+		Detail:          expectedDetail,
+	})
+}
+
+func newRequestListAuditEvents(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, slug, query string,
+) *http.Response {
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/" + slug + "/audit" + query, nil)
+	req.Header.Set("Client-Operation", utils.ListAuditEvents)
+	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+	req.Header.Set("User-Slug", userSlug)
+
+	resp, err := app.Test(req, -1)
+
+	if err != nil {
+		t.Fatalf("Send test request failed: %s", err.Error())
+	}
+
+	return resp
+}
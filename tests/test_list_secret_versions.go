@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/controllers"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/tests/helpers"
+	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+func testListSecretVersions(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
+	t.Run("invalid_slug_400_bad_request", func(t *testing.T) {
+		slug := "notARealSlug"
+		testListSecretVersionsClientError(
+			t, app, conf, 400, utils.ErrorSecretSlug, slug, helpers.NewSlug(t), slug,
+		)
+	})
+
+	t.Run("valid_slug_404_not_found", func(t *testing.T) {
+		setup.SetUpWithData(t, db)
+		slug := helpers.NewSlug(t)
+		testListSecretVersionsClientError(
+			t, app, conf, 404, utils.ErrorNotFound, slug, helpers.NewSlug(t), slug,
+		)
+	})
+
+	t.Run("other_users_slug_404_not_found", func(t *testing.T) {
+		users, _, _, secrets := setup.SetUpWithData(t, db)
+		slug := secrets[0].Slug
+		testListSecretVersionsClientError(
+			t, app, conf, 404, utils.ErrorNotFound, slug, users[1].Slug, slug,
+		)
+	})
+
+	t.Run("no_versions_200_ok", func(t *testing.T) {
+		_, _, _, secrets := setup.SetUpWithData(t, db)
+		respBody := testListSecretVersionsSuccess(t, app, conf, secrets[0].UserSlug, secrets[0].Slug)
+		require.Empty(t, respBody.Versions)
+	})
+
+	t.Run("valid_slug_200_ok", func(t *testing.T) {
+		_, _, _, secrets := setup.SetUpWithData(t, db)
+		secret := secrets[0]
+
+		for _, body := range []string{
+			`{"secret_string":"updated_string"}`,
+			`{"secret_label":"updated_label","secret_string":"updated_again_string"}`,
+		} {
+			resp := newRequestUpdateSecret(t, app, conf, secret.UserSlug, secret.Slug, body)
+			require.Equal(t, 204, resp.StatusCode)
+		}
+
+		respBody := testListSecretVersionsSuccess(t, app, conf, secret.UserSlug, secret.Slug)
+		require.Equal(t, 2, len(respBody.Versions))
+
+		// Newest first: the value replaced by the second update, then the original.
+		require.Equal(t, secret.Label, respBody.Versions[0].Label)
+		require.Equal(t, "updated_string", respBody.Versions[0].String)
+		require.Equal(t, secret.Label, respBody.Versions[1].Label)
+		require.Equal(t, "secret[_string='foodeater1234']@0.0.0.0", respBody.Versions[1].String)
+		require.True(t, respBody.Versions[1].SecretUpdatedAt.Equal(secret.UpdatedAt))
+		require.Empty(t, respBody.Versions[0].SecretSlug)
+		require.Empty(t, respBody.Versions[0].UserSlug)
+	})
+
+	t.Run("swapped_ciphertext_500_error", func(t *testing.T) {
+		_, _, _, secrets := setup.SetUpWithData(t, db)
+		secret := secrets[0]
+
+		resp := newRequestUpdateSecret(
+			t, app, conf, secret.UserSlug, secret.Slug, `{"secret_string":"updated_string"}`,
+		)
+		require.Equal(t, 204, resp.StatusCode)
+
+		// Another secret's ciphertext, as it is under the user's key now.
+		var other models.Secret
+		helpers.QueryTestSecretBySlug(t, db, &other, secrets[1].Slug)
+
+		if result := db.Model(&models.SecretVersion{}).Where("secret_slug = ?", secret.Slug).
+		UpdateColumn("string", other.String); result.Error != nil {
+			t.Fatalf("Secret version update failed: %s", result.Error.Error())
+		}
+
+		testListSecretVersionsClientError(
+			t, app, conf, 500, utils.ErrorDecrypt, "cipher: message authentication failed",
+			secret.UserSlug, secret.Slug,
+		)
+	})
+}
+
+func testListSecretVersionsClientError(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, expectedStatus int,
+	expectedMessage, expectedDetail, userSlug, slug string,
+) {
+	resp := newRequestListSecretVersions(t, app, conf, userSlug, slug)
+	require.Equal(t, expectedStatus, resp.StatusCode)
+	helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+		ClientOperation: utils.ListSecretVersions,
+		Message:         expectedMessage,
+		Detail:          expectedDetail,
+	})
+}
+
+func testListSecretVersionsSuccess(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, slug string,
+) (respBody controllers.ListSecretVersionsResponseBody) {
+	resp := newRequestListSecretVersions(t, app, conf, userSlug, slug)
+	require.Equal(t, 200, resp.StatusCode)
+
+	if body, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("Read response body failed: %s", err.Error())
+	} else if err := json.Unmarshal(body, &respBody); err != nil {
+		t.Fatalf("JSON unmarshal failed: %s", err.Error())
+	}
+
+	return
+}
+
+func newRequestListSecretVersions(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, slug string,
+) *http.Response {
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/api/secrets/%s/versions", slug), nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Client-Operation", utils.ListSecretVersions)
+	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+	req.Header.Set("User-Slug", userSlug)
+	req.Header.Set(conf.PASSWORD_HEADER_KEY, helpers.HexHash[:64])
+
+	resp, err := app.Test(req, -1)
+
+	if err != nil {
+		t.Fatalf("Send test request failed: %s", err.Error())
+	}
+
+	return resp
+}
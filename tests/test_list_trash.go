@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/controllers"
+	"github.com/liobrdev/simplepasswords_vaults/tests/helpers"
+	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+func testListTrash(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
+	t.Run("missing_user_slug_header_400_bad_request", func(t *testing.T) {
+		resp := newRequestListTrash(t, app, conf, "")
+		require.Equal(t, 400, resp.StatusCode)
+		helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+			ClientOperation: utils.ListTrash,
+			Message:         utils.ErrorUserSlug,
+		})
+	})
+
+	t.Run("empty_trash_200_ok", func(t *testing.T) {
+		users, _, _, _ := setup.SetUpWithData(t, db)
+		respBody := testListTrashSuccess(t, app, conf, users[0].Slug)
+		require.Empty(t, respBody.Vaults)
+		require.Empty(t, respBody.Entries)
+		require.Empty(t, respBody.Secrets)
+	})
+
+	t.Run("trashed_records_200_ok", func(t *testing.T) {
+		users, vaults, entries, secrets := setup.SetUpWithData(t, db)
+
+		// A secret of vaults[1] is trashed on its own before the whole vault is.
+		resp := newRequestDeleteEntry(t, app, conf, users[0].Slug, entries[0].Slug)
+		require.Equal(t, 204, resp.StatusCode)
+		resp = newRequestDeleteSecret(t, app, conf, users[0].Slug, secrets[4].Slug)
+		require.Equal(t, 204, resp.StatusCode)
+		resp = newRequestDeleteVault(t, app, conf, users[0].Slug, vaults[1].Slug)
+		require.Equal(t, 204, resp.StatusCode)
+
+		respBody := testListTrashSuccess(t, app, conf, users[0].Slug)
+
+		require.Equal(t, 1, len(respBody.Vaults))
+		require.Equal(t, vaults[1].Slug, respBody.Vaults[0].Slug)
+		require.Equal(t, vaults[1].Title, respBody.Vaults[0].Title)
+		require.False(t, respBody.Vaults[0].DeletedAt.IsZero())
+
+		require.Equal(t, 1, len(respBody.Entries))
+		require.Equal(t, entries[0].Slug, respBody.Entries[0].Slug)
+		require.Equal(t, entries[0].Title, respBody.Entries[0].Title)
+		require.Equal(t, vaults[0].Slug, respBody.Entries[0].VaultSlug)
+		require.False(t, respBody.Entries[0].DeletedAt.IsZero())
+
+		require.Equal(t, 1, len(respBody.Secrets))
+		require.Equal(t, secrets[4].Slug, respBody.Secrets[0].Slug)
+		require.Equal(t, secrets[4].Label, respBody.Secrets[0].Label)
+		require.Equal(t, entries[2].Slug, respBody.Secrets[0].EntrySlug)
+		require.Equal(t, vaults[1].Slug, respBody.Secrets[0].VaultSlug)
+		require.True(t, respBody.Secrets[0].DeletedAt.Before(respBody.Vaults[0].DeletedAt))
+
+		respBody = testListTrashSuccess(t, app, conf, users[1].Slug)
+		require.Empty(t, respBody.Vaults)
+		require.Empty(t, respBody.Entries)
+		require.Empty(t, respBody.Secrets)
+	})
+}
+
+func testListTrashSuccess(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug string,
+) (respBody controllers.ListTrashResponseBody) {
+	resp := newRequestListTrash(t, app, conf, userSlug)
+	require.Equal(t, 200, resp.StatusCode)
+
+	if body, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("Read response body failed: %s", err.Error())
+	} else if err := json.Unmarshal(body, &respBody); err != nil {
+		t.Fatalf("JSON unmarshal failed: %s", err.Error())
+	}
+
+	return
+}
+
+func newRequestListTrash(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug string,
+) *http.Response {
+
+	req := httptest.NewRequest("GET", "/api/trash", nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Client-Operation", utils.ListTrash)
+	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+	req.Header.Set("User-Slug", userSlug)
+
+	resp, err := app.Test(req, -1)
+
+	if err != nil {
+		t.Fatalf("Send test request failed: %s", err.Error())
+	}
+
+	return resp
+}
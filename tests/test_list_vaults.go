@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"encoding/base64"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -22,7 +23,129 @@ import (
 func testListVaults(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
 	t.Run("invalid_slug_400_bad_request", func(t *testing.T) {
 		slug := "notARealSlug"
-		testListVaultsClientError(t, app, conf, 400, utils.ErrorUserSlug, slug, slug)
+		testListVaultsClientError(t, app, conf, 400, utils.ErrorUserSlug, slug, slug, "")
+	})
+
+	t.Run("invalid_limit_400_bad_request", func(t *testing.T) {
+		slug := helpers.NewSlug(t)
+
+		for _, limit := range []string{"0", "-1", "501", "ten"} {
+			testListVaultsClientError(
+				t, app, conf, 400, utils.ErrorLimit, limit, slug, "?limit=" + limit,
+			)
+		}
+	})
+
+	t.Run("invalid_sort_400_bad_request", func(t *testing.T) {
+		testListVaultsClientError(
+			t, app, conf, 400, utils.ErrorSort, "slug", helpers.NewSlug(t), "?sort=slug",
+		)
+	})
+
+	t.Run("too_long_title_prefix_400_bad_request", func(t *testing.T) {
+		if prefix, err := utils.GenerateSlug(256); err != nil {
+			t.Fatalf("Generate long string failed: %s", err.Error())
+		} else {
+			testListVaultsClientError(
+				t, app, conf, 400, utils.ErrorTitlePrefix, "Too long",
+				helpers.NewSlug(t), "?title_prefix=" + prefix,
+			)
+		}
+	})
+
+	t.Run("invalid_cursor_400_bad_request", func(t *testing.T) {
+		slug := helpers.NewSlug(t)
+
+		testListVaultsClientError(
+			t, app, conf, 400, utils.ErrorCursor, "notACursor!", slug, "?cursor=notACursor!",
+		)
+
+		cursor := base64.RawURLEncoding.EncodeToString([]byte(`{"s":"created","v":"x","k":"y"}`))
+		testListVaultsClientError(
+			t, app, conf, 400, utils.ErrorCursor, cursor, slug, "?cursor=" + cursor,
+		)
+	})
+
+	t.Run("cursor_sort_mismatch_400_bad_request", func(t *testing.T) {
+		users, _, _, _ := setup.SetUpWithData(t, db)
+		slug := users[0].Slug
+
+		page := testListVaultsPage(t, app, conf, slug, "?limit=1&sort=title")
+		require.NotEmpty(t, page.NextCursor)
+
+		testListVaultsClientError(
+			t, app, conf, 400, utils.ErrorCursor, utils.ErrorCursorSort,
+			slug, "?limit=1&sort=updated&cursor=" + page.NextCursor,
+		)
+	})
+
+	t.Run("paginated_200_ok", func(t *testing.T) {
+		users, _, _, _ := setup.SetUpWithData(t, db)
+		slug := users[0].Slug
+		createTestVaults(t, db, slug, "Zeta", "alpha", "beta", "Gamma", "delta")
+
+		for _, sort := range []string{"title", "created", "updated"} {
+			var pagedVaults []models.Vault
+
+			if result := db.Where("user_slug = ?", slug).Find(&pagedVaults); result.Error != nil {
+				t.Fatalf("Vaults query failed: %s", result.Error.Error())
+			}
+
+			require.Equal(t, 7, len(pagedVaults))
+			pagedVaults = pagedVaults[:0]
+			query := "?limit=2&sort=" + sort
+
+			for pages := 0; ; pages++ {
+				require.Less(t, pages, 4)
+				page := testListVaultsPage(t, app, conf, slug, query)
+				pagedVaults = append(pagedVaults, page.Vaults...)
+
+				if page.NextCursor == "" {
+					require.Equal(t, 3, pages)
+					break
+				}
+
+				require.Equal(t, 2, len(page.Vaults))
+				query = "?limit=2&sort=" + sort + "&cursor=" + page.NextCursor
+			}
+
+			require.Equal(t, 7, len(pagedVaults))
+
+			for i := 1; i < len(pagedVaults); i++ {
+				previous, current := pagedVaults[i - 1], pagedVaults[i]
+				require.NotEqual(t, previous.Slug, current.Slug)
+
+				switch sort {
+				case "title":
+					require.Less(t, previous.Title, current.Title)
+				case "created":
+					require.False(t, previous.CreatedAt.Before(current.CreatedAt))
+				case "updated":
+					require.False(t, previous.UpdatedAt.Before(current.UpdatedAt))
+				}
+			}
+		}
+	})
+
+	t.Run("title_prefix_200_ok", func(t *testing.T) {
+		users, _, _, _ := setup.SetUpWithData(t, db)
+		slug := users[0].Slug
+		createTestVaults(t, db, slug, "Bank", "bank_old", "Banking%", "Email")
+
+		page := testListVaultsPage(t, app, conf, slug, "?sort=title&title_prefix=BANK")
+		require.Equal(t, 3, len(page.Vaults))
+		require.Equal(t, "Bank", page.Vaults[0].Title)
+		require.Equal(t, "Banking%", page.Vaults[1].Title)
+		require.Equal(t, "bank_old", page.Vaults[2].Title)
+		require.Empty(t, page.NextCursor)
+
+		// Wildcards in the prefix are matched literally.
+		page = testListVaultsPage(t, app, conf, slug, "?title_prefix=bank_")
+		require.Equal(t, 1, len(page.Vaults))
+		require.Equal(t, "bank_old", page.Vaults[0].Title)
+
+		page = testListVaultsPage(t, app, conf, slug, "?title_prefix=%25")
+		require.Empty(t, page.Vaults)
 	})
 
 	t.Run("valid_slug_200_ok", func(t *testing.T) {
@@ -30,11 +153,36 @@ func testListVaults(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppC
 	})
 }
 
+func createTestVaults(t *testing.T, db *gorm.DB, userSlug string, titles ...string) {
+	for _, title := range titles {
+		vault := models.Vault{Slug: helpers.NewSlug(t), Title: title, UserSlug: userSlug}
+
+		if result := db.Create(&vault); result.Error != nil {
+			t.Fatalf("Vault creation failed: %s", result.Error.Error())
+		}
+	}
+}
+
+func testListVaultsPage(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, slug, query string,
+) (page controllers.ListVaultsResponseBody) {
+	resp := newRequestListVaults(t, app, conf, slug, query)
+	require.Equal(t, 200, resp.StatusCode)
+
+	if respBody, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("Read response body failed: %s", err.Error())
+	} else if err := json.Unmarshal(respBody, &page); err != nil {
+		t.Fatalf("JSON unmarshal failed: %s", err.Error())
+	}
+
+	return
+}
+
 func testListVaultsClientError(
 	t *testing.T, app *fiber.App, conf *config.AppConfig, expectedStatus int,
-	expectedMessage, expectedDetail, slug string,
+	expectedMessage, expectedDetail, slug, query string,
 ) {
-	resp := newRequestListVaults(t, app, conf, slug)
+	resp := newRequestListVaults(t, app, conf, slug, query)
 	require.Equal(t, expectedStatus, resp.StatusCode)
 	helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
 		ClientOperation: utils.ListVaults,
@@ -46,7 +194,7 @@ func testListVaultsClientError(
 func testListVaultsSuccess(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
 	users, vaults, _, _ := setup.SetUpWithData(t, db)
 	slug := users[0].Slug
-	resp := newRequestListVaults(t, app, conf, slug)
+	resp := newRequestListVaults(t, app, conf, slug, "")
 	require.Equal(t, 200, resp.StatusCode)
 
 	if respBody, err := io.ReadAll(resp.Body); err != nil {
@@ -60,6 +208,11 @@ func testListVaultsSuccess(t *testing.T, app *fiber.App, db *gorm.DB, conf *conf
 			t.Fatalf("JSON unmarshal failed: %s", err.Error())
 		}
 
+		// The user created both, so they own both.
+		for i := range vaultsJSON {
+			vaultsJSON[i].Role = models.RoleOwner
+		}
+
 		var listVaultsRespBody controllers.ListVaultsResponseBody
 
 		if err := json.Unmarshal(respBody, &listVaultsRespBody); err != nil {
@@ -74,10 +227,10 @@ func testListVaultsSuccess(t *testing.T, app *fiber.App, db *gorm.DB, conf *conf
 }
 
 func newRequestListVaults(
-	t *testing.T, app *fiber.App, conf *config.AppConfig, slug string,
+	t *testing.T, app *fiber.App, conf *config.AppConfig, slug, query string,
 ) *http.Response {
 
-	req := httptest.NewRequest("GET", "/api/vaults", nil)
+	req := httptest.NewRequest("GET", "/api/vaults" + query, nil)
 	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
 	req.Header.Set("Client-Operation", utils.ListVaults)
 	req.Header.Set("Content-Type", "application/json")
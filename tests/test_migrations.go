@@ -0,0 +1,245 @@
+package tests
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/database"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/tests/helpers"
+	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
+)
+
+var testMigrationModels = []interface{}{
+	&models.User{}, &models.Vault{}, &models.Entry{}, &models.Secret{}, &models.SecretVersion{},
+	&models.AuditEvent{}, &models.IntegrityRecord{}, &models.IntegrityRoot{}, &models.APIClient{},
+	&models.Share{}, &models.ShareSecret{}, &models.VaultMember{}, &models.AuthFailure{},
+}
+
+func testMigrations(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
+	t.Run("new_database_at_latest_version", func(t *testing.T) {
+		setup.TearDown(t, db)
+
+		version, err := database.SchemaVersion(db)
+		require.NoError(t, err)
+		require.Equal(t, 0, version)
+		require.ErrorIs(t, database.CheckSchemaVersion(db), database.ErrSchemaBehind)
+
+		applied, err := database.Migrate(db)
+		require.NoError(t, err)
+		require.Equal(t, database.LatestSchemaVersion(), len(applied))
+		require.NoError(t, database.CheckSchemaVersion(db))
+
+		applied, err = database.Migrate(db)
+		require.NoError(t, err)
+		require.Empty(t, applied)
+	})
+
+	t.Run("schema_matches_models", func(t *testing.T) {
+		setup.SetUp(t, db)
+		requireTestSchemaMatchesModels(t, db)
+	})
+
+	t.Run("down_and_up", func(t *testing.T) {
+		setup.SetUpWithData(t, db)
+		testMigrationsDownAndUp(t, db)
+	})
+
+	t.Run("adopts_auto_migrated_database", func(t *testing.T) {
+		// The tables AutoMigrate created before there were migrations.
+		setup.TearDown(t, db)
+		require.NoError(t, db.AutoMigrate(
+			&baselineUser{}, &baselineVault{}, &baselineEntry{}, &baselineSecret{},
+		))
+		require.False(t, db.Migrator().HasColumn("vaults", "deleted_at"))
+
+		now := time.Now()
+		user := baselineUser{Slug: helpers.NewSlug(t), CreatedAt: now, UpdatedAt: now}
+		require.NoError(t, db.Create(&user).Error)
+		vault := baselineVault{Slug: helpers.NewSlug(t), Title: "vault", UserSlug: user.Slug}
+		require.NoError(t, db.Create(&vault).Error)
+
+		applied, err := database.Migrate(db)
+		require.NoError(t, err)
+		require.Equal(t, database.LatestSchemaVersion(), len(applied))
+		require.NoError(t, database.CheckSchemaVersion(db))
+		requireTestSchemaMatchesModels(t, db)
+
+		var userCount int64
+		helpers.CountUsers(t, db, &userCount)
+		require.Equal(t, int64(1), userCount)
+
+		// The existing vault isn't in the trash.
+		var vaultCount int64
+		require.NoError(t, db.Model(&models.Vault{}).Where("slug = ?", vault.Slug).
+		Count(&vaultCount).Error)
+		require.Equal(t, int64(1), vaultCount)
+	})
+
+	t.Run("database_ahead_refused", func(t *testing.T) {
+		setup.SetUp(t, db)
+		require.NoError(t, db.Create(&database.SchemaMigration{
+			Version: database.LatestSchemaVersion() + 1, Name: "from_the_future",
+		}).Error)
+
+		require.ErrorIs(t, database.CheckSchemaVersion(db), database.ErrSchemaAhead)
+
+		_, err := database.Migrate(db)
+		require.ErrorIs(t, err, database.ErrSchemaAhead)
+
+		_, err = database.MigrateDown(db, 0)
+		require.ErrorIs(t, err, database.ErrSchemaAhead)
+
+		// Nothing was reverted.
+		require.True(t, db.Migrator().HasTable(&models.Vault{}))
+	})
+
+	t.Run("unknown_target_version", func(t *testing.T) {
+		setup.SetUp(t, db)
+
+		_, err := database.MigrateUp(db, database.LatestSchemaVersion() + 1)
+		require.Error(t, err)
+
+		_, err = database.MigrateDown(db, -1)
+		require.Error(t, err)
+	})
+
+	// Runs against a scratch Postgres database when one is given, since the production
+	// schema comes from the Postgres migration files rather than the SQLite ones.
+	t.Run("postgres", func(t *testing.T) {
+		dsn := os.Getenv("VAULTS_TEST_POSTGRES_DSN")
+
+		if dsn == "" {
+			t.Skip("VAULTS_TEST_POSTGRES_DSN isn't set")
+		}
+
+		pg, err := gorm.Open(postgres.Open(dsn), &gorm.Config{SkipDefaultTransaction: true})
+		require.NoError(t, err)
+
+		setup.TearDown(t, pg)
+		defer setup.TearDown(t, pg)
+
+		_, err = database.Migrate(pg)
+		require.NoError(t, err)
+		requireTestSchemaMatchesModels(t, pg)
+		testMigrationsDownAndUp(t, pg)
+	})
+}
+
+func testMigrationsDownAndUp(t *testing.T, db *gorm.DB) {
+	latest := database.LatestSchemaVersion()
+
+	reverted, err := database.MigrateDown(db, 0)
+	require.NoError(t, err)
+	require.Equal(t, latest, len(reverted))
+	require.Equal(t, latest, reverted[0].Version)
+	require.Equal(t, 1, reverted[len(reverted) - 1].Version)
+
+	for _, model := range testMigrationModels {
+		require.False(t, db.Migrator().HasTable(model))
+	}
+
+	version, err := database.SchemaVersion(db)
+	require.NoError(t, err)
+	require.Equal(t, 0, version)
+
+	applied, err := database.MigrateUp(db, 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(applied))
+	require.Equal(t, "initial_schema", applied[0].Name)
+	require.ErrorIs(t, database.CheckSchemaVersion(db), database.ErrSchemaBehind)
+
+	applied, err = database.Migrate(db)
+	require.NoError(t, err)
+	require.Equal(t, latest - 1, len(applied))
+	require.NoError(t, database.CheckSchemaVersion(db))
+	requireTestSchemaMatchesModels(t, db)
+}
+
+// requireTestSchemaMatchesModels checks that the migrations created every column and
+// index the models declare, so the two can't drift apart.
+func requireTestSchemaMatchesModels(t *testing.T, db *gorm.DB) {
+	for _, model := range testMigrationModels {
+		stmt := &gorm.Statement{DB: db}
+		require.NoError(t, stmt.Parse(model))
+		require.True(t, db.Migrator().HasTable(model), stmt.Schema.Table)
+
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" {
+				require.True(
+					t, db.Migrator().HasColumn(model, field.DBName),
+					stmt.Schema.Table + "." + field.DBName,
+				)
+			}
+		}
+
+		for name := range stmt.Schema.ParseIndexes() {
+			require.True(t, db.Migrator().HasIndex(model, name), name)
+		}
+	}
+}
+
+// The models as they were before there were migrations, for AutoMigrate to create the
+// tables that databases deployed back then still have.
+type baselineUser struct {
+	Slug      string           `gorm:"primaryKey;not null"`
+	CreatedAt time.Time        `gorm:"autoCreateTime:nano;not null"`
+	UpdatedAt time.Time        `gorm:"autoUpdateTime:nano;not null"`
+	Vaults    []baselineVault  `gorm:"foreignKey:UserSlug;constraint:OnDelete:CASCADE"`
+	Entries   []baselineEntry  `gorm:"foreignKey:UserSlug"`
+	Secrets   []baselineSecret `gorm:"foreignKey:UserSlug"`
+}
+
+func (baselineUser) TableName() string {
+	return "users"
+}
+
+type baselineVault struct {
+	Slug      string          `gorm:"primaryKey;not null"`
+	CreatedAt time.Time       `gorm:"autoCreateTime:nano;not null"`
+	UpdatedAt time.Time       `gorm:"autoUpdateTime:nano;not null"`
+	Title     string          `gorm:"uniqueIndex:unique_title_user_slug;not null"`
+	UserSlug  string          `gorm:"uniqueIndex:unique_title_user_slug;index;not null"`
+	Entries   []baselineEntry `gorm:"foreignKey:VaultSlug;constraint:OnDelete:CASCADE"`
+}
+
+func (baselineVault) TableName() string {
+	return "vaults"
+}
+
+type baselineEntry struct {
+	Slug      string           `gorm:"primaryKey;not null"`
+	CreatedAt time.Time        `gorm:"autoCreateTime:nano;not null"`
+	UpdatedAt time.Time        `gorm:"autoUpdateTime:nano;not null"`
+	Title     string           `gorm:"uniqueIndex:unique_title_vault_slug;not null"`
+	VaultSlug string           `gorm:"uniqueIndex:unique_title_vault_slug;index;not null"`
+	UserSlug  string           `gorm:"not null"`
+	Secrets   []baselineSecret `gorm:"foreignKey:EntrySlug;constraint:OnDelete:CASCADE"`
+}
+
+func (baselineEntry) TableName() string {
+	return "entries"
+}
+
+type baselineSecret struct {
+	Slug      string    `gorm:"primaryKey;not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime:nano;not null"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime:nano;not null"`
+	Label     string    `gorm:"uniqueIndex:unique_label_entry_slug;not null"`
+	String    string    `gorm:"not null"`
+	Priority  uint8     `gorm:"not null"`
+	EntrySlug string    `gorm:"uniqueIndex:unique_label_entry_slug;index;not null"`
+	VaultSlug string    `gorm:"not null"`
+	UserSlug  string    `gorm:"not null"`
+}
+
+func (baselineSecret) TableName() string {
+	return "secrets"
+}
@@ -25,23 +25,24 @@ func testMoveSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppC
 	t.Run("empty_body_400_bad_request", func(t *testing.T) {
 		testMoveSecretClientError(
 			t, app, conf, 400, utils.ErrorParse,
-			"invalid character '\x00' looking for beginning of value", dummySlug, "",
+			"invalid character '\x00' looking for beginning of value", helpers.NewSlug(t), dummySlug, "",
 		)
 	})
 
 	t.Run("array_body_400_bad_request", func(t *testing.T) {
 		testMoveSecretClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character '[' looking for beginning of value",
-			dummySlug, "[]",
+			helpers.NewSlug(t), dummySlug, "[]",
 		)
 
 		testMoveSecretClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character '[' looking for beginning of value",
-			dummySlug, "[{}]",
+			helpers.NewSlug(t), dummySlug, "[{}]",
 		)
 
 		testMoveSecretClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character '[' looking for beginning of value",
+			helpers.NewSlug(t),
 			dummySlug, fmt.Sprintf(`[{"secret_priority":"0","entry_slug":"%s"}]`, dummySlug),
 		)
 	})
@@ -49,47 +50,51 @@ func testMoveSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppC
 	t.Run("boolean_body_400_bad_request", func(t *testing.T) {
 		testMoveSecretClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character 't' looking for beginning of value",
-			dummySlug, "true",
+			helpers.NewSlug(t), dummySlug, "true",
 		)
 
 		testMoveSecretClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character 'f' looking for beginning of value",
-			dummySlug, "false",
+			helpers.NewSlug(t), dummySlug, "false",
 		)
 	})
 
 	t.Run("string_body_400_bad_request", func(t *testing.T) {
 		testMoveSecretClientError(
 			t, app, conf, 400, utils.ErrorParse, `invalid character '"' looking for beginning of value`,
-			dummySlug, `"Valid JSON, but not an object."`,
+			helpers.NewSlug(t), dummySlug, `"Valid JSON, but not an object."`,
 		)
 	})
 
 	t.Run("null_body_400_bad_request", func(t *testing.T) {
-		testMoveSecretClientError(t, app, conf, 400, utils.ErrorSecretPriority, "", dummySlug, "null")
+		testMoveSecretClientError(
+			t, app, conf, 400, utils.ErrorSecretPriority, "", helpers.NewSlug(t), dummySlug, "null",
+		)
 	})
 
 	t.Run("empty_object_body_400_bad_request", func(t *testing.T) {
-		testMoveSecretClientError(t, app, conf, 400, utils.ErrorSecretPriority, "", dummySlug, "{}")
+		testMoveSecretClientError(
+			t, app, conf, 400, utils.ErrorSecretPriority, "", helpers.NewSlug(t), dummySlug, "{}",
+		)
 	})
 
 	t.Run("missing_secret_priority_400_bad_request", func(t *testing.T) {
 		testMoveSecretClientError(
-			t, app, conf, 400, utils.ErrorSecretPriority, "", dummySlug,
+			t, app, conf, 400, utils.ErrorSecretPriority, "", helpers.NewSlug(t), dummySlug,
 			fmt.Sprintf(`{"secert_priority":"0","entry_slug":"%s"}`, dummySlug),
 		)
 	})
 
 	t.Run("null_secret_priority_400_bad_request", func(t *testing.T) {
 		testMoveSecretClientError(
-			t, app, conf, 400, utils.ErrorSecretPriority, "", dummySlug,
+			t, app, conf, 400, utils.ErrorSecretPriority, "", helpers.NewSlug(t), dummySlug,
 			fmt.Sprintf(`{"secret_priority":null,"entry_slug":"%s"}`, dummySlug),
 		)
 	})
 
 	t.Run("empty_secret_priority_400_bad_request", func(t *testing.T) {
 		testMoveSecretClientError(
-			t, app, conf, 400, utils.ErrorSecretPriority, "", dummySlug,
+			t, app, conf, 400, utils.ErrorSecretPriority, "", helpers.NewSlug(t), dummySlug,
 			fmt.Sprintf(`{"secret_priority":"","entry_slug":"%s"}`, dummySlug),
 		)
 	})
@@ -97,51 +102,52 @@ func testMoveSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppC
 	t.Run("invalid_secret_priority_400_bad_request", func(t *testing.T) {
 		testMoveSecretClientError(
 			t, app, conf, 400, utils.ErrorSecretPriority, `strconv.Atoi: parsing "abc": invalid syntax`,
+			helpers.NewSlug(t),
 			dummySlug, fmt.Sprintf(`{"secret_priority":"abc","entry_slug":"%s"}`, dummySlug),
 		)
 	})
 
 	t.Run("missing_entry_slug_400_bad_request", func(t *testing.T) {
 		testMoveSecretClientError(
-			t, app, conf, 400, utils.ErrorEntrySlug, "", dummySlug,
+			t, app, conf, 400, utils.ErrorEntrySlug, "", helpers.NewSlug(t), dummySlug,
 			fmt.Sprintf(`{"secret_priority":"0","enrty_slug":"%s"}`, dummySlug),
 		)
 	})
 
 	t.Run("null_entry_slug_400_bad_request", func(t *testing.T) {
 		testMoveSecretClientError(
-			t, app, conf, 400, utils.ErrorEntrySlug, "", dummySlug,
+			t, app, conf, 400, utils.ErrorEntrySlug, "", helpers.NewSlug(t), dummySlug,
 			`{"secret_priority":"0","entry_slug":null}`,
 		)
 	})
 
 	t.Run("empty_entry_slug_400_bad_request", func(t *testing.T) {
 		testMoveSecretClientError(
-			t, app, conf, 400, utils.ErrorEntrySlug, "", dummySlug,
+			t, app, conf, 400, utils.ErrorEntrySlug, "", helpers.NewSlug(t), dummySlug,
 			`{"secret_priority":"0","entry_slug":""}`,
 		)
 	})
 
 	t.Run("invalid_entry_slug_400_bad_request", func(t *testing.T) {
 		testMoveSecretClientError(
-			t, app, conf, 400, utils.ErrorEntrySlug, dummySlug + "a", dummySlug,
+			t, app, conf, 400, utils.ErrorEntrySlug, dummySlug + "a", helpers.NewSlug(t), dummySlug,
 			fmt.Sprintf(`{"secret_priority":"0","entry_slug":"%s"}`, dummySlug + "a"),
 		)
 
 		testMoveSecretClientError(
-			t, app, conf, 400, utils.ErrorEntrySlug, dummySlug[:15], dummySlug,
+			t, app, conf, 400, utils.ErrorEntrySlug, dummySlug[:15], helpers.NewSlug(t), dummySlug,
 			fmt.Sprintf(`{"secret_priority":"0","entry_slug":"%s"}`, dummySlug[:15]),
 		)
 
 		testMoveSecretClientError(
-			t, app, conf, 400, utils.ErrorEntrySlug, dummySlug[:15] + "!", dummySlug,
+			t, app, conf, 400, utils.ErrorEntrySlug, dummySlug[:15] + "!", helpers.NewSlug(t), dummySlug,
 			fmt.Sprintf(`{"secret_priority":"0","entry_slug":"%s"}`, dummySlug[:15] + "!"),
 		)
 	})
 
 	t.Run("entry_slug_404_not_found", func(t *testing.T) {
 		testMoveSecretClientError(
-			t, app, conf, 404, utils.ErrorNotFound, "No secrets found", dummySlug,
+			t, app, conf, 404, utils.ErrorNotFound, "No secrets found", helpers.NewSlug(t), dummySlug,
 			fmt.Sprintf(`{"secret_priority":"0","entry_slug":"%s"}`, dummySlug),
 		)
 	})
@@ -150,11 +156,24 @@ func testMoveSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppC
 		_, _, entries, _ := setup.SetUpWithData(t, db)
 
 		testMoveSecretClientError(
-			t, app, conf, 404, utils.ErrorSecretSlug, "Not found", dummySlug,
+			t, app, conf, 404, utils.ErrorSecretSlug, "Not found", entries[7].UserSlug, dummySlug,
 			fmt.Sprintf(`{"secret_priority":"0","entry_slug":"%s"}`, entries[7].Slug),
 		)
 	})
 
+	t.Run("other_users_slug_404_not_found", func(t *testing.T) {
+		users, _, _, secrets := setup.SetUpWithData(t, db)
+
+		testMoveSecretClientError(
+			t, app, conf, 404, utils.ErrorNotFound, "No secrets found", users[1].Slug, secrets[0].Slug,
+			fmt.Sprintf(`{"secret_priority":"1","entry_slug":"%s"}`, secrets[0].EntrySlug),
+		)
+
+		var secret models.Secret
+		helpers.QueryTestSecretBySlug(t, db, &secret, secrets[0].Slug)
+		require.Equal(t, secrets[0].Priority, secret.Priority)
+	})
+
 	t.Run("valid_body_one_secret_204_no_content", func(t *testing.T) {
 		_, _, _, secrets := setup.SetUpWithData(t, db)
 
@@ -200,6 +219,54 @@ func testMoveSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppC
 		)
 	})
 
+	t.Run("password_header_not_needed_204_no_content", func(t *testing.T) {
+		entry := newTestPasswordUserEntry(t, app, db, conf)
+		body := fmt.Sprintf(`{"secret_priority":"0","entry_slug":"%s"}`, entry.Slug)
+
+		testMoveSecretSuccess(t, app, db, conf, 0, 0, entry.Slug, entry.Secrets[0].Slug, body)
+
+		// Not even a wrong password is looked at.
+		resp := newRequestWithVaultKey(
+			t, app, conf, http.MethodPatch, "/api/secrets/" + entry.Secrets[0].Slug + "/move",
+			utils.MoveSecret, entry.UserSlug, "wr0ngVal!dPW", body,
+		)
+		require.Equal(t, 204, resp.StatusCode)
+	})
+
+	t.Run("spoofed_client_operation_401_unauthorized", func(t *testing.T) {
+		entry := newTestPasswordUserEntry(t, app, db, conf)
+		body := fmt.Sprintf(`{"secret_priority":"0","entry_slug":"%s"}`, entry.Slug)
+
+		// Saying a request is a move doesn't skip the key check on any other route.
+		for _, request := range []struct{ method, target, body string }{
+			{http.MethodPatch, "/api/secrets/" + entry.Secrets[0].Slug, body},
+			{http.MethodGet, "/api/entries/" + entry.Slug, ""},
+			{http.MethodGet, "/api/secrets/" + entry.Secrets[0].Slug + "/versions", ""},
+		} {
+			resp := newRequestWithVaultKey(
+				t, app, conf, request.method, request.target, utils.MoveSecret, entry.UserSlug,
+				"wr0ngVal!dPW", request.body,
+			)
+			testVaultKeyWrong(t, resp, utils.MoveSecret, request.body)
+		}
+	})
+
+	t.Run("legacy_route_204_no_content", func(t *testing.T) {
+		_, _, _, secrets := setup.SetUpWithData(t, db)
+		body := fmt.Sprintf(`{"secret_priority":"4","entry_slug":"%s"}`, secrets[14].EntrySlug)
+
+		// Moves sent to the secret's own route, as they were before, are still moves.
+		resp := newRequestWithVaultKey(
+			t, app, conf, http.MethodPatch, "/api/secrets/" + secrets[14].Slug, utils.MoveSecret,
+			secrets[14].UserSlug, "", body,
+		)
+		require.Equal(t, 204, resp.StatusCode)
+
+		var secret models.Secret
+		helpers.QueryTestSecretBySlug(t, db, &secret, secrets[14].Slug)
+		require.EqualValues(t, 4, secret.Priority)
+	})
+
 	t.Run("valid_body_same_priority_204_no_content", func(t *testing.T) {
 		_, _, _, secrets := setup.SetUpWithData(t, db)
 
@@ -212,9 +279,9 @@ func testMoveSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppC
 
 func testMoveSecretClientError(
 	t *testing.T, app *fiber.App, conf *config.AppConfig, expectedStatus int,
-	expectedMessage, expectedDetail, slug, body string,
+	expectedMessage, expectedDetail, userSlug, slug, body string,
 ) {
-	resp := newRequestMoveSecret(t, app, conf, slug, body)
+	resp := newRequestMoveSecret(t, app, conf, userSlug, slug, body)
 	require.Equal(t, expectedStatus, resp.StatusCode)
 	helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
 		ClientOperation: utils.MoveSecret,
@@ -231,7 +298,7 @@ func testMoveSecretSuccess(
 	var secretsBeforeMove []models.Secret
 	helpers.QueryTestSecretsByEntry(t, db, &secretsBeforeMove, entrySlug)
 
-	resp := newRequestMoveSecret(t, app, conf, secretSlug, body)
+	resp := newRequestMoveSecret(t, app, conf, secretsBeforeMove[0].UserSlug, secretSlug, body)
 	require.Equal(t, 204, resp.StatusCode)
 
 	if respBody, err := io.ReadAll(resp.Body); err != nil {
@@ -274,11 +341,12 @@ func testMoveSecretSuccess(
 }
 
 func newRequestMoveSecret(
-	t *testing.T, app *fiber.App, conf *config.AppConfig, slug, body string,
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, slug, body string,
 ) *http.Response {
 	reqBody := strings.NewReader(body)
-	req := httptest.NewRequest("PATCH", "/api/secrets/" + slug, reqBody)
+	req := httptest.NewRequest("PATCH", "/api/secrets/" + slug + "/move", reqBody)
 	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+	req.Header.Set("User-Slug", userSlug)
 	req.Header.Set("Client-Operation", utils.MoveSecret)
 	req.Header.Set("Content-Type", "application/json")
 
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/database"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/tests/helpers"
+	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
+)
+
+func testPurgeTrash(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
+	t.Run("empty_trash_purges_nothing", func(t *testing.T) {
+		setup.SetUpWithData(t, db)
+
+		purged, err := database.PurgeTrash(db, time.Now().UTC())
+		require.NoError(t, err)
+		require.EqualValues(t, 0, purged)
+	})
+
+	t.Run("purges_only_records_older_than_cutoff", func(t *testing.T) {
+		_, vaults, _, secrets := setup.SetUpWithData(t, db)
+		vault := vaults[1]
+
+		createTestSecretVersion(t, app, db, conf, &secrets[4])
+		createTestSecretVersion(t, app, db, conf, &secrets[0])
+
+		resp := newRequestDeleteVault(t, app, conf, vault.UserSlug, vault.Slug)
+		require.Equal(t, 204, resp.StatusCode)
+
+		purged, err := database.PurgeTrash(db, time.Now().UTC().Add(-time.Hour))
+		require.NoError(t, err)
+		require.EqualValues(t, 0, purged)
+
+		// One vault, with two entries of two secrets each.
+		purged, err = database.PurgeTrash(db, time.Now().UTC().Add(time.Second))
+		require.NoError(t, err)
+		require.EqualValues(t, 7, purged)
+
+		var vaultCount, entryCount, secretCount, versionCount int64
+		db.Unscoped().Model(&models.Vault{}).Count(&vaultCount)
+		db.Unscoped().Model(&models.Entry{}).Count(&entryCount)
+		db.Unscoped().Model(&models.Secret{}).Count(&secretCount)
+		require.EqualValues(t, 3, vaultCount)
+		require.EqualValues(t, 6, entryCount)
+		require.EqualValues(t, 16, secretCount)
+
+		helpers.CountSecretVersions(t, db, &versionCount)
+		require.EqualValues(t, 1, versionCount)
+
+		result := db.Unscoped().First(&models.Vault{}, "slug = ?", vault.Slug)
+		require.ErrorIs(t, result.Error, gorm.ErrRecordNotFound)
+	})
+}
@@ -0,0 +1,244 @@
+package tests
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/tests/helpers"
+	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+func testRekeyUser(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
+	bodyFmt := `{"old_key":"%s","new_key":"%s"}`
+	oldKey := helpers.HexHash[:64]
+	newKey := hex.EncodeToString(utils.HashToken(helpers.VALID_EMAIL + "n3wVal!dPA5SWoRD"))[:64]
+
+	t.Run("invalid_slug_400_bad_request", func(t *testing.T) {
+		slug := "notARealSlug"
+		testRekeyUserClientError(
+			t, app, conf, 400, utils.ErrorUserSlug, slug, slug, fmt.Sprintf(bodyFmt, oldKey, newKey),
+		)
+	})
+
+	t.Run("empty_body_400_bad_request", func(t *testing.T) {
+		testRekeyUserClientError(
+			t, app, conf, 400, utils.ErrorParse,
+			"invalid character '\x00' looking for beginning of value", helpers.NewSlug(t), "",
+		)
+	})
+
+	t.Run("array_body_400_bad_request", func(t *testing.T) {
+		testRekeyUserClientError(
+			t, app, conf, 400, utils.ErrorParse, "invalid character '[' looking for beginning of value",
+			helpers.NewSlug(t), "[" + fmt.Sprintf(bodyFmt, oldKey, newKey) + "]",
+		)
+	})
+
+	t.Run("empty_object_body_400_bad_request", func(t *testing.T) {
+		testRekeyUserClientError(t, app, conf, 400, utils.ErrorOldKey, "", helpers.NewSlug(t), "{}")
+	})
+
+	t.Run("invalid_old_key_400_bad_request", func(t *testing.T) {
+		testRekeyUserClientError(
+			t, app, conf, 400, utils.ErrorOldKey, "", helpers.NewSlug(t),
+			fmt.Sprintf(bodyFmt, oldKey[:63], newKey),
+		)
+
+		testRekeyUserClientError(
+			t, app, conf, 400, utils.ErrorOldKey, "", helpers.NewSlug(t),
+			fmt.Sprintf(bodyFmt, oldKey[:63] + "z", newKey),
+		)
+	})
+
+	t.Run("invalid_new_key_400_bad_request", func(t *testing.T) {
+		testRekeyUserClientError(
+			t, app, conf, 400, utils.ErrorNewKey, "", helpers.NewSlug(t),
+			fmt.Sprintf(bodyFmt, oldKey, ""),
+		)
+
+		testRekeyUserClientError(
+			t, app, conf, 400, utils.ErrorNewKey, "Same as `old_key`.", helpers.NewSlug(t),
+			fmt.Sprintf(bodyFmt, oldKey, oldKey),
+		)
+	})
+
+	t.Run("valid_body_404_not_found", func(t *testing.T) {
+		setup.SetUpWithData(t, db)
+		slug := helpers.NewSlug(t)
+		testRekeyUserClientError(
+			t, app, conf, 404, utils.ErrorNotFound, slug, slug, fmt.Sprintf(bodyFmt, oldKey, newKey),
+		)
+	})
+
+	t.Run("wrong_old_key_400_bad_request", func(t *testing.T) {
+		users, _, _, _ := setup.SetUpWithData(t, db)
+
+		var secretsBefore []models.Secret
+
+		if result := db.Order("slug").Find(&secretsBefore, "user_slug = ?", users[0].Slug);
+		result.Error != nil {
+			t.Fatalf("Secrets query failed: %s", result.Error.Error())
+		}
+
+		resp := newRequestRekeyUser(t, app, conf, users[0].Slug, fmt.Sprintf(bodyFmt, newKey, oldKey))
+		require.Equal(t, 400, resp.StatusCode)
+
+		var secretsAfter []models.Secret
+
+		if result := db.Order("slug").Find(&secretsAfter, "user_slug = ?", users[0].Slug);
+		result.Error != nil {
+			t.Fatalf("Secrets query failed: %s", result.Error.Error())
+		}
+
+		require.Equal(t, secretsBefore, secretsAfter)
+	})
+
+	t.Run("error_responses_redact_keys", func(t *testing.T) {
+		users, _, _, _ := setup.SetUpWithData(t, db)
+
+		for _, req := range []struct {
+			status int
+			slug   string
+			body   string
+		}{
+			{400, users[0].Slug, fmt.Sprintf(bodyFmt, newKey, oldKey)},
+			{404, helpers.NewSlug(t), fmt.Sprintf(bodyFmt, oldKey, newKey)},
+			{400, users[0].Slug, "[" + fmt.Sprintf(bodyFmt, oldKey, newKey) + "]"},
+			{400, users[0].Slug, fmt.Sprintf(`{"old_key":"%s","new_key":"%s`, oldKey, newKey)},
+		} {
+			resp := newRequestRekeyUser(t, app, conf, req.slug, req.body)
+			require.Equal(t, req.status, resp.StatusCode)
+
+			if respBody, err := io.ReadAll(resp.Body); err != nil {
+				t.Fatalf("Read response body failed: %s", err.Error())
+			} else {
+				require.NotContains(t, string(respBody), oldKey)
+				require.NotContains(t, string(respBody), newKey)
+				require.Contains(t, string(respBody), utils.Redacted)
+			}
+		}
+	})
+
+	t.Run("valid_body_204_no_content", func(t *testing.T) {
+		testRekeyUserSuccess(t, app, db, conf, oldKey, newKey, fmt.Sprintf(bodyFmt, oldKey, newKey))
+	})
+
+	t.Run("trashed_secret_rekeyed_204_no_content", func(t *testing.T) {
+		users, _, _, secrets := setup.SetUpWithData(t, db)
+		secret := secrets[0]
+
+		resp := newRequestDeleteSecret(t, app, conf, secret.UserSlug, secret.Slug)
+		require.Equal(t, 204, resp.StatusCode)
+		resp = newRequestRekeyUser(t, app, conf, users[0].Slug, fmt.Sprintf(bodyFmt, oldKey, newKey))
+		require.Equal(t, 204, resp.StatusCode)
+
+		var trashedSecret models.Secret
+
+		if result := db.Unscoped().First(&trashedSecret, "slug = ?", secret.Slug);
+		result.Error != nil {
+			t.Fatalf("Trashed secret query failed: %s", result.Error.Error())
+		}
+
+		_, err := utils.Decrypt(
+			trashedSecret.String, helpers.UserSecretsKey(t, db, conf, users[0].Slug, newKey),
+			helpers.SecretAdditionalData(&trashedSecret),
+		)
+		require.NoError(t, err)
+	})
+}
+
+func testRekeyUserClientError(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, expectedStatus int,
+	expectedMessage, expectedDetail, slug, body string,
+) {
+	resp := newRequestRekeyUser(t, app, conf, slug, body)
+	require.Equal(t, expectedStatus, resp.StatusCode)
+	helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+		ClientOperation: utils.RekeyUser,
+		Message:         expectedMessage,
+		Detail:          expectedDetail,
+		RequestBody:     body,
+	})
+}
+
+func testRekeyUserSuccess(
+	t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig,
+	oldKey, newKey, body string,
+) {
+	users, _, _, secrets := setup.SetUpWithData(t, db)
+
+	resp := newRequestRekeyUser(t, app, conf, users[0].Slug, body)
+	require.Equal(t, 204, resp.StatusCode)
+
+	if respBody, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("Read response body failed: %s", err.Error())
+	} else {
+		require.Empty(t, respBody)
+	}
+
+	// The user got a data key, so the secrets are no longer under the client key alone.
+	secretsKey := helpers.UserSecretsKey(t, db, conf, users[0].Slug, newKey)
+	require.NotEqual(t, newKey, secretsKey)
+
+	for _, secretBefore := range secrets {
+		var secretAfter models.Secret
+		helpers.QueryTestSecretBySlug(t, db, &secretAfter, secretBefore.Slug)
+
+		plaintextBefore, err := utils.Decrypt(
+			secretBefore.String, oldKey, helpers.SecretAdditionalData(&secretBefore),
+		)
+
+		if err != nil {
+			t.Fatalf("Password decryption failed: %s", err.Error())
+		}
+
+		if secretBefore.UserSlug == users[0].Slug {
+			require.NotEqual(t, secretBefore.String, secretAfter.String)
+
+			_, err := utils.Decrypt(
+				secretAfter.String, oldKey, helpers.SecretAdditionalData(&secretAfter),
+			)
+			require.Error(t, err)
+
+			if plaintextAfter, err := utils.Decrypt(
+				secretAfter.String, secretsKey, helpers.SecretAdditionalData(&secretAfter),
+			); err != nil {
+				t.Fatalf("Password decryption failed: %s", err.Error())
+			} else {
+				require.Equal(t, plaintextBefore, plaintextAfter)
+			}
+		} else {
+			require.Equal(t, secretBefore.String, secretAfter.String)
+		}
+	}
+}
+
+func newRequestRekeyUser(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, slug, body string,
+) *http.Response {
+	reqBody := strings.NewReader(body)
+	req := httptest.NewRequest(http.MethodPost, "/api/users/" + slug + "/rekey", reqBody)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Client-Operation", utils.RekeyUser)
+	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+
+	resp, err := app.Test(req, -1)
+
+	if err != nil {
+		t.Fatalf("Send test request failed: %s", err.Error())
+	}
+
+	return resp
+}
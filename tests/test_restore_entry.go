@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/tests/helpers"
+	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+func testRestoreEntry(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
+	t.Run("invalid_slug_400_bad_request", func(t *testing.T) {
+		slug := "notARealSlug"
+		testRestoreEntryClientError(
+			t, app, conf, 400, utils.ErrorEntrySlug, slug, helpers.NewSlug(t), slug,
+		)
+	})
+
+	t.Run("valid_slug_404_not_found", func(t *testing.T) {
+		setup.SetUpWithData(t, db)
+		slug := helpers.NewSlug(t)
+		testRestoreEntryClientError(
+			t, app, conf, 404, utils.ErrorNotFound, slug, helpers.NewSlug(t), slug,
+		)
+	})
+
+	t.Run("untrashed_slug_404_not_found", func(t *testing.T) {
+		_, _, entries, _ := setup.SetUpWithData(t, db)
+		slug := entries[0].Slug
+		testRestoreEntryClientError(
+			t, app, conf, 404, utils.ErrorNotFound, slug, entries[0].UserSlug, slug,
+		)
+	})
+
+	t.Run("other_users_slug_404_not_found", func(t *testing.T) {
+		users, _, entries, _ := setup.SetUpWithData(t, db)
+		slug := entries[0].Slug
+
+		resp := newRequestDeleteEntry(t, app, conf, entries[0].UserSlug, slug)
+		require.Equal(t, 204, resp.StatusCode)
+
+		testRestoreEntryClientError(t, app, conf, 404, utils.ErrorNotFound, slug, users[1].Slug, slug)
+	})
+
+	t.Run("vault_trashed_409_conflict", func(t *testing.T) {
+		_, vaults, entries, _ := setup.SetUpWithData(t, db)
+		entry := entries[0]
+
+		resp := newRequestDeleteEntry(t, app, conf, entry.UserSlug, entry.Slug)
+		require.Equal(t, 204, resp.StatusCode)
+		resp = newRequestDeleteVault(t, app, conf, entry.UserSlug, vaults[0].Slug)
+		require.Equal(t, 204, resp.StatusCode)
+
+		testRestoreEntryClientError(
+			t, app, conf, 409, utils.ErrorParentTrashed, vaults[0].Slug, entry.UserSlug, entry.Slug,
+		)
+	})
+
+	t.Run("title_taken_409_conflict", func(t *testing.T) {
+		_, _, entries, _ := setup.SetUpWithData(t, db)
+		entry := entries[0]
+
+		resp := newRequestDeleteEntry(t, app, conf, entry.UserSlug, entry.Slug)
+		require.Equal(t, 204, resp.StatusCode)
+
+		// A trashed entry's title is free to be taken.
+		resp = newRequestCreateEntry(t, app, conf, entry.UserSlug, fmt.Sprintf(
+			`{"user_slug":"%s","vault_slug":"%s","entry_title":"%s","secrets":[{` +
+			`"secret_label":"label","secret_string":"string"}]}`,
+			entry.UserSlug, entry.VaultSlug, entry.Title,
+		))
+		require.Equal(t, 204, resp.StatusCode)
+
+		testRestoreEntryClientError(
+			t, app, conf, 409, utils.ErrorDuplicateEntry,
+			"UNIQUE constraint failed: entries.title, entries.vault_slug", entry.UserSlug, entry.Slug,
+		)
+	})
+
+	t.Run("valid_slug_204_no_content", func(t *testing.T) {
+		testRestoreEntrySuccess(t, app, db, conf)
+	})
+}
+
+func testRestoreEntryClientError(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, expectedStatus int,
+	expectedMessage, expectedDetail, userSlug, slug string,
+) {
+	resp := newRequestRestoreEntry(t, app, conf, userSlug, slug)
+	require.Equal(t, expectedStatus, resp.StatusCode)
+	helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+		ClientOperation: utils.RestoreEntry,
+		Message:         expectedMessage,
+		Detail:          expectedDetail,
+	})
+}
+
+func testRestoreEntrySuccess(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
+	_, _, entries, secrets := setup.SetUpWithData(t, db)
+	entry := entries[0]
+
+	// secrets[0] is trashed on its own first, so restoring the entry must leave it be.
+	resp := newRequestDeleteSecret(t, app, conf, entry.UserSlug, secrets[0].Slug)
+	require.Equal(t, 204, resp.StatusCode)
+	resp = newRequestDeleteEntry(t, app, conf, entry.UserSlug, entry.Slug)
+	require.Equal(t, 204, resp.StatusCode)
+
+	var entryCount, secretCount int64
+	helpers.CountEntries(t, db, &entryCount)
+	helpers.CountSecrets(t, db, &secretCount)
+	require.EqualValues(t, 7, entryCount)
+	require.EqualValues(t, 18, secretCount)
+
+	resp = newRequestRestoreEntry(t, app, conf, entry.UserSlug, entry.Slug)
+	require.Equal(t, 204, resp.StatusCode)
+
+	if respBody, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("Read response body failed: %s", err.Error())
+	} else {
+		require.Empty(t, respBody)
+	}
+
+	helpers.CountEntries(t, db, &entryCount)
+	helpers.CountSecrets(t, db, &secretCount)
+	require.EqualValues(t, 8, entryCount)
+	require.EqualValues(t, 19, secretCount)
+
+	var restoredEntry models.Entry
+	helpers.QueryTestEntryEager(t, db, &restoredEntry, entry.Title)
+	require.Len(t, restoredEntry.Secrets, 1)
+	require.Equal(t, secrets[1].Slug, restoredEntry.Secrets[0].Slug)
+	require.EqualValues(t, 0, restoredEntry.Secrets[0].Priority)
+
+	result := db.First(&models.Secret{}, "slug = ?", secrets[0].Slug)
+	require.ErrorIs(t, result.Error, gorm.ErrRecordNotFound)
+}
+
+func newRequestRestoreEntry(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, slug string,
+) *http.Response {
+
+	req := httptest.NewRequest(http.MethodPost, "/api/entries/" + slug + "/restore", nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Client-Operation", utils.RestoreEntry)
+	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+	req.Header.Set("User-Slug", userSlug)
+
+	resp, err := app.Test(req, -1)
+
+	if err != nil {
+		t.Fatalf("Send test request failed: %s", err.Error())
+	}
+
+	return resp
+}
@@ -0,0 +1,195 @@
+package tests
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/tests/helpers"
+	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+func testRestoreSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
+	t.Run("invalid_slug_400_bad_request", func(t *testing.T) {
+		slug := "notARealSlug"
+		testRestoreSecretClientError(
+			t, app, conf, 400, utils.ErrorSecretSlug, slug, helpers.NewSlug(t), slug,
+		)
+	})
+
+	t.Run("valid_slug_404_not_found", func(t *testing.T) {
+		setup.SetUpWithData(t, db)
+		slug := helpers.NewSlug(t)
+		testRestoreSecretClientError(
+			t, app, conf, 404, utils.ErrorNotFound, slug, helpers.NewSlug(t), slug,
+		)
+	})
+
+	t.Run("untrashed_slug_404_not_found", func(t *testing.T) {
+		_, _, _, secrets := setup.SetUpWithData(t, db)
+		slug := secrets[0].Slug
+		testRestoreSecretClientError(
+			t, app, conf, 404, utils.ErrorNotFound, slug, secrets[0].UserSlug, slug,
+		)
+	})
+
+	t.Run("other_users_slug_404_not_found", func(t *testing.T) {
+		users, _, _, secrets := setup.SetUpWithData(t, db)
+		slug := secrets[0].Slug
+
+		resp := newRequestDeleteSecret(t, app, conf, secrets[0].UserSlug, slug)
+		require.Equal(t, 204, resp.StatusCode)
+
+		testRestoreSecretClientError(t, app, conf, 404, utils.ErrorNotFound, slug, users[1].Slug, slug)
+	})
+
+	t.Run("entry_trashed_409_conflict", func(t *testing.T) {
+		_, _, entries, secrets := setup.SetUpWithData(t, db)
+		secret := secrets[0]
+
+		resp := newRequestDeleteSecret(t, app, conf, secret.UserSlug, secret.Slug)
+		require.Equal(t, 204, resp.StatusCode)
+		resp = newRequestDeleteEntry(t, app, conf, secret.UserSlug, entries[0].Slug)
+		require.Equal(t, 204, resp.StatusCode)
+
+		testRestoreSecretClientError(
+			t, app, conf, 409, utils.ErrorParentTrashed, entries[0].Slug, secret.UserSlug, secret.Slug,
+		)
+	})
+
+	t.Run("label_taken_409_conflict", func(t *testing.T) {
+		_, _, _, secrets := setup.SetUpWithData(t, db)
+		secret := secrets[0]
+
+		resp := newRequestDeleteSecret(t, app, conf, secret.UserSlug, secret.Slug)
+		require.Equal(t, 204, resp.StatusCode)
+
+		// A trashed secret's label is free to be taken.
+		resp = newRequestCreateSecret(t, app, conf, secret.UserSlug, fmt.Sprintf(
+			`{"user_slug":"%s","vault_slug":"%s","entry_slug":"%s",` +
+			`"secret_label":"%s","secret_string":"string"}`,
+			secret.UserSlug, secret.VaultSlug, secret.EntrySlug, secret.Label,
+		))
+		require.Equal(t, 204, resp.StatusCode)
+
+		testRestoreSecretClientError(
+			t, app, conf, 409, utils.ErrorDuplicateSecret,
+			"UNIQUE constraint failed: secrets.label, secrets.entry_slug", secret.UserSlug,
+			secret.Slug,
+		)
+
+		// The failed restore left the entry's priorities as they were.
+		var priorities []uint8
+		require.NoError(t, db.Model(&models.Secret{}).Where("entry_slug = ?", secret.EntrySlug).
+		Order("priority").Pluck("priority", &priorities).Error)
+		require.Equal(t, []uint8{0, 1}, priorities)
+	})
+
+	t.Run("valid_slug_204_no_content", func(t *testing.T) {
+		_, _, _, secrets := setup.SetUpWithData(t, db)
+		secret := secrets[15]
+
+		resp := newRequestDeleteSecret(t, app, conf, secret.UserSlug, secret.Slug)
+		require.Equal(t, 204, resp.StatusCode)
+
+		testRestoreSecretSuccess(t, app, db, conf, &secret, secret.Priority)
+
+		var secretsAfterRestore []models.Secret
+		helpers.QueryTestSecretsByEntry(t, db, &secretsAfterRestore, secret.EntrySlug)
+		require.Equal(t, 7, len(secretsAfterRestore))
+
+		for _, secretAfter := range secretsAfterRestore {
+			for _, secretBefore := range secrets[13:] {
+				if secretAfter.Slug == secretBefore.Slug {
+					require.Equal(t, secretBefore.Priority, secretAfter.Priority)
+				}
+			}
+		}
+	})
+
+	t.Run("entry_shrunk_204_no_content", func(t *testing.T) {
+		_, _, _, secrets := setup.SetUpWithData(t, db)
+		secret := secrets[19]
+
+		resp := newRequestDeleteSecret(t, app, conf, secret.UserSlug, secret.Slug)
+		require.Equal(t, 204, resp.StatusCode)
+		resp = newRequestDeleteSecret(t, app, conf, secret.UserSlug, secrets[18].Slug)
+		require.Equal(t, 204, resp.StatusCode)
+
+		// Six secrets were ahead of it, but only five are left.
+		testRestoreSecretSuccess(t, app, db, conf, &secret, 5)
+	})
+}
+
+func testRestoreSecretClientError(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, expectedStatus int,
+	expectedMessage, expectedDetail, userSlug, slug string,
+) {
+	resp := newRequestRestoreSecret(t, app, conf, userSlug, slug)
+	require.Equal(t, expectedStatus, resp.StatusCode)
+	helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+		ClientOperation: utils.RestoreSecret,
+		Message:         expectedMessage,
+		Detail:          expectedDetail,
+	})
+}
+
+func testRestoreSecretSuccess(
+	t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig,
+	secret *models.Secret, expectedPriority uint8,
+) {
+	resp := newRequestRestoreSecret(t, app, conf, secret.UserSlug, secret.Slug)
+	require.Equal(t, 204, resp.StatusCode)
+
+	if respBody, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("Read response body failed: %s", err.Error())
+	} else {
+		require.Empty(t, respBody)
+	}
+
+	var restoredSecret models.Secret
+	helpers.QueryTestSecretBySlug(t, db, &restoredSecret, secret.Slug)
+	require.Equal(t, secret.Label, restoredSecret.Label)
+	require.Equal(t, secret.String, restoredSecret.String)
+	require.Equal(t, expectedPriority, restoredSecret.Priority)
+
+	var secretsAfterRestore []models.Secret
+	helpers.QueryTestSecretsByEntry(t, db, &secretsAfterRestore, secret.EntrySlug)
+
+	// Priorities are still contiguous from zero, with no duplicates.
+	seen := make([]bool, len(secretsAfterRestore))
+
+	for _, secretAfter := range secretsAfterRestore {
+		require.Less(t, int(secretAfter.Priority), len(seen))
+		require.False(t, seen[secretAfter.Priority])
+		seen[secretAfter.Priority] = true
+	}
+}
+
+func newRequestRestoreSecret(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, slug string,
+) *http.Response {
+
+	req := httptest.NewRequest(http.MethodPost, "/api/secrets/" + slug + "/restore", nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Client-Operation", utils.RestoreSecret)
+	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+	req.Header.Set("User-Slug", userSlug)
+
+	resp, err := app.Test(req, -1)
+
+	if err != nil {
+		t.Fatalf("Send test request failed: %s", err.Error())
+	}
+
+	return resp
+}
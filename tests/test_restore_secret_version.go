@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/tests/helpers"
+	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+func testRestoreSecretVersion(
+	t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig,
+) {
+	t.Run("invalid_slug_400_bad_request", func(t *testing.T) {
+		slug := "notARealSlug"
+		testRestoreSecretVersionClientError(
+			t, app, conf, 400, utils.ErrorSecretSlug, slug, helpers.NewSlug(t), slug, helpers.NewSlug(t),
+		)
+	})
+
+	t.Run("invalid_version_slug_400_bad_request", func(t *testing.T) {
+		versionSlug := "notARealSlug"
+		testRestoreSecretVersionClientError(
+			t, app, conf, 400, utils.ErrorSecretVersionSlug, versionSlug,
+			helpers.NewSlug(t), helpers.NewSlug(t), versionSlug,
+		)
+	})
+
+	t.Run("valid_slug_404_not_found", func(t *testing.T) {
+		setup.SetUpWithData(t, db)
+		slug := helpers.NewSlug(t)
+		testRestoreSecretVersionClientError(
+			t, app, conf, 404, utils.ErrorNotFound, slug, helpers.NewSlug(t), slug, helpers.NewSlug(t),
+		)
+	})
+
+	t.Run("valid_version_slug_404_not_found", func(t *testing.T) {
+		_, _, _, secrets := setup.SetUpWithData(t, db)
+		versionSlug := helpers.NewSlug(t)
+		testRestoreSecretVersionClientError(
+			t, app, conf, 404, utils.ErrorNotFound, versionSlug,
+			secrets[0].UserSlug, secrets[0].Slug, versionSlug,
+		)
+	})
+
+	t.Run("other_secrets_version_slug_404_not_found", func(t *testing.T) {
+		_, _, _, secrets := setup.SetUpWithData(t, db)
+		version := createTestSecretVersion(t, app, db, conf, &secrets[1])
+
+		testRestoreSecretVersionClientError(
+			t, app, conf, 404, utils.ErrorNotFound, version.Slug,
+			secrets[0].UserSlug, secrets[0].Slug, version.Slug,
+		)
+	})
+
+	t.Run("other_users_slug_404_not_found", func(t *testing.T) {
+		users, _, _, secrets := setup.SetUpWithData(t, db)
+		version := createTestSecretVersion(t, app, db, conf, &secrets[0])
+
+		testRestoreSecretVersionClientError(
+			t, app, conf, 404, utils.ErrorNotFound, secrets[0].Slug,
+			users[1].Slug, secrets[0].Slug, version.Slug,
+		)
+	})
+
+	t.Run("valid_slugs_204_no_content", func(t *testing.T) {
+		_, _, _, secrets := setup.SetUpWithData(t, db)
+		secret := secrets[0]
+		version := createTestSecretVersion(t, app, db, conf, &secret)
+
+		var updatedSecret models.Secret
+		helpers.QueryTestSecretBySlug(t, db, &updatedSecret, secret.Slug)
+
+		resp := newRequestRestoreSecretVersion(t, app, conf, secret.UserSlug, secret.Slug, version.Slug)
+		require.Equal(t, 204, resp.StatusCode)
+
+		if respBody, err := io.ReadAll(resp.Body); err != nil {
+			t.Fatalf("Read response body failed: %s", err.Error())
+		} else {
+			require.Empty(t, respBody)
+		}
+
+		var restoredSecret models.Secret
+		helpers.QueryTestSecretBySlug(t, db, &restoredSecret, secret.Slug)
+		require.Equal(t, secret.Label, restoredSecret.Label)
+		requireSameSecretString(t, db, conf, &secret, restoredSecret.String)
+
+		// The value that was replaced by the restore is itself kept as a version.
+		var versions []models.SecretVersion
+
+		if result := db.Order("created_at DESC").Find(&versions, "secret_slug = ?", secret.Slug);
+		result.Error != nil {
+			t.Fatalf("Secret versions query failed: %s", result.Error.Error())
+		}
+
+		require.Equal(t, 2, len(versions))
+		require.Equal(t, updatedSecret.Label, versions[0].Label)
+		require.Equal(t, updatedSecret.String, versions[0].String)
+		require.Equal(t, version.Slug, versions[1].Slug)
+	})
+}
+
+// createTestSecretVersion updates the secret through the API so that its current value
+// is archived, and returns the resulting version.
+func createTestSecretVersion(
+	t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig, secret *models.Secret,
+) (version models.SecretVersion) {
+	resp := newRequestUpdateSecret(
+		t, app, conf, secret.UserSlug, secret.Slug,
+		`{"secret_label":"updated_label","secret_string":"updated_string"}`,
+	)
+	require.Equal(t, 204, resp.StatusCode)
+
+	if result := db.First(&version, "secret_slug = ?", secret.Slug); result.Error != nil {
+		t.Fatalf("Secret version query failed: %s", result.Error.Error())
+	}
+
+	return
+}
+
+func testRestoreSecretVersionClientError(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, expectedStatus int,
+	expectedMessage, expectedDetail, userSlug, slug, versionSlug string,
+) {
+	resp := newRequestRestoreSecretVersion(t, app, conf, userSlug, slug, versionSlug)
+	require.Equal(t, expectedStatus, resp.StatusCode)
+	helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+		ClientOperation: utils.RestoreSecretVersion,
+		Message:         expectedMessage,
+		Detail:          expectedDetail,
+	})
+}
+
+func newRequestRestoreSecretVersion(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, slug, versionSlug string,
+) *http.Response {
+
+	req := httptest.NewRequest(
+		http.MethodPost, fmt.Sprintf("/api/secrets/%s/versions/%s/restore", slug, versionSlug), nil,
+	)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Client-Operation", utils.RestoreSecretVersion)
+	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+	req.Header.Set("User-Slug", userSlug)
+
+	resp, err := app.Test(req, -1)
+
+	if err != nil {
+		t.Fatalf("Send test request failed: %s", err.Error())
+	}
+
+	return resp
+}
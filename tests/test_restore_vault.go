@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/tests/helpers"
+	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+func testRestoreVault(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
+	t.Run("invalid_slug_400_bad_request", func(t *testing.T) {
+		slug := "notARealSlug"
+		testRestoreVaultClientError(
+			t, app, conf, 400, utils.ErrorVaultSlug, slug, helpers.NewSlug(t), slug,
+		)
+	})
+
+	t.Run("valid_slug_404_not_found", func(t *testing.T) {
+		setup.SetUpWithData(t, db)
+		testRestoreVaultClientError(
+			t, app, conf, 404, utils.ErrorNoRowsAffected,
+			"Likely that slug was not found in the trash.", helpers.NewSlug(t), helpers.NewSlug(t),
+		)
+	})
+
+	t.Run("untrashed_slug_404_not_found", func(t *testing.T) {
+		_, vaults, _, _ := setup.SetUpWithData(t, db)
+		testRestoreVaultClientError(
+			t, app, conf, 404, utils.ErrorNoRowsAffected,
+			"Likely that slug was not found in the trash.", vaults[0].UserSlug, vaults[0].Slug,
+		)
+	})
+
+	t.Run("other_users_slug_404_not_found", func(t *testing.T) {
+		users, vaults, _, _ := setup.SetUpWithData(t, db)
+
+		resp := newRequestDeleteVault(t, app, conf, vaults[0].UserSlug, vaults[0].Slug)
+		require.Equal(t, 204, resp.StatusCode)
+
+		testRestoreVaultClientError(
+			t, app, conf, 404, utils.ErrorNoRowsAffected,
+			"Likely that slug was not found in the trash.", users[1].Slug, vaults[0].Slug,
+		)
+
+		var vaultCount int64
+		helpers.CountVaults(t, db, &vaultCount)
+		require.EqualValues(t, 3, vaultCount)
+	})
+
+	t.Run("title_taken_409_conflict", func(t *testing.T) {
+		_, vaults, _, _ := setup.SetUpWithData(t, db)
+		vault := vaults[0]
+
+		resp := newRequestDeleteVault(t, app, conf, vault.UserSlug, vault.Slug)
+		require.Equal(t, 204, resp.StatusCode)
+
+		// A trashed vault's title is free to be taken.
+		resp = newRequestCreateVault(t, app, conf, vault.UserSlug, fmt.Sprintf(
+			`{"user_slug":"%s","vault_title":"%s"}`, vault.UserSlug, vault.Title,
+		))
+		require.Equal(t, 204, resp.StatusCode)
+
+		testRestoreVaultClientError(
+			t, app, conf, 409, utils.ErrorDuplicateVault,
+			"UNIQUE constraint failed: vaults.title, vaults.user_slug", vault.UserSlug, vault.Slug,
+		)
+	})
+
+	t.Run("valid_slug_204_no_content", func(t *testing.T) {
+		testRestoreVaultSuccess(t, app, db, conf)
+	})
+}
+
+func testRestoreVaultClientError(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, expectedStatus int,
+	expectedMessage, expectedDetail, userSlug, slug string,
+) {
+	resp := newRequestRestoreVault(t, app, conf, userSlug, slug)
+	require.Equal(t, expectedStatus, resp.StatusCode)
+	helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+		ClientOperation: utils.RestoreVault,
+		Message:         expectedMessage,
+		Detail:          expectedDetail,
+	})
+}
+
+func testRestoreVaultSuccess(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
+	_, vaults, entries, _ := setup.SetUpWithData(t, db)
+	vault := vaults[1]
+
+	// entries[2] is trashed on its own first, so restoring the vault must leave it be.
+	resp := newRequestDeleteEntry(t, app, conf, vault.UserSlug, entries[2].Slug)
+	require.Equal(t, 204, resp.StatusCode)
+	resp = newRequestDeleteVault(t, app, conf, vault.UserSlug, vault.Slug)
+	require.Equal(t, 204, resp.StatusCode)
+
+	var vaultCount, entryCount, secretCount int64
+	helpers.CountVaults(t, db, &vaultCount)
+	helpers.CountEntries(t, db, &entryCount)
+	helpers.CountSecrets(t, db, &secretCount)
+	require.EqualValues(t, 3, vaultCount)
+	require.EqualValues(t, 6, entryCount)
+	require.EqualValues(t, 16, secretCount)
+
+	resp = newRequestRestoreVault(t, app, conf, vault.UserSlug, vault.Slug)
+	require.Equal(t, 204, resp.StatusCode)
+
+	if respBody, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("Read response body failed: %s", err.Error())
+	} else {
+		require.Empty(t, respBody)
+	}
+
+	helpers.CountVaults(t, db, &vaultCount)
+	helpers.CountEntries(t, db, &entryCount)
+	helpers.CountSecrets(t, db, &secretCount)
+	require.EqualValues(t, 4, vaultCount)
+	require.EqualValues(t, 7, entryCount)
+	require.EqualValues(t, 18, secretCount)
+
+	var restoredVault models.Vault
+	helpers.QueryTestVaultEager(t, db, &restoredVault, vault.Title)
+	require.True(t, vault.UpdatedAt.Equal(restoredVault.UpdatedAt))
+	require.Len(t, restoredVault.Entries, 1)
+	require.Equal(t, entries[3].Slug, restoredVault.Entries[0].Slug)
+	require.Len(t, restoredVault.Entries[0].Secrets, 2)
+
+	result := db.First(&models.Entry{}, "slug = ?", entries[2].Slug)
+	require.ErrorIs(t, result.Error, gorm.ErrRecordNotFound)
+}
+
+func newRequestRestoreVault(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, slug string,
+) *http.Response {
+
+	req := httptest.NewRequest(http.MethodPost, "/api/vaults/" + slug + "/restore", nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Client-Operation", utils.RestoreVault)
+	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+	req.Header.Set("User-Slug", userSlug)
+
+	resp, err := app.Test(req, -1)
+
+	if err != nil {
+		t.Fatalf("Send test request failed: %s", err.Error())
+	}
+
+	return resp
+}
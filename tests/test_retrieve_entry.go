@@ -12,6 +12,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/database"
 	"github.com/liobrdev/simplepasswords_vaults/models"
 	"github.com/liobrdev/simplepasswords_vaults/tests/helpers"
 	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
@@ -21,24 +22,154 @@ import (
 func testRetrieveEntry(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
 	t.Run("invalid_slug_400_bad_request", func(t *testing.T) {
 		slug := "notARealSlug"
-		testRetrieveEntryClientError(t, app, conf, 400, utils.ErrorEntrySlug, slug, slug)
+		testRetrieveEntryClientError(
+			t, app, conf, 400, utils.ErrorEntrySlug, slug, helpers.NewSlug(t), slug,
+		)
 	})
 
 	t.Run("valid_slug_404_not_found", func(t *testing.T) {
 		slug := helpers.NewSlug(t)
-		testRetrieveEntryClientError(t, app, conf, 404, utils.ErrorNotFound, slug, slug)
+		testRetrieveEntryClientError(
+			t, app, conf, 404, utils.ErrorNotFound, slug, helpers.NewSlug(t), slug,
+		)
+	})
+
+	t.Run("other_users_slug_404_not_found", func(t *testing.T) {
+		users, _, entries, _ := setup.SetUpWithData(t, db)
+		slug := entries[0].Slug
+		testRetrieveEntryClientError(t, app, conf, 404, utils.ErrorNotFound, slug, users[1].Slug, slug)
+	})
+
+	t.Run("swapped_ciphertext_500_error", func(t *testing.T) {
+		_, _, entries, secrets := setup.SetUpWithData(t, db)
+
+		if result := db.Model(&models.Secret{}).Where("slug = ?", secrets[0].Slug).
+		UpdateColumn("string", secrets[1].String); result.Error != nil {
+			t.Fatalf("Secret update failed: %s", result.Error.Error())
+		}
+
+		testRetrieveEntryClientError(
+			t, app, conf, 500, utils.ErrorDecrypt, "cipher: message authentication failed",
+			entries[0].UserSlug, entries[0].Slug,
+		)
 	})
 
 	t.Run("valid_slug_200_ok", func(t *testing.T) {
 		testRetrieveEntrySuccess(t, app, db, conf)
 	})
+
+	t.Run("unbound_ciphertexts_upgraded_200_ok", func(t *testing.T) {
+		_, _, entries, secrets := setup.SetUpWithData(t, db)
+
+		for _, secret := range secrets[:2] {
+			plaintext, err := utils.Decrypt(
+				secret.String, helpers.HexHash[:64], helpers.SecretAdditionalData(&secret),
+			)
+			require.NoError(t, err)
+
+			unboundString, err := utils.UpgradeLegacyCiphertext(
+				helpers.EncryptLegacy(t, plaintext, helpers.HexHash[:64]),
+			)
+			require.NoError(t, err)
+			require.False(t, utils.IsBoundCiphertext(unboundString))
+
+			if result := db.Model(&models.Secret{}).Where("slug = ?", secret.Slug).
+			UpdateColumn("string", unboundString); result.Error != nil {
+				t.Fatalf("Secret update failed: %s", result.Error.Error())
+			}
+		}
+
+		// The manifest recorded the legacy ciphertexts before they were upgraded.
+		require.NoError(t, database.RefreshIntegrity(
+			db, entries[0].UserSlug, database.IntegrityAllSecrets(),
+		))
+
+		resp := newRequestRetrieveEntry(t, app, conf, entries[0].UserSlug, entries[0].Slug)
+		require.Equal(t, 200, resp.StatusCode)
+
+		report, err := database.VerifyIntegrity(db, entries[0].UserSlug)
+		require.NoError(t, err)
+		require.True(t, report.Intact)
+		require.Empty(t, report.Discrepancies)
+		require.Zero(t, report.UnboundSecrets)
+
+		for _, secretBefore := range secrets[:2] {
+			var secretAfter models.Secret
+			helpers.QueryTestSecretBySlug(t, db, &secretAfter, secretBefore.Slug)
+			require.True(t, utils.IsBoundCiphertext(secretAfter.String))
+			require.True(t, secretBefore.UpdatedAt.Equal(secretAfter.UpdatedAt))
+
+			_, err := utils.Decrypt(
+				secretAfter.String,
+				helpers.UserSecretsKey(t, db, conf, secretAfter.UserSlug, helpers.HexHash[:64]),
+				helpers.SecretAdditionalData(&secretBefore),
+			)
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("swapped_unbound_ciphertexts_500_internal_server_error", func(t *testing.T) {
+		_, _, entries, secrets := setup.SetUpWithData(t, db)
+		unboundStrings := []string{}
+
+		for _, secret := range secrets[:2] {
+			plaintext, err := utils.Decrypt(
+				secret.String, helpers.HexHash[:64], helpers.SecretAdditionalData(&secret),
+			)
+			require.NoError(t, err)
+
+			unboundString, err := utils.UpgradeLegacyCiphertext(
+				helpers.EncryptLegacy(t, plaintext, helpers.HexHash[:64]),
+			)
+			require.NoError(t, err)
+			unboundStrings = append(unboundStrings, unboundString)
+
+			if result := db.Model(&models.Secret{}).Where("slug = ?", secret.Slug).
+			UpdateColumn("string", unboundString); result.Error != nil {
+				t.Fatalf("Secret update failed: %s", result.Error.Error())
+			}
+		}
+
+		require.NoError(t, database.RefreshIntegrity(
+			db, entries[0].UserSlug, database.IntegrityAllSecrets(),
+		))
+
+		// Each secret's ciphertext is copied onto the other, which nothing in them shows.
+		for i, secret := range secrets[:2] {
+			if result := db.Model(&models.Secret{}).Where("slug = ?", secret.Slug).
+			UpdateColumn("string", unboundStrings[1 - i]); result.Error != nil {
+				t.Fatalf("Secret update failed: %s", result.Error.Error())
+			}
+		}
+
+		resp := newRequestRetrieveEntry(t, app, conf, entries[0].UserSlug, entries[0].Slug)
+		require.Equal(t, 500, resp.StatusCode)
+		helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+			ClientOperation: utils.RetrieveEntry,
+			Message:         utils.ErrorDecrypt,
+			Detail:          utils.ErrUnboundCiphertext.Error(),
+		})
+
+		// Neither is bound, since that would make the swap look genuine.
+		report, err := database.VerifyIntegrity(db, entries[0].UserSlug)
+		require.NoError(t, err)
+		require.False(t, report.Intact)
+		require.Len(t, report.Discrepancies, 2)
+		require.EqualValues(t, 2, report.UnboundSecrets)
+
+		for i, secret := range secrets[:2] {
+			var secretAfter models.Secret
+			helpers.QueryTestSecretBySlug(t, db, &secretAfter, secret.Slug)
+			require.Equal(t, unboundStrings[1 - i], secretAfter.String)
+		}
+	})
 }
 
 func testRetrieveEntryClientError(
 	t *testing.T, app *fiber.App, conf *config.AppConfig, expectedStatus int,
-	expectedMessage, expectedDetail, slug string,
+	expectedMessage, expectedDetail, userSlug, slug string,
 ) {
-	resp := newRequestRetrieveEntry(t, app, conf, slug)
+	resp := newRequestRetrieveEntry(t, app, conf, userSlug, slug)
 	require.Equal(t, expectedStatus, resp.StatusCode)
 	helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
 		ClientOperation: utils.RetrieveEntry,
@@ -53,7 +184,7 @@ func testRetrieveEntrySuccess(t *testing.T, app *fiber.App, db *gorm.DB, conf *c
 	var entryFromDB models.Entry
 	helpers.QueryTestEntryEager(t, db, &entryFromDB, "entry@0.1.1.*")
 
-	resp := newRequestRetrieveEntry(t, app, conf, entryFromDB.Slug)
+	resp := newRequestRetrieveEntry(t, app, conf, entryFromDB.UserSlug, entryFromDB.Slug)
 	require.Equal(t, 200, resp.StatusCode)
 
 	if respBody, err := io.ReadAll(resp.Body); err != nil {
@@ -69,8 +200,10 @@ func testRetrieveEntrySuccess(t *testing.T, app *fiber.App, db *gorm.DB, conf *c
 		require.Equal(t, entryFromDB.Title, respEntry.Title)
 		require.Equal(t, "entry@0.1.1.*", respEntry.Title)
 
-		if plaintext, err := utils.Decrypt(entryFromDB.Secrets[0].String, helpers.HexHash[:64]);
-		err != nil {
+		if plaintext, err := utils.Decrypt(
+			entryFromDB.Secrets[0].String, helpers.HexHash[:64],
+			helpers.SecretAdditionalData(&entryFromDB.Secrets[0]),
+		); err != nil {
 			t.Fatalf("Password decryption failed: %s", err.Error())
 		} else {
 			require.Equal(t, "secret[_string='foodeater1234']@0.1.1.0", plaintext)
@@ -79,8 +212,10 @@ func testRetrieveEntrySuccess(t *testing.T, app *fiber.App, db *gorm.DB, conf *c
 			require.EqualValues(t, 0, respEntry.Secrets[0].Priority)
 		}
 
-		if plaintext, err := utils.Decrypt(entryFromDB.Secrets[1].String, helpers.HexHash[:64]);
-		err != nil {
+		if plaintext, err := utils.Decrypt(
+			entryFromDB.Secrets[1].String, helpers.HexHash[:64],
+			helpers.SecretAdditionalData(&entryFromDB.Secrets[1]),
+		); err != nil {
 			t.Fatalf("Password decryption failed: %s", err.Error())
 		} else {
 			require.Equal(t, "secret[_string='3a7!ng40oD']@0.1.1.1", plaintext)
@@ -92,13 +227,14 @@ func testRetrieveEntrySuccess(t *testing.T, app *fiber.App, db *gorm.DB, conf *c
 }
 
 func newRequestRetrieveEntry(
-	t *testing.T, app *fiber.App, conf *config.AppConfig, slug string,
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, slug string,
 ) *http.Response {
 
 	req := httptest.NewRequest("GET", "/api/entries/" + slug, nil)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Client-Operation", utils.RetrieveEntry)
 	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+	req.Header.Set("User-Slug", userSlug)
 	req.Header.Set(conf.PASSWORD_HEADER_KEY, helpers.HexHash[:64])
 
 	resp, err := app.Test(req, -1)
@@ -12,6 +12,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/controllers"
 	"github.com/liobrdev/simplepasswords_vaults/models"
 	"github.com/liobrdev/simplepasswords_vaults/tests/helpers"
 	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
@@ -21,24 +22,108 @@ import (
 func testRetrieveVault(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
 	t.Run("invalid_slug_400_bad_request", func(t *testing.T) {
 		slug := "notARealSlug"
-		testRetrieveVaultClientError(t, app, conf, 400, utils.ErrorVaultSlug, slug, slug)
+		testRetrieveVaultClientError(
+			t, app, conf, 400, utils.ErrorVaultSlug, slug, helpers.NewSlug(t), slug, "",
+		)
 	})
 
 	t.Run("valid_slug_404_not_found", func(t *testing.T) {
 		slug := helpers.NewSlug(t)
-		testRetrieveVaultClientError(t, app, conf, 404, utils.ErrorNotFound, slug, slug)
+		testRetrieveVaultClientError(
+			t, app, conf, 404, utils.ErrorNotFound, slug, helpers.NewSlug(t), slug, "",
+		)
+	})
+
+	t.Run("other_users_slug_404_not_found", func(t *testing.T) {
+		users, vaults, _, _ := setup.SetUpWithData(t, db)
+		slug := vaults[0].Slug
+		testRetrieveVaultClientError(
+			t, app, conf, 404, utils.ErrorNotFound, slug, users[1].Slug, slug, "",
+		)
+	})
+
+	t.Run("missing_user_slug_header_400_bad_request", func(t *testing.T) {
+		_, vaults, _, _ := setup.SetUpWithData(t, db)
+		testRetrieveVaultClientError(
+			t, app, conf, 400, utils.ErrorUserSlug, "", "", vaults[0].Slug, "",
+		)
+	})
+
+	t.Run("invalid_limit_400_bad_request", func(t *testing.T) {
+		_, vaults, _, _ := setup.SetUpWithData(t, db)
+		testRetrieveVaultClientError(
+			t, app, conf, 400, utils.ErrorLimit, "0", vaults[0].UserSlug, vaults[0].Slug, "?limit=0",
+		)
 	})
 
 	t.Run("valid_slug_200_ok", func(t *testing.T) {
 		testRetrieveVaultSuccess(t, app, db, conf)
 	})
+
+	t.Run("paginated_entries_200_ok", func(t *testing.T) {
+		_, vaults, _, _ := setup.SetUpWithData(t, db)
+		vault := vaults[0]
+
+		for _, title := range []string{"site.com", "Site.org", "shop.com"} {
+			entry := models.Entry{
+				Slug: helpers.NewSlug(t), Title: title, VaultSlug: vault.Slug, UserSlug: vault.UserSlug,
+			}
+
+			if result := db.Create(&entry); result.Error != nil {
+				t.Fatalf("Entry creation failed: %s", result.Error.Error())
+			}
+		}
+
+		page := testRetrieveVaultPage(t, app, conf, &vault, "?limit=2&sort=title")
+		require.Equal(t, vault.Slug, page.Slug)
+		require.Equal(t, 2, len(page.Entries))
+		require.Equal(t, "Site.org", page.Entries[0].Title)
+		require.Equal(t, "entry@0.0.0.*", page.Entries[1].Title)
+		require.NotEmpty(t, page.NextCursor)
+
+		page = testRetrieveVaultPage(
+			t, app, conf, &vault, "?limit=2&sort=title&cursor=" + page.NextCursor,
+		)
+		require.Equal(t, 2, len(page.Entries))
+		require.Equal(t, "entry@0.0.1.*", page.Entries[0].Title)
+		require.Equal(t, "shop.com", page.Entries[1].Title)
+		require.NotEmpty(t, page.NextCursor)
+
+		page = testRetrieveVaultPage(
+			t, app, conf, &vault, "?limit=2&sort=title&cursor=" + page.NextCursor,
+		)
+		require.Equal(t, 1, len(page.Entries))
+		require.Equal(t, "site.com", page.Entries[0].Title)
+		require.Empty(t, page.NextCursor)
+
+		page = testRetrieveVaultPage(t, app, conf, &vault, "?sort=title&title_prefix=SITE.")
+		require.Equal(t, 2, len(page.Entries))
+		require.Equal(t, "Site.org", page.Entries[0].Title)
+		require.Equal(t, "site.com", page.Entries[1].Title)
+		require.Empty(t, page.NextCursor)
+	})
+}
+
+func testRetrieveVaultPage(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, vault *models.Vault, query string,
+) (page controllers.RetrieveVaultResponseBody) {
+	resp := newRequestRetrieveVault(t, app, conf, vault.UserSlug, vault.Slug, query)
+	require.Equal(t, 200, resp.StatusCode)
+
+	if respBody, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("Read response body failed: %s", err.Error())
+	} else if err := json.Unmarshal(respBody, &page); err != nil {
+		t.Fatalf("JSON unmarshal failed: %s", err.Error())
+	}
+
+	return
 }
 
 func testRetrieveVaultClientError(
 	t *testing.T, app *fiber.App, conf *config.AppConfig,
-	expectedStatus int, expectedMessage, expectedDetail, slug string,
+	expectedStatus int, expectedMessage, expectedDetail, userSlug, slug, query string,
 ) {
-	resp := newRequestRetrieveVault(t, app, conf, slug)
+	resp := newRequestRetrieveVault(t, app, conf, userSlug, slug, query)
 	require.Equal(t, expectedStatus, resp.StatusCode)
 	helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
 		ClientOperation: utils.RetrieveVault,
@@ -53,7 +138,7 @@ func testRetrieveVaultSuccess(t *testing.T, app *fiber.App, db *gorm.DB, conf *c
 	var expectedVault models.Vault
 	helpers.QueryTestVault(t, db, &expectedVault, "vault@0.1.*.*")
 
-	resp := newRequestRetrieveVault(t, app, conf, expectedVault.Slug)
+	resp := newRequestRetrieveVault(t, app, conf, expectedVault.UserSlug, expectedVault.Slug, "")
 	require.Equal(t, 200, resp.StatusCode)
 
 	if respBody, err := io.ReadAll(resp.Body); err != nil {
@@ -82,13 +167,14 @@ func testRetrieveVaultSuccess(t *testing.T, app *fiber.App, db *gorm.DB, conf *c
 }
 
 func newRequestRetrieveVault(
-	t *testing.T, app *fiber.App, conf *config.AppConfig, slug string,
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, slug, query string,
 ) *http.Response {
 
-	req := httptest.NewRequest(http.MethodGet, "/api/vaults/" + slug, nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/vaults/" + slug + query, nil)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Client-Operation", utils.RetrieveVault)
 	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+	req.Header.Set("User-Slug", userSlug)
 	resp, err := app.Test(req)
 
 	if err != nil {
@@ -0,0 +1,209 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/controllers"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/tests/helpers"
+	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+func testSearch(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
+	t.Run("missing_query_400_bad_request", func(t *testing.T) {
+		testSearchClientError(t, app, conf, 400, utils.ErrorSearchQuery, "", helpers.NewSlug(t), "")
+	})
+
+	t.Run("too_long_query_400_bad_request", func(t *testing.T) {
+		if q, err := utils.GenerateSlug(256); err != nil {
+			t.Fatalf("Generate long string failed: %s", err.Error())
+		} else {
+			testSearchClientError(
+				t, app, conf, 400, utils.ErrorSearchQuery, "Too long", helpers.NewSlug(t), "?q=" + q,
+			)
+		}
+	})
+
+	t.Run("invalid_limit_400_bad_request", func(t *testing.T) {
+		testSearchClientError(
+			t, app, conf, 400, utils.ErrorLimit, "0", helpers.NewSlug(t), "?q=bank&limit=0",
+		)
+	})
+
+	t.Run("secret_strings_not_searched_200_ok", func(t *testing.T) {
+		users, _, _, _ := setup.SetUpWithData(t, db)
+		respBody := testSearchSuccess(t, app, conf, users[0].Slug, "foodeater")
+		require.Empty(t, respBody.Results)
+	})
+
+	t.Run("ranked_results_200_ok", func(t *testing.T) {
+		users, vaults, entries, _ := setup.SetUpWithData(t, db)
+		userSlug := users[0].Slug
+		createSearchTestData(t, db, &vaults[0], &entries[0])
+
+		respBody := testSearchSuccess(t, app, conf, userSlug, "BANK")
+		require.Equal(t, []controllers.SearchResult{
+			{Kind: controllers.SearchKindVault, Title: "Bank"},
+			{Kind: controllers.SearchKindSecret, Title: "bankpin"},
+			{Kind: controllers.SearchKindEntry, Title: "Bank of Foo"},
+			{Kind: controllers.SearchKindEntry, Title: "My bank"},
+			{Kind: controllers.SearchKindVault, Title: "Foobank"},
+		}, searchResultTitles(respBody.Results))
+
+		require.Equal(t, respBody.Results[0].Slug, respBody.Results[0].VaultSlug)
+		require.Empty(t, respBody.Results[0].EntrySlug)
+		require.Equal(t, vaults[0].Slug, respBody.Results[1].VaultSlug)
+		require.Equal(t, entries[0].Slug, respBody.Results[1].EntrySlug)
+		require.Equal(t, vaults[0].Slug, respBody.Results[2].VaultSlug)
+		require.Equal(t, respBody.Results[2].Slug, respBody.Results[2].EntrySlug)
+
+		respBody = testSearchSuccess(t, app, conf, userSlug, "bank&limit=2")
+		require.Equal(t, 2, len(respBody.Results))
+		require.Equal(t, "Bank", respBody.Results[0].Title)
+		require.Equal(t, "bankpin", respBody.Results[1].Title)
+
+		respBody = testSearchSuccess(t, app, conf, users[1].Slug, "bank")
+		require.Empty(t, respBody.Results)
+	})
+
+	t.Run("trashed_records_not_searched_200_ok", func(t *testing.T) {
+		users, vaults, entries, _ := setup.SetUpWithData(t, db)
+		userSlug := users[0].Slug
+		createSearchTestData(t, db, &vaults[0], &entries[0])
+
+		resp := newRequestDeleteVault(t, app, conf, userSlug, vaults[0].Slug)
+		require.Equal(t, 204, resp.StatusCode)
+
+		respBody := testSearchSuccess(t, app, conf, userSlug, "bank")
+		require.Equal(t, []controllers.SearchResult{
+			{Kind: controllers.SearchKindVault, Title: "Bank"},
+			{Kind: controllers.SearchKindVault, Title: "Foobank"},
+		}, searchResultTitles(respBody.Results))
+	})
+
+	t.Run("team_vaults_searched_200_ok", func(t *testing.T) {
+		entry, memberSlug := newTestTeamVault(t, app, db, conf, "m3mb3rVal!dPW", models.RoleViewer)
+
+		respBody := testSearchSuccess(t, app, conf, memberSlug, "vault")
+		require.Equal(t, []controllers.SearchResult{
+			{Kind: controllers.SearchKindVault, Title: "vault"},
+		}, searchResultTitles(respBody.Results))
+		require.Equal(t, entry.VaultSlug, respBody.Results[0].Slug)
+
+		respBody = testSearchSuccess(t, app, conf, memberSlug, "e")
+		require.Equal(t, []controllers.SearchResult{
+			{Kind: controllers.SearchKindEntry, Title: "entry"},
+			{Kind: controllers.SearchKindSecret, Title: "label"},
+		}, searchResultTitles(respBody.Results))
+		require.Equal(t, entry.Slug, respBody.Results[0].Slug)
+		require.Equal(t, entry.Secrets[0].Slug, respBody.Results[1].Slug)
+
+		respBody = testSearchSuccess(t, app, conf, helpers.NewSlug(t), "e")
+		require.Empty(t, respBody.Results)
+	})
+
+	t.Run("wildcards_matched_literally_200_ok", func(t *testing.T) {
+		users, vaults, entries, _ := setup.SetUpWithData(t, db)
+		createSearchTestData(t, db, &vaults[0], &entries[0])
+
+		respBody := testSearchSuccess(t, app, conf, users[0].Slug, url.QueryEscape("%"))
+		require.Empty(t, respBody.Results)
+	})
+}
+
+func createSearchTestData(t *testing.T, db *gorm.DB, vault *models.Vault, entry *models.Entry) {
+	createTestVaults(t, db, vault.UserSlug, "Bank", "Foobank")
+
+	for _, title := range []string{"Bank of Foo", "My bank"} {
+		newEntry := models.Entry{
+			Slug: helpers.NewSlug(t), Title: title, VaultSlug: vault.Slug, UserSlug: vault.UserSlug,
+		}
+
+		if result := db.Create(&newEntry); result.Error != nil {
+			t.Fatalf("Entry creation failed: %s", result.Error.Error())
+		}
+	}
+
+	secret := models.Secret{
+		Slug:      helpers.NewSlug(t),
+		Label:     "bankpin",
+		String:    "Never searched, so never decrypted.",
+		Priority:  2,
+		EntrySlug: entry.Slug,
+		VaultSlug: vault.Slug,
+		UserSlug:  vault.UserSlug,
+	}
+
+	if result := db.Create(&secret); result.Error != nil {
+		t.Fatalf("Secret creation failed: %s", result.Error.Error())
+	}
+}
+
+// searchResultTitles keeps only the kind and title of each result, for comparing order.
+func searchResultTitles(results []controllers.SearchResult) []controllers.SearchResult {
+	titles := []controllers.SearchResult{}
+
+	for _, result := range results {
+		titles = append(titles, controllers.SearchResult{Kind: result.Kind, Title: result.Title})
+	}
+
+	return titles
+}
+
+func testSearchClientError(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, expectedStatus int,
+	expectedMessage, expectedDetail, userSlug, query string,
+) {
+	resp := newRequestSearch(t, app, conf, userSlug, query)
+	require.Equal(t, expectedStatus, resp.StatusCode)
+	helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+		ClientOperation: utils.Search,
+		Message:         expectedMessage,
+		Detail:          expectedDetail,
+	})
+}
+
+func testSearchSuccess(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, q string,
+) (respBody controllers.SearchResponseBody) {
+	resp := newRequestSearch(t, app, conf, userSlug, "?q=" + q)
+	require.Equal(t, 200, resp.StatusCode)
+
+	if body, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("Read response body failed: %s", err.Error())
+	} else if err := json.Unmarshal(body, &respBody); err != nil {
+		t.Fatalf("JSON unmarshal failed: %s", err.Error())
+	}
+
+	return
+}
+
+func newRequestSearch(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, query string,
+) *http.Response {
+
+	req := httptest.NewRequest(http.MethodGet, "/api/search" + query, nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Client-Operation", utils.Search)
+	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+	req.Header.Set("User-Slug", userSlug)
+
+	resp, err := app.Test(req, -1)
+
+	if err != nil {
+		t.Fatalf("Send test request failed: %s", err.Error())
+	}
+
+	return resp
+}
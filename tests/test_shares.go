@@ -0,0 +1,345 @@
+package tests
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/controllers"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/tests/helpers"
+	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+func testShares(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
+	granteePassword := "gr4nt33Val!dPW"
+	shareBodyFmt := `{"entry_slug":"%s","grantee_slug":"%s","permission":"%s"}`
+
+	t.Run("shared_entry_200_ok", func(t *testing.T) {
+		entry, share := newTestShare(t, app, db, conf, granteePassword, models.PermissionRead)
+
+		respBody := testRetrieveShareSuccess(t, app, conf, share, granteePassword)
+		require.Equal(t, "entry", respBody.EntryTitle)
+		require.Equal(t, models.PermissionRead, respBody.Permission)
+		require.Len(t, respBody.Secrets, 1)
+		require.Equal(t, "label", respBody.Secrets[0].Label)
+		require.Equal(t, "string", respBody.Secrets[0].String)
+
+		// The grantee's copy is under neither user's own key.
+		var shareSecret models.ShareSecret
+		require.NoError(t, db.First(&shareSecret, "share_slug = ?", share.Slug).Error)
+		require.NotEqual(t, entry.Secrets[0].String, shareSecret.String)
+
+		// Only the grantee retrieves the share.
+		resp := newRequestWithVaultKey(
+			t, app, conf, http.MethodGet, "/api/shares/" + share.Slug, utils.RetrieveShare,
+			entry.UserSlug, helpers.VALID_PW, "",
+		)
+		require.Equal(t, 404, resp.StatusCode)
+
+		resp = newRequestWithVaultKey(
+			t, app, conf, http.MethodGet, "/api/shares", utils.ListShares, share.GranteeSlug,
+			granteePassword, "",
+		)
+		require.Equal(t, 200, resp.StatusCode)
+
+		var listBody controllers.ListSharesResponseBody
+
+		if respBody, err := io.ReadAll(resp.Body); err != nil {
+			t.Fatalf("Read response body failed: %s", err.Error())
+		} else if err := json.Unmarshal(respBody, &listBody); err != nil {
+			t.Fatalf("JSON unmarshal failed: %s", err.Error())
+		}
+
+		require.Empty(t, listBody.Owned)
+		require.Len(t, listBody.Received, 1)
+		require.Equal(t, share.Slug, listBody.Received[0].Slug)
+	})
+
+	t.Run("grantee_without_key_409_conflict", func(t *testing.T) {
+		entry := newTestPasswordUserEntry(t, app, db, conf)
+		grantee := models.User{Slug: helpers.NewSlug(t)}
+		require.NoError(t, db.Create(&grantee).Error)
+		body := fmt.Sprintf(shareBodyFmt, entry.Slug, grantee.Slug, models.PermissionRead)
+
+		resp := newRequestWithVaultKey(
+			t, app, conf, http.MethodPost, "/api/shares", utils.CreateShare, entry.UserSlug,
+			helpers.VALID_PW, body,
+		)
+		require.Equal(t, 409, resp.StatusCode)
+		helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+			ClientOperation: utils.CreateShare,
+			Message:         utils.ErrorGranteeShareKey,
+			Detail:          grantee.Slug,
+			RequestBody:     body,
+		})
+	})
+
+	t.Run("grantee_self_400_bad_request", func(t *testing.T) {
+		entry := newTestPasswordUserEntry(t, app, db, conf)
+		body := fmt.Sprintf(shareBodyFmt, entry.Slug, entry.UserSlug, models.PermissionRead)
+
+		resp := newRequestWithVaultKey(
+			t, app, conf, http.MethodPost, "/api/shares", utils.CreateShare, entry.UserSlug,
+			helpers.VALID_PW, body,
+		)
+		require.Equal(t, 400, resp.StatusCode)
+		helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+			ClientOperation: utils.CreateShare,
+			Message:         utils.ErrorGranteeSlug,
+			Detail:          "Same as `User-Slug` header.",
+			RequestBody:     body,
+		})
+	})
+
+	t.Run("invalid_permission_400_bad_request", func(t *testing.T) {
+		entry := newTestPasswordUserEntry(t, app, db, conf)
+		body := fmt.Sprintf(shareBodyFmt, entry.Slug, helpers.NewSlug(t), "admin")
+
+		resp := newRequestWithVaultKey(
+			t, app, conf, http.MethodPost, "/api/shares", utils.CreateShare, entry.UserSlug,
+			helpers.VALID_PW, body,
+		)
+		require.Equal(t, 400, resp.StatusCode)
+		helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+			ClientOperation: utils.CreateShare,
+			Message:         utils.ErrorSharePermission,
+			Detail:          "admin",
+			RequestBody:     body,
+		})
+	})
+
+	t.Run("already_shared_409_conflict", func(t *testing.T) {
+		entry, share := newTestShare(t, app, db, conf, granteePassword, models.PermissionRead)
+		body := fmt.Sprintf(shareBodyFmt, entry.Slug, share.GranteeSlug, models.PermissionWrite)
+
+		resp := newRequestWithVaultKey(
+			t, app, conf, http.MethodPost, "/api/shares", utils.CreateShare, entry.UserSlug,
+			helpers.VALID_PW, body,
+		)
+		require.Equal(t, 409, resp.StatusCode)
+	})
+
+	t.Run("owner_updates_propagated_200_ok", func(t *testing.T) {
+		entry, share := newTestShare(t, app, db, conf, granteePassword, models.PermissionRead)
+
+		resp := newRequestWithVaultKey(
+			t, app, conf, http.MethodPatch, "/api/secrets/" + entry.Secrets[0].Slug,
+			utils.UpdateSecret, entry.UserSlug, helpers.VALID_PW,
+			`{"secret_string":"updated_string"}`,
+		)
+		require.Equal(t, 204, resp.StatusCode)
+
+		resp = newRequestWithVaultKey(
+			t, app, conf, http.MethodPost, "/api/secrets", utils.CreateSecret, entry.UserSlug,
+			helpers.VALID_PW, fmt.Sprintf(
+				`{"user_slug":"%s","vault_slug":"%s","entry_slug":"%s",` +
+				`"secret_label":"new_label","secret_string":"new_string"}`,
+				entry.UserSlug, entry.VaultSlug, entry.Slug,
+			),
+		)
+		require.Equal(t, 204, resp.StatusCode)
+
+		respBody := testRetrieveShareSuccess(t, app, conf, share, granteePassword)
+		require.Len(t, respBody.Secrets, 2)
+		require.Equal(t, "updated_string", respBody.Secrets[0].String)
+		require.Equal(t, "new_string", respBody.Secrets[1].String)
+
+		// So is a restored version.
+		var version models.SecretVersion
+		require.NoError(t, db.First(&version, "secret_slug = ?", entry.Secrets[0].Slug).Error)
+
+		resp = newRequestWithVaultKey(
+			t, app, conf, http.MethodPost,
+			"/api/secrets/" + entry.Secrets[0].Slug + "/versions/" + version.Slug + "/restore",
+			utils.RestoreSecretVersion, entry.UserSlug, helpers.VALID_PW, "",
+		)
+		require.Equal(t, 204, resp.StatusCode)
+
+		respBody = testRetrieveShareSuccess(t, app, conf, share, granteePassword)
+		require.Equal(t, "string", respBody.Secrets[0].String)
+	})
+
+	t.Run("rekeyed_owner_propagated_200_ok", func(t *testing.T) {
+		entry, share := newTestShare(t, app, db, conf, granteePassword, models.PermissionRead)
+		newPassword := "n3wVal!dPA5SWoRD"
+
+		resp := newRequestRekeyUser(t, app, conf, entry.UserSlug, fmt.Sprintf(
+			`{"old_password":"%s","new_password":"%s"}`, helpers.VALID_PW, newPassword,
+		))
+		require.Equal(t, 204, resp.StatusCode)
+
+		resp = newRequestWithVaultKey(
+			t, app, conf, http.MethodPatch, "/api/secrets/" + entry.Secrets[0].Slug,
+			utils.UpdateSecret, entry.UserSlug, newPassword, `{"secret_string":"updated_string"}`,
+		)
+		require.Equal(t, 204, resp.StatusCode)
+
+		respBody := testRetrieveShareSuccess(t, app, conf, share, granteePassword)
+		require.Equal(t, "updated_string", respBody.Secrets[0].String)
+	})
+
+	t.Run("read_only_403_forbidden", func(t *testing.T) {
+		entry, share := newTestShare(t, app, db, conf, granteePassword, models.PermissionRead)
+		body := `{"secret_string":"written_string"}`
+
+		resp := newRequestWithVaultKey(
+			t, app, conf, http.MethodPatch,
+			"/api/shares/" + share.Slug + "/secrets/" + entry.Secrets[0].Slug,
+			utils.UpdateSharedSecret, share.GranteeSlug, granteePassword, body,
+		)
+		require.Equal(t, 403, resp.StatusCode)
+		helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+			ClientOperation: utils.UpdateSharedSecret,
+			Message:         utils.ErrorShareReadOnly,
+			Detail:          share.Slug,
+			RequestBody:     body,
+		})
+	})
+
+	t.Run("grantee_write_applied_on_owner_unlock_200_ok", func(t *testing.T) {
+		entry, share := newTestShare(t, app, db, conf, granteePassword, models.PermissionWrite)
+
+		resp := newRequestWithVaultKey(
+			t, app, conf, http.MethodPatch,
+			"/api/shares/" + share.Slug + "/secrets/" + entry.Secrets[0].Slug,
+			utils.UpdateSharedSecret, share.GranteeSlug, granteePassword,
+			`{"secret_string":"written_string"}`,
+		)
+		require.Equal(t, 204, resp.StatusCode)
+
+		respBody := testRetrieveShareSuccess(t, app, conf, share, granteePassword)
+		require.Equal(t, "written_string", respBody.Secrets[0].String)
+
+		// The owner's secret is untouched until the owner unlocks it.
+		var secret models.Secret
+		helpers.QueryTestSecretBySlug(t, db, &secret, entry.Secrets[0].Slug)
+		require.Equal(t, entry.Secrets[0].String, secret.String)
+
+		resp = newRequestWithVaultKey(
+			t, app, conf, http.MethodGet, "/api/entries/" + entry.Slug, utils.RetrieveEntry,
+			entry.UserSlug, helpers.VALID_PW, "",
+		)
+		require.Equal(t, 200, resp.StatusCode)
+
+		var respEntry models.Entry
+
+		if respBody, err := io.ReadAll(resp.Body); err != nil {
+			t.Fatalf("Read response body failed: %s", err.Error())
+		} else if err := json.Unmarshal(respBody, &respEntry); err != nil {
+			t.Fatalf("JSON unmarshal failed: %s", err.Error())
+		}
+
+		require.Equal(t, "written_string", respEntry.Secrets[0].String)
+
+		var versionCount, pendingCount int64
+		require.NoError(t, db.Model(&models.SecretVersion{}).
+			Where("secret_slug = ?", secret.Slug).Count(&versionCount).Error)
+		require.EqualValues(t, 1, versionCount)
+		require.NoError(t, db.Model(&models.ShareSecret{}).
+			Where("written = ?", true).Count(&pendingCount).Error)
+		require.EqualValues(t, 0, pendingCount)
+	})
+
+	t.Run("revoked_404_not_found", func(t *testing.T) {
+		entry, share := newTestShare(t, app, db, conf, granteePassword, models.PermissionRead)
+
+		resp := newRequestWithVaultKey(
+			t, app, conf, http.MethodDelete, "/api/shares/" + share.Slug, utils.DeleteShare,
+			entry.UserSlug, "", "",
+		)
+		require.Equal(t, 204, resp.StatusCode)
+
+		resp = newRequestWithVaultKey(
+			t, app, conf, http.MethodGet, "/api/shares/" + share.Slug, utils.RetrieveShare,
+			share.GranteeSlug, granteePassword, "",
+		)
+		require.Equal(t, 404, resp.StatusCode)
+		helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+			ClientOperation: utils.RetrieveShare,
+			Message:         utils.ErrorNotFound,
+			Detail:          share.Slug,
+		})
+
+		var shareSecretCount int64
+		require.NoError(t, db.Model(&models.ShareSecret{}).Count(&shareSecretCount).Error)
+		require.EqualValues(t, 0, shareSecretCount)
+	})
+
+	t.Run("raw_key_user_given_share_keys_204_no_content", func(t *testing.T) {
+		_, _, entries, _ := setup.SetUpWithData(t, db)
+		entry := entries[0]
+
+		var user models.User
+		helpers.QueryTestUser(t, db, &user, entry.UserSlug)
+		require.Empty(t, user.PublicKey)
+
+		resp := newRequestWithVaultKey(
+			t, app, conf, http.MethodGet, "/api/entries/" + entry.Slug, utils.RetrieveEntry,
+			entry.UserSlug, helpers.HexHash[:64], "",
+		)
+		require.Equal(t, 200, resp.StatusCode)
+
+		helpers.QueryTestUser(t, db, &user, entry.UserSlug)
+		require.NotEmpty(t, user.PublicKey)
+
+		_, err := utils.Decrypt(
+			user.PrivateKey, helpers.UserSecretsKey(t, db, conf, user.Slug, helpers.HexHash[:64]),
+			utils.PrivateKeyAdditionalData(user.Slug),
+		)
+		require.NoError(t, err)
+	})
+}
+
+// newTestShare shares an entry of a new user's with another new user, whose password is
+// `granteePassword`, through the API.
+func newTestShare(
+	t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig,
+	granteePassword, permission string,
+) (entry models.Entry, share models.Share) {
+	entry = newTestPasswordUserEntry(t, app, db, conf)
+	granteeSlug := helpers.NewSlug(t)
+
+	resp := newRequestCreateUser(
+		t, app, conf, fmt.Sprintf(`{"user_slug":"%s"}`, granteeSlug), granteePassword,
+	)
+	require.Equal(t, 204, resp.StatusCode)
+
+	resp = newRequestWithVaultKey(
+		t, app, conf, http.MethodPost, "/api/shares", utils.CreateShare, entry.UserSlug,
+		helpers.VALID_PW, fmt.Sprintf(
+			`{"entry_slug":"%s","grantee_slug":"%s","permission":"%s"}`,
+			entry.Slug, granteeSlug, permission,
+		),
+	)
+	require.Equal(t, 204, resp.StatusCode)
+	require.NoError(t, db.First(&share, "entry_slug = ?", entry.Slug).Error)
+
+	return
+}
+
+func testRetrieveShareSuccess(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, share models.Share, password string,
+) (respBody controllers.RetrieveShareResponseBody) {
+	resp := newRequestWithVaultKey(
+		t, app, conf, http.MethodGet, "/api/shares/" + share.Slug, utils.RetrieveShare,
+		share.GranteeSlug, password, "",
+	)
+	require.Equal(t, 200, resp.StatusCode)
+
+	if body, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("Read response body failed: %s", err.Error())
+	} else if err := json.Unmarshal(body, &respBody); err != nil {
+		t.Fatalf("JSON unmarshal failed: %s", err.Error())
+	}
+
+	return
+}
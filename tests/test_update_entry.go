@@ -26,69 +26,76 @@ func testUpdateEntry(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 	t.Run("empty_body_400_bad_request", func(t *testing.T) {
 		testUpdateEntryClientError(
 			t, app, conf, 400, utils.ErrorParse,
-			"invalid character '\x00' looking for beginning of value", dummySlug, "",
+			"invalid character '\x00' looking for beginning of value", helpers.NewSlug(t), dummySlug, "",
 		)
 	})
 
 	t.Run("array_body_400_bad_request", func(t *testing.T) {
 		testUpdateEntryClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character '[' looking for beginning of value",
-			dummySlug, "[]",
+			helpers.NewSlug(t), dummySlug, "[]",
 		)
 
 		testUpdateEntryClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character '[' looking for beginning of value",
-			dummySlug, "[{}]",
+			helpers.NewSlug(t), dummySlug, "[{}]",
 		)
 
 		testUpdateEntryClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character '[' looking for beginning of value",
-			dummySlug, `[{"entry_title":"updated@0.1.1.*"}]`,
+			helpers.NewSlug(t), dummySlug, `[{"entry_title":"updated@0.1.1.*"}]`,
 		)
 	})
 
 	t.Run("boolean_body_400_bad_request", func(t *testing.T) {
 		testUpdateEntryClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character 't' looking for beginning of value",
-			dummySlug, "true",
+			helpers.NewSlug(t), dummySlug, "true",
 		)
 
 		testUpdateEntryClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character 'f' looking for beginning of value",
-			dummySlug, "false",
+			helpers.NewSlug(t), dummySlug, "false",
 		)
 	})
 
 	t.Run("string_body_400_bad_request", func(t *testing.T) {
 		testUpdateEntryClientError(
 			t, app, conf, 400, utils.ErrorParse, `invalid character '"' looking for beginning of value`,
-			dummySlug, `"Valid JSON, but not an object."`,
+			helpers.NewSlug(t), dummySlug, `"Valid JSON, but not an object."`,
 		)
 	})
 
 	t.Run("null_body_400_bad_request", func(t *testing.T) {
-		testUpdateEntryClientError(t, app, conf, 400, utils.ErrorEntryTitle, "", dummySlug, "null")
+		testUpdateEntryClientError(
+			t, app, conf, 400, utils.ErrorEntryTitle, "", helpers.NewSlug(t), dummySlug, "null",
+		)
 	})
 
 	t.Run("empty_object_body_400_bad_request", func(t *testing.T) {
-		testUpdateEntryClientError(t, app, conf, 400, utils.ErrorEntryTitle, "", dummySlug, "{}")
+		testUpdateEntryClientError(
+			t, app, conf, 400, utils.ErrorEntryTitle, "", helpers.NewSlug(t), dummySlug, "{}",
+		)
 	})
 
 	t.Run("missing_entry_title_400_bad_request", func(t *testing.T) {
 		testUpdateEntryClientError(
-			t, app, conf, 400, utils.ErrorEntryTitle, "", dummySlug, `{"enrty_title":"Spelled wrong!"}`,
+			t, app, conf, 400, utils.ErrorEntryTitle, "", helpers.NewSlug(t), dummySlug,
+			`{"enrty_title":"Spelled wrong!"}`,
 		)
 	})
 
 	t.Run("null_entry_title_400_bad_request", func(t *testing.T) {
 		testUpdateEntryClientError(
-			t, app, conf, 400, utils.ErrorEntryTitle, "", dummySlug, `{"entry_title":null}`,
+			t, app, conf, 400, utils.ErrorEntryTitle, "", helpers.NewSlug(t), dummySlug,
+			`{"entry_title":null}`,
 		)
 	})
 
 	t.Run("empty_entry_title_400_bad_request", func(t *testing.T) {
 		testUpdateEntryClientError(
-			t, app, conf, 400, utils.ErrorEntryTitle, "", dummySlug, `{"entry_title":""}`,
+			t, app, conf, 400, utils.ErrorEntryTitle, "", helpers.NewSlug(t), dummySlug,
+			`{"entry_title":""}`,
 		)
 	})
 
@@ -97,7 +104,7 @@ func testUpdateEntry(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 			t.Fatalf("Generate long string failed: %s", err.Error())
 		} else {
 			testUpdateEntryClientError(
-				t, app, conf, 400, utils.ErrorEntryTitle, "Too long", dummySlug,
+				t, app, conf, 400, utils.ErrorEntryTitle, "Too long", helpers.NewSlug(t), dummySlug,
 				fmt.Sprintf(bodyFmt, title),
 			)
 		}
@@ -107,9 +114,9 @@ func testUpdateEntry(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 		_, _, entries, _ := setup.SetUpWithData(t, db)
 
 		testUpdateEntryClientError(
-			t, app, conf, 500, utils.ErrorFailedDB,
+			t, app, conf, 409, utils.ErrorDuplicateEntry,
 			"UNIQUE constraint failed: entries.title, entries.vault_slug",
-			entries[0].Slug, fmt.Sprintf(bodyFmt, entries[1].Title),
+			entries[0].UserSlug, entries[0].Slug, fmt.Sprintf(bodyFmt, entries[1].Title),
 		)
 	})
 
@@ -118,10 +125,23 @@ func testUpdateEntry(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 
 		testUpdateEntryClientError(
 			t, app, conf, 404, utils.ErrorNoRowsAffected, "Likely that slug was not found.",
-			dummySlug, fmt.Sprintf(bodyFmt, "updated@0.1.1.*"),
+			helpers.NewSlug(t), dummySlug, fmt.Sprintf(bodyFmt, "updated@0.1.1.*"),
 		)
 	})
 
+	t.Run("other_users_slug_404_not_found", func(t *testing.T) {
+		users, _, entries, _ := setup.SetUpWithData(t, db)
+
+		testUpdateEntryClientError(
+			t, app, conf, 404, utils.ErrorNoRowsAffected, "Likely that slug was not found.",
+			users[1].Slug, entries[0].Slug, fmt.Sprintf(bodyFmt, "updated@0.1.1.*"),
+		)
+
+		var entry models.Entry
+		helpers.QueryTestEntry(t, db, &entry, entries[0].Title)
+		require.Equal(t, entries[0].Slug, entry.Slug)
+	})
+
 	t.Run("valid_body_204_no_content", func(t *testing.T) {
 		updatedEntryTitle := "updated@0.1.1.*"
 
@@ -145,9 +165,9 @@ func testUpdateEntry(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 
 func testUpdateEntryClientError(
 	t *testing.T, app *fiber.App, conf *config.AppConfig, expectedStatus int,
-	expectedMessage, expectedDetail, slug, body string,
+	expectedMessage, expectedDetail, userSlug, slug, body string,
 ) {
-	resp := newRequestUpdateEntry(t, app, conf, slug, body)
+	resp := newRequestUpdateEntry(t, app, conf, userSlug, slug, body)
 	require.Equal(t, expectedStatus, resp.StatusCode)
 	helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
 		ClientOperation: utils.UpdateEntry,
@@ -166,7 +186,9 @@ func testUpdateEntrySuccess(
 	var entryBeforeUpdate models.Entry
 	helpers.QueryTestEntry(t, db, &entryBeforeUpdate, "entry@0.1.1.*")
 
-	resp := newRequestUpdateEntry(t, app, conf, entryBeforeUpdate.Slug, body)
+	resp := newRequestUpdateEntry(
+		t, app, conf, entryBeforeUpdate.UserSlug, entryBeforeUpdate.Slug, body,
+	)
 	require.Equal(t, 204, resp.StatusCode)
 
 	if respBody, err := io.ReadAll(resp.Body); err != nil {
@@ -187,7 +209,7 @@ func testUpdateEntrySuccess(
 }
 
 func newRequestUpdateEntry(
-	t *testing.T, app *fiber.App, conf *config.AppConfig, slug, body string,
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, slug, body string,
 ) *http.Response {
 
 	reqBody := strings.NewReader(body)
@@ -195,6 +217,7 @@ func newRequestUpdateEntry(
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Client-Operation", utils.UpdateEntry)
 	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+	req.Header.Set("User-Slug", userSlug)
 
 	resp, err := app.Test(req)
 
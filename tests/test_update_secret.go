@@ -25,43 +25,43 @@ func testUpdateSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.Ap
 	t.Run("empty_body_400_bad_request", func(t *testing.T) {
 		testUpdateSecretClientError(
 			t, app, conf, 400, utils.ErrorParse,
-			"invalid character '\x00' looking for beginning of value", dummySlug, "",
+			"invalid character '\x00' looking for beginning of value", helpers.NewSlug(t), dummySlug, "",
 		)
 	})
 
 	t.Run("array_body_400_bad_request", func(t *testing.T) {
 		testUpdateSecretClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character '[' looking for beginning of value",
-			dummySlug, "[]",
+			helpers.NewSlug(t), dummySlug, "[]",
 		)
 
 		testUpdateSecretClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character '[' looking for beginning of value",
-			dummySlug, "[{}]",
+			helpers.NewSlug(t), dummySlug, "[{}]",
 		)
 
 		testUpdateSecretClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character '[' looking for beginning of value",
-			dummySlug, `[{"secret_label":"abc","secret_string":"123"}]`,
+			helpers.NewSlug(t), dummySlug, `[{"secret_label":"abc","secret_string":"123"}]`,
 		)
 	})
 
 	t.Run("boolean_body_400_bad_request", func(t *testing.T) {
 		testUpdateSecretClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character 't' looking for beginning of value",
-			dummySlug, "true",
+			helpers.NewSlug(t), dummySlug, "true",
 		)
 
 		testUpdateSecretClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character 'f' looking for beginning of value",
-			dummySlug, "false",
+			helpers.NewSlug(t), dummySlug, "false",
 		)
 	})
 
 	t.Run("string_body_400_bad_request", func(t *testing.T) {
 		testUpdateSecretClientError(
 			t, app, conf, 400, utils.ErrorParse, `invalid character '"' looking for beginning of value`,
-			dummySlug, `"Valid JSON, but not an object."`,
+			helpers.NewSlug(t), dummySlug, `"Valid JSON, but not an object."`,
 		)
 	})
 
@@ -70,12 +70,12 @@ func testUpdateSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.Ap
 
 		testUpdateSecretClientError(
 			t, app, conf, 400, utils.ErrorEmptyUpdateSecret, "Null or empty object or fields.",
-			secrets[0].Slug, "null",
+			helpers.NewSlug(t), secrets[0].Slug, "null",
 		)
 
 		testUpdateSecretClientError(
 			t, app, conf, 400, utils.ErrorEmptyUpdateSecret, "Null or empty object or fields.",
-			secrets[0].Slug, "{}",
+			helpers.NewSlug(t), secrets[0].Slug, "{}",
 		)
 	})
 
@@ -84,17 +84,17 @@ func testUpdateSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.Ap
 
 		testUpdateSecretClientError(
 			t, app, conf, 400, utils.ErrorEmptyUpdateSecret, "Null or empty object or fields.",
-			secrets[0].Slug, `{"secret_label":"","secret_string":""}`,
+			helpers.NewSlug(t), secrets[0].Slug, `{"secret_label":"","secret_string":""}`,
 		)
 
 		testUpdateSecretClientError(
 			t, app, conf, 400, utils.ErrorEmptyUpdateSecret, "Null or empty object or fields.",
-			secrets[0].Slug, `{"secret_label":null,"secret_string":null}`,
+			helpers.NewSlug(t), secrets[0].Slug, `{"secret_label":null,"secret_string":null}`,
 		)
 
 		testUpdateSecretClientError(
 			t, app, conf, 400, utils.ErrorEmptyUpdateSecret, "Null or empty object or fields.",
-			secrets[0].Slug, `{"weird_label":"abc","weird_string":"123"}`,
+			helpers.NewSlug(t), secrets[0].Slug, `{"weird_label":"abc","weird_string":"123"}`,
 		)
 	})
 
@@ -103,7 +103,7 @@ func testUpdateSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.Ap
 			t.Fatalf("Generate long string failed: %s", err.Error())
 		} else {
 			testUpdateSecretClientError(
-				t, app, conf, 400, utils.ErrorSecretLabel, "Too long", dummySlug,
+				t, app, conf, 400, utils.ErrorSecretLabel, "Too long", helpers.NewSlug(t), dummySlug,
 				fmt.Sprintf(`{"secret_label":"%s"}`, label),
 			)
 		}
@@ -114,18 +114,19 @@ func testUpdateSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.Ap
 			t.Fatalf("Generate long string failed: %s", err.Error())
 		} else {
 			testUpdateSecretClientError(
-				t, app, conf, 400, utils.ErrorSecretString, "Too long", dummySlug,
+				t, app, conf, 400, utils.ErrorSecretString, "Too long", helpers.NewSlug(t), dummySlug,
 				fmt.Sprintf(`{"secret_string":"%s"}`, str),
 			)
 		}
 	})
 
-	t.Run("valid_body_secret_label_already_exists_500_error", func(t *testing.T) {
+	t.Run("valid_body_secret_label_already_exists_409_conflict", func(t *testing.T) {
 		_, _, _, secrets := setup.SetUpWithData(t, db)
 
 		testUpdateSecretClientError(
-			t, app, conf, 500, utils.ErrorFailedDB,
-			"UNIQUE constraint failed: secrets.label, secrets.entry_slug", secrets[0].Slug,
+			t, app, conf, 409, utils.ErrorDuplicateSecret,
+			"UNIQUE constraint failed: secrets.label, secrets.entry_slug",
+			secrets[0].UserSlug, secrets[0].Slug,
 			fmt.Sprintf(`{"secret_label":"%s"}`, secrets[1].Label),
 		)
 	})
@@ -133,11 +134,30 @@ func testUpdateSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.Ap
 	t.Run("valid_body_404_not_found", func(t *testing.T) {
 		setup.SetUpWithData(t, db)
 		testUpdateSecretClientError(
-			t, app, conf, 404, utils.ErrorNoRowsAffected, "Likely that slug was not found.",
-			dummySlug, `{"secret_label":"updated_secret"}`,
+			t, app, conf, 404, utils.ErrorNotFound, dummySlug,
+			helpers.NewSlug(t), dummySlug, `{"secret_label":"updated_secret"}`,
 		)
 	})
 
+	t.Run("other_users_slug_404_not_found", func(t *testing.T) {
+		users, _, _, secrets := setup.SetUpWithData(t, db)
+
+		testUpdateSecretClientError(
+			t, app, conf, 404, utils.ErrorNotFound, secrets[0].Slug,
+			users[1].Slug, secrets[0].Slug, `{"secret_label":"updated_secret"}`,
+		)
+
+		testUpdateSecretClientError(
+			t, app, conf, 404, utils.ErrorNotFound, secrets[0].Slug,
+			users[1].Slug, secrets[0].Slug, `{"secret_string":"updated_string"}`,
+		)
+
+		var secret models.Secret
+		helpers.QueryTestSecretBySlug(t, db, &secret, secrets[0].Slug)
+		require.Equal(t, secrets[0].Label, secret.Label)
+		require.Equal(t, secrets[0].String, secret.String)
+	})
+
 	t.Run("valid_body_204_no_content", func(t *testing.T) {
 		_, _, _, secrets := setup.SetUpWithData(t, db)
 		slug := secrets[0].Slug
@@ -166,6 +186,62 @@ func testUpdateSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.Ap
 		)
 	})
 
+	t.Run("previous_value_archived_204_no_content", func(t *testing.T) {
+		_, _, _, secrets := setup.SetUpWithData(t, db)
+		secret := secrets[0]
+
+		testUpdateSecretSuccess(
+			t, app, db, conf, secret.Slug, "updated_label", "updated_string",
+			`{"secret_label":"updated_label","secret_string":"updated_string"}`,
+		)
+
+		var versions []models.SecretVersion
+
+		if result := db.Find(&versions, "secret_slug = ?", secret.Slug); result.Error != nil {
+			t.Fatalf("Secret versions query failed: %s", result.Error.Error())
+		}
+
+		require.Equal(t, 1, len(versions))
+		require.Equal(t, secret.Label, versions[0].Label)
+		requireSameSecretString(t, db, conf, &secret, versions[0].String)
+		require.Equal(t, secret.EntrySlug, versions[0].EntrySlug)
+		require.Equal(t, secret.VaultSlug, versions[0].VaultSlug)
+		require.Equal(t, secret.UserSlug, versions[0].UserSlug)
+		require.True(t, secret.UpdatedAt.Equal(versions[0].SecretUpdatedAt))
+	})
+
+	t.Run("versions_pruned_to_retention_204_no_content", func(t *testing.T) {
+		_, _, _, secrets := setup.SetUpWithData(t, db)
+		slug := secrets[0].Slug
+
+		defer func(retention int) { conf.SECRET_VERSION_RETENTION = retention }(
+			conf.SECRET_VERSION_RETENTION,
+		)
+		conf.SECRET_VERSION_RETENTION = 2
+
+		for i := 0; i < 4; i++ {
+			updatedSecretString := fmt.Sprintf("updated_string_%d", i)
+
+			testUpdateSecretSuccess(
+				t, app, db, conf, slug, "", updatedSecretString,
+				fmt.Sprintf(`{"secret_string":"%s"}`, updatedSecretString),
+			)
+		}
+
+		var versionCount int64
+		helpers.CountSecretVersions(t, db, &versionCount)
+		require.EqualValues(t, 2, versionCount)
+
+		conf.SECRET_VERSION_RETENTION = 0
+
+		testUpdateSecretSuccess(
+			t, app, db, conf, slug, "", "updated_string_4", `{"secret_string":"updated_string_4"}`,
+		)
+
+		helpers.CountSecretVersions(t, db, &versionCount)
+		require.EqualValues(t, 0, versionCount)
+	})
+
 	t.Run("valid_body_irrelevant_data_204_no_content", func(t *testing.T) {
 		_, _, _, secrets := setup.SetUpWithData(t, db)
 		slug := secrets[0].Slug
@@ -184,9 +260,9 @@ func testUpdateSecret(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.Ap
 
 func testUpdateSecretClientError(
 	t *testing.T, app *fiber.App, conf *config.AppConfig, expectedStatus int,
-	expectedMessage, expectedDetail, slug, body string,
+	expectedMessage, expectedDetail, userSlug, slug, body string,
 ) {
-	resp := newRequestUpdateSecret(t, app, conf, slug, body)
+	resp := newRequestUpdateSecret(t, app, conf, userSlug, slug, body)
 	require.Equal(t, expectedStatus, resp.StatusCode)
 	helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
 		ClientOperation: utils.UpdateSecret,
@@ -208,15 +284,17 @@ func testUpdateSecretSuccess(
 	}
 
 	if updatedSecretString == "" {
-		if plaintext, err := utils.Decrypt(secretBeforeUpdate.String, helpers.HexHash[:64]);
-		err != nil {
+		if plaintext, err := utils.Decrypt(
+			secretBeforeUpdate.String, helpers.HexHash[:64],
+			helpers.SecretAdditionalData(&secretBeforeUpdate),
+		); err != nil {
 			t.Fatalf("Password decryption failed: %s", err.Error())
 		} else {
 			updatedSecretString = plaintext
 		}
 	}
 
-	resp := newRequestUpdateSecret(t, app, conf, slug, body)
+	resp := newRequestUpdateSecret(t, app, conf, secretBeforeUpdate.UserSlug, slug, body)
 	require.Equal(t, 204, resp.StatusCode)
 
 	if respBody, err := io.ReadAll(resp.Body); err != nil {
@@ -228,8 +306,11 @@ func testUpdateSecretSuccess(
 	var secretAfterUpdate models.Secret
 	helpers.QueryTestSecretBySlug(t, db, &secretAfterUpdate, slug)
 
-	if plaintext, err := utils.Decrypt(secretAfterUpdate.String, helpers.HexHash[:64]);
-	err != nil {
+	if plaintext, err := utils.Decrypt(
+		secretAfterUpdate.String,
+		helpers.UserSecretsKey(t, db, conf, secretAfterUpdate.UserSlug, helpers.HexHash[:64]),
+		helpers.SecretAdditionalData(&secretAfterUpdate),
+	); err != nil {
 		t.Fatalf("Password decryption failed: %s", err.Error())
 	} else {
 		require.Equal(t, updatedSecretString, plaintext)
@@ -242,7 +323,7 @@ func testUpdateSecretSuccess(
 }
 
 func newRequestUpdateSecret(
-	t *testing.T, app *fiber.App, conf *config.AppConfig, slug, body string,
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, slug, body string,
 ) *http.Response {
 
 	reqBody := strings.NewReader(body)
@@ -250,6 +331,7 @@ func newRequestUpdateSecret(
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Client-Operation", utils.UpdateSecret)
 	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+	req.Header.Set("User-Slug", userSlug)
 	req.Header.Set(conf.PASSWORD_HEADER_KEY, helpers.HexHash[:64])
 
 	resp, err := app.Test(req, -1)
@@ -260,3 +342,21 @@ func newRequestUpdateSecret(
 
 	return resp
 }
+
+// requireSameSecretString requires `ciphertext` to be the fixture secret's value, since
+// the secret's key, and so its ciphertext, has changed since it was set up.
+func requireSameSecretString(
+	t *testing.T, db *gorm.DB, conf *config.AppConfig, secret *models.Secret, ciphertext string,
+) {
+	expected, err := utils.Decrypt(
+		secret.String, helpers.HexHash[:64], helpers.SecretAdditionalData(secret),
+	)
+	require.NoError(t, err)
+
+	actual, err := utils.Decrypt(
+		ciphertext, helpers.UserSecretsKey(t, db, conf, secret.UserSlug, helpers.HexHash[:64]),
+		helpers.SecretAdditionalData(secret),
+	)
+	require.NoError(t, err)
+	require.Equal(t, expected, actual)
+}
@@ -25,74 +25,77 @@ func testUpdateVault(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 	t.Run("empty_body_400_bad_request", func(t *testing.T) {
 		testUpdateVaultClientError(
 			t, app, conf, 400, utils.ErrorParse,
-			"invalid character '\x00' looking for beginning of value", helpers.NewSlug(t), "",
+			"invalid character '\x00' looking for beginning of value", helpers.NewSlug(t),
+			helpers.NewSlug(t), "",
 		)
 	})
 
 	t.Run("array_body_400_bad_request", func(t *testing.T) {
 		testUpdateVaultClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character '[' looking for beginning of value",
-			helpers.NewSlug(t), "[]",
+			helpers.NewSlug(t), helpers.NewSlug(t), "[]",
 		)
 
 		testUpdateVaultClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character '[' looking for beginning of value",
-			helpers.NewSlug(t), "[{}]",
+			helpers.NewSlug(t), helpers.NewSlug(t), "[{}]",
 		)
 
 		testUpdateVaultClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character '[' looking for beginning of value",
-			helpers.NewSlug(t), `[{"vault_title":"updated@0.1.*.*"}]`,
+			helpers.NewSlug(t), helpers.NewSlug(t), `[{"vault_title":"updated@0.1.*.*"}]`,
 		)
 	})
 
 	t.Run("boolean_body_400_bad_request", func(t *testing.T) {
 		testUpdateVaultClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character 't' looking for beginning of value",
-			helpers.NewSlug(t), "true",
+			helpers.NewSlug(t), helpers.NewSlug(t), "true",
 		)
 
 		testUpdateVaultClientError(
 			t, app, conf, 400, utils.ErrorParse, "invalid character 'f' looking for beginning of value",
-			helpers.NewSlug(t), "false",
+			helpers.NewSlug(t), helpers.NewSlug(t), "false",
 		)
 	})
 
 	t.Run("string_body_400_bad_request", func(t *testing.T) {
 		testUpdateVaultClientError(
 			t, app, conf, 400, utils.ErrorParse, `invalid character '"' looking for beginning of value`,
-			helpers.NewSlug(t), `"Valid JSON, but not an object."`,
+			helpers.NewSlug(t), helpers.NewSlug(t), `"Valid JSON, but not an object."`,
 		)
 	})
 
 	t.Run("null_body_400_bad_request", func(t *testing.T) {
 		testUpdateVaultClientError(
-			t, app, conf, 400, utils.ErrorVaultTitle, "", helpers.NewSlug(t), "null",
+			t, app, conf, 400, utils.ErrorVaultTitle, "", helpers.NewSlug(t), helpers.NewSlug(t), "null",
 		)
 	})
 
 	t.Run("empty_object_body_400_bad_request", func(t *testing.T) {
 		testUpdateVaultClientError(
-			t, app, conf, 400, utils.ErrorVaultTitle, "", helpers.NewSlug(t), "{}",
+			t, app, conf, 400, utils.ErrorVaultTitle, "", helpers.NewSlug(t), helpers.NewSlug(t), "{}",
 		)
 	})
 
 	t.Run("missing_vault_title_400_bad_request", func(t *testing.T) {
 		testUpdateVaultClientError(
 			t, app, conf, 400, utils.ErrorVaultTitle, "",
-			helpers.NewSlug(t), `{"vualt_title":"Spelled wrong!"}`,
+			helpers.NewSlug(t), helpers.NewSlug(t), `{"vualt_title":"Spelled wrong!"}`,
 		)
 	})
 
 	t.Run("null_vault_title_400_bad_request", func(t *testing.T) {
 		testUpdateVaultClientError(
-			t, app, conf, 400, utils.ErrorVaultTitle, "", helpers.NewSlug(t), `{"vault_title":null}`,
+			t, app, conf, 400, utils.ErrorVaultTitle, "", helpers.NewSlug(t), helpers.NewSlug(t),
+			`{"vault_title":null}`,
 		)
 	})
 
 	t.Run("empty_vault_title_400_bad_request", func(t *testing.T) {
 		testUpdateVaultClientError(
-			t, app, conf, 400, utils.ErrorVaultTitle, "", helpers.NewSlug(t), `{"vault_title":""}`,
+			t, app, conf, 400, utils.ErrorVaultTitle, "", helpers.NewSlug(t), helpers.NewSlug(t),
+			`{"vault_title":""}`,
 		)
 	})
 
@@ -102,7 +105,7 @@ func testUpdateVault(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 		} else {
 			testUpdateVaultClientError(
 				t, app, conf, 400, utils.ErrorVaultTitle, "Too long",
-				helpers.NewSlug(t), fmt.Sprintf(bodyFmt, title),
+				helpers.NewSlug(t), helpers.NewSlug(t), fmt.Sprintf(bodyFmt, title),
 			)
 		}
 	})
@@ -111,17 +114,30 @@ func testUpdateVault(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 		setup.SetUpWithData(t, db)
 		testUpdateVaultClientError(
 			t, app, conf, 404, utils.ErrorNoRowsAffected, "Likely that slug was not found.",
-			helpers.NewSlug(t), `{"vault_title":"updated@0.1.*.*"}`,
+			helpers.NewSlug(t), helpers.NewSlug(t), `{"vault_title":"updated@0.1.*.*"}`,
 		)
 	})
 
+	t.Run("other_users_slug_404_not_found", func(t *testing.T) {
+		users, vaults, _, _ := setup.SetUpWithData(t, db)
+
+		testUpdateVaultClientError(
+			t, app, conf, 404, utils.ErrorNoRowsAffected, "Likely that slug was not found.",
+			users[1].Slug, vaults[0].Slug, `{"vault_title":"updated@0.1.*.*"}`,
+		)
+
+		var vault models.Vault
+		helpers.QueryTestVault(t, db, &vault, vaults[0].Title)
+		require.Equal(t, vaults[0].Slug, vault.Slug)
+	})
+
 	t.Run("valid_body_vault_title_already_exists_409_conflict", func(t *testing.T) {
 		_, vaults, _, _ := setup.SetUpWithData(t, db)
 
 		testUpdateVaultClientError(
-			t, app, conf, 500, utils.ErrorFailedDB,
+			t, app, conf, 409, utils.ErrorDuplicateVault,
 			"UNIQUE constraint failed: vaults.title, vaults.user_slug",
-			vaults[0].Slug, fmt.Sprintf(bodyFmt, vaults[1].Title),
+			vaults[0].UserSlug, vaults[0].Slug, fmt.Sprintf(bodyFmt, vaults[1].Title),
 		)
 	})
 
@@ -148,9 +164,9 @@ func testUpdateVault(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.App
 
 func testUpdateVaultClientError(
 	t *testing.T, app *fiber.App, conf *config.AppConfig,
-	expectedStatus int, expectedMessage, expectedDetail, slug, body string,
+	expectedStatus int, expectedMessage, expectedDetail, userSlug, slug, body string,
 ) {
-	resp := newRequestUpdateVault(t, app, conf, slug, body)
+	resp := newRequestUpdateVault(t, app, conf, userSlug, slug, body)
 	require.Equal(t, expectedStatus, resp.StatusCode)
 	helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
 		ClientOperation: utils.UpdateVault,
@@ -169,7 +185,9 @@ func testUpdateVaultSuccess(
 	var vaultBeforeUpdate models.Vault
 	helpers.QueryTestVault(t, db, &vaultBeforeUpdate, "vault@0.1.*.*")
 
-	resp := newRequestUpdateVault(t, app, conf, vaultBeforeUpdate.Slug, body)
+	resp := newRequestUpdateVault(
+		t, app, conf, vaultBeforeUpdate.UserSlug, vaultBeforeUpdate.Slug, body,
+	)
 	require.Equal(t, 204, resp.StatusCode)
 
 	if respBody, err := io.ReadAll(resp.Body); err != nil {
@@ -190,7 +208,7 @@ func testUpdateVaultSuccess(
 }
 
 func newRequestUpdateVault(
-	t *testing.T, app *fiber.App, conf *config.AppConfig, slug, body string,
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, slug, body string,
 ) *http.Response {
 
 	reqBody := strings.NewReader(body)
@@ -198,6 +216,7 @@ func newRequestUpdateVault(
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Client-Operation", utils.RetrieveVault)
 	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+	req.Header.Set("User-Slug", userSlug)
 	resp, err := app.Test(req)
 
 	if err != nil {
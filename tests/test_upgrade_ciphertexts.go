@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/database"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/tests/helpers"
+	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+func testUpgradeCiphertexts(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
+	t.Run("new_ciphertexts_are_versioned_envelopes", func(t *testing.T) {
+		_, _, _, secrets := setup.SetUpWithData(t, db)
+
+		for _, secret := range secrets {
+			envelope, err := utils.ParseEnvelope(secret.String)
+			require.NoError(t, err)
+			require.Equal(t, utils.EnvelopeVersion2, envelope.Version)
+			require.Equal(t, utils.AlgorithmAESGCM, envelope.Algorithm)
+			require.NotEmpty(t, envelope.KeyID)
+		}
+	})
+
+	t.Run("envelope_key_id_mismatch_fails_decryption", func(t *testing.T) {
+		_, _, _, secrets := setup.SetUpWithData(t, db)
+		_, err := utils.Decrypt(
+			secrets[0].String, helpers.HexHash[64:], helpers.SecretAdditionalData(&secrets[0]),
+		)
+		require.ErrorIs(t, err, utils.ErrKeyIDMismatch)
+	})
+
+	t.Run("legacy_rows_decrypt_and_upgrade", func(t *testing.T) {
+		_, _, entries, secrets := setup.SetUpWithData(t, db)
+		legacyStrings := map[string]string{}
+
+		for _, secret := range secrets[:4] {
+			plaintext, err := utils.Decrypt(
+				secret.String, helpers.HexHash[:64], helpers.SecretAdditionalData(&secret),
+			)
+			require.NoError(t, err)
+
+			legacyString := helpers.EncryptLegacy(t, plaintext, helpers.HexHash[:64])
+			legacyStrings[secret.Slug] = plaintext
+
+			if result := db.Model(&models.Secret{}).Where("slug = ?", secret.Slug).
+			UpdateColumn("string", legacyString); result.Error != nil {
+				t.Fatalf("Legacy secret update failed: %s", result.Error.Error())
+			}
+		}
+
+		upgraded, skipped, err := database.UpgradeLegacyCiphertexts(db)
+		require.NoError(t, err)
+		require.EqualValues(t, 4, upgraded)
+		require.Zero(t, skipped)
+
+		upgraded, skipped, err = database.UpgradeLegacyCiphertexts(db)
+		require.NoError(t, err)
+		require.EqualValues(t, 0, upgraded)
+		require.Zero(t, skipped)
+
+		for _, secretBefore := range secrets {
+			var secretAfter models.Secret
+			helpers.QueryTestSecretBySlug(t, db, &secretAfter, secretBefore.Slug)
+			require.False(t, utils.IsLegacyCiphertext(secretAfter.String))
+			require.True(t, secretBefore.UpdatedAt.Equal(secretAfter.UpdatedAt))
+
+			plaintext, err := utils.Decrypt(
+				secretAfter.String, helpers.HexHash[:64], helpers.SecretAdditionalData(&secretAfter),
+			)
+			require.NoError(t, err)
+
+			if expected, ok := legacyStrings[secretBefore.Slug]; ok {
+				require.Equal(t, expected, plaintext)
+				require.True(t, strings.HasPrefix(secretAfter.String, "$1$aesgcm$$"))
+			} else {
+				require.Equal(t, secretBefore.String, secretAfter.String)
+			}
+		}
+
+		resp := newRequestRetrieveEntry(t, app, conf, entries[0].UserSlug, entries[0].Slug)
+		require.Equal(t, 200, resp.StatusCode)
+	})
+
+	t.Run("unparseable_rows_skipped", func(t *testing.T) {
+		_, _, _, secrets := setup.SetUpWithData(t, db)
+
+		for i, secret := range secrets[:3] {
+			plaintext, err := utils.Decrypt(
+				secret.String, helpers.HexHash[:64], helpers.SecretAdditionalData(&secret),
+			)
+			require.NoError(t, err)
+
+			legacyString := helpers.EncryptLegacy(t, plaintext, helpers.HexHash[:64])
+
+			// The second row isn't hex, so it can't be a legacy ciphertext.
+			if i == 1 {
+				legacyString = "not hex"
+			}
+
+			if result := db.Model(&models.Secret{}).Where("slug = ?", secret.Slug).
+			UpdateColumn("string", legacyString); result.Error != nil {
+				t.Fatalf("Legacy secret update failed: %s", result.Error.Error())
+			}
+		}
+
+		upgraded, skipped, err := database.UpgradeLegacyCiphertexts(db)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, upgraded)
+		require.EqualValues(t, 1, skipped)
+
+		for i, secret := range secrets[:3] {
+			var secretAfter models.Secret
+			helpers.QueryTestSecretBySlug(t, db, &secretAfter, secret.Slug)
+
+			if i == 1 {
+				require.Equal(t, "not hex", secretAfter.String)
+			} else {
+				require.True(t, strings.HasPrefix(secretAfter.String, "$1$aesgcm$$"))
+			}
+		}
+
+		// The bad row is skipped again, and nothing else is left to upgrade.
+		upgraded, skipped, err = database.UpgradeLegacyCiphertexts(db)
+		require.NoError(t, err)
+		require.EqualValues(t, 0, upgraded)
+		require.EqualValues(t, 1, skipped)
+	})
+}
@@ -0,0 +1,433 @@
+package tests
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	vaultsApp "github.com/liobrdev/simplepasswords_vaults/app"
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/database"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/routes"
+	"github.com/liobrdev/simplepasswords_vaults/tests/helpers"
+	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+func testUserKDF(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
+	oldKey := helpers.HexHash[:64]
+	password := "n3wVal!dPA5SWoRD"
+	bodyFmt := `{"old_key":"%s","new_password":"%s"}`
+
+	t.Run("created_user_200_ok", func(t *testing.T) {
+		setup.SetUp(t, db)
+		slug := helpers.NewSlug(t)
+
+		resp := newRequestCreateUser(
+			t, app, conf, fmt.Sprintf(`{"user_slug":"%s"}`, slug), helpers.VALID_PW,
+		)
+		require.Equal(t, 204, resp.StatusCode)
+
+		params := testUserKDFSuccess(t, app, conf, slug)
+		require.Equal(t, utils.KDFArgon2id, params.KDF)
+		require.Regexp(t, "^[0-9a-f]{32}$", params.Salt)
+		require.EqualValues(t, conf.KDF_TIME, params.Time)
+		require.EqualValues(t, conf.KDF_MEMORY_KIB, params.Memory)
+		require.EqualValues(t, conf.KDF_THREADS, params.Threads)
+	})
+
+	t.Run("raw_key_user_200_ok", func(t *testing.T) {
+		users, _, _, _ := setup.SetUpWithData(t, db)
+		require.Equal(t, utils.KDFParams{}, testUserKDFSuccess(t, app, conf, users[0].Slug))
+	})
+
+	t.Run("other_users_slug_400_bad_request", func(t *testing.T) {
+		users, _, _, _ := setup.SetUpWithData(t, db)
+
+		resp := newRequestUserKDF(t, app, conf, users[0].Slug, users[1].Slug)
+		require.Equal(t, 400, resp.StatusCode)
+		helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+			ClientOperation: utils.RetrieveUserKDF,
+			Message:         utils.ErrorUserSlug,
+			Detail:          utils.ErrorUserSlugHeader,
+		})
+	})
+
+	t.Run("valid_slug_404_not_found", func(t *testing.T) {
+		setup.SetUp(t, db)
+		slug := helpers.NewSlug(t)
+
+		resp := newRequestUserKDF(t, app, conf, slug, slug)
+		require.Equal(t, 404, resp.StatusCode)
+		helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+			ClientOperation: utils.RetrieveUserKDF,
+			Message:         utils.ErrorNotFound,
+			Detail:          slug,
+		})
+	})
+
+	t.Run("raw_key_user_password_400_bad_request", func(t *testing.T) {
+		setup.SetUpWithData(t, db)
+
+		var entry models.Entry
+		helpers.QueryTestEntryEager(t, db, &entry, "entry@0.1.1.*")
+
+		resp := newRequestRetrieveEntryWithPassword(
+			t, app, conf, entry.UserSlug, entry.Slug, password,
+		)
+		require.Equal(t, 400, resp.StatusCode)
+		helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+			ClientOperation: utils.RetrieveEntry,
+			Message:         utils.ErrorPassword,
+			Detail:          "Expected a hex-encoded key.",
+		})
+	})
+
+	t.Run("rekeyed_to_password_200_ok", func(t *testing.T) {
+		setup.SetUpWithData(t, db)
+
+		var entry models.Entry
+		helpers.QueryTestEntryEager(t, db, &entry, "entry@0.1.1.*")
+
+		resp := newRequestRekeyUser(
+			t, app, conf, entry.UserSlug, fmt.Sprintf(bodyFmt, oldKey, password),
+		)
+		require.Equal(t, 204, resp.StatusCode)
+
+		params := testUserKDFSuccess(t, app, conf, entry.UserSlug)
+		require.Equal(t, utils.KDFArgon2id, params.KDF)
+
+		resp = newRequestRetrieveEntryWithPassword(
+			t, app, conf, entry.UserSlug, entry.Slug, password,
+		)
+		require.Equal(t, 200, resp.StatusCode)
+		requireTestEntryPlaintexts(t, resp)
+
+		// The key itself is no longer accepted in place of the password.
+		resp = newRequestRetrieveEntryWithPassword(t, app, conf, entry.UserSlug, entry.Slug, oldKey)
+		require.Equal(t, 401, resp.StatusCode)
+	})
+
+	t.Run("empty_password_400_bad_request", func(t *testing.T) {
+		setup.SetUpWithData(t, db)
+
+		var entry models.Entry
+		helpers.QueryTestEntryEager(t, db, &entry, "entry@0.1.1.*")
+
+		resp := newRequestRekeyUser(
+			t, app, conf, entry.UserSlug, fmt.Sprintf(bodyFmt, oldKey, password),
+		)
+		require.Equal(t, 204, resp.StatusCode)
+
+		resp = newRequestRetrieveEntryWithPassword(t, app, conf, entry.UserSlug, entry.Slug, "")
+		require.Equal(t, 400, resp.StatusCode)
+		helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+			ClientOperation: utils.RetrieveEntry,
+			Message:         utils.ErrorPassword,
+			Detail:          "Empty",
+		})
+	})
+
+	t.Run("weaker_params_upgraded_200_ok", func(t *testing.T) {
+		setup.SetUpWithData(t, db)
+
+		var entry models.Entry
+		helpers.QueryTestEntryEager(t, db, &entry, "entry@0.1.1.*")
+
+		resp := newRequestRekeyUser(
+			t, app, conf, entry.UserSlug, fmt.Sprintf(bodyFmt, oldKey, password),
+		)
+		require.Equal(t, 204, resp.StatusCode)
+		paramsBefore := testUserKDFSuccess(t, app, conf, entry.UserSlug)
+
+		upgradedConf := *conf
+		upgradedConf.KDF_TIME = conf.KDF_TIME + 1
+		upgradedApp := vaultsApp.CreateApp(&upgradedConf)
+		routes.Register(upgradedApp, db, &upgradedConf)
+
+		resp = newRequestRetrieveEntryWithPassword(
+			t, upgradedApp, &upgradedConf, entry.UserSlug, entry.Slug, password,
+		)
+		require.Equal(t, 200, resp.StatusCode)
+		requireTestEntryPlaintexts(t, resp)
+
+		// The upgrade runs after the response.
+		waitForTestKDFUpgrade(t, db, entry.UserSlug, paramsBefore.Salt)
+		paramsAfter := testUserKDFSuccess(t, app, conf, entry.UserSlug)
+		require.NotEqual(t, paramsBefore.Salt, paramsAfter.Salt)
+		require.EqualValues(t, upgradedConf.KDF_TIME, paramsAfter.Time)
+		require.False(t, paramsAfter.WeakerThan(paramsBefore))
+
+		// Every secret was rekeyed to the key derived with the new parameters.
+		clientKey, err := paramsAfter.DeriveKey(password)
+		require.NoError(t, err)
+		newKey := helpers.UserSecretsKey(t, db, conf, entry.UserSlug, clientKey)
+
+		var secrets []models.Secret
+
+		if result := db.Find(&secrets, "user_slug = ?", entry.UserSlug); result.Error != nil {
+			t.Fatalf("Secrets query failed: %s", result.Error.Error())
+		}
+
+		require.NotEmpty(t, secrets)
+
+		for _, secret := range secrets {
+			_, err := utils.Decrypt(secret.String, newKey, helpers.SecretAdditionalData(&secret))
+			require.NoError(t, err)
+		}
+
+		// Already upgraded, so unlocking again changes nothing.
+		resp = newRequestRetrieveEntryWithPassword(
+			t, upgradedApp, &upgradedConf, entry.UserSlug, entry.Slug, password,
+		)
+		require.Equal(t, 200, resp.StatusCode)
+		require.Equal(t, paramsAfter, testUserKDFSuccess(t, app, conf, entry.UserSlug))
+	})
+
+	t.Run("concurrent_unlocks_upgraded_once_200_ok", func(t *testing.T) {
+		setup.SetUpWithData(t, db)
+
+		var entry models.Entry
+		helpers.QueryTestEntryEager(t, db, &entry, "entry@0.1.1.*")
+
+		resp := newRequestRekeyUser(
+			t, app, conf, entry.UserSlug, fmt.Sprintf(bodyFmt, oldKey, password),
+		)
+		require.Equal(t, 204, resp.StatusCode)
+		paramsBefore := testUserKDFSuccess(t, app, conf, entry.UserSlug)
+
+		upgradedConf := *conf
+		upgradedConf.KDF_TIME = conf.KDF_TIME + 1
+		upgradedApp := vaultsApp.CreateApp(&upgradedConf)
+		routes.Register(upgradedApp, db, &upgradedConf)
+
+		statuses := make(chan int, 4)
+
+		for i := 0; i < cap(statuses); i++ {
+			go func() {
+				req := httptest.NewRequest("GET", "/api/entries/" + entry.Slug, nil)
+				req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+				req.Header.Set("User-Slug", entry.UserSlug)
+				req.Header.Set("Client-Operation", utils.RetrieveEntry)
+				req.Header.Set(conf.PASSWORD_HEADER_KEY, password)
+
+				if resp, err := upgradedApp.Test(req, -1); err != nil {
+					statuses <- 0
+				} else {
+					statuses <- resp.StatusCode
+				}
+			}()
+		}
+
+		for i := 0; i < cap(statuses); i++ {
+			require.Equal(t, 200, <-statuses)
+		}
+
+		waitForTestKDFUpgrade(t, db, entry.UserSlug, paramsBefore.Salt)
+		paramsAfter := testUserKDFSuccess(t, app, conf, entry.UserSlug)
+		require.EqualValues(t, upgradedConf.KDF_TIME, paramsAfter.Time)
+
+		// The secrets were rekeyed once, to the key derived with the salt that was kept.
+		resp = newRequestRetrieveEntryWithPassword(
+			t, upgradedApp, &upgradedConf, entry.UserSlug, entry.Slug, password,
+		)
+		require.Equal(t, 200, resp.StatusCode)
+		requireTestEntryPlaintexts(t, resp)
+		require.Equal(t, paramsAfter, testUserKDFSuccess(t, app, conf, entry.UserSlug))
+
+		report, err := database.VerifyIntegrity(db, entry.UserSlug)
+		require.NoError(t, err)
+		require.True(t, report.Intact)
+	})
+
+	t.Run("write_during_upgrade_204_no_content", func(t *testing.T) {
+		setup.SetUpWithData(t, db)
+
+		var entry models.Entry
+		helpers.QueryTestEntryEager(t, db, &entry, "entry@0.1.1.*")
+
+		resp := newRequestRekeyUser(
+			t, app, conf, entry.UserSlug, fmt.Sprintf(bodyFmt, oldKey, password),
+		)
+		require.Equal(t, 204, resp.StatusCode)
+		paramsBefore := testUserKDFSuccess(t, app, conf, entry.UserSlug)
+
+		upgradedConf := *conf
+		upgradedConf.KDF_TIME = conf.KDF_TIME + 1
+		upgradedApp := vaultsApp.CreateApp(&upgradedConf)
+		routes.Register(upgradedApp, db, &upgradedConf)
+
+		// The secret is inserted only once the upgrade has had the chance to replace the key
+		// it was encrypted under, which it mustn't take until the secret is written.
+		paused := make(chan bool)
+		var pause sync.Once
+		require.NoError(t, db.Callback().Create().Before("gorm:create").Register(
+			"tests:pause_secret_create", func(tx *gorm.DB) {
+				if tx.Statement.Table != "secrets" {
+					return
+				}
+
+				pause.Do(func() {
+					close(paused)
+
+					for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); {
+						var count int64
+						db.Model(&models.User{}).
+						Where("slug = ? AND kdf_salt = ?", entry.UserSlug, paramsBefore.Salt).
+						Count(&count)
+
+						if count == 0 {
+							return
+						}
+
+						time.Sleep(10 * time.Millisecond)
+					}
+				})
+			},
+		))
+		defer db.Callback().Create().Remove("tests:pause_secret_create")
+
+		status := make(chan int, 1)
+
+		go func() {
+			req := httptest.NewRequest(http.MethodPost, "/api/secrets", strings.NewReader(
+				fmt.Sprintf(
+					`{"user_slug":"%s","vault_slug":"%s","entry_slug":"%s",` +
+					`"secret_label":"label","secret_string":"string"}`,
+					entry.UserSlug, entry.VaultSlug, entry.Slug,
+				),
+			))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+			req.Header.Set("User-Slug", entry.UserSlug)
+			req.Header.Set("Client-Operation", utils.CreateSecret)
+			req.Header.Set(conf.PASSWORD_HEADER_KEY, password)
+
+			if resp, err := upgradedApp.Test(req, -1); err != nil {
+				status <- 0
+			} else {
+				status <- resp.StatusCode
+			}
+		}()
+
+		// The unlock starts the upgrade while the secret is being written.
+		<-paused
+		resp = newRequestRetrieveEntryWithPassword(
+			t, upgradedApp, &upgradedConf, entry.UserSlug, entry.Slug, password,
+		)
+		require.Equal(t, 200, resp.StatusCode)
+		require.Equal(t, 204, <-status)
+
+		// An upgrade that had to give way is tried again on the next unlock.
+		resp = newRequestRetrieveEntryWithPassword(
+			t, upgradedApp, &upgradedConf, entry.UserSlug, entry.Slug, password,
+		)
+		require.Equal(t, 200, resp.StatusCode)
+		waitForTestKDFUpgrade(t, db, entry.UserSlug, paramsBefore.Salt)
+
+		resp = newRequestRetrieveEntryWithPassword(
+			t, upgradedApp, &upgradedConf, entry.UserSlug, entry.Slug, password,
+		)
+		require.Equal(t, 200, resp.StatusCode)
+
+		var respEntry models.Entry
+
+		if respBody, err := io.ReadAll(resp.Body); err != nil {
+			t.Fatalf("Read response body failed: %s", err.Error())
+		} else if err := json.Unmarshal(respBody, &respEntry); err != nil {
+			t.Fatalf("JSON unmarshal failed: %s", err.Error())
+		}
+
+		require.Len(t, respEntry.Secrets, 3)
+		require.Equal(t, "string", respEntry.Secrets[2].String)
+	})
+}
+
+// waitForTestKDFUpgrade waits for the user's salt to be replaced by a background upgrade.
+func waitForTestKDFUpgrade(t *testing.T, db *gorm.DB, userSlug, salt string) {
+	require.Eventually(t, func() bool {
+		var count int64
+		db.Model(&models.User{}).Where("slug = ? AND kdf_salt = ?", userSlug, salt).Count(&count)
+		return count == 0
+	}, 5 * time.Second, 10 * time.Millisecond)
+}
+
+func testUserKDFSuccess(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug string,
+) (params utils.KDFParams) {
+	resp := newRequestUserKDF(t, app, conf, userSlug, userSlug)
+	require.Equal(t, 200, resp.StatusCode)
+
+	if respBody, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("Read response body failed: %s", err.Error())
+	} else if err := json.Unmarshal(respBody, &params); err != nil {
+		t.Fatalf("JSON unmarshal failed: %s", err.Error())
+	}
+
+	return
+}
+
+// requireTestEntryPlaintexts checks the secrets of "entry@0.1.1.*" were decrypted.
+func requireTestEntryPlaintexts(t *testing.T, resp *http.Response) {
+	var respEntry models.Entry
+
+	if respBody, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("Read response body failed: %s", err.Error())
+	} else if err := json.Unmarshal(respBody, &respEntry); err != nil {
+		t.Fatalf("JSON unmarshal failed: %s", err.Error())
+	}
+
+	require.Len(t, respEntry.Secrets, 2)
+	require.Equal(t, "secret[_string='foodeater1234']@0.1.1.0", respEntry.Secrets[0].String)
+	require.Equal(t, "secret[_string='3a7!ng40oD']@0.1.1.1", respEntry.Secrets[1].String)
+}
+
+func newRequestUserKDF(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, slug string,
+) *http.Response {
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/" + slug + "/kdf", nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Client-Operation", utils.RetrieveUserKDF)
+	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+	req.Header.Set("User-Slug", userSlug)
+
+	resp, err := app.Test(req, -1)
+
+	if err != nil {
+		t.Fatalf("Send test request failed: %s", err.Error())
+	}
+
+	return resp
+}
+
+func newRequestRetrieveEntryWithPassword(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, slug, password string,
+) *http.Response {
+
+	req := httptest.NewRequest(http.MethodGet, "/api/entries/" + slug, nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Client-Operation", utils.RetrieveEntry)
+	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+	req.Header.Set("User-Slug", userSlug)
+	req.Header.Set(conf.PASSWORD_HEADER_KEY, password)
+
+	resp, err := app.Test(req, -1)
+
+	if err != nil {
+		t.Fatalf("Send test request failed: %s", err.Error())
+	}
+
+	return resp
+}
@@ -0,0 +1,204 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/tests/helpers"
+	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+func testVaultKey(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
+	key := helpers.HexHash[:64]
+	wrongKey := helpers.HexHash[64:]
+	secretBodyFmt := `{` +
+		`"user_slug":"%s",` +
+		`"vault_slug":"%s",` +
+		`"entry_slug":"%s",` +
+		`"secret_label":"label",` +
+		`"secret_string":"string"` +
+		`}`
+
+	t.Run("wrong_key_retrieve_entry_401_unauthorized", func(t *testing.T) {
+		_, _, entries, _ := setup.SetUpWithData(t, db)
+		entry := entries[0]
+
+		resp := newRequestWithVaultKey(
+			t, app, conf, http.MethodGet, "/api/entries/" + entry.Slug, utils.RetrieveEntry,
+			entry.UserSlug, wrongKey, "",
+		)
+		testVaultKeyWrong(t, resp, utils.RetrieveEntry, "")
+
+		// A key that wasn't confirmed is never kept as the user's.
+		var user models.User
+		helpers.QueryTestUser(t, db, &user, entry.UserSlug)
+		require.Empty(t, user.KeyCheck)
+	})
+
+	t.Run("wrong_key_create_secret_401_unauthorized", func(t *testing.T) {
+		_, _, entries, _ := setup.SetUpWithData(t, db)
+		entry := entries[0]
+		body := fmt.Sprintf(secretBodyFmt, entry.UserSlug, entry.VaultSlug, entry.Slug)
+
+		var secretCount, newSecretCount int64
+		helpers.CountSecrets(t, db, &secretCount)
+
+		resp := newRequestWithVaultKey(
+			t, app, conf, http.MethodPost, "/api/secrets", utils.CreateSecret, entry.UserSlug,
+			wrongKey, body,
+		)
+		testVaultKeyWrong(t, resp, utils.CreateSecret, body)
+
+		helpers.CountSecrets(t, db, &newSecretCount)
+		require.Equal(t, secretCount, newSecretCount)
+	})
+
+	t.Run("wrong_key_update_secret_401_unauthorized", func(t *testing.T) {
+		_, _, _, secrets := setup.SetUpWithData(t, db)
+		secret := secrets[0]
+		body := `{"secret_string":"updated_string"}`
+
+		resp := newRequestWithVaultKey(
+			t, app, conf, http.MethodPatch, "/api/secrets/" + secret.Slug, utils.UpdateSecret,
+			secret.UserSlug, wrongKey, body,
+		)
+		testVaultKeyWrong(t, resp, utils.UpdateSecret, body)
+
+		var secretAfter models.Secret
+		helpers.QueryTestSecretBySlug(t, db, &secretAfter, secret.Slug)
+		require.Equal(t, secret.String, secretAfter.String)
+	})
+
+	t.Run("key_check_backfilled_204_no_content", func(t *testing.T) {
+		_, _, entries, _ := setup.SetUpWithData(t, db)
+		entry := entries[0]
+		body := fmt.Sprintf(secretBodyFmt, entry.UserSlug, entry.VaultSlug, entry.Slug)
+
+		resp := newRequestWithVaultKey(
+			t, app, conf, http.MethodPost, "/api/secrets", utils.CreateSecret, entry.UserSlug,
+			key, body,
+		)
+		require.Equal(t, 204, resp.StatusCode)
+
+		var user models.User
+		helpers.QueryTestUser(t, db, &user, entry.UserSlug)
+		require.NotEmpty(t, user.KeyCheck)
+
+		_, err := utils.Decrypt(
+			user.KeyCheck, helpers.UserSecretsKey(t, db, conf, user.Slug, key),
+			utils.KeyCheckAdditionalData(user.Slug),
+		)
+		require.NoError(t, err)
+
+		// From then on, keys are checked against it.
+		resp = newRequestWithVaultKey(
+			t, app, conf, http.MethodPost, "/api/secrets", utils.CreateSecret, entry.UserSlug,
+			wrongKey, body,
+		)
+		testVaultKeyWrong(t, resp, utils.CreateSecret, body)
+	})
+
+	t.Run("created_user_password_checked_204_no_content", func(t *testing.T) {
+		setup.SetUp(t, db)
+		userSlug := helpers.NewSlug(t)
+
+		resp := newRequestCreateUser(
+			t, app, conf, fmt.Sprintf(`{"user_slug":"%s"}`, userSlug), helpers.VALID_PW,
+		)
+		require.Equal(t, 204, resp.StatusCode)
+
+		vault := models.Vault{Slug: helpers.NewSlug(t), UserSlug: userSlug, Title: "vault"}
+		require.NoError(t, db.Create(&vault).Error)
+
+		body := fmt.Sprintf(
+			`{"user_slug":"%s","vault_slug":"%s","entry_title":"entry","secrets":[{` +
+			`"secret_label":"label","secret_string":"string"}]}`,
+			userSlug, vault.Slug,
+		)
+
+		resp = newRequestWithVaultKey(
+			t, app, conf, http.MethodPost, "/api/entries", utils.CreateEntry, userSlug,
+			helpers.VALID_PW + "!", body,
+		)
+		testVaultKeyWrong(t, resp, utils.CreateEntry, body)
+
+		var entryCount int64
+		helpers.CountEntries(t, db, &entryCount)
+		require.EqualValues(t, 0, entryCount)
+
+		resp = newRequestWithVaultKey(
+			t, app, conf, http.MethodPost, "/api/entries", utils.CreateEntry, userSlug,
+			helpers.VALID_PW, body,
+		)
+		require.Equal(t, 204, resp.StatusCode)
+
+		helpers.CountEntries(t, db, &entryCount)
+		require.EqualValues(t, 1, entryCount)
+	})
+
+	t.Run("rekeyed_key_check_401_unauthorized", func(t *testing.T) {
+		_, _, entries, _ := setup.SetUpWithData(t, db)
+		entry := entries[0]
+		rekeyFmt := `{"old_key":"%s","new_key":"%s"}`
+
+		resp := newRequestRekeyUser(t, app, conf, entry.UserSlug, fmt.Sprintf(rekeyFmt, key, wrongKey))
+		require.Equal(t, 204, resp.StatusCode)
+
+		resp = newRequestWithVaultKey(
+			t, app, conf, http.MethodGet, "/api/entries/" + entry.Slug, utils.RetrieveEntry,
+			entry.UserSlug, key, "",
+		)
+		testVaultKeyWrong(t, resp, utils.RetrieveEntry, "")
+
+		resp = newRequestWithVaultKey(
+			t, app, conf, http.MethodGet, "/api/entries/" + entry.Slug, utils.RetrieveEntry,
+			entry.UserSlug, wrongKey, "",
+		)
+		require.Equal(t, 200, resp.StatusCode)
+
+		// The old key is refused by the key check, before any secret is decrypted.
+		body := fmt.Sprintf(rekeyFmt, key, wrongKey)
+		resp = newRequestRekeyUser(t, app, conf, entry.UserSlug, body)
+		testVaultKeyWrong(t, resp, utils.RekeyUser, body)
+	})
+}
+
+func testVaultKeyWrong(t *testing.T, resp *http.Response, operation, body string) {
+	require.Equal(t, 401, resp.StatusCode)
+	helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+		ClientOperation: operation,
+		Message:         utils.ErrorVaultKey,
+		RequestBody:     body,
+	})
+}
+
+func newRequestWithVaultKey(
+	t *testing.T, app *fiber.App, conf *config.AppConfig,
+	method, target, operation, userSlug, key, body string,
+) *http.Response {
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Client-Operation", operation)
+	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+	req.Header.Set("User-Slug", userSlug)
+	req.Header.Set(conf.PASSWORD_HEADER_KEY, key)
+
+	resp, err := app.Test(req, -1)
+
+	if err != nil {
+		t.Fatalf("Send test request failed: %s", err.Error())
+	}
+
+	return resp
+}
@@ -0,0 +1,351 @@
+package tests
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/controllers"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/tests/helpers"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+func testVaultMembers(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
+	memberPassword := "m3mb3rVal!dPW"
+
+	t.Run("editor_writes_secrets_204_no_content", func(t *testing.T) {
+		entry, memberSlug := newTestTeamVault(t, app, db, conf, memberPassword, models.RoleEditor)
+
+		var memberCount int64
+		require.NoError(t, db.Model(&models.VaultMember{}).
+		Where("vault_slug = ?", entry.VaultSlug).Count(&memberCount).Error)
+		require.EqualValues(t, 2, memberCount)
+
+		// The vault's secrets were re-encrypted under the vault key.
+		var secret models.Secret
+		require.NoError(t, db.First(&secret, "slug = ?", entry.Secrets[0].Slug).Error)
+		require.NotEqual(t, entry.Secrets[0].String, secret.String)
+
+		memberEntry := testRetrieveTeamEntry(t, app, conf, entry.Slug, memberSlug, memberPassword)
+		require.Len(t, memberEntry.Secrets, 1)
+		require.Equal(t, "string", memberEntry.Secrets[0].String)
+
+		resp := newRequestWithVaultKey(
+			t, app, conf, http.MethodPost, "/api/secrets", utils.CreateSecret, memberSlug,
+			memberPassword, fmt.Sprintf(
+				`{"user_slug":"%s","vault_slug":"%s","entry_slug":"%s",` +
+				`"secret_label":"label2","secret_string":"string2"}`,
+				memberSlug, entry.VaultSlug, entry.Slug,
+			),
+		)
+		require.Equal(t, 204, resp.StatusCode)
+
+		resp = newRequestWithVaultKey(
+			t, app, conf, http.MethodPatch, "/api/secrets/" + entry.Secrets[0].Slug,
+			utils.UpdateSecret, memberSlug, memberPassword, `{"secret_string":"updated"}`,
+		)
+		require.Equal(t, 204, resp.StatusCode)
+
+		// The creator reads what the editor wrote.
+		ownerEntry := testRetrieveTeamEntry(t, app, conf, entry.Slug, entry.UserSlug, helpers.VALID_PW)
+		require.Len(t, ownerEntry.Secrets, 2)
+		require.Equal(t, "updated", ownerEntry.Secrets[0].String)
+		require.Equal(t, "string2", ownerEntry.Secrets[1].String)
+
+		// The editor's secret belongs to the vault's creator, like the rest of it.
+		var editorSecret models.Secret
+		require.NoError(t, db.First(&editorSecret, "slug = ?", ownerEntry.Secrets[1].Slug).Error)
+		require.Equal(t, entry.UserSlug, editorSecret.UserSlug)
+
+		resp = newRequestWithVaultKey(
+			t, app, conf, http.MethodGet, "/api/vaults", utils.ListVaults, memberSlug, "", "",
+		)
+		require.Equal(t, 200, resp.StatusCode)
+
+		var listBody controllers.ListVaultsResponseBody
+
+		if respBody, err := io.ReadAll(resp.Body); err != nil {
+			t.Fatalf("Read response body failed: %s", err.Error())
+		} else if err := json.Unmarshal(respBody, &listBody); err != nil {
+			t.Fatalf("JSON unmarshal failed: %s", err.Error())
+		}
+
+		require.Len(t, listBody.Vaults, 1)
+		require.Equal(t, entry.VaultSlug, listBody.Vaults[0].Slug)
+		require.Equal(t, models.RoleEditor, listBody.Vaults[0].Role)
+	})
+
+	t.Run("viewer_403_forbidden", func(t *testing.T) {
+		entry, memberSlug := newTestTeamVault(t, app, db, conf, memberPassword, models.RoleViewer)
+
+		memberEntry := testRetrieveTeamEntry(t, app, conf, entry.Slug, memberSlug, memberPassword)
+		require.Equal(t, "string", memberEntry.Secrets[0].String)
+
+		body := `{"entry_title":"renamed"}`
+		resp := newRequestWithVaultKey(
+			t, app, conf, http.MethodPatch, "/api/entries/" + entry.Slug, utils.UpdateEntry,
+			memberSlug, "", body,
+		)
+		require.Equal(t, 403, resp.StatusCode)
+		helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+			ClientOperation: utils.UpdateEntry,
+			Message:         utils.ErrorVaultRole,
+			Detail:          models.RoleViewer,
+			RequestBody:     body,
+		})
+
+		resp = newRequestWithVaultKey(
+			t, app, conf, http.MethodDelete, "/api/secrets/" + entry.Secrets[0].Slug,
+			utils.DeleteSecret, memberSlug, "", "",
+		)
+		require.Equal(t, 403, resp.StatusCode)
+
+		body = fmt.Sprintf(
+			`{"member_slug":"%s","member_role":"%s"}`, helpers.NewSlug(t), models.RoleViewer,
+		)
+		resp = newRequestWithVaultKey(
+			t, app, conf, http.MethodPost, "/api/vaults/" + entry.VaultSlug + "/members",
+			utils.AddVaultMember, memberSlug, memberPassword, body,
+		)
+		require.Equal(t, 403, resp.StatusCode)
+	})
+
+	t.Run("editor_trash_204_no_content", func(t *testing.T) {
+		entry, memberSlug := newTestTeamVault(t, app, db, conf, memberPassword, models.RoleEditor)
+		secretSlug := entry.Secrets[0].Slug
+
+		resp := newRequestWithVaultKey(
+			t, app, conf, http.MethodDelete, "/api/secrets/" + secretSlug, utils.DeleteSecret,
+			memberSlug, "", "",
+		)
+		require.Equal(t, 204, resp.StatusCode)
+
+		// Whatever the editor trashes, both they and the vault's creator can see and restore.
+		for _, userSlug := range []string{memberSlug, entry.UserSlug} {
+			trash := testListTrashSuccess(t, app, conf, userSlug)
+			require.Len(t, trash.Secrets, 1)
+			require.Equal(t, secretSlug, trash.Secrets[0].Slug)
+		}
+
+		resp = newRequestWithVaultKey(
+			t, app, conf, http.MethodPost, "/api/secrets/" + secretSlug + "/restore",
+			utils.RestoreSecret, memberSlug, "", "",
+		)
+		require.Equal(t, 204, resp.StatusCode)
+
+		resp = newRequestWithVaultKey(
+			t, app, conf, http.MethodDelete, "/api/entries/" + entry.Slug, utils.DeleteEntry,
+			memberSlug, "", "",
+		)
+		require.Equal(t, 204, resp.StatusCode)
+
+		trash := testListTrashSuccess(t, app, conf, memberSlug)
+		require.Empty(t, trash.Vaults)
+		require.Len(t, trash.Entries, 1)
+		require.Equal(t, entry.Slug, trash.Entries[0].Slug)
+		require.Empty(t, trash.Secrets)
+
+		resp = newRequestWithVaultKey(
+			t, app, conf, http.MethodPost, "/api/entries/" + entry.Slug + "/restore",
+			utils.RestoreEntry, memberSlug, "", "",
+		)
+		require.Equal(t, 204, resp.StatusCode)
+
+		// The restored entry and secret still belong to the vault's creator.
+		memberEntry := testRetrieveTeamEntry(t, app, conf, entry.Slug, memberSlug, memberPassword)
+		require.Len(t, memberEntry.Secrets, 1)
+		require.Equal(t, "string", memberEntry.Secrets[0].String)
+
+		var secret models.Secret
+		require.NoError(t, db.First(&secret, "slug = ?", secretSlug).Error)
+		require.Equal(t, entry.UserSlug, secret.UserSlug)
+	})
+
+	t.Run("owner_trash_vault_204_no_content", func(t *testing.T) {
+		entry, memberSlug := newTestTeamVault(t, app, db, conf, memberPassword, models.RoleOwner)
+
+		resp := newRequestWithVaultKey(
+			t, app, conf, http.MethodDelete, "/api/vaults/" + entry.VaultSlug, utils.DeleteVault,
+			memberSlug, "", "",
+		)
+		require.Equal(t, 204, resp.StatusCode)
+
+		for _, userSlug := range []string{memberSlug, entry.UserSlug} {
+			trash := testListTrashSuccess(t, app, conf, userSlug)
+			require.Len(t, trash.Vaults, 1)
+			require.Equal(t, entry.VaultSlug, trash.Vaults[0].Slug)
+			require.Empty(t, trash.Entries)
+			require.Empty(t, trash.Secrets)
+		}
+
+		resp = newRequestWithVaultKey(
+			t, app, conf, http.MethodPost, "/api/vaults/" + entry.VaultSlug + "/restore",
+			utils.RestoreVault, memberSlug, "", "",
+		)
+		require.Equal(t, 204, resp.StatusCode)
+
+		memberEntry := testRetrieveTeamEntry(t, app, conf, entry.Slug, memberSlug, memberPassword)
+		require.Equal(t, "string", memberEntry.Secrets[0].String)
+	})
+
+	t.Run("viewer_trash_403_forbidden", func(t *testing.T) {
+		entry, memberSlug := newTestTeamVault(t, app, db, conf, memberPassword, models.RoleViewer)
+		secretSlug := entry.Secrets[0].Slug
+
+		resp := newRequestWithVaultKey(
+			t, app, conf, http.MethodDelete, "/api/secrets/" + secretSlug, utils.DeleteSecret,
+			entry.UserSlug, "", "",
+		)
+		require.Equal(t, 204, resp.StatusCode)
+
+		trash := testListTrashSuccess(t, app, conf, memberSlug)
+		require.Empty(t, trash.Secrets)
+
+		resp = newRequestWithVaultKey(
+			t, app, conf, http.MethodPost, "/api/secrets/" + secretSlug + "/restore",
+			utils.RestoreSecret, memberSlug, "", "",
+		)
+		require.Equal(t, 403, resp.StatusCode)
+		helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+			ClientOperation: utils.RestoreSecret,
+			Message:         utils.ErrorVaultRole,
+			Detail:          models.RoleViewer,
+		})
+	})
+
+	t.Run("removed_member_404_not_found", func(t *testing.T) {
+		entry, memberSlug := newTestTeamVault(t, app, db, conf, memberPassword, models.RoleEditor)
+		target := "/api/vaults/" + entry.VaultSlug + "/members/" + memberSlug
+
+		resp := newRequestWithVaultKey(
+			t, app, conf, http.MethodDelete, target, utils.RemoveVaultMember, entry.UserSlug, "", "",
+		)
+		require.Equal(t, 204, resp.StatusCode)
+
+		resp = newRequestWithVaultKey(
+			t, app, conf, http.MethodGet, "/api/entries/" + entry.Slug, utils.RetrieveEntry,
+			memberSlug, memberPassword, "",
+		)
+		require.Equal(t, 404, resp.StatusCode)
+
+		// The vault stays a team vault, and its creator still reads it.
+		ownerEntry := testRetrieveTeamEntry(t, app, conf, entry.Slug, entry.UserSlug, helpers.VALID_PW)
+		require.Equal(t, "string", ownerEntry.Secrets[0].String)
+	})
+
+	t.Run("creator_400_bad_request", func(t *testing.T) {
+		entry, _ := newTestTeamVault(t, app, db, conf, memberPassword, models.RoleOwner)
+		target := "/api/vaults/" + entry.VaultSlug + "/members/" + entry.UserSlug
+
+		resp := newRequestWithVaultKey(
+			t, app, conf, http.MethodDelete, target, utils.RemoveVaultMember, entry.UserSlug, "", "",
+		)
+		require.Equal(t, 400, resp.StatusCode)
+		helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+			ClientOperation: utils.RemoveVaultMember,
+			Message:         utils.ErrorMemberSlug,
+			Detail:          "Vault creator can't be removed.",
+		})
+
+		body := fmt.Sprintf(`{"member_role":"%s"}`, models.RoleViewer)
+		resp = newRequestWithVaultKey(
+			t, app, conf, http.MethodPatch, target, utils.UpdateVaultMember, entry.UserSlug, "", body,
+		)
+		require.Equal(t, 400, resp.StatusCode)
+		helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+			ClientOperation: utils.UpdateVaultMember,
+			Message:         utils.ErrorMemberRole,
+			Detail:          "Vault creator is always an owner.",
+			RequestBody:     body,
+		})
+	})
+
+	t.Run("shared_entry_409_conflict", func(t *testing.T) {
+		entry, share := newTestShare(t, app, db, conf, memberPassword, models.PermissionRead)
+		body := fmt.Sprintf(
+			`{"member_slug":"%s","member_role":"%s"}`, share.GranteeSlug, models.RoleViewer,
+		)
+
+		resp := newRequestWithVaultKey(
+			t, app, conf, http.MethodPost, "/api/vaults/" + entry.VaultSlug + "/members",
+			utils.AddVaultMember, entry.UserSlug, helpers.VALID_PW, body,
+		)
+		require.Equal(t, 409, resp.StatusCode)
+		helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+			ClientOperation: utils.AddVaultMember,
+			Message:         utils.ErrorSharedEntries,
+			Detail:          entry.VaultSlug,
+			RequestBody:     body,
+		})
+	})
+
+	t.Run("team_vault_share_409_conflict", func(t *testing.T) {
+		entry, memberSlug := newTestTeamVault(t, app, db, conf, memberPassword, models.RoleViewer)
+		body := fmt.Sprintf(
+			`{"entry_slug":"%s","grantee_slug":"%s","permission":"%s"}`,
+			entry.Slug, memberSlug, models.PermissionRead,
+		)
+
+		resp := newRequestWithVaultKey(
+			t, app, conf, http.MethodPost, "/api/shares", utils.CreateShare, entry.UserSlug,
+			helpers.VALID_PW, body,
+		)
+		require.Equal(t, 409, resp.StatusCode)
+		helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+			ClientOperation: utils.CreateShare,
+			Message:         utils.ErrorTeamVaultShare,
+			Detail:          entry.VaultSlug,
+			RequestBody:     body,
+		})
+	})
+}
+
+// newTestTeamVault adds a new user, whose password is `memberPassword`, to the vault of a
+// new user's entry with `role`, through the API.
+func newTestTeamVault(
+	t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig,
+	memberPassword, role string,
+) (entry models.Entry, memberSlug string) {
+	entry = newTestPasswordUserEntry(t, app, db, conf)
+	memberSlug = helpers.NewSlug(t)
+
+	resp := newRequestCreateUser(
+		t, app, conf, fmt.Sprintf(`{"user_slug":"%s"}`, memberSlug), memberPassword,
+	)
+	require.Equal(t, 204, resp.StatusCode)
+
+	resp = newRequestWithVaultKey(
+		t, app, conf, http.MethodPost, "/api/vaults/" + entry.VaultSlug + "/members",
+		utils.AddVaultMember, entry.UserSlug, helpers.VALID_PW,
+		fmt.Sprintf(`{"member_slug":"%s","member_role":"%s"}`, memberSlug, role),
+	)
+	require.Equal(t, 204, resp.StatusCode)
+
+	return
+}
+
+func testRetrieveTeamEntry(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, slug, userSlug, password string,
+) (entry models.Entry) {
+	resp := newRequestWithVaultKey(
+		t, app, conf, http.MethodGet, "/api/entries/" + slug, utils.RetrieveEntry, userSlug,
+		password, "",
+	)
+	require.Equal(t, 200, resp.StatusCode)
+
+	if body, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("Read response body failed: %s", err.Error())
+	} else if err := json.Unmarshal(body, &entry); err != nil {
+		t.Fatalf("JSON unmarshal failed: %s", err.Error())
+	}
+
+	return
+}
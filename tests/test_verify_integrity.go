@@ -0,0 +1,198 @@
+package tests
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/liobrdev/simplepasswords_vaults/config"
+	"github.com/liobrdev/simplepasswords_vaults/database"
+	"github.com/liobrdev/simplepasswords_vaults/models"
+	"github.com/liobrdev/simplepasswords_vaults/tests/helpers"
+	"github.com/liobrdev/simplepasswords_vaults/tests/setup"
+	"github.com/liobrdev/simplepasswords_vaults/utils"
+)
+
+func testVerifyIntegrity(t *testing.T, app *fiber.App, db *gorm.DB, conf *config.AppConfig) {
+	t.Run("invalid_slug_400_bad_request", func(t *testing.T) {
+		slug := "notARealSlug"
+		testVerifyIntegrityClientError(t, app, conf, 400, utils.ErrorUserSlug, slug, slug, slug)
+	})
+
+	t.Run("user_slug_header_mismatch_400_bad_request", func(t *testing.T) {
+		testVerifyIntegrityClientError(
+			t, app, conf, 400, utils.ErrorUserSlug, utils.ErrorUserSlugHeader,
+			helpers.NewSlug(t), helpers.NewSlug(t),
+		)
+	})
+
+	t.Run("valid_slug_404_not_found", func(t *testing.T) {
+		setup.SetUpWithData(t, db)
+		slug := helpers.NewSlug(t)
+		testVerifyIntegrityClientError(t, app, conf, 404, utils.ErrorNotFound, slug, slug, slug)
+	})
+
+	t.Run("writes_stay_intact_200_ok", func(t *testing.T) {
+		users, vaults, entries, secrets := setup.SetUpWithData(t, db)
+		slug := users[0].Slug
+
+		report := verifyTestIntegrity(t, app, conf, slug)
+		require.True(t, report.Intact)
+		require.Equal(t, 2 + 4 + 8, report.Records)
+
+		for _, resp := range []*http.Response{
+			newRequestCreateVault(
+				t, app, conf, slug, `{"user_slug":"` + slug + `","vault_title":"Verified"}`,
+			),
+			newRequestUpdateVault(t, app, conf, slug, vaults[0].Slug, `{"vault_title":"Renamed"}`),
+			newRequestCreateEntry(t, app, conf, slug, fmt.Sprintf(
+				`{"user_slug":"%s","vault_slug":"%s","entry_title":"Verified","secrets":[{` +
+				`"secret_label":"username","secret_string":"foodeater","secret_priority":0}]}`,
+				slug, vaults[0].Slug,
+			)),
+			newRequestUpdateSecret(
+				t, app, conf, slug, secrets[0].Slug, `{"secret_string":"new string"}`,
+			),
+			newRequestMoveSecret(
+				t, app, conf, slug, secrets[1].Slug,
+				fmt.Sprintf(`{"secret_priority":"0","entry_slug":"%s"}`, secrets[1].EntrySlug),
+			),
+			newRequestDeleteSecret(t, app, conf, slug, secrets[2].Slug),
+			newRequestDeleteEntry(t, app, conf, slug, entries[1].Slug),
+			newRequestDeleteVault(t, app, conf, slug, vaults[1].Slug),
+			newRequestRestoreVault(t, app, conf, slug, vaults[1].Slug),
+			newRequestRekeyUser(t, app, conf, slug, fmt.Sprintf(
+				`{"old_key":"%s","new_key":"%s"}`, helpers.HexHash[:64],
+				hex.EncodeToString(utils.HashToken("n3wVal!dPA5SWoRD"))[:64],
+			)),
+		} {
+			require.Equal(t, 204, resp.StatusCode)
+		}
+
+		report = verifyTestIntegrity(t, app, conf, slug)
+		require.True(t, report.Intact, report.Discrepancies)
+		require.Equal(t, 3 + 5 + 9, report.Records)
+		require.Empty(t, report.Discrepancies)
+
+		purged, err := database.PurgeTrash(db, time.Now().UTC().Add(time.Minute))
+		require.NoError(t, err)
+		require.NotZero(t, purged)
+		require.True(t, verifyTestIntegrity(t, app, conf, slug).Intact)
+	})
+
+	t.Run("out_of_band_changes_reported_200_ok", func(t *testing.T) {
+		users, vaults, entries, secrets := setup.SetUpWithData(t, db)
+		slug := users[0].Slug
+
+		// An altered secret, an entry removed outright and a vault inserted directly.
+		require.NoError(t, db.Model(&models.Secret{}).Where("slug = ?", secrets[0].Slug).
+		UpdateColumn("string", secrets[1].String).Error)
+		require.NoError(t, db.Unscoped().Delete(&models.Entry{}, "slug = ?", entries[1].Slug).Error)
+
+		added := vaults[0]
+		added.Slug = helpers.NewSlug(t)
+		added.Title = "Inserted"
+		require.NoError(t, db.Create(&added).Error)
+
+		// Another user's records aren't theirs to report.
+		require.NoError(t, db.Model(&models.Vault{}).Where("slug = ?", vaults[2].Slug).
+		UpdateColumn("title", "Altered").Error)
+
+		report := verifyTestIntegrity(t, app, conf, slug)
+		require.False(t, report.Intact)
+		require.False(t, report.ManifestAltered)
+		require.Equal(t, []database.IntegrityDiscrepancy{
+			{Kind: database.IntegrityVault, Slug: added.Slug, Change: database.IntegrityAdded},
+			{Kind: database.IntegrityEntry, Slug: entries[1].Slug, Change: database.IntegrityRemoved},
+			{Kind: database.IntegritySecret, Slug: secrets[0].Slug, Change: database.IntegrityAltered},
+		}, report.Discrepancies)
+
+		// A later write through the API doesn't hide changes outside what it touched.
+		resp := newRequestUpdateSecret(
+			t, app, conf, slug, secrets[4].Slug, `{"secret_string":"new string"}`,
+		)
+		require.Equal(t, 204, resp.StatusCode)
+		require.Equal(t, 3, len(verifyTestIntegrity(t, app, conf, slug).Discrepancies))
+
+		require.False(t, verifyTestIntegrity(t, app, conf, users[1].Slug).Intact)
+
+		// Rebuilding accepts the rows as they are now.
+		require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+			return database.RefreshIntegrity(tx, slug, database.IntegrityAll())
+		}))
+		require.True(t, verifyTestIntegrity(t, app, conf, slug).Intact)
+	})
+
+	t.Run("manifest_altered_200_ok", func(t *testing.T) {
+		users, _, _, secrets := setup.SetUpWithData(t, db)
+		slug := users[0].Slug
+
+		// Covering up a change by rewriting its digest still breaks the root.
+		require.NoError(t, db.Model(&models.Secret{}).Where("slug = ?", secrets[0].Slug).
+		UpdateColumn("string", secrets[1].String).Error)
+
+		var record models.IntegrityRecord
+		require.NoError(t, db.First(&record, "kind = ?", database.IntegrityVault).Error)
+		require.NoError(t, db.Model(&models.IntegrityRecord{}).
+		Where("kind = ? AND slug = ?", database.IntegritySecret, secrets[0].Slug).
+		UpdateColumn("digest", record.Digest).Error)
+
+		report := verifyTestIntegrity(t, app, conf, slug)
+		require.False(t, report.Intact)
+		require.True(t, report.ManifestAltered)
+	})
+}
+
+func verifyTestIntegrity(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, slug string,
+) (report database.IntegrityReport) {
+	resp := newRequestVerifyIntegrity(t, app, conf, slug, slug)
+	require.Equal(t, 200, resp.StatusCode)
+
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		t.Fatalf("JSON decode failed: %s", err.Error())
+	}
+
+	require.Equal(t, slug, report.UserSlug)
+
+	return
+}
+
+func testVerifyIntegrityClientError(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, expectedStatus int,
+	expectedMessage, expectedDetail, userSlug, slug string,
+) {
+	resp := newRequestVerifyIntegrity(t, app, conf, userSlug, slug)
+	require.Equal(t, expectedStatus, resp.StatusCode)
+	helpers.AssertErrorResponseBody(t, resp, utils.ErrorResponseBody{
+		ClientOperation: utils.VerifyIntegrity,
+		Message:         expectedMessage,
+		Detail:          expectedDetail,
+	})
+}
+
+func newRequestVerifyIntegrity(
+	t *testing.T, app *fiber.App, conf *config.AppConfig, userSlug, slug string,
+) *http.Response {
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/" + slug + "/integrity", nil)
+	req.Header.Set("Client-Operation", utils.VerifyIntegrity)
+	req.Header.Set("Authorization", "Token " + conf.VAULTS_ACCESS_TOKEN)
+	req.Header.Set("User-Slug", userSlug)
+
+	resp, err := app.Test(req, -1)
+
+	if err != nil {
+		t.Fatalf("Send test request failed: %s", err.Error())
+	}
+
+	return resp
+}
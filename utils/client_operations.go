@@ -5,16 +5,38 @@ const (
 	CreateVault   string = "create_vault"
 	CreateEntry   string = "create_entry"
 	CreateSecret  string = "create_secret"
+	CreateShare		string = "create_share"
+	AddVaultMember	string = "add_vault_member"
+	RekeyUser     string = "rekey_user"
+	ExportUser		string = "export_user"
 	ListVaults		string = "list_vaults"
+	ListSecretVersions	string = "list_secret_versions"
+	ListTrash			string = "list_trash"
+	ListShares		string = "list_shares"
+	ListVaultMembers	string = "list_vault_members"
+	ListAuditEvents	string = "list_audit_events"
+	VerifyIntegrity	string = "verify_integrity"
+	Search				string = "search"
+	Import				string = "import"
 	RetrieveUser  string = "retrieve_user"
+	RetrieveUserKDF	string = "retrieve_user_kdf"
 	RetrieveVault string = "retrieve_vault"
 	RetrieveEntry string = "retrieve_entry"
+	RetrieveShare	string = "retrieve_share"
 	UpdateVault   string = "update_vault"
 	UpdateEntry   string = "update_entry"
 	UpdateSecret  string = "update_secret"
 	MoveSecret		string = "move_secret"
+	UpdateSharedSecret	string = "update_shared_secret"
+	UpdateVaultMember	string = "update_vault_member"
+	RestoreSecretVersion	string = "restore_secret_version"
+	RestoreVault	string = "restore_vault"
+	RestoreEntry	string = "restore_entry"
+	RestoreSecret	string = "restore_secret"
 	DeleteVault   string = "delete_vault"
 	DeleteEntry   string = "delete_entry"
 	DeleteSecret  string = "delete_secret"
+	DeleteShare		string = "delete_share"
+	RemoveVaultMember	string = "remove_vault_member"
 	TestAuthReq		string = "test_auth_req"
 )
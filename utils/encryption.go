@@ -5,26 +5,163 @@ import (
 	"crypto/cipher"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"io"
+	"strconv"
+	"strings"
 )
 
-func Encrypt(plaintext, hexEncodedKey string) (hexEncodedCiphertext string, err error) {
-	var key []byte
+// Ciphertexts are stored as self-describing envelopes of the form
+//
+//	$<version>$<algorithm>$<key id>$<hex nonce>$<hex ciphertext>
+//
+// Ciphertexts written before envelopes existed are a bare hex string of
+// nonce||ciphertext; those are still accepted by Decrypt and can be reframed
+// without the key by UpgradeLegacyCiphertext.
+//
+// Version 1 envelopes are plain AES-GCM. Version 2 envelopes additionally bind
+// the ciphertext to caller-supplied additional data, so that a ciphertext copied
+// onto another record fails authentication instead of decrypting.
+const (
+	EnvelopeVersion1 uint8  = 1
+	EnvelopeVersion2 uint8  = 2
+	AlgorithmAESGCM  string = "aesgcm"
+	envelopePrefix   string = "$"
+	envelopeFields   int    = 5
+	keyIDLength      int    = 16
+)
 
-	if key, err = hex.DecodeString(hexEncodedKey); err != nil {
+var (
+	ErrInvalidEnvelope     = errors.New("invalid ciphertext envelope")
+	ErrUnsupportedEnvelope = errors.New("unsupported ciphertext envelope")
+	ErrKeyIDMismatch       = errors.New("ciphertext was encrypted under a different key")
+	ErrUnboundCiphertext   = errors.New("ciphertext isn't bound to its record")
+)
+
+type Envelope struct {
+	Version    uint8
+	Algorithm  string
+	KeyID      string
+	Nonce      []byte
+	Ciphertext []byte
+}
+
+func (e Envelope) String() string {
+	return envelopePrefix + strings.Join([]string{
+		strconv.FormatUint(uint64(e.Version), 10),
+		e.Algorithm,
+		e.KeyID,
+		hex.EncodeToString(e.Nonce),
+		hex.EncodeToString(e.Ciphertext),
+	}, envelopePrefix)
+}
+
+func ParseEnvelope(encoded string) (envelope Envelope, err error) {
+	if !strings.HasPrefix(encoded, envelopePrefix) {
+		return envelope, ErrInvalidEnvelope
+	}
+
+	fields := strings.Split(encoded[len(envelopePrefix):], envelopePrefix)
+
+	if len(fields) != envelopeFields {
+		return envelope, ErrInvalidEnvelope
+	}
+
+	var version uint64
+
+	if version, err = strconv.ParseUint(fields[0], 10, 8); err != nil {
+		return envelope, ErrInvalidEnvelope
+	}
+
+	envelope.Version = uint8(version)
+	envelope.Algorithm = fields[1]
+	envelope.KeyID = fields[2]
+
+	if envelope.Nonce, err = hex.DecodeString(fields[3]); err != nil {
+		return envelope, ErrInvalidEnvelope
+	}
+
+	if envelope.Ciphertext, err = hex.DecodeString(fields[4]); err != nil {
+		return envelope, ErrInvalidEnvelope
+	}
+
+	return envelope, nil
+}
+
+func IsLegacyCiphertext(encoded string) bool {
+	return !strings.HasPrefix(encoded, envelopePrefix)
+}
+
+// IsBoundCiphertext reports whether a ciphertext was sealed with additional data.
+// Anything else should be re-encrypted the next time its key is available.
+func IsBoundCiphertext(encoded string) bool {
+	envelope, err := ParseEnvelope(encoded)
+	return err == nil && envelope.Version >= EnvelopeVersion2
+}
+
+// SecretAdditionalData identifies the record a secret's ciphertext belongs to.
+// Slugs never contain ':', so the encoding is unambiguous.
+func SecretAdditionalData(secretSlug, entrySlug, userSlug string) []byte {
+	return []byte("secret:" + secretSlug + ":entry:" + entrySlug + ":user:" + userSlug)
+}
+
+// KeyCheckAdditionalData binds a user's key check to the user.
+func KeyCheckAdditionalData(userSlug string) []byte {
+	return []byte("key_check:user:" + userSlug)
+}
+
+// KeyID returns a short, non-reversible fingerprint of a hex-encoded key so that
+// an envelope records which key sealed it without revealing the key itself.
+func KeyID(hexEncodedKey string) (string, error) {
+	key, err := hex.DecodeString(hexEncodedKey)
+
+	if err != nil {
 		return "", err
 	}
 
-	var block cipher.Block
+	return hex.EncodeToString(HashToken(string(key)))[:keyIDLength], nil
+}
 
-	if block, err = aes.NewCipher(key); err != nil {
+// UpgradeLegacyCiphertext reframes a bare hex nonce||ciphertext as a version 1
+// envelope. The key is not needed, so the key id of the result is left empty.
+func UpgradeLegacyCiphertext(hexEncodedCiphertext string) (string, error) {
+	if !IsLegacyCiphertext(hexEncodedCiphertext) {
+		return hexEncodedCiphertext, nil
+	}
+
+	ciphertext, err := hex.DecodeString(hexEncodedCiphertext)
+
+	if err != nil {
+		return "", err
+	}
+
+	nonceSize := legacyNonceSize()
+
+	if len(ciphertext) < nonceSize {
+		return "", ErrInvalidEnvelope
+	}
+
+	return Envelope{
+		Version:    EnvelopeVersion1,
+		Algorithm:  AlgorithmAESGCM,
+		Nonce:      ciphertext[:nonceSize],
+		Ciphertext: ciphertext[nonceSize:],
+	}.String(), nil
+}
+
+func Encrypt(
+	plaintext, hexEncodedKey string, additionalData []byte,
+) (encodedCiphertext string, err error) {
+	var key []byte
+
+	if key, err = hex.DecodeString(hexEncodedKey); err != nil {
 		return "", err
 	}
 
 	var gcm cipher.AEAD
 
-	if gcm, err = cipher.NewGCM(block); err != nil {
-    return "", err
+	if gcm, err = newGCM(key); err != nil {
+		return "", err
 	}
 
 	nonce := make([]byte, gcm.NonceSize())
@@ -33,43 +170,103 @@ func Encrypt(plaintext, hexEncodedKey string) (hexEncodedCiphertext string, err
 		return "", err
 	}
 
-	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	var keyID string
 
-	return hex.EncodeToString(ciphertext), nil 
+	if keyID, err = KeyID(hexEncodedKey); err != nil {
+		return "", err
+	}
+
+	return Envelope{
+		Version:    EnvelopeVersion2,
+		Algorithm:  AlgorithmAESGCM,
+		KeyID:      keyID,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, []byte(plaintext), additionalData),
+	}.String(), nil
 }
 
-func Decrypt(hexEncodedCiphertext, hexEncodedKey string) (plaintext string, err error) {
-	var key []byte
+// Decrypt opens any supported envelope. The additional data is ignored for
+// legacy and version 1 ciphertexts, which were sealed without it.
+func Decrypt(
+	encodedCiphertext, hexEncodedKey string, additionalData []byte,
+) (plaintext string, err error) {
+	var envelope Envelope
 
-	if key, err = hex.DecodeString(hexEncodedKey); err != nil {
+	if IsLegacyCiphertext(encodedCiphertext) {
+		if encodedCiphertext, err = UpgradeLegacyCiphertext(encodedCiphertext); err != nil {
+			return "", err
+		}
+	}
+
+	if envelope, err = ParseEnvelope(encodedCiphertext); err != nil {
 		return "", err
 	}
 
-	var block cipher.Block
+	switch envelope.Version {
+	case EnvelopeVersion1:
+		return openAESGCM(envelope, hexEncodedKey, nil)
+	case EnvelopeVersion2:
+		return openAESGCM(envelope, hexEncodedKey, additionalData)
+	default:
+		return "", ErrUnsupportedEnvelope
+	}
+}
 
-	if block, err = aes.NewCipher(key); err != nil {
-		return "", err
+func openAESGCM(
+	envelope Envelope, hexEncodedKey string, additionalData []byte,
+) (plaintext string, err error) {
+	if envelope.Algorithm != AlgorithmAESGCM {
+		return "", ErrUnsupportedEnvelope
 	}
 
-	var gcm cipher.AEAD
+	if envelope.KeyID != "" {
+		if keyID, err := KeyID(hexEncodedKey); err != nil {
+			return "", err
+		} else if keyID != envelope.KeyID {
+			return "", ErrKeyIDMismatch
+		}
+	}
 
-	if gcm, err = cipher.NewGCM(block); err != nil {
-    return "", err
+	var key []byte
+
+	if key, err = hex.DecodeString(hexEncodedKey); err != nil {
+		return "", err
 	}
 
-	var ciphertext []byte
+	var gcm cipher.AEAD
 
-	if ciphertext, err = hex.DecodeString(hexEncodedCiphertext); err != nil {
+	if gcm, err = newGCM(key); err != nil {
 		return "", err
 	}
 
-	nonce, textToDecrypt := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	if len(envelope.Nonce) != gcm.NonceSize() {
+		return "", ErrInvalidEnvelope
+	}
 
 	var decryptedText []byte
 
-	if decryptedText, err = gcm.Open(nil, nonce, textToDecrypt, nil); err != nil {
+	if decryptedText, err = gcm.Open(
+		nil, envelope.Nonce, envelope.Ciphertext, additionalData,
+	); err != nil {
 		return "", err
 	}
 
 	return string(decryptedText), nil
 }
+
+func newGCM(key []byte) (gcm cipher.AEAD, err error) {
+	var block cipher.Block
+
+	if block, err = aes.NewCipher(key); err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// Legacy ciphertexts were always sealed with the standard GCM nonce size, which
+// does not depend on the AES key length.
+func legacyNonceSize() int {
+	gcm, _ := newGCM(make([]byte, 32))
+	return gcm.NonceSize()
+}
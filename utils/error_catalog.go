@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// ProblemTypePrefix begins the `type` URI of every problem response, before its code.
+const ProblemTypePrefix string = "urn:simplepasswords:error:"
+
+// CatalogError is the stable description of one of the messages in error_messages.go.
+type CatalogError struct {
+	// Code is what clients should compare, where they used to compare the message.
+	Code string
+	// Field is the request field the error is about, if any.
+	Field string
+	// DetailCodes are more specific codes for some of the message's details.
+	DetailCodes map[string]string
+}
+
+// errorCatalog has every message a handler responds with. Codes are never reused or
+// changed once released; a message can be reworded, but keeps its codes.
+var errorCatalog = map[string]CatalogError{
+	ErrorParse: {Code: "BODY_UNPARSABLE"},
+	ErrorUserSlug: {"USER_SLUG_INVALID", "user_slug", map[string]string{
+		ErrorUserSlugHeader: "USER_SLUG_HEADER_MISMATCH",
+	}},
+	ErrorOldKey: {Code: "OLD_KEY_INVALID", Field: "old_key"},
+	ErrorNewKey: {"NEW_KEY_INVALID", "new_key", map[string]string{
+		"Same as `old_key`.": "NEW_KEY_UNCHANGED",
+	}},
+	ErrorOldPassword: {Code: "OLD_PASSWORD_INVALID", Field: "old_password"},
+	ErrorNewPassword: {"NEW_PASSWORD_INVALID", "new_password", map[string]string{
+		"Too short": "NEW_PASSWORD_TOO_SHORT", "Too long": "NEW_PASSWORD_TOO_LONG",
+		"Same as `old_password`.": "NEW_PASSWORD_UNCHANGED",
+	}},
+	ErrorPassword: {"PASSWORD_INVALID", "", map[string]string{
+		"Expected a hex-encoded key.": "PASSWORD_NOT_A_KEY", "Empty": "PASSWORD_EMPTY",
+		"Too short": "PASSWORD_TOO_SHORT", "Too long": "PASSWORD_TOO_LONG",
+	}},
+	ErrorVaultSlug: {"VAULT_SLUG_INVALID", "vault_slug", map[string]string{
+		ErrorEntryVaultSlug: "VAULT_SLUG_ENTRY_MISMATCH",
+	}},
+	ErrorVaultTitle: {"VAULT_TITLE_INVALID", "vault_title", map[string]string{
+		"": "VAULT_TITLE_EMPTY", "Too long": "VAULT_TITLE_TOO_LONG",
+	}},
+	ErrorEntrySlug: {Code: "ENTRY_SLUG_INVALID", Field: "entry_slug"},
+	ErrorEntryTitle: {"ENTRY_TITLE_INVALID", "entry_title", map[string]string{
+		"": "ENTRY_TITLE_EMPTY", "Too long": "ENTRY_TITLE_TOO_LONG",
+	}},
+	ErrorSecretSlug: {"SECRET_SLUG_INVALID", "secret_slug", map[string]string{
+		"Not found": "SECRET_NOT_FOUND",
+	}},
+	ErrorSecretLabel: {"SECRET_LABEL_INVALID", "secret_label", map[string]string{
+		"Too long": "SECRET_LABEL_TOO_LONG",
+	}},
+	ErrorSecretString: {"SECRET_STRING_INVALID", "secret_string", map[string]string{
+		"Too long": "SECRET_STRING_TOO_LONG",
+	}},
+	ErrorSecretPriority:    {Code: "SECRET_PRIORITY_INVALID", Field: "secret_priority"},
+	ErrorSecretVersionSlug: {Code: "SECRET_VERSION_SLUG_INVALID", Field: "version_slug"},
+	ErrorShareSlug:         {Code: "SHARE_SLUG_INVALID", Field: "share_slug"},
+	ErrorGranteeSlug: {"GRANTEE_SLUG_INVALID", "grantee_slug", map[string]string{
+		"Same as `User-Slug` header.": "GRANTEE_SLUG_SELF",
+	}},
+	ErrorGranteeShareKey: {Code: "GRANTEE_SHARE_KEY_MISSING", Field: "grantee_slug"},
+	ErrorSharePermission: {Code: "SHARE_PERMISSION_INVALID", Field: "permission"},
+	ErrorShareReadOnly:   {Code: "SHARE_READ_ONLY"},
+	ErrorTeamVaultShare:  {Code: "TEAM_VAULT_SHARE"},
+	ErrorSharedEntries:   {Code: "VAULT_ENTRIES_SHARED"},
+	ErrorMemberSlug: {"MEMBER_SLUG_INVALID", "member_slug", map[string]string{
+		"Same as `User-Slug` header.": "MEMBER_SLUG_SELF",
+		"Vault creator can't be removed.": "MEMBER_SLUG_CREATOR",
+	}},
+	ErrorMemberRole: {"MEMBER_ROLE_INVALID", "member_role", map[string]string{
+		"Vault creator is always an owner.": "MEMBER_ROLE_CREATOR",
+	}},
+	ErrorMemberShareKey: {Code: "MEMBER_SHARE_KEY_MISSING", Field: "member_slug"},
+	ErrorVaultRole:      {Code: "VAULT_ROLE_FORBIDDEN"},
+	ErrorLimit:             {Code: "LIMIT_INVALID", Field: "limit"},
+	ErrorSort:              {Code: "SORT_INVALID", Field: "sort"},
+	ErrorCursor:            {Code: "CURSOR_INVALID", Field: "cursor"},
+	ErrorCursorSort:        {Code: "CURSOR_SORT_MISMATCH", Field: "cursor"},
+	ErrorTitlePrefix: {"TITLE_PREFIX_INVALID", "title_prefix", map[string]string{
+		"Too long": "TITLE_PREFIX_TOO_LONG",
+	}},
+	ErrorSearchQuery: {"SEARCH_QUERY_INVALID", "q", map[string]string{
+		"": "SEARCH_QUERY_EMPTY", "Too long": "SEARCH_QUERY_TOO_LONG",
+	}},
+	ErrorAuditOperation:  {Code: "AUDIT_OPERATION_INVALID", Field: "operation"},
+	ErrorAuditTargetSlug: {Code: "AUDIT_TARGET_SLUG_INVALID", Field: "target_slug"},
+	ErrorAuditOutcome:    {Code: "AUDIT_OUTCOME_INVALID", Field: "outcome"},
+	ErrorAuditSince:      {Code: "AUDIT_SINCE_INVALID", Field: "since"},
+	ErrorAuditUntil:      {Code: "AUDIT_UNTIL_INVALID", Field: "until"},
+	ErrorImportFormat:    {Code: "IMPORT_FORMAT_INVALID", Field: "format"},
+	ErrorImportData: {"IMPORT_DATA_INVALID", "data", map[string]string{
+		"": "IMPORT_DATA_EMPTY",
+	}},
+	ErrorExportPassphrase: {"EXPORT_PASSPHRASE_INVALID", "", map[string]string{
+		"Too short": "EXPORT_PASSPHRASE_TOO_SHORT", "Too long": "EXPORT_PASSPHRASE_TOO_LONG",
+	}},
+	ErrorEmptyUpdateSecret:        {Code: "UPDATE_SECRET_EMPTY"},
+	ErrorSecrets:                  {Code: "SECRETS_INVALID", Field: "secrets"},
+	ErrorItemSecrets:              {Code: "SECRETS_ITEM_INVALID", Field: "secrets"},
+	ErrorDuplicateSecretsLabel:    {Code: "SECRET_LABEL_DUPLICATE", Field: "secrets"},
+	ErrorDuplicateSecretsPriority: {Code: "SECRET_PRIORITY_DUPLICATE", Field: "secrets"},
+	ErrorDuplicateUser:            {Code: "USER_DUPLICATE", Field: "user_slug"},
+	ErrorDuplicateVault:           {Code: "VAULT_DUPLICATE", Field: "vault_title"},
+	ErrorDuplicateEntry:           {Code: "ENTRY_DUPLICATE", Field: "entry_title"},
+	ErrorDuplicateSecret:          {Code: "SECRET_DUPLICATE", Field: "secret_label"},
+	ErrorDuplicateShare:           {Code: "SHARE_DUPLICATE", Field: "grantee_slug"},
+	ErrorDuplicateMember:          {Code: "MEMBER_DUPLICATE", Field: "member_slug"},
+	ErrorMissingReference:         {Code: "REFERENCE_MISSING"},
+	ErrorMissingField:             {Code: "FIELD_MISSING"},
+	ErrorImportTrashed:            {Code: "TRASH_CONFLICT"},
+	ErrorFailedDB:                 {Code: "DATABASE_ERROR"},
+	ErrorNotFound:                 {Code: "NOT_FOUND"},
+	ErrorParentTrashed:            {Code: "PARENT_TRASHED"},
+	ErrorNoRowsAffected:           {Code: "NOT_FOUND"},
+	ErrorToken:                    {Code: "TOKEN_INVALID"},
+	ErrorTokenScope:               {Code: "TOKEN_SCOPE_MISSING"},
+	ErrorAuthFailures:             {Code: "AUTH_THROTTLED"},
+	ErrorVaultKey:                 {Code: "VAULT_KEY_WRONG"},
+	ErrorEncrypt:                  {Code: "ENCRYPTION_FAILED"},
+	ErrorDecrypt:                  {Code: "DECRYPTION_FAILED"},
+}
+
+// itemFieldRegexp matches details like "secrets[1].Label; len(secrets) == 2".
+var itemFieldRegexp = regexp.MustCompile(`^secrets\[([0-9]+)\]\.(Label|String)\b`)
+
+// ErrorCode is the stable code for an error response's message and detail. Messages that
+// aren't in the catalog, such as a database error's own text, get a code for their status.
+func ErrorCode(statusCode int, message, detail string) string {
+	if entry, ok := errorCatalog[message]; !ok {
+		if statusCode >= 500 {
+			return "INTERNAL_ERROR"
+		}
+
+		return "REQUEST_INVALID"
+	} else if code, ok := entry.DetailCodes[detail]; ok {
+		return code
+	} else {
+		return entry.Code
+	}
+}
+
+// errorTitle is the message of a catalogued error, or else the status's text, so a title
+// never carries the text of some other error.
+func errorTitle(statusCode int, message string) string {
+	if _, ok := errorCatalog[message]; ok {
+		return message
+	}
+
+	return http.StatusText(statusCode)
+}
+
+// errorLocation points to the field an error is about: a JSON pointer into the request
+// body if the body has it, or else the name of the query or path parameter.
+func errorLocation(message, detail string, body []byte) (pointer, parameter string) {
+	field := errorCatalog[message].Field
+
+	if field == "" {
+		return
+	}
+
+	if match := itemFieldRegexp.FindStringSubmatch(detail); match != nil {
+		return "/secrets/" + match[1] + "/secret_" + strings.ToLower(match[2]), ""
+	}
+
+	var fields map[string]json.RawMessage
+
+	if json.Unmarshal(body, &fields) == nil {
+		if _, ok := fields[field]; ok {
+			return "/" + field, ""
+		}
+	}
+
+	return "", field
+}
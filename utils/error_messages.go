@@ -3,7 +3,14 @@ package utils
 const (
 	ErrorParse             				string = "Failed to parse request body."
 	ErrorUserSlug          				string = "Invalid `user_slug`."
+	ErrorUserSlugHeader						string = "Does not match `User-Slug` header."
+	ErrorOldKey									string = "Invalid `old_key`."
+	ErrorNewKey									string = "Invalid `new_key`."
+	ErrorOldPassword							string = "Invalid `old_password`."
+	ErrorNewPassword							string = "Invalid `new_password`."
+	ErrorPassword									string = "Invalid password."
 	ErrorVaultSlug         				string = "Invalid `vault_slug`."
+	ErrorEntryVaultSlug						string = "Does not match the entry's `vault_slug`."
 	ErrorVaultTitle        				string = "Invalid `vault_title`."
 	ErrorEntrySlug         				string = "Invalid `entry_slug`."
 	ErrorEntryTitle        				string = "Invalid `entry_title`."
@@ -11,6 +18,32 @@ const (
 	ErrorSecretLabel       				string = "Invalid `secret_label`."
 	ErrorSecretString      				string = "Invalid `secret_string`."
 	ErrorSecretPriority						string = "Invalid `secret_priority`."
+	ErrorSecretVersionSlug				string = "Invalid `secret_version_slug`."
+	ErrorShareSlug								string = "Invalid `share_slug`."
+	ErrorGranteeSlug							string = "Invalid `grantee_slug`."
+	ErrorGranteeShareKey					string = "Grantee has no sharing key yet."
+	ErrorSharePermission					string = "Invalid `permission`."
+	ErrorShareReadOnly						string = "Share doesn't permit writes."
+	ErrorTeamVaultShare						string = "Entry is in a team vault."
+	ErrorSharedEntries						string = "Vault has shared entries."
+	ErrorMemberSlug								string = "Invalid `member_slug`."
+	ErrorMemberRole								string = "Invalid `member_role`."
+	ErrorMemberShareKey						string = "Member has no sharing key yet."
+	ErrorVaultRole								string = "Role doesn't permit this."
+	ErrorLimit										string = "Invalid `limit`."
+	ErrorSort											string = "Invalid `sort`."
+	ErrorCursor										string = "Invalid `cursor`."
+	ErrorCursorSort								string = "Does not match `sort`."
+	ErrorTitlePrefix							string = "Invalid `title_prefix`."
+	ErrorSearchQuery							string = "Invalid `q`."
+	ErrorAuditOperation						string = "Invalid `operation`."
+	ErrorAuditTargetSlug					string = "Invalid `target_slug`."
+	ErrorAuditOutcome							string = "Invalid `outcome`."
+	ErrorAuditSince								string = "Invalid `since`."
+	ErrorAuditUntil								string = "Invalid `until`."
+	ErrorImportFormat							string = "Invalid `format`."
+	ErrorImportData								string = "Invalid `data`."
+	ErrorExportPassphrase					string = "Invalid export passphrase."
 	ErrorEmptyUpdateSecret 				string = "Empty 'update_secret' body."
 	ErrorSecrets           				string = "Invalid `secrets`."
 	ErrorItemSecrets       				string = "Invalid item in `secrets`."
@@ -18,10 +51,21 @@ const (
 	ErrorDuplicateSecretsPriority	string = "Duplicate `entry.secrets.secret_priority`."
 	ErrorDuplicateUser		 				string = "User already exists."
 	ErrorDuplicateVault		 				string = "Vault already exists."
+	ErrorDuplicateEntry						string = "Entry already exists."
+	ErrorDuplicateSecret					string = "Secret already exists."
+	ErrorDuplicateShare						string = "Entry already shared with grantee."
+	ErrorDuplicateMember					string = "User is already a member."
+	ErrorMissingReference					string = "Refers to a record that doesn't exist."
+	ErrorMissingField							string = "Missing a required field."
+	ErrorImportTrashed						string = "Conflicts with a record in the trash."
 	ErrorFailedDB          				string = "Failed DB operation."
 	ErrorNotFound          				string = "Record not found."
+	ErrorParentTrashed						string = "Parent record is in the trash."
 	ErrorNoRowsAffected    				string = "result.RowsAffected == 0"
 	ErrorToken						 				string = "Invalid token."
+	ErrorTokenScope								string = "Token lacks the required scope."
+	ErrorAuthFailures							string = "Too many failed authorizations."
+	ErrorVaultKey									string = "Wrong vault key."
 	ErrorEncrypt									string = "Failed encryption."
 	ErrorDecrypt									string = "Failed decryption."
 )
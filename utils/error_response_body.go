@@ -1,10 +1,39 @@
 package utils
 
+// ProblemResponseBody is an RFC 7807 problem, with the error's code, the request's ID and
+// the field the error is about as extension members. Its detail and the members after
+// ClientOperation are only in verbose responses.
+type ProblemResponseBody struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Code      string `json:"code"`
+	RequestID string `json:"request_id"`
+	// Pointer is a JSON pointer to the field of the request body the error is about, and
+	// Parameter the query or path parameter it's about.
+	Pointer         string `json:"pointer,omitempty"`
+	Parameter       string `json:"parameter,omitempty"`
+	ClientOperation string `json:"client_operation,omitempty"`
+	Caller          string `json:"caller,omitempty"`
+	ContextString   string `json:"context_string,omitempty"`
+	RequestBody     string `json:"request_body,omitempty"`
+}
+
+// ErrorResponseBody is the legacy shape of a verbose error response.
 type ErrorResponseBody struct {
 	Caller					string `json:"caller"`
 	ClientOperation string `json:"client_operation"`
+	Code            string `json:"code"`
 	Message         string `json:"message"`
 	ContextString   string `json:"context_string"`
 	RequestBody     string `json:"request_body"`
 	Detail          string `json:"detail"`
+	RequestID       string `json:"request_id"`
+}
+
+// ErrorCodeResponseBody is the legacy shape of an error response that isn't verbose.
+type ErrorCodeResponseBody struct {
+	Code      string `json:"code"`
+	RequestID string `json:"request_id"`
 }
@@ -0,0 +1,276 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+
+	"github.com/goccy/go-json"
+	"golang.org/x/crypto/argon2"
+)
+
+// An export file is one line of JSON describing how it was encrypted, followed by the
+// export itself in chunks of at most ExportChunkSize bytes. Each chunk is framed as
+//
+//	<1-byte final flag><4-byte big-endian ciphertext length><AES-GCM ciphertext>
+//
+// and sealed with a nonce made of the header's nonce prefix, the chunk's counter and
+// the final flag, with the header line as additional data. Chunks can't be reordered,
+// dropped or moved between files, and a file cut short is missing its final chunk, so
+// an export that failed halfway through can't be mistaken for a complete one.
+const (
+	ExportFormat      string = "simplepasswords-export"
+	ExportVersion     uint8  = 1
+	ExportKDF         string = "argon2id"
+	ExportChunkSize   int    = 64 * 1024
+	exportNoncePrefix int    = 7
+)
+
+var (
+	ErrInvalidExport   = errors.New("invalid export file")
+	ErrTruncatedExport = errors.New("export file is truncated")
+)
+
+type ExportHeader struct {
+	Format      string `json:"format"`
+	Version     uint8  `json:"version"`
+	KDF         string `json:"kdf"`
+	Time        uint32 `json:"time"`
+	Memory      uint32 `json:"memory"`
+	Threads     uint8  `json:"threads"`
+	Salt        string `json:"salt"`
+	NoncePrefix string `json:"nonce_prefix"`
+}
+
+func (h ExportHeader) deriveAEAD(passphrase string) (cipher.AEAD, error) {
+	salt, err := hex.DecodeString(h.Salt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	key := argon2.IDKey([]byte(passphrase), salt, h.Time, h.Memory, h.Threads, 32)
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+type exportChunker struct {
+	aead        cipher.AEAD
+	noncePrefix []byte
+	header      []byte
+	counter     uint32
+}
+
+func (e *exportChunker) nonce(final bool) []byte {
+	nonce := make([]byte, 0, e.aead.NonceSize())
+	nonce = append(nonce, e.noncePrefix...)
+	nonce = binary.BigEndian.AppendUint32(nonce, e.counter)
+
+	if final {
+		return append(nonce, 1)
+	}
+
+	return append(nonce, 0)
+}
+
+// ExportWriter encrypts everything written to it into an export file. Close must be
+// called to write the final chunk.
+type ExportWriter struct {
+	exportChunker
+	w      io.Writer
+	buffer []byte
+}
+
+func NewExportWriter(w io.Writer, passphrase string) (*ExportWriter, error) {
+	salt := make([]byte, 16)
+	noncePrefix := make([]byte, exportNoncePrefix)
+
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	} else if _, err := io.ReadFull(rand.Reader, noncePrefix); err != nil {
+		return nil, err
+	}
+
+	header := ExportHeader{
+		Format:      ExportFormat,
+		Version:     ExportVersion,
+		KDF:         ExportKDF,
+		Time:        3,
+		Memory:      64 * 1024,
+		Threads:     4,
+		Salt:        hex.EncodeToString(salt),
+		NoncePrefix: hex.EncodeToString(noncePrefix),
+	}
+
+	aead, err := header.deriveAEAD(passphrase)
+
+	if err != nil {
+		return nil, err
+	}
+
+	headerLine, err := json.Marshal(&header)
+
+	if err != nil {
+		return nil, err
+	} else if _, err := w.Write(append(headerLine, '\n')); err != nil {
+		return nil, err
+	}
+
+	return &ExportWriter{
+		exportChunker: exportChunker{aead: aead, noncePrefix: noncePrefix, header: headerLine},
+		w:             w,
+		buffer:        make([]byte, 0, ExportChunkSize),
+	}, nil
+}
+
+func (ew *ExportWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		// A full buffer is only sealed once more data arrives, since it could be the last.
+		if len(ew.buffer) == ExportChunkSize {
+			if err = ew.seal(false); err != nil {
+				return
+			}
+		}
+
+		copied := copy(ew.buffer[len(ew.buffer):ExportChunkSize], p)
+		ew.buffer = ew.buffer[:len(ew.buffer) + copied]
+		p = p[copied:]
+		n += copied
+	}
+
+	return
+}
+
+func (ew *ExportWriter) Close() error {
+	return ew.seal(true)
+}
+
+func (ew *ExportWriter) seal(final bool) error {
+	ciphertext := ew.aead.Seal(nil, ew.nonce(final), ew.buffer, ew.header)
+	frame := make([]byte, 5, 5 + len(ciphertext))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(ciphertext)))
+
+	if final {
+		frame[0] = 1
+	}
+
+	if _, err := ew.w.Write(append(frame, ciphertext...)); err != nil {
+		return err
+	}
+
+	ew.counter++
+	ew.buffer = ew.buffer[:0]
+
+	return nil
+}
+
+// ExportReader decrypts an export file written by ExportWriter.
+type ExportReader struct {
+	exportChunker
+	r     *bufio.Reader
+	chunk []byte
+	final bool
+}
+
+func NewExportReader(r io.Reader, passphrase string) (*ExportReader, error) {
+	reader := bufio.NewReader(r)
+	headerLine, err := reader.ReadBytes('\n')
+
+	if err != nil {
+		return nil, ErrInvalidExport
+	}
+
+	headerLine = headerLine[:len(headerLine) - 1]
+	var header ExportHeader
+
+	if err := json.Unmarshal(headerLine, &header); err != nil {
+		return nil, ErrInvalidExport
+	} else if header.Format != ExportFormat || header.Version != ExportVersion ||
+	header.KDF != ExportKDF {
+		return nil, ErrInvalidExport
+	}
+
+	noncePrefix, err := hex.DecodeString(header.NoncePrefix)
+
+	if err != nil || len(noncePrefix) != exportNoncePrefix {
+		return nil, ErrInvalidExport
+	}
+
+	aead, err := header.deriveAEAD(passphrase)
+
+	if err != nil {
+		return nil, ErrInvalidExport
+	}
+
+	return &ExportReader{
+		exportChunker: exportChunker{aead: aead, noncePrefix: noncePrefix, header: headerLine},
+		r:             reader,
+	}, nil
+}
+
+func (er *ExportReader) Read(p []byte) (int, error) {
+	for len(er.chunk) == 0 {
+		if er.final {
+			if _, err := er.r.ReadByte(); err != io.EOF {
+				return 0, ErrInvalidExport
+			}
+
+			return 0, io.EOF
+		} else if err := er.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, er.chunk)
+	er.chunk = er.chunk[n:]
+
+	return n, nil
+}
+
+func (er *ExportReader) open() error {
+	frame := make([]byte, 5)
+
+	if _, err := io.ReadFull(er.r, frame); err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrTruncatedExport
+	} else if err != nil {
+		return err
+	} else if frame[0] > 1 {
+		return ErrInvalidExport
+	}
+
+	length := binary.BigEndian.Uint32(frame[1:])
+
+	if int(length) > ExportChunkSize + er.aead.Overhead() {
+		return ErrInvalidExport
+	}
+
+	ciphertext := make([]byte, length)
+
+	if _, err := io.ReadFull(er.r, ciphertext); err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrTruncatedExport
+	} else if err != nil {
+		return err
+	}
+
+	er.final = frame[0] == 1
+	chunk, err := er.aead.Open(nil, er.nonce(er.final), ciphertext, er.header)
+
+	if err != nil {
+		return err
+	}
+
+	er.chunk = chunk
+	er.counter++
+
+	return nil
+}
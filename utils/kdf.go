@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Users whose KDF is KDFArgon2id send a master password, and the key of their secrets is
+// derived from it with Argon2id, under their own salt and parameters. Users whose KDF is
+// KDFRawKey send the hex-encoded key itself, as every user did before.
+const (
+	KDFRawKey       string = ""
+	KDFArgon2id     string = "argon2id"
+	KDFSaltLength   int    = 16
+	KDFKeyLength    uint32 = 32
+	MinPasswordSize int    = 8
+	MaxPasswordSize int    = 1024
+)
+
+var ErrInvalidKDFParams = errors.New("invalid key derivation parameters")
+
+// KDFParams are what a client needs to derive the same key as the service. Memory is in
+// KiB.
+type KDFParams struct {
+	KDF     string `json:"kdf"`
+	Salt    string `json:"salt"`
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"`
+	Threads uint8  `json:"threads"`
+}
+
+// NewKDFParams returns Argon2id parameters with a new random salt.
+func NewKDFParams(time, memory uint32, threads uint8) (KDFParams, error) {
+	salt := make([]byte, KDFSaltLength)
+
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return KDFParams{}, err
+	}
+
+	params := KDFParams{
+		KDF: KDFArgon2id, Salt: hex.EncodeToString(salt),
+		Time: time, Memory: memory, Threads: threads,
+	}
+
+	if !params.valid() {
+		return KDFParams{}, ErrInvalidKDFParams
+	}
+
+	return params, nil
+}
+
+// DeriveKey returns the hex-encoded key derived from `password`.
+func (p KDFParams) DeriveKey(password string) (string, error) {
+	if !p.valid() {
+		return "", ErrInvalidKDFParams
+	}
+
+	salt, err := hex.DecodeString(p.Salt)
+
+	if err != nil || len(salt) != KDFSaltLength {
+		return "", ErrInvalidKDFParams
+	}
+
+	return hex.EncodeToString(
+		argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, KDFKeyLength),
+	), nil
+}
+
+// WeakerThan reports whether any of the parameters is below `target`'s, so that keys
+// derived with them should be derived again with `target`'s.
+func (p KDFParams) WeakerThan(target KDFParams) bool {
+	return p.Time < target.Time || p.Memory < target.Memory || p.Threads < target.Threads
+}
+
+// Argon2id needs at least one pass and thread, and 8 KiB of memory per thread.
+func (p KDFParams) valid() bool {
+	return p.KDF == KDFArgon2id && p.Time > 0 && p.Threads > 0 &&
+	p.Memory >= 8 * uint32(p.Threads)
+}
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Every user's secrets are encrypted under a combination of the key the client sends, and
+// a random data key of the user's that only the service has, wrapped by a key-encryption
+// key (KEK). Neither the database nor the client's key is enough on its own.
+const DataKeyLength int = 32
+
+var ErrUnwrapKey = errors.New("data key unwrap failed")
+
+// KeyProvider wraps and unwraps users' data keys under a KEK it holds. It may hold
+// previous KEKs too, so that data keys wrapped under them can be unwrapped, and rewrapped,
+// while the KEK is rotated.
+type KeyProvider interface {
+	// WrapKey encrypts the hex-encoded `dataKey` under the current KEK.
+	WrapKey(dataKey string, additionalData []byte) (wrappedKey string, err error)
+	// UnwrapKey decrypts `wrappedKey` under whichever of the KEKs wrapped it.
+	UnwrapKey(wrappedKey string, additionalData []byte) (dataKey string, err error)
+	// IsCurrent reports whether `wrappedKey` is wrapped under the current KEK.
+	IsCurrent(wrappedKey string) bool
+}
+
+// LocalKeyProvider holds its KEKs in memory, as loaded from a config file.
+type LocalKeyProvider struct {
+	current string
+	// keks are every hex-encoded KEK, current and previous, by key id.
+	keks map[string]string
+}
+
+// NewLocalKeyProvider returns a provider of `keks`, a comma-separated list of hex-encoded
+// 256-bit KEKs. The first is current, and the rest are previous ones still to be rotated
+// away from.
+func NewLocalKeyProvider(keks string) (*LocalKeyProvider, error) {
+	provider := LocalKeyProvider{keks: map[string]string{}}
+
+	for i, kek := range strings.Split(keks, ",") {
+		if kek = strings.TrimSpace(kek); len(kek) != 2 * DataKeyLength {
+			return nil, fmt.Errorf("KEK %d is not %d hex-encoded bytes", i + 1, DataKeyLength)
+		}
+
+		keyID, err := KeyID(kek)
+
+		if err != nil {
+			return nil, fmt.Errorf("KEK %d is not hex-encoded: %w", i + 1, err)
+		} else if i == 0 {
+			provider.current = keyID
+		}
+
+		provider.keks[keyID] = kek
+	}
+
+	return &provider, nil
+}
+
+func (p *LocalKeyProvider) WrapKey(dataKey string, additionalData []byte) (string, error) {
+	return Encrypt(dataKey, p.keks[p.current], additionalData)
+}
+
+func (p *LocalKeyProvider) UnwrapKey(wrappedKey string, additionalData []byte) (string, error) {
+	envelope, err := ParseEnvelope(wrappedKey)
+
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrUnwrapKey, err)
+	}
+
+	kek, ok := p.keks[envelope.KeyID]
+
+	if !ok {
+		return "", fmt.Errorf("%w: no KEK with key id %q", ErrUnwrapKey, envelope.KeyID)
+	}
+
+	dataKey, err := Decrypt(wrappedKey, kek, additionalData)
+
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrUnwrapKey, err)
+	}
+
+	return dataKey, nil
+}
+
+func (p *LocalKeyProvider) IsCurrent(wrappedKey string) bool {
+	envelope, err := ParseEnvelope(wrappedKey)
+	return err == nil && envelope.KeyID == p.current
+}
+
+// NewDataKey returns a new random hex-encoded data key.
+func NewDataKey() (string, error) {
+	dataKey := make([]byte, DataKeyLength)
+
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(dataKey), nil
+}
+
+// DataKeyAdditionalData binds a wrapped data key to its user.
+func DataKeyAdditionalData(userSlug string) []byte {
+	return []byte("data_key:user:" + userSlug)
+}
+
+// CombineKeys returns the hex-encoded key that secrets are encrypted under, from the
+// client's key and the user's data key.
+func CombineKeys(clientKey, dataKey string) (string, error) {
+	clientKeyBytes, err := hex.DecodeString(clientKey)
+
+	if err != nil {
+		return "", err
+	}
+
+	dataKeyBytes, err := hex.DecodeString(dataKey)
+
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, dataKeyBytes)
+	mac.Write(clientKeyBytes)
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
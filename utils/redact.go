@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	Redacted string = "[REDACTED]"
+
+	errorResponseConfigLocal string = "error_response_config"
+	// minRedactedLength keeps short header values, like a bare "Token", from redacting
+	// every occurrence of a common word.
+	minRedactedLength int = 8
+)
+
+// ErrorResponseConfig controls what RespondWithError tells the client.
+type ErrorResponseConfig struct {
+	// Verbose responses say where and why a request failed, with sensitive values
+	// redacted. Otherwise a response has only the error's code and the request ID.
+	Verbose bool
+	// SensitiveHeaders are request headers whose values are redacted wherever they appear.
+	// The Authorization header always is.
+	SensitiveHeaders []string
+}
+
+// ConfigureErrorResponses applies `conf` to the error responses of the handlers after it.
+// Without it, responses aren't verbose.
+func ConfigureErrorResponses(conf ErrorResponseConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(errorResponseConfigLocal, &conf)
+		return c.Next()
+	}
+}
+
+func errorResponseConfig(c *fiber.Ctx) *ErrorResponseConfig {
+	if conf, ok := c.Locals(errorResponseConfigLocal).(*ErrorResponseConfig); ok {
+		return conf
+	}
+
+	return &ErrorResponseConfig{}
+}
+
+// RedactRequestBody replaces the values of the JSON fields that hold secrets or keys, even
+// in a body that doesn't parse.
+func RedactRequestBody(body string) string {
+	return SensitiveFieldRegexp.ReplaceAllString(body, `${1}"` + Redacted + `"`)
+}
+
+// redactor returns a func replacing the sensitive header values of the request, and each
+// word of them, such as the token of an Authorization header.
+func (conf *ErrorResponseConfig) redactor(c *fiber.Ctx) func(string) string {
+	oldnew := []string{}
+
+	for _, key := range append([]string{fiber.HeaderAuthorization}, conf.SensitiveHeaders...) {
+		if key == "" {
+			continue
+		}
+
+		value := c.Get(key)
+
+		for _, s := range append([]string{value}, strings.Fields(value)...) {
+			if len(s) >= minRedactedLength {
+				oldnew = append(oldnew, s, Redacted)
+			}
+		}
+	}
+
+	replacer := strings.NewReplacer(oldnew...)
+
+	return replacer.Replace
+}
@@ -8,4 +8,10 @@ var (
 	FailedSecretSlugRegexp = regexp.MustCompile("^Failed to generate `secret.Slug`:")
 	AuthHeaderRegexp			 = regexp.MustCompile(`^[Tt]oken [\w-]{80}$`)
 	TokenNullRegexp				 = regexp.MustCompile(`^[Tt]oken (null)?$`)
+	OperationRegexp				 = regexp.MustCompile(`^[a-z_]{1,64}$`)
+	HexKeyRegexp					 = regexp.MustCompile(`^([0-9a-fA-F]{32}|[0-9a-fA-F]{48}|[0-9a-fA-F]{64})$`)
+	SensitiveFieldRegexp	 = regexp.MustCompile(
+		`(?i)("(?:secret_string|old_key|new_key|old_password|new_password|data)"\s*:\s*)` +
+		`("(?:[^"\\]|\\.)*"?|[^,}\]\s]*)`,
+	)
 )
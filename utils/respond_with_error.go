@@ -7,15 +7,65 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+const (
+	MIMEApplicationProblemJSON string = "application/problem+json"
+	// ErrorFormatHeader set to ErrorFormatLegacy gets error responses in the shape they had
+	// before problem responses, an ErrorResponseBody or ErrorCodeResponseBody.
+	ErrorFormatHeader string = "Error-Format"
+	ErrorFormatLegacy string = "legacy"
+)
+
+// RespondWithError writes an RFC 7807 problem response, verbose or not as
+// ConfigureErrorResponses set. A verbose one never includes the values of secret fields or
+// sensitive headers.
 func RespondWithError(c *fiber.Ctx, statusCode int, operation, message, detail string) error {
+	conf := errorResponseConfig(c)
+	code := ErrorCode(statusCode, message, detail)
+	requestID := c.GetRespHeader(fiber.HeaderXRequestID)
+	legacy := c.Get(ErrorFormatHeader) == ErrorFormatLegacy
+
+	problem := ProblemResponseBody{
+		Type:      ProblemTypePrefix + code,
+		Title:     errorTitle(statusCode, message),
+		Status:    statusCode,
+		Code:      code,
+		RequestID: requestID,
+	}
+
+	problem.Pointer, problem.Parameter = errorLocation(message, detail, c.Body())
+
+	if !conf.Verbose {
+		if legacy {
+			return c.Status(statusCode).JSON(ErrorCodeResponseBody{Code: code, RequestID: requestID})
+		}
+
+		return c.Status(statusCode).JSON(problem, MIMEApplicationProblemJSON)
+	}
+
 	_, file, line, _ := runtime.Caller(1)
+	redact := conf.redactor(c)
+	caller := file + ":" + strconv.FormatInt(int64(line), 10)
+	requestBody := redact(RedactRequestBody(string(c.Body())))
+
+	if legacy {
+		return c.Status(statusCode).JSON(ErrorResponseBody{
+			Caller:					 caller,
+			ClientOperation: operation,
+			Code:            code,
+			Message:         redact(message),
+			ContextString:   redact(c.String()),
+			RequestBody:     requestBody,
+			Detail:          redact(detail),
+			RequestID:       requestID,
+		})
+	}
+
+	problem.Title = redact(message)
+	problem.Detail = redact(detail)
+	problem.ClientOperation = operation
+	problem.Caller = caller
+	problem.ContextString = redact(c.String())
+	problem.RequestBody = requestBody
 
-	return c.Status(statusCode).JSON(ErrorResponseBody{
-		Caller:					 file + ":" + strconv.FormatInt(int64(line), 10),
-		ClientOperation: operation,
-		Message:         message,
-		ContextString:   c.String(),
-		RequestBody:     string(c.Body()),
-		Detail:          detail,
-	})
+	return c.Status(statusCode).JSON(problem, MIMEApplicationProblemJSON)
 }
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Every user has an X25519 key pair for sharing. Entries are shared by deriving a share
+// key from an ephemeral key pair and the grantee's public key, so that the grantee can
+// derive it again from their private key and the ephemeral public key, which the share
+// keeps. The owner keeps the share key encrypted under their own key instead.
+const shareKeyContext string = "simplepasswords_vaults share key"
+
+// NewShareKeyPair returns a new hex-encoded X25519 key pair.
+func NewShareKeyPair() (publicKey, privateKey string, err error) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	return hex.EncodeToString(key.PublicKey().Bytes()), hex.EncodeToString(key.Bytes()), nil
+}
+
+// ShareKey returns the hex-encoded key shared between the holders of the hex-encoded
+// X25519 `privateKey` and `publicKey`.
+func ShareKey(privateKey, publicKey string) (string, error) {
+	privateKeyBytes, err := hex.DecodeString(privateKey)
+
+	if err != nil {
+		return "", err
+	}
+
+	publicKeyBytes, err := hex.DecodeString(publicKey)
+
+	if err != nil {
+		return "", err
+	}
+
+	private, err := ecdh.X25519().NewPrivateKey(privateKeyBytes)
+
+	if err != nil {
+		return "", err
+	}
+
+	public, err := ecdh.X25519().NewPublicKey(publicKeyBytes)
+
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := private.ECDH(public)
+
+	if err != nil {
+		return "", err
+	}
+
+	digest := sha256.Sum256(append([]byte(shareKeyContext), secret...))
+
+	return hex.EncodeToString(digest[:]), nil
+}
+
+// PrivateKeyAdditionalData binds a user's encrypted private key to the user.
+func PrivateKeyAdditionalData(userSlug string) []byte {
+	return []byte("private_key:user:" + userSlug)
+}
+
+// ShareKeyAdditionalData binds the owner's copy of a share key to its share.
+func ShareKeyAdditionalData(shareSlug string) []byte {
+	return []byte("share_key:share:" + shareSlug)
+}
+
+// VaultKeyAdditionalData binds a member's copy of a team vault's key to the vault and member.
+func VaultKeyAdditionalData(vaultSlug, userSlug string) []byte {
+	return []byte("vault_key:vault:" + vaultSlug + ":user:" + userSlug)
+}
+
+// ShareSecretAdditionalData binds a grantee's copy of a secret to its share and secret.
+func ShareSecretAdditionalData(shareSlug, secretSlug string) []byte {
+	return []byte("share_secret:share:" + shareSlug + ":secret:" + secretSlug)
+}